@@ -311,6 +311,49 @@ var _ = Describe("bake command", func() {
 		})
 	})
 
+	Context("when the --reproducible flag is provided", func() {
+		BeforeEach(func() {
+			commandWithArgs = append(commandWithArgs,
+				"--reproducible",
+				"--stemcells-directory", singleStemcellDirectory,
+			)
+		})
+
+		It("produces identical tiles from identical inputs", func() {
+			command := exec.Command(pathToMain, commandWithArgs...)
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(session).Should(gexec.Exit(0))
+
+			firstTile, err := ioutil.ReadFile(outputFile)
+			Expect(err).NotTo(HaveOccurred())
+
+			time.Sleep(2 * time.Second)
+
+			command = exec.Command(pathToMain, commandWithArgs...)
+
+			session, err = gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(session).Should(gexec.Exit(0))
+
+			secondTile, err := ioutil.ReadFile(outputFile)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(secondTile).To(Equal(firstTile))
+
+			zr, err := zip.OpenReader(outputFile)
+			Expect(err).NotTo(HaveOccurred())
+			defer zr.Close()
+
+			for _, f := range zr.File {
+				Expect(f.Modified.Equal(time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC))).To(BeTrue())
+			}
+		})
+	})
+
 	Context("when the --kilnfile flag is provided", func() {
 
 		It("generates a tile with the correct metadata including the stemcell criteria from the Kilnfile.lock", func() {
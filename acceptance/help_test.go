@@ -17,12 +17,17 @@ Usage: kiln [options] <command> [<args>]
   --version, -v  bool  prints the kiln release version (default: false)
 
 Commands:
-  bake     bakes a tile
-  fetch    fetches releases
-  help     prints this usage information
-  publish  publish tile on Pivnet
-  update   updates stemcell_criteria and releases
-  version  prints the kiln release version
+  bake      bakes a tile
+  cache     manages the release cache
+  diff      compares two tiles
+  fetch     fetches releases
+  help      prints this usage information
+  init      generates a new tile
+  manifest  generates a BOSH manifest
+  publish   publish tile on Pivnet
+  update    updates stemcell_criteria and releases
+  validate  validates baked metadata
+  version   prints the kiln release version
 `
 
 const BAKE_USAGE = `kiln bake
@@ -39,18 +44,20 @@ Command Arguments:
   --icon, -i                         string             path to icon file
   --instance-groups-directory, -ig   string (variadic)  path to a directory containing instance groups
   --jobs-directory, -j               string (variadic)  path to a directory containing jobs
-  --kilnfile, -kf                    string             path to Kilnfile  (NOTE: mutually exclusive with --stemcell-directory)
-  --metadata, -m                     string (required)  path to the metadata file
+  --kilnfile, -kf                    string             path to Kilnfile  (NOTE: with --stemcells-directory the stemcells are verified against Kilnfile.lock)
+  --metadata, -m                     string             path to the metadata file (default: metadata in the bake section of the Kilnfile)
   --metadata-only, -mo               bool               don't build a tile, output the metadata to stdout
   --migrations-directory, -md        string (variadic)  path to a directory containing migrations
   --output-file, -o                  string             path to where the tile will be output
   --properties-directory, -pd        string (variadic)  path to a directory containing property blueprints
   --releases-directory, -rd          string (variadic)  path to a directory containing release tarballs
+  --reproducible                     bool               produces a byte-identical tile for identical inputs (NOTE: enabled when SOURCE_DATE_EPOCH is set)
   --runtime-configs-directory, -rcd  string (variadic)  path to a directory containing runtime configs
   --sha256                           bool               calculates a SHA256 checksum of the output file
   --stemcell-tarball, -st            string             deprecated -- path to a stemcell tarball  (NOTE: mutually exclusive with --kilnfile)
-  --stemcells-directory, -sd         string (variadic)  path to a directory containing stemcells  (NOTE: mutually exclusive with --kilnfile or --stemcell-tarball)
+  --stemcells-directory, -sd         string (variadic)  path to a directory containing stemcells  (NOTE: mutually exclusive with --stemcell-tarball)
   --stub-releases, -sr               bool               skips importing release tarballs into the tile
+  --validate                         bool               validates the interpolated metadata against Ops Manager rules
   --variable, -vr                    string (variadic)  key value pairs of variables to interpolate
   --variables-file, -vf              string (variadic)  path to a file containing variables to interpolate
   --version, -v                      string             version of the tile
@@ -64,13 +71,21 @@ Usage: kiln [options] fetch [<args>]
   --version, -v  bool  prints the kiln release version (default: false)
 
 Command Arguments:
-  --allow-only-publishable-releases  bool               include releases that would not be shipped with the tile (development builds)
-  --download-threads, -dt            int                number of parallel threads to download parts from S3
-  --kilnfile, -kf                    string             path to Kilnfile (default: Kilnfile)
-  --no-confirm, -n                   bool               non-interactive mode, will delete extra releases in releases dir without prompting
-  --releases-directory, -rd          string             path to a directory to download releases into (default: releases)
-  --variable, -vr                    string (variadic)  variable in key=value format
-  --variables-file, -vf              string (variadic)  path to variables file
+  --allow-only-publishable-releases                        bool               include releases that would not be shipped with the tile (development builds)
+  --cache-directory, -cd                                   string             path to the release cache shared between tiles (default: $KILN_CACHE_DIR or ~/.kiln/cache)
+  --download-threads, -dt                                  int                number of parallel threads to download parts from S3
+  --dry-run                                                bool               print which releases would be kept, downloaded and deleted without changing anything
+  --format                                                 string             dry run output format, either table or json (default: table)
+  --kilnfile, -kf                                          string             path to Kilnfile (default: Kilnfile)
+  --no-cache                                               bool               do not use the release cache
+  --no-confirm, -n                                         bool               non-interactive mode, will delete extra releases in releases dir without prompting
+  --parallel-downloads, -pd                                int                number of releases to download concurrently (default: 4)
+  --pivotal-network-token, -pt, PIVOTAL_NETWORK_API_TOKEN  string             uaa access token for network.pivotal.io
+  --releases-directory, -rd                                string             path to a directory to download releases into (default: releases)
+  --stemcells-directory, -sd                               string             path to a directory to download the stemcell tarballs pinned in Kilnfile.lock into
+  --variable, -vr                                          string (variadic)  variable in key=value format
+  --variables-file, -vf                                    string (variadic)  path to variables file
+
 `
 
 var _ = Describe("help", func() {
@@ -0,0 +1,113 @@
+package acceptance_test
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/onsi/gomega/gexec"
+	yaml "gopkg.in/yaml.v2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("init command", func() {
+	var (
+		tmpDir  string
+		tileDir string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "kiln-init-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		tileDir = filepath.Join(tmpDir, "cf")
+
+		command := exec.Command(pathToMain, "init", "--name", "cf", "--directory", tileDir)
+		session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(session).Should(gexec.Exit(0))
+
+		release, err := ioutil.ReadFile("fixtures/releases/cf-release-235.0.0-3215.4.0.tgz")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.Mkdir(filepath.Join(tileDir, "releases"), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(tileDir, "releases", "cf-release-235.0.0-3215.4.0.tgz"), release, 0644)).To(Succeed())
+
+		Expect(ioutil.WriteFile(filepath.Join(tileDir, "Kilnfile.lock"), []byte(`---
+releases:
+- name: cf
+  version: "235"
+stemcell_criteria:
+  os: ubuntu-trusty
+  version: "3215.4"
+`), 0644)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	It("generates a tile that bakes once its release and stemcell are filled in", func() {
+		command := exec.Command(pathToMain, "bake", "--version", "1.2.3", "--metadata-only")
+		command.Dir = tileDir
+
+		session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(session).Should(gexec.Exit(0))
+
+		var metadata struct {
+			Name             string `yaml:"name"`
+			ProductVersion   string `yaml:"product_version"`
+			StemcellCriteria struct {
+				OS      string `yaml:"os"`
+				Version string `yaml:"version"`
+			} `yaml:"stemcell_criteria"`
+			Releases []struct {
+				Name    string `yaml:"name"`
+				Version string `yaml:"version"`
+			} `yaml:"releases"`
+			JobTypes []struct {
+				Name string `yaml:"name"`
+			} `yaml:"job_types"`
+		}
+		Expect(yaml.Unmarshal(session.Out.Contents(), &metadata)).To(Succeed())
+
+		Expect(metadata.Name).To(Equal("cf"))
+		Expect(metadata.ProductVersion).To(Equal("1.2.3"))
+		Expect(metadata.StemcellCriteria.OS).To(Equal("ubuntu-trusty"))
+		Expect(metadata.StemcellCriteria.Version).To(Equal("3215.4"))
+		Expect(metadata.Releases).To(HaveLen(1))
+		Expect(metadata.Releases[0].Name).To(Equal("cf"))
+		Expect(metadata.Releases[0].Version).To(Equal("235"))
+		Expect(metadata.JobTypes).To(HaveLen(1))
+		Expect(metadata.JobTypes[0].Name).To(Equal("cf"))
+	})
+
+	It("does not put anything but migrations in the migrations directory of the tile", func() {
+		outputFile := filepath.Join(tmpDir, "cf-1.2.3.pivotal")
+
+		command := exec.Command(pathToMain, "bake", "--version", "1.2.3", "--output-file", outputFile)
+		command.Dir = tileDir
+
+		session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(session).Should(gexec.Exit(0))
+
+		zr, err := zip.OpenReader(outputFile)
+		Expect(err).NotTo(HaveOccurred())
+		defer zr.Close()
+
+		var migrations []string
+		for _, f := range zr.File {
+			if strings.HasPrefix(f.Name, "migrations/") && !strings.HasSuffix(f.Name, "/") {
+				migrations = append(migrations, f.Name)
+			}
+		}
+		Expect(migrations).To(BeEmpty())
+	})
+})
@@ -0,0 +1,11 @@
+package builder
+
+import "time"
+
+// SetWriteProgressInterval changes how often the progress of writing release
+// tarballs is logged and returns a func that restores it.
+func SetWriteProgressInterval(interval time.Duration) func() {
+	previous := writeProgressInterval
+	writeProgressInterval = interval
+	return func() { writeProgressInterval = previous }
+}
@@ -1,8 +1,13 @@
 package fakes
 
-import "fmt"
+import (
+	"fmt"
+	"sync"
+)
 
 type Logger struct {
+	mu sync.Mutex
+
 	PrintfCall struct {
 		Receives struct {
 			LogLines []string
@@ -17,9 +22,13 @@ type Logger struct {
 }
 
 func (l *Logger) Printf(format string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	l.PrintfCall.Receives.LogLines = append(l.PrintfCall.Receives.LogLines, fmt.Sprintf(format, v...))
 }
 
 func (l *Logger) Println(v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	l.PrintlnCall.Receives.LogLines = append(l.PrintlnCall.Receives.LogLines, fmt.Sprintf("%s", v...))
 }
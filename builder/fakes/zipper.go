@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 	"sync"
+	"time"
 )
 
 type Zipper struct {
@@ -54,6 +55,11 @@ type Zipper struct {
 	createFolderReturnsOnCall map[int]struct {
 		result1 error
 	}
+	SetModifiedTimeStub        func(time.Time)
+	setModifiedTimeMutex       sync.RWMutex
+	setModifiedTimeArgsForCall []struct {
+		arg1 time.Time
+	}
 	SetWriterStub        func(io.Writer)
 	setWriterMutex       sync.RWMutex
 	setWriterArgsForCall []struct {
@@ -70,15 +76,16 @@ func (fake *Zipper) Add(arg1 string, arg2 io.Reader) error {
 		arg1 string
 		arg2 io.Reader
 	}{arg1, arg2})
+	stub := fake.AddStub
+	fakeReturns := fake.addReturns
 	fake.recordInvocation("Add", []interface{}{arg1, arg2})
 	fake.addMutex.Unlock()
-	if fake.AddStub != nil {
-		return fake.AddStub(arg1, arg2)
+	if stub != nil {
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.addReturns
 	return fakeReturns.result1
 }
 
@@ -132,15 +139,16 @@ func (fake *Zipper) AddWithMode(arg1 string, arg2 io.Reader, arg3 os.FileMode) e
 		arg2 io.Reader
 		arg3 os.FileMode
 	}{arg1, arg2, arg3})
+	stub := fake.AddWithModeStub
+	fakeReturns := fake.addWithModeReturns
 	fake.recordInvocation("AddWithMode", []interface{}{arg1, arg2, arg3})
 	fake.addWithModeMutex.Unlock()
-	if fake.AddWithModeStub != nil {
-		return fake.AddWithModeStub(arg1, arg2, arg3)
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.addWithModeReturns
 	return fakeReturns.result1
 }
 
@@ -191,15 +199,16 @@ func (fake *Zipper) Close() error {
 	ret, specificReturn := fake.closeReturnsOnCall[len(fake.closeArgsForCall)]
 	fake.closeArgsForCall = append(fake.closeArgsForCall, struct {
 	}{})
+	stub := fake.CloseStub
+	fakeReturns := fake.closeReturns
 	fake.recordInvocation("Close", []interface{}{})
 	fake.closeMutex.Unlock()
-	if fake.CloseStub != nil {
-		return fake.CloseStub()
+	if stub != nil {
+		return stub()
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.closeReturns
 	return fakeReturns.result1
 }
 
@@ -244,15 +253,16 @@ func (fake *Zipper) CreateFolder(arg1 string) error {
 	fake.createFolderArgsForCall = append(fake.createFolderArgsForCall, struct {
 		arg1 string
 	}{arg1})
+	stub := fake.CreateFolderStub
+	fakeReturns := fake.createFolderReturns
 	fake.recordInvocation("CreateFolder", []interface{}{arg1})
 	fake.createFolderMutex.Unlock()
-	if fake.CreateFolderStub != nil {
-		return fake.CreateFolderStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.createFolderReturns
 	return fakeReturns.result1
 }
 
@@ -298,14 +308,47 @@ func (fake *Zipper) CreateFolderReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *Zipper) SetModifiedTime(arg1 time.Time) {
+	fake.setModifiedTimeMutex.Lock()
+	fake.setModifiedTimeArgsForCall = append(fake.setModifiedTimeArgsForCall, struct {
+		arg1 time.Time
+	}{arg1})
+	stub := fake.SetModifiedTimeStub
+	fake.recordInvocation("SetModifiedTime", []interface{}{arg1})
+	fake.setModifiedTimeMutex.Unlock()
+	if stub != nil {
+		fake.SetModifiedTimeStub(arg1)
+	}
+}
+
+func (fake *Zipper) SetModifiedTimeCallCount() int {
+	fake.setModifiedTimeMutex.RLock()
+	defer fake.setModifiedTimeMutex.RUnlock()
+	return len(fake.setModifiedTimeArgsForCall)
+}
+
+func (fake *Zipper) SetModifiedTimeCalls(stub func(time.Time)) {
+	fake.setModifiedTimeMutex.Lock()
+	defer fake.setModifiedTimeMutex.Unlock()
+	fake.SetModifiedTimeStub = stub
+}
+
+func (fake *Zipper) SetModifiedTimeArgsForCall(i int) time.Time {
+	fake.setModifiedTimeMutex.RLock()
+	defer fake.setModifiedTimeMutex.RUnlock()
+	argsForCall := fake.setModifiedTimeArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *Zipper) SetWriter(arg1 io.Writer) {
 	fake.setWriterMutex.Lock()
 	fake.setWriterArgsForCall = append(fake.setWriterArgsForCall, struct {
 		arg1 io.Writer
 	}{arg1})
+	stub := fake.SetWriterStub
 	fake.recordInvocation("SetWriter", []interface{}{arg1})
 	fake.setWriterMutex.Unlock()
-	if fake.SetWriterStub != nil {
+	if stub != nil {
 		fake.SetWriterStub(arg1)
 	}
 }
@@ -340,6 +383,8 @@ func (fake *Zipper) Invocations() map[string][][]interface{} {
 	defer fake.closeMutex.RUnlock()
 	fake.createFolderMutex.RLock()
 	defer fake.createFolderMutex.RUnlock()
+	fake.setModifiedTimeMutex.RLock()
+	defer fake.setModifiedTimeMutex.RUnlock()
 	fake.setWriterMutex.RLock()
 	defer fake.setWriterMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
@@ -5,16 +5,24 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
 	"regexp"
+	"sort"
+	"strings"
 	"text/template"
+	"text/template/parse"
 
 	yamlConverter "github.com/ghodss/yaml"
 	yaml "gopkg.in/yaml.v2"
 )
 
-type Interpolator struct{}
+type Interpolator struct {
+	logger logger
+}
 
 type InterpolateInput struct {
+	MetadataFile       string
 	Version            string
 	BOSHVariables      map[string]interface{}
 	Variables          map[string]interface{}
@@ -30,56 +38,195 @@ type InterpolateInput struct {
 	StubReleases       bool
 }
 
+// partSource is a part interpolated into the metadata, named by the helper
+// that references it. Parts read from directories know their file.
+type partSource struct {
+	helper string
+	name   string
+	file   string
+}
+
+func (s partSource) String() string {
+	if s.file == "" {
+		return fmt.Sprintf("%s %q", s.helper, s.name)
+	}
+
+	return fmt.Sprintf("%s %q (%s)", s.helper, s.name, s.file)
+}
+
+// references records the lookups made while interpolating, so that every
+// missing reference is reported at once and unused parts can be warned about.
+// It also keeps the first error from within a part, which the template
+// package would otherwise only report as the failing helper of the metadata.
+type references struct {
+	missing []missingReference
+	used    map[string]map[string]bool
+
+	failure      error
+	failureChain []partSource
+}
+
+type missingReference struct {
+	chain   []partSource
+	helper  string
+	name    string
+	message string
+}
+
 func NewInterpolator() Interpolator {
 	return Interpolator{}
 }
 
+// NewInterpolatorWithLogger returns an Interpolator that warns about the
+// variables and parts that are not referenced by the metadata.
+func NewInterpolatorWithLogger(logger logger) Interpolator {
+	return Interpolator{logger: logger}
+}
+
 func (i Interpolator) Interpolate(input InterpolateInput, templateYAML []byte) ([]byte, error) {
-	interpolatedYAML, err := i.interpolate(input, templateYAML)
+	refs := &references{used: map[string]map[string]bool{}}
+
+	metadataFile := input.MetadataFile
+	if metadataFile == "" {
+		metadataFile = "metadata"
+	}
+
+	interpolatedYAML, lines, err := i.interpolateMetadata(refs, metadataFile, input, templateYAML)
 	if err != nil {
 		return nil, err
 	}
 
+	if len(refs.missing) > 0 {
+		var problems []string
+		for _, missing := range refs.missing {
+			reference := partSource{helper: missing.helper, name: missing.name}
+			if len(missing.chain) > 0 {
+				reference = missing.chain[0]
+			}
+
+			for _, line := range lines(reference) {
+				problems = append(problems, "- "+SourceError{
+					Sources: append([]string{line}, chainSources(missing.chain)...),
+					Err:     errors.New(missing.message),
+				}.Error())
+			}
+		}
+
+		return nil, fmt.Errorf("template execution failed: unresolved references:\n%s", strings.Join(problems, "\n"))
+	}
+
+	i.warnUnused(refs, input)
+
 	prettyMetadata, err := i.prettyPrint(interpolatedYAML)
 	if err != nil {
-		return nil, err // un-tested
+		return nil, WithSource(metadataFile, fmt.Errorf("interpolated metadata is not valid YAML: %s", err))
 	}
 
 	return prettyMetadata, nil
 }
 
-func (i Interpolator) interpolate(input InterpolateInput, templateYAML []byte) ([]byte, error) {
+// interpolateMetadata interpolates the metadata itself. It returns a function
+// that finds the lines of the metadata that reference a part, or the file
+// when none do.
+func (i Interpolator) interpolateMetadata(refs *references, metadataFile string, input InterpolateInput, templateYAML []byte) ([]byte, func(partSource) []string, error) {
+	interpolatedYAML, tree, err := i.execute(refs, nil, metadataFile, input, templateYAML)
+
+	lines := func(reference partSource) []string {
+		var lines []string
+		if tree != nil {
+			walkCommands(tree.Root, func(command *parse.CommandNode) {
+				if len(command.Args) < 2 {
+					return
+				}
+
+				helper, ok := command.Args[0].(*parse.IdentifierNode)
+				if !ok || helper.Ident != reference.helper {
+					return
+				}
+
+				name, ok := command.Args[1].(*parse.StringNode)
+				if !ok || name.Text != reference.name {
+					return
+				}
+
+				line := bytes.Count(templateYAML[:command.Position()], []byte("\n")) + 1
+				lines = append(lines, fmt.Sprintf("%s:%d", metadataFile, line))
+			})
+		}
+
+		if len(lines) == 0 {
+			return []string{metadataFile}
+		}
+
+		return lines
+	}
+
+	if err != nil {
+		if refs.failure != nil {
+			return nil, nil, WithSource(lines(refs.failureChain[0])[0], refs.failure)
+		}
+
+		return nil, nil, err
+	}
+
+	return interpolatedYAML, lines, nil
+}
+
+// interpolate interpolates a value of the metadata, such as a part that the
+// chain leads to.
+func (i Interpolator) interpolate(refs *references, chain []partSource, input InterpolateInput, templateYAML []byte) ([]byte, error) {
+	name := "value"
+	if len(chain) > 0 {
+		name = chain[len(chain)-1].name
+	}
+
+	interpolatedYAML, _, err := i.execute(refs, chain, name, input, templateYAML)
+	if err != nil {
+		return nil, refs.fail(chain, fmt.Errorf("unable to interpolate value: %s", err))
+	}
+
+	return interpolatedYAML, nil
+}
+
+func (i Interpolator) execute(refs *references, chain []partSource, name string, input InterpolateInput, templateYAML []byte) ([]byte, *parse.Tree, error) {
+	lookup := func(helper string, values map[string]interface{}, name, missingDirectory, notFound string) (string, error) {
+		if values == nil {
+			refs.addMissing(chain, helper, name, fmt.Sprintf("%s %q: %s", helper, name, missingDirectory))
+			return "null", nil
+		}
+
+		val, ok := values[name]
+		if !ok {
+			refs.addMissing(chain, helper, name, notFound)
+			return "null", nil
+		}
+
+		refs.use(helper, name)
+
+		source := partSource{helper: helper, name: name}
+		if part, ok := val.(Part); ok {
+			source.file = part.File
+			val = part.Metadata
+		}
+
+		return i.interpolateValueIntoYAML(refs, append(chain[:len(chain):len(chain)], source), input, val)
+	}
+
 	templateHelpers := template.FuncMap{
 		"bosh_variable": func(key string) (string, error) {
-			if input.BOSHVariables == nil {
-				return "", errors.New("--bosh-variables-directory must be specified")
-			}
-			val, ok := input.BOSHVariables[key]
-			if !ok {
-				return "", fmt.Errorf("could not find bosh variable with key '%s'", key)
-			}
-			return i.interpolateValueIntoYAML(input, val)
+			return lookup("bosh_variable", input.BOSHVariables, key,
+				"--bosh-variables-directory must be specified",
+				fmt.Sprintf("could not find bosh variable with key '%s'", key))
 		},
 		"form": func(key string) (string, error) {
-			if input.FormTypes == nil {
-				return "", errors.New("--forms-directory must be specified")
-			}
-			val, ok := input.FormTypes[key]
-			if !ok {
-				return "", fmt.Errorf("could not find form with key '%s'", key)
-			}
-
-			return i.interpolateValueIntoYAML(input, val)
+			return lookup("form", input.FormTypes, key,
+				"--forms-directory must be specified",
+				fmt.Sprintf("could not find form with key '%s'", key))
 		},
 		"property": func(name string) (string, error) {
-			if input.PropertyBlueprints == nil {
-				return "", errors.New("--properties-directory must be specified")
-			}
-			val, ok := input.PropertyBlueprints[name]
-			if !ok {
-				return "", fmt.Errorf("could not find property blueprint with name '%s'", name)
-			}
-			return i.interpolateValueIntoYAML(input, val)
+			return lookup("property", input.PropertyBlueprints, name,
+				"--properties-directory must be specified",
+				fmt.Sprintf("could not find property blueprint with name '%s'", name))
 		},
 		"regexReplaceAll": func(regex, inputString, replaceString string) (string, error) {
 			re, err := regexp.Compile(regex)
@@ -89,24 +236,16 @@ func (i Interpolator) interpolate(input InterpolateInput, templateYAML []byte) (
 			return re.ReplaceAllString(inputString, replaceString), nil
 		},
 		"release": func(name string) (string, error) {
-			if input.ReleaseManifests == nil {
-				return "", errors.New("missing ReleaseManifests")
+			if _, ok := input.ReleaseManifests[name]; !ok && input.ReleaseManifests != nil && input.StubReleases {
+				return i.interpolateValueIntoYAML(refs, append(chain[:len(chain):len(chain)], partSource{helper: "release", name: name}), input, map[string]interface{}{
+					"name":    name,
+					"version": "UNKNOWN",
+				})
 			}
 
-			val, ok := input.ReleaseManifests[name]
-
-			if !ok {
-				if input.StubReleases {
-					val = map[string]interface{}{
-						"name":    name,
-						"version": "UNKNOWN",
-					}
-				} else {
-					return "", fmt.Errorf("could not find release with name '%s'", name)
-				}
-			}
-
-			return i.interpolateValueIntoYAML(input, val)
+			return lookup("release", input.ReleaseManifests, name,
+				"missing ReleaseManifests",
+				fmt.Sprintf("could not find release with name '%s'", name))
 		},
 		"stemcell": func(osname ...string) (string, error) {
 			if input.StemcellManifest == nil && len(input.StemcellManifests) == 0 {
@@ -122,32 +261,27 @@ func (i Interpolator) interpolate(input InterpolateInput, templateYAML []byte) (
 			}
 
 			if len(osname) > 0 {
-				return i.interpolateValueIntoYAML(input, input.StemcellManifests[osname[0]])
+				return i.interpolateValueIntoYAML(refs, chain, input, input.StemcellManifests[osname[0]])
 			}
 
 			if len(input.StemcellManifests) == 1 {
 				for _, stemcell := range input.StemcellManifests {
-					return i.interpolateValueIntoYAML(input, stemcell)
+					return i.interpolateValueIntoYAML(refs, chain, input, stemcell)
 				}
 			}
 
-			return i.interpolateValueIntoYAML(input, input.StemcellManifest)
+			return i.interpolateValueIntoYAML(refs, chain, input, input.StemcellManifest)
 		},
 		"version": func() (string, error) {
 			if input.Version == "" {
 				return "", errors.New("--version must be specified")
 			}
-			return i.interpolateValueIntoYAML(input, input.Version)
+			return i.interpolateValueIntoYAML(refs, chain, input, input.Version)
 		},
 		"variable": func(key string) (string, error) {
-			if input.Variables == nil {
-				return "", errors.New("--variable or --variables-file must be specified")
-			}
-			val, ok := input.Variables[key]
-			if !ok {
-				return "", fmt.Errorf("could not find variable with key '%s'", key)
-			}
-			return i.interpolateValueIntoYAML(input, val)
+			return lookup("variable", input.Variables, key,
+				"--variable or --variables-file must be specified",
+				fmt.Sprintf("could not find variable with key '%s'", key))
 		},
 		"icon": func() (string, error) {
 			if input.IconImage == "" {
@@ -156,39 +290,56 @@ func (i Interpolator) interpolate(input InterpolateInput, templateYAML []byte) (
 			return input.IconImage, nil
 		},
 		"instance_group": func(name string) (string, error) {
-			if input.InstanceGroups == nil {
-				return "", errors.New("--instance-groups-directory must be specified")
-			}
-			val, ok := input.InstanceGroups[name]
-			if !ok {
-				return "", fmt.Errorf("could not find instance_group with name '%s'", name)
-			}
-
-			return i.interpolateValueIntoYAML(input, val)
+			return lookup("instance_group", input.InstanceGroups, name,
+				"--instance-groups-directory must be specified",
+				fmt.Sprintf("could not find instance_group with name '%s'", name))
 		},
 		"job": func(name string) (string, error) {
-			if input.Jobs == nil {
-				return "", errors.New("--jobs-directory must be specified")
+			return lookup("job", input.Jobs, name,
+				"--jobs-directory must be specified",
+				fmt.Sprintf("could not find job with name '%s'", name))
+		},
+		"runtime_config": func(name string) (string, error) {
+			return lookup("runtime_config", input.RuntimeConfigs, name,
+				"--runtime-configs-directory must be specified",
+				fmt.Sprintf("could not find runtime_config with name '%s'", name))
+		},
+		"include": func(path string) (string, error) {
+			file := path
+			if !filepath.IsAbs(path) && input.MetadataFile != "" {
+				file = filepath.Join(filepath.Dir(input.MetadataFile), path)
 			}
-			val, ok := input.Jobs[name]
-			if !ok {
-				return "", fmt.Errorf("could not find job with name '%s'", name)
+
+			if filepath.Clean(file) == filepath.Clean(input.MetadataFile) {
+				return "", fmt.Errorf("could not include %q: the metadata cannot include itself", path)
 			}
 
-			return i.interpolateValueIntoYAML(input, val)
-		},
-		"runtime_config": func(name string) (string, error) {
-			if input.RuntimeConfigs == nil {
-				return "", errors.New("--runtime-configs-directory must be specified")
+			for _, source := range chain {
+				if source.helper == "include" && filepath.Clean(source.file) == filepath.Clean(file) {
+					return "", fmt.Errorf("could not include %q: it is already included by %s", path, chainString(chain))
+				}
 			}
-			val, ok := input.RuntimeConfigs[name]
-			if !ok {
-				return "", fmt.Errorf("could not find runtime_config with name '%s'", name)
+
+			contents, err := ioutil.ReadFile(file)
+			if err != nil {
+				return "", fmt.Errorf("could not include %q: %s", path, err)
+			}
+
+			var part interface{}
+			err = yaml.Unmarshal(contents, &part)
+			if err != nil {
+				return "", WithSource(file, fmt.Errorf("cannot unmarshal: %s", err))
 			}
 
-			return i.interpolateValueIntoYAML(input, val)
+			return i.interpolateValueIntoYAML(refs, append(chain[:len(chain):len(chain)], partSource{helper: "include", name: path, file: file}), input, part)
 		},
 		"select": func(field, input string) (string, error) {
+			// NOTE: a missing reference interpolates as null, which has nothing
+			// to select from
+			if input == "null" {
+				return input, nil
+			}
+
 			object := map[string]interface{}{}
 
 			err := json.Unmarshal([]byte(input), &object)
@@ -210,38 +361,185 @@ func (i Interpolator) interpolate(input InterpolateInput, templateYAML []byte) (
 		},
 	}
 
-	t, err := template.New("metadata").
+	for helper, function := range helperLibrary() {
+		templateHelpers[helper] = function
+	}
+
+	t, err := template.New(name).
 		Delims("$(", ")").
 		Funcs(templateHelpers).
 		Parse(string(templateYAML))
 
 	if err != nil {
-		return nil, fmt.Errorf("template parsing failed: %s", err)
+		return nil, nil, fmt.Errorf("template parsing failed: %s", err)
 	}
 
+	// NOTE: variables read as .name, or $.name, are used like the variable
+	// helper
+	walkCommands(t.Tree.Root, func(command *parse.CommandNode) {
+		for _, arg := range command.Args {
+			switch field := arg.(type) {
+			case *parse.FieldNode:
+				refs.use("variable", field.Ident[0])
+			case *parse.VariableNode:
+				if len(field.Ident) > 1 && field.Ident[0] == "$" {
+					refs.use("variable", field.Ident[1])
+				}
+			}
+		}
+	})
+
 	var buffer bytes.Buffer
 	err = t.Execute(&buffer, input.Variables)
 	if err != nil {
-		return nil, fmt.Errorf("template execution failed: %s", err)
+		return nil, t.Tree, fmt.Errorf("template execution failed: %s", err)
+	}
+
+	return buffer.Bytes(), t.Tree, nil
+}
+
+func (refs *references) addMissing(chain []partSource, helper, name, message string) {
+	for _, missing := range refs.missing {
+		if chainString(missing.chain) == chainString(chain) && missing.helper == helper && missing.name == name {
+			return
+		}
+	}
+
+	refs.missing = append(refs.missing, missingReference{
+		chain:   chain,
+		helper:  helper,
+		name:    name,
+		message: message,
+	})
+}
+
+func (refs *references) use(helper, name string) {
+	if refs.used[helper] == nil {
+		refs.used[helper] = map[string]bool{}
+	}
+	refs.used[helper][name] = true
+}
+
+// fail keeps the first error from within a part, with the chain of parts that
+// leads to it. Errors outside of parts are returned as they are.
+func (refs *references) fail(chain []partSource, err error) error {
+	if len(chain) == 0 {
+		return err
+	}
+
+	if refs.failure == nil {
+		refs.failure = SourceError{Sources: chainSources(chain), Err: err}
+		refs.failureChain = chain
+	}
+
+	return refs.failure
+}
+
+func chainSources(chain []partSource) []string {
+	var sources []string
+	for _, source := range chain {
+		sources = append(sources, source.String())
 	}
 
-	return buffer.Bytes(), nil
+	return sources
 }
 
-func (i Interpolator) interpolateValueIntoYAML(input InterpolateInput, val interface{}) (string, error) {
+func chainString(chain []partSource) string {
+	return strings.Join(chainSources(chain), " > ")
+}
+
+func walkCommands(node parse.Node, visit func(*parse.CommandNode)) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			walkCommands(child, visit)
+		}
+	case *parse.ActionNode:
+		walkCommands(n.Pipe, visit)
+	case *parse.IfNode:
+		walkCommands(n.Pipe, visit)
+		walkCommands(n.List, visit)
+		walkCommands(n.ElseList, visit)
+	case *parse.RangeNode:
+		walkCommands(n.Pipe, visit)
+		walkCommands(n.List, visit)
+		walkCommands(n.ElseList, visit)
+	case *parse.WithNode:
+		walkCommands(n.Pipe, visit)
+		walkCommands(n.List, visit)
+		walkCommands(n.ElseList, visit)
+	case *parse.TemplateNode:
+		walkCommands(n.Pipe, visit)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, command := range n.Cmds {
+			walkCommands(command, visit)
+		}
+	case *parse.CommandNode:
+		visit(n)
+		for _, arg := range n.Args {
+			walkCommands(arg, visit)
+		}
+	}
+}
+
+// warnUnused logs the variables and parts that were loaded but never
+// referenced by the metadata or the parts it references.
+func (i Interpolator) warnUnused(refs *references, input InterpolateInput) {
+	if i.logger == nil {
+		return
+	}
+
+	var unused []string
+	for _, loaded := range []struct {
+		helper string
+		values map[string]interface{}
+	}{
+		{helper: "variable", values: input.Variables},
+		{helper: "bosh_variable", values: input.BOSHVariables},
+		{helper: "form", values: input.FormTypes},
+		{helper: "property", values: input.PropertyBlueprints},
+		{helper: "instance_group", values: input.InstanceGroups},
+		{helper: "job", values: input.Jobs},
+		{helper: "runtime_config", values: input.RuntimeConfigs},
+	} {
+		var names []string
+		for name := range loaded.values {
+			if !refs.used[loaded.helper][name] {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			unused = append(unused, fmt.Sprintf("- %s %q", loaded.helper, name))
+		}
+	}
+
+	if len(unused) > 0 {
+		i.logger.Printf("Warning: the following were loaded but are not referenced by the metadata:\n%s", strings.Join(unused, "\n"))
+	}
+}
+
+func (i Interpolator) interpolateValueIntoYAML(refs *references, chain []partSource, input InterpolateInput, val interface{}) (string, error) {
 	initialYAML, err := yaml.Marshal(val)
 	if err != nil {
 		return "", err // should never happen
 	}
 
-	interpolatedYAML, err := i.interpolate(input, initialYAML)
+	interpolatedYAML, err := i.interpolate(refs, chain, input, initialYAML)
 	if err != nil {
-		return "", fmt.Errorf("unable to interpolate value: %s", err)
+		return "", err
 	}
 
 	inlinedYAML, err := i.yamlMarshalOneLine(interpolatedYAML)
 	if err != nil {
-		return "", err // un-tested
+		return "", refs.fail(chain, fmt.Errorf("interpolated value is not valid YAML: %s", err))
 	}
 
 	return string(inlinedYAML), nil
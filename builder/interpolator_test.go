@@ -2,6 +2,7 @@ package builder_test
 
 import (
 	. "github.com/pivotal-cf/kiln/builder"
+	"github.com/pivotal-cf/kiln/builder/fakes"
 	yaml "gopkg.in/yaml.v2"
 
 	. "github.com/onsi/ginkgo"
@@ -389,6 +390,48 @@ some_runtime_configs:
 		})
 	})
 
+	Context("when parts are loaded but not referenced", func() {
+		It("warns about them", func() {
+			logger := &fakes.Logger{}
+			interpolator = NewInterpolatorWithLogger(logger)
+
+			input.Variables["some-unused-variable"] = "some-value"
+			input.Jobs["some-unused-job"] = Metadata{"name": "some-unused-job"}
+			input.FormTypes["some-unused-form"] = Metadata{"name": "some-unused-form"}
+
+			_, err := interpolator.Interpolate(input, []byte(templateYAML))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintfCall.Receives.LogLines).To(Equal([]string{`Warning: the following were loaded but are not referenced by the metadata:
+- variable "some-unused-variable"
+- form "some-unused-form"
+- job "some-unused-job"`}))
+		})
+
+		It("does not warn about variables read as fields", func() {
+			logger := &fakes.Logger{}
+			interpolator = NewInterpolatorWithLogger(logger)
+
+			input.Variables["some_field"] = "some-value"
+			input.Variables["some_root_field"] = "other-value"
+
+			_, err := interpolator.Interpolate(input, []byte(templateYAML+`
+some_field: $( .some_field | default "fallback" )
+some_root_field: $( with .some_field )$( $.some_root_field )$( end )
+`))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintfCall.Receives.LogLines).To(BeEmpty())
+		})
+
+		It("does not warn without a logger", func() {
+			input.Variables["some-unused-variable"] = "some-value"
+
+			_, err := interpolator.Interpolate(input, []byte(templateYAML))
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
 	Context("failure cases", func() {
 		Context("when the requested form name is not found", func() {
 			It("returns an error", func() {
@@ -647,6 +690,102 @@ stemcell_criteria: $( stemcell "windows" )
 			})
 		})
 
+		Context("when more than one reference is missing", func() {
+			It("reports all of them with their sources", func() {
+				input.MetadataFile = "base.yml"
+				input.InstanceGroups["some-instance-group"] = Part{
+					File: "instance-groups/some-instance-group.yml",
+					Name: "some-instance-group",
+					Metadata: Metadata{
+						"name":      "some-instance-group",
+						"templates": []string{"$( job \"some-missing-job\" )"},
+					},
+				}
+
+				_, err := interpolator.Interpolate(input, []byte(`---
+name: $( variable "some-missing-variable" )
+version: $( release "some-missing-release" | select "version" )
+some_form_types:
+- $( form "some-missing-form" )
+- $( form "some-missing-form" )
+some_job_types:
+- $( instance_group "some-instance-group" )
+`))
+				Expect(err).To(MatchError(`template execution failed: unresolved references:
+- base.yml:2: could not find variable with key 'some-missing-variable'
+- base.yml:3: could not find release with name 'some-missing-release'
+- base.yml:5: could not find form with key 'some-missing-form'
+- base.yml:6: could not find form with key 'some-missing-form'
+- base.yml:8 > instance_group "some-instance-group" (instance-groups/some-instance-group.yml): could not find job with name 'some-missing-job'`))
+			})
+		})
+
+		Context("when a nested part fails to interpolate", func() {
+			It("names the metadata line, parts and files that lead to it", func() {
+				input.MetadataFile = "base.yml"
+				input.InstanceGroups["some-instance-group"] = Part{
+					File:     "instance-groups/some-instance-group.yml",
+					Name:     "some-instance-group",
+					Metadata: Metadata{"templates": []string{"$( job \"some-job\" )"}},
+				}
+				input.Jobs["some-job"] = Part{
+					File:     "jobs/some-job.yml",
+					Name:     "some-job",
+					Metadata: Metadata{"release": "$( release \"some-release\" | select \"key-not-there\" )"},
+				}
+
+				_, err := interpolator.Interpolate(input, []byte(`---
+name: some-name
+job_types:
+- $( instance_group "some-instance-group" )
+`))
+				Expect(err).To(BeAssignableToTypeOf(SourceError{}))
+				Expect(err.(SourceError).Sources).To(Equal([]string{
+					"base.yml:4",
+					`instance_group "some-instance-group" (instance-groups/some-instance-group.yml)`,
+					`job "some-job" (jobs/some-job.yml)`,
+				}))
+				Expect(err.Error()).To(HavePrefix(`base.yml:4 > instance_group "some-instance-group" (instance-groups/some-instance-group.yml) > job "some-job" (jobs/some-job.yml): unable to interpolate value:`))
+				Expect(err.Error()).To(ContainSubstring(`could not select "key-not-there", key does not exist`))
+			})
+		})
+
+		Context("when a part interpolates into invalid YAML", func() {
+			It("names the metadata line, the part and its file", func() {
+				input.MetadataFile = "base.yml"
+				input.Variables = map[string]interface{}{"some_text": "first line\n  second: line"}
+				input.PropertyBlueprints = map[string]interface{}{
+					"some-property": Part{
+						File:     "properties/some-property.yml",
+						Name:     "some-property",
+						Metadata: Metadata{"name": "some-property", "default": "$( .some_text )"},
+					},
+				}
+
+				_, err := interpolator.Interpolate(input, []byte(`---
+name: some-name
+property_blueprints:
+- $( property "some-property" )
+`))
+				Expect(err).To(BeAssignableToTypeOf(SourceError{}))
+				Expect(err.(SourceError).Sources).To(Equal([]string{
+					"base.yml:4",
+					`property "some-property" (properties/some-property.yml)`,
+				}))
+				Expect(err.Error()).To(ContainSubstring("interpolated value is not valid YAML"))
+			})
+		})
+
+		Context("when the interpolated metadata is not valid YAML", func() {
+			It("names the metadata file", func() {
+				input.MetadataFile = "base.yml"
+				input.Variables = map[string]interface{}{"some-text": "some: text"}
+
+				_, err := interpolator.Interpolate(input, []byte(`name: $( variable "some-text" | trim )`))
+				Expect(err).To(MatchError(HavePrefix("base.yml: interpolated metadata is not valid YAML:")))
+			})
+		})
+
 		Context("input to regexReplaceAll is not valid regex", func() {
 			It("returns an error", func() {
 				interpolator := NewInterpolator()
@@ -70,24 +70,24 @@ func (r MetadataPartsDirectoryReader) readMetadataRecursivelyFromDir(path string
 			var fileVars map[string]interface{}
 			err = yaml.Unmarshal([]byte(data), &fileVars)
 			if err != nil {
-				return fmt.Errorf("cannot unmarshal '%s': %s", filePath, err)
+				return WithSource(filePath, fmt.Errorf("cannot unmarshal: %s", err))
 			}
 
 			var ok bool
 			vars, ok = fileVars[r.topLevelKey]
 			if !ok {
-				return fmt.Errorf("not a %s file: %q", r.topLevelKey, filePath)
+				return WithSource(filePath, fmt.Errorf("not a %s file", r.topLevelKey))
 			}
 		} else {
 			err = yaml.Unmarshal([]byte(data), &vars)
 			if err != nil {
-				return fmt.Errorf("cannot unmarshal '%s': %s", filePath, err)
+				return WithSource(filePath, fmt.Errorf("cannot unmarshal: %s", err))
 			}
 		}
 
-		parts, err = r.readMetadataIntoParts(pathpkg.Base(filePath), vars, parts)
+		parts, err = r.readMetadataIntoParts(filePath, vars, parts)
 		if err != nil {
-			return fmt.Errorf("file '%s' with top-level key '%s' has an invalid format: %s", filePath, r.topLevelKey, err)
+			return WithSource(filePath, fmt.Errorf("invalid format: %s", err))
 		}
 
 		return nil
@@ -125,7 +125,7 @@ func (r MetadataPartsDirectoryReader) readMetadataIntoParts(fileName string, var
 	return parts, nil
 }
 
-func (r MetadataPartsDirectoryReader) buildPartFromMetadata(metadata map[interface{}]interface{}, legacyFilename string) (Part, error) {
+func (r MetadataPartsDirectoryReader) buildPartFromMetadata(metadata map[interface{}]interface{}, fileName string) (Part, error) {
 	name, ok := metadata["alias"].(string)
 	if !ok {
 		name, ok = metadata["name"].(string)
@@ -135,7 +135,7 @@ func (r MetadataPartsDirectoryReader) buildPartFromMetadata(metadata map[interfa
 	}
 	delete(metadata, "alias")
 
-	return Part{File: legacyFilename, Name: name, Metadata: metadata}, nil
+	return Part{File: fileName, Name: name, Metadata: metadata}, nil
 }
 
 func (r MetadataPartsDirectoryReader) orderWithOrderFromFile(path string, parts []Part) ([]Part, error) {
@@ -154,12 +154,12 @@ func (r MetadataPartsDirectoryReader) orderWithOrderFromFile(path string, parts
 	var files map[string][]interface{}
 	err = yaml.Unmarshal([]byte(data), &files)
 	if err != nil {
-		return []Part{}, fmt.Errorf("Invalid format for '%s': %s", orderPath, err)
+		return []Part{}, WithSource(orderPath, fmt.Errorf("Invalid format: %s", err))
 	}
 
 	orderedNames, ok := files[r.orderKey]
 	if !ok {
-		return []Part{}, fmt.Errorf("Could not find top-level order key '%s' in '%s'", r.orderKey, orderPath)
+		return []Part{}, WithSource(orderPath, fmt.Errorf("Could not find top-level order key '%s'", r.orderKey))
 	}
 
 	var outputs []Part
@@ -172,7 +172,7 @@ func (r MetadataPartsDirectoryReader) orderWithOrderFromFile(path string, parts
 			}
 		}
 		if !found {
-			return []Part{}, fmt.Errorf("file specified in _order.yml %q does not exist in %q", name, path)
+			return []Part{}, WithSource(orderPath, fmt.Errorf("file specified in _order.yml %q does not exist in %q", name, path))
 		}
 	}
 
@@ -54,7 +54,7 @@ type: password
 			Expect(err).NotTo(HaveOccurred())
 			Expect(vars).To(Equal([]Part{
 				{
-					File: "vars-file-1.yml",
+					File: filepath.Join(tempDir, "vars-file-1.yml"),
 					Name: "variable-1",
 					Metadata: map[interface{}]interface{}{
 						"name": "variable-1",
@@ -62,7 +62,7 @@ type: password
 					},
 				},
 				{
-					File: "vars-file-1.yml",
+					File: filepath.Join(tempDir, "vars-file-1.yml"),
 					Name: "variable-2-alias",
 					Metadata: map[interface{}]interface{}{
 						"name": "variable-2",
@@ -70,7 +70,7 @@ type: password
 					},
 				},
 				{
-					File: "vars-file-2.yml",
+					File: filepath.Join(tempDir, "vars-file-2.yml"),
 					Name: "variable-3",
 					Metadata: map[interface{}]interface{}{
 						"name": "variable-3",
@@ -170,7 +170,7 @@ variables:
 				Expect(err).NotTo(HaveOccurred())
 				Expect(vars).To(Equal([]Part{
 					{
-						File: "vars-file-1.yml",
+						File: filepath.Join(tempDir, "vars-file-1.yml"),
 						Name: "variable-1",
 						Metadata: map[interface{}]interface{}{
 							"name": "variable-1",
@@ -178,7 +178,7 @@ variables:
 						},
 					},
 					{
-						File: "vars-file-1.yml",
+						File: filepath.Join(tempDir, "vars-file-1.yml"),
 						Name: "variable-2",
 						Metadata: map[interface{}]interface{}{
 							"name": "variable-2",
@@ -186,7 +186,7 @@ variables:
 						},
 					},
 					{
-						File: "vars-file-2.yml",
+						File: filepath.Join(tempDir, "vars-file-2.yml"),
 						Name: "variable-3",
 						Metadata: map[interface{}]interface{}{
 							"name": "variable-3",
@@ -205,7 +205,7 @@ variables:
 
 					_, err = reader.Read(tempDir)
 					Expect(err).To(HaveOccurred())
-					Expect(err.Error()).To(MatchRegexp(`not a variables file: .*not-a-vars-file\.yml`))
+					Expect(err.Error()).To(MatchRegexp(`not-a-vars-file\.yml: not a variables file`))
 				})
 			})
 		})
@@ -272,7 +272,7 @@ variables:
 				Expect(err).NotTo(HaveOccurred())
 				Expect(vars).To(Equal([]Part{
 					{
-						File: "vars-file-2.yml",
+						File: filepath.Join(tempDir, "vars-file-2.yml"),
 						Name: "variable-3",
 						Metadata: map[interface{}]interface{}{
 							"name": "variable-3",
@@ -280,7 +280,7 @@ variables:
 						},
 					},
 					{
-						File: "vars-file-1.yml",
+						File: filepath.Join(tempDir, "vars-file-1.yml"),
 						Name: "variable-2",
 						Metadata: map[interface{}]interface{}{
 							"name": "variable-2",
@@ -288,7 +288,7 @@ variables:
 						},
 					},
 					{
-						File: "vars-file-1.yml",
+						File: filepath.Join(tempDir, "vars-file-1.yml"),
 						Name: "variable-1",
 						Metadata: map[interface{}]interface{}{
 							"name": "variable-1",
@@ -0,0 +1,27 @@
+package builder
+
+import "strings"
+
+// SourceError is an error in the metadata or one of its parts. Its sources
+// locate the error, from the outermost, such as a line of the metadata, to the
+// part or helper it happened in.
+type SourceError struct {
+	Sources []string
+	Err     error
+}
+
+func (e SourceError) Error() string {
+	return strings.Join(e.Sources, " > ") + ": " + e.Err.Error()
+}
+
+// WithSource adds an outer source to err.
+func WithSource(source string, err error) error {
+	if sourceErr, ok := err.(SourceError); ok {
+		return SourceError{
+			Sources: append([]string{source}, sourceErr.Sources...),
+			Err:     sourceErr.Err,
+		}
+	}
+
+	return SourceError{Sources: []string{source}, Err: err}
+}
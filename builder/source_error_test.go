@@ -0,0 +1,23 @@
+package builder_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/pivotal-cf/kiln/builder"
+)
+
+var _ = Describe("SourceError", func() {
+	It("names its sources from the outermost", func() {
+		err := SourceError{Sources: []string{"base.yml:4", `job "some-job" (jobs/some-job.yml)`}, Err: errors.New("some-error")}
+		Expect(err).To(MatchError(`base.yml:4 > job "some-job" (jobs/some-job.yml): some-error`))
+	})
+
+	Describe("WithSource", func() {
+		It("adds an outer source", func() {
+			err := WithSource("some-directory", WithSource("some-file.yml", errors.New("some-error")))
+			Expect(err).To(Equal(SourceError{Sources: []string{"some-directory", "some-file.yml"}, Err: errors.New("some-error")}))
+		})
+	})
+})
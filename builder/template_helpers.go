@@ -0,0 +1,295 @@
+package builder
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/semver"
+	yamlConverter "github.com/ghodss/yaml"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// helperLibrary has the general purpose helpers of metadata templates. The
+// lookups such as version and variable return JSON, so the helpers take a JSON
+// string as the value it encodes, and the helpers that build lists and maps
+// return JSON in turn.
+func helperLibrary() template.FuncMap {
+	return template.FuncMap{
+		"upper": func(s interface{}) string { return strings.ToUpper(toString(s)) },
+		"lower": func(s interface{}) string { return strings.ToLower(toString(s)) },
+		"trim":  func(s interface{}) string { return strings.TrimSpace(toString(s)) },
+		"join":  join,
+		"split": func(separator string, s interface{}) (string, error) {
+			return encodeJSON("split", strings.Split(toString(s), separator))
+		},
+
+		"default":  defaultValue,
+		"required": required,
+		"coalesce": coalesce,
+
+		"list": list,
+		"dict": dict,
+		"keys": keys,
+		"has":  has,
+
+		"b64enc": func(s interface{}) string { return base64.StdEncoding.EncodeToString([]byte(toString(s))) },
+		"toJson": toJSON,
+		"toYaml": toYAML,
+		"indent": indent,
+
+		"semverCompare": semverCompare,
+		"semverLessThan": func(a, b interface{}) (bool, error) {
+			return semverLess("semverLessThan", a, b)
+		},
+		"semverGreaterThan": func(a, b interface{}) (bool, error) {
+			return semverLess("semverGreaterThan", b, a)
+		},
+	}
+}
+
+func toString(value interface{}) string {
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Sprint(value)
+	}
+
+	if strings.HasPrefix(s, `"`) {
+		var decoded string
+		if json.Unmarshal([]byte(s), &decoded) == nil {
+			return decoded
+		}
+	}
+
+	return s
+}
+
+// fromJSON decodes the JSON that lookups and the collection helpers return,
+// including scalars such as null, false and 0. Any other value is returned as
+// it is.
+func fromJSON(value interface{}) interface{} {
+	s, ok := value.(string)
+	if !ok {
+		return value
+	}
+
+	var decoded interface{}
+	if json.Unmarshal([]byte(s), &decoded) != nil {
+		return value
+	}
+
+	return decoded
+}
+
+func join(separator string, items interface{}) (string, error) {
+	list, err := toList(items)
+	if err != nil {
+		return "", fmt.Errorf("join: %s", err)
+	}
+
+	var elements []string
+	for _, item := range list {
+		elements = append(elements, toString(item))
+	}
+
+	return strings.Join(elements, separator), nil
+}
+
+// defaultValue returns the value unless it is empty, like a missing variable
+// accessed as .name.
+func defaultValue(fallback, value interface{}) interface{} {
+	if isEmpty(value) {
+		return fallback
+	}
+
+	return value
+}
+
+func required(message string, value interface{}) (interface{}, error) {
+	if isEmpty(value) {
+		return nil, errors.New(message)
+	}
+
+	return value, nil
+}
+
+func coalesce(values ...interface{}) interface{} {
+	for _, value := range values {
+		if !isEmpty(value) {
+			return value
+		}
+	}
+
+	return nil
+}
+
+func isEmpty(value interface{}) bool {
+	value = fromJSON(value)
+	if value == nil {
+		return true
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+
+	return false
+}
+
+func list(items ...interface{}) (string, error) {
+	decoded := make([]interface{}, len(items))
+	for i, item := range items {
+		decoded[i] = fromJSON(item)
+	}
+
+	return encodeJSON("list", decoded)
+}
+
+func dict(pairs ...interface{}) (string, error) {
+	if len(pairs)%2 != 0 {
+		return "", errors.New("dict: expected pairs of keys and values")
+	}
+
+	d := map[string]interface{}{}
+	for i := 0; i < len(pairs); i += 2 {
+		d[toString(pairs[i])] = fromJSON(pairs[i+1])
+	}
+
+	return encodeJSON("dict", d)
+}
+
+func keys(m interface{}) (string, error) {
+	v := reflect.ValueOf(fromJSON(m))
+	if v.Kind() != reflect.Map {
+		return "", fmt.Errorf("keys: expected a map, got %T", m)
+	}
+
+	names := []string{}
+	for _, key := range v.MapKeys() {
+		names = append(names, fmt.Sprint(key.Interface()))
+	}
+	sort.Strings(names)
+
+	return encodeJSON("keys", names)
+}
+
+// has reports whether a list contains the item, or a map has it as a key.
+func has(item, collection interface{}) (bool, error) {
+	v := reflect.ValueOf(fromJSON(collection))
+	if v.Kind() == reflect.Map {
+		for _, key := range v.MapKeys() {
+			if fmt.Sprint(key.Interface()) == toString(item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	list, err := toList(collection)
+	if err != nil {
+		return false, fmt.Errorf("has: %s", err)
+	}
+
+	// NOTE: elements are compared as the strings they print as, so that the
+	// JSON strings of lookups and the numbers decoded from JSON match
+	for _, element := range list {
+		if reflect.DeepEqual(element, item) || toString(element) == toString(item) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func toList(items interface{}) ([]interface{}, error) {
+	v := reflect.ValueOf(fromJSON(items))
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("expected a list, got %T", items)
+	}
+
+	list := make([]interface{}, v.Len())
+	for i := range list {
+		list[i] = v.Index(i).Interface()
+	}
+
+	return list, nil
+}
+
+func toYAML(value interface{}) (string, error) {
+	output, err := yaml.Marshal(fromJSON(value))
+	if err != nil {
+		return "", fmt.Errorf("toYaml: %s", err)
+	}
+
+	return strings.TrimSuffix(string(output), "\n"), nil
+}
+
+func toJSON(value interface{}) (string, error) {
+	return encodeJSON("toJson", fromJSON(value))
+}
+
+// encodeJSON goes through YAML, as maps read from YAML do not have string
+// keys.
+func encodeJSON(helper string, value interface{}) (string, error) {
+	output, err := yaml.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("%s: %s", helper, err)
+	}
+
+	output, err = yamlConverter.YAMLToJSON(output)
+	if err != nil {
+		return "", fmt.Errorf("%s: %s", helper, err)
+	}
+
+	return string(output), nil
+}
+
+func indent(spaces int, s string) string {
+	padding := strings.Repeat(" ", spaces)
+	return padding + strings.Replace(s, "\n", "\n"+padding, -1)
+}
+
+func semverCompare(constraint, version interface{}) (bool, error) {
+	c, err := semver.NewConstraint(toString(constraint))
+	if err != nil {
+		return false, fmt.Errorf("semverCompare: %s", err)
+	}
+
+	v, err := semver.NewVersion(toString(version))
+	if err != nil {
+		return false, fmt.Errorf("semverCompare: %s", err)
+	}
+
+	return c.Check(v), nil
+}
+
+func semverLess(helper string, a, b interface{}) (bool, error) {
+	va, err := semver.NewVersion(toString(a))
+	if err != nil {
+		return false, fmt.Errorf("%s: %s", helper, err)
+	}
+
+	vb, err := semver.NewVersion(toString(b))
+	if err != nil {
+		return false, fmt.Errorf("%s: %s", helper, err)
+	}
+
+	return va.LessThan(vb), nil
+}
@@ -0,0 +1,283 @@
+package builder_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/pivotal-cf/kiln/builder"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/pivotal-cf-experimental/gomegamatchers"
+)
+
+var _ = Describe("template helpers", func() {
+	var (
+		input        InterpolateInput
+		interpolator Interpolator
+	)
+
+	BeforeEach(func() {
+		interpolator = NewInterpolator()
+
+		input = InterpolateInput{
+			Version: "1.2.3",
+			Variables: map[string]interface{}{
+				"some-variable":  " Some Value ",
+				"some_list":      []interface{}{"a", "b", "c"},
+				"some_map":       map[interface{}]interface{}{"b": 2, "a": 1},
+				"some_empty":     "",
+				"some_condition": true,
+			},
+		}
+	})
+
+	interpolate := func(template string) string {
+		interpolatedYAML, err := interpolator.Interpolate(input, []byte(template))
+		Expect(err).NotTo(HaveOccurred())
+		return string(interpolatedYAML)
+	}
+
+	Describe("string helpers", func() {
+		It("changes case, trims, joins and splits", func() {
+			Expect(interpolate(`
+upper: $( variable "some-variable" | upper )
+lower: $( variable "some-variable" | lower )
+trimmed: "[$( variable "some-variable" | trim )]"
+joined: $( join "," .some_list )
+split: $( split "." version | toJson )
+bare_split: $( split "," "a,b" )
+`)).To(HelpfullyMatchYAML(`
+upper: SOME VALUE
+lower: some value
+trimmed: "[Some Value]"
+joined: a,b,c
+split: ["1", "2", "3"]
+bare_split: ["a", "b"]
+`))
+		})
+	})
+
+	Describe("conditional helpers", func() {
+		It("defaults empty and missing values", func() {
+			Expect(interpolate(`
+empty: $( .some_empty | default "fallback" )
+missing: $( .some_missing | default "fallback" )
+present: $( variable "some-variable" | trim | default "fallback" )
+coalesced: $( coalesce .some_missing "" "first" "second" )
+`)).To(HelpfullyMatchYAML(`
+empty: fallback
+missing: fallback
+present: Some Value
+coalesced: first
+`))
+		})
+
+		It("treats variables that are null, false or 0 as empty", func() {
+			input.Variables["some_null"] = nil
+			input.Variables["some_false"] = false
+			input.Variables["some_zero"] = 0
+
+			Expect(interpolate(`
+null: $( variable "some_null" | default "fallback" )
+false: $( variable "some_false" | default "fallback" )
+zero: $( variable "some_zero" | default "fallback" )
+`)).To(HelpfullyMatchYAML(`
+null: fallback
+false: fallback
+zero: fallback
+`))
+
+			_, err := interpolator.Interpolate(input, []byte(`required: $( variable "some_false" | required "some_false must be set" )`))
+			Expect(err).To(MatchError(ContainSubstring("some_false must be set")))
+		})
+
+		It("requires values", func() {
+			Expect(interpolate(`required: $( required "needs a version" version )`)).To(HelpfullyMatchYAML(`required: "1.2.3"`))
+
+			_, err := interpolator.Interpolate(input, []byte(`required: $( .some_missing | required "some-missing must be set" )`))
+			Expect(err).To(MatchError(ContainSubstring("some-missing must be set")))
+		})
+	})
+
+	Describe("collection helpers", func() {
+		It("builds and inspects lists and maps", func() {
+			Expect(interpolate(`
+list: $( list "a" 1 true | toJson )
+dict: $( dict "name" "some-name" "count" 2 | toJson )
+keys: $( keys .some_map | toJson )
+has_item: $( has "b" .some_list )
+has_piped: $( .some_list | has "c" )
+has_key: $( has "a" .some_map )
+missing_item: $( has "d" .some_list )
+`)).To(HelpfullyMatchYAML(`
+list: ["a", 1, true]
+dict: {"count": 2, "name": "some-name"}
+keys: ["a", "b"]
+has_item: true
+has_piped: true
+has_key: true
+missing_item: false
+`))
+		})
+
+		It("returns JSON, so that the lists and maps are valid YAML", func() {
+			Expect(interpolate(`
+list: $( list "x" "y" )
+dict: $( dict "k" "v" )
+keys: $( keys .some_map )
+nested: $( list "a" "b" | dict "items" )
+`)).To(HelpfullyMatchYAML(`
+list: ["x", "y"]
+dict: {"k": "v"}
+keys: ["a", "b"]
+nested: {"items": ["a", "b"]}
+`))
+		})
+
+		It("takes the JSON that lookups and other helpers return", func() {
+			Expect(interpolate(`
+has_item: $( variable "some_list" | has "b" )
+missing_item: $( variable "some_list" | has "d" )
+has_key: $( variable "some_map" | has "a" )
+keys: $( variable "some_map" | keys )
+joined: $( variable "some_list" | join "-" )
+split_joined: $( split "," "a,b" | join "+" )
+listed: $( list "x" "y" | has "y" )
+yaml: |
+$( variable "some_list" | toYaml | indent 2 )
+`)).To(HelpfullyMatchYAML(`
+has_item: true
+missing_item: false
+has_key: true
+keys: ["a", "b"]
+joined: a-b-c
+split_joined: a+b
+listed: true
+yaml: |
+  - a
+  - b
+  - c
+`))
+		})
+
+		It("returns an error for pairs that are not complete", func() {
+			_, err := interpolator.Interpolate(input, []byte(`dict: $( dict "name" )`))
+			Expect(err).To(MatchError(ContainSubstring("dict: expected pairs of keys and values")))
+		})
+	})
+
+	Describe("encoding helpers", func() {
+		It("encodes values", func() {
+			Expect(interpolate(`
+encoded: $( variable "some-variable" | trim | b64enc )
+json: $( toJson .some_map )
+yaml: |
+$( toYaml .some_list | indent 2 )
+`)).To(HelpfullyMatchYAML(`
+encoded: U29tZSBWYWx1ZQ==
+json: {"a": 1, "b": 2}
+yaml: |
+  - a
+  - b
+  - c
+`))
+		})
+	})
+
+	Describe("semver helpers", func() {
+		It("compares versions", func() {
+			Expect(interpolate(`
+compare: $( semverCompare ">= 1.2, < 2" version )
+not_compare: $( semverCompare "~1.1" version )
+less: $( semverLessThan version "1.10.0" )
+greater: $( semverGreaterThan version "1.10.0" )
+`)).To(HelpfullyMatchYAML(`
+compare: true
+not_compare: false
+less: true
+greater: false
+`))
+		})
+
+		It("returns an error for invalid versions", func() {
+			_, err := interpolator.Interpolate(input, []byte(`less: $( semverLessThan "not-a-version" version )`))
+			Expect(err).To(MatchError(ContainSubstring("semverLessThan: Invalid Semantic Version")))
+		})
+	})
+
+	Describe("include", func() {
+		var tmpDir string
+
+		BeforeEach(func() {
+			var err error
+			tmpDir, err = ioutil.TempDir("", "include-test")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.MkdirAll(filepath.Join(tmpDir, "includes"), 0755)).To(Succeed())
+
+			input.MetadataFile = filepath.Join(tmpDir, "base.yml")
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(tmpDir)).To(Succeed())
+		})
+
+		It("interpolates the part relative to the metadata", func() {
+			err := ioutil.WriteFile(filepath.Join(tmpDir, "includes", "some-part.yml"), []byte(`
+name: $( variable "some-variable" | trim )
+version: $( version )
+`), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(interpolate(`some_part: $( include "includes/some-part.yml" )`)).To(HelpfullyMatchYAML(`
+some_part:
+  name: Some Value
+  version: 1.2.3
+`))
+		})
+
+		Context("when the included file does not exist", func() {
+			It("returns an error", func() {
+				_, err := interpolator.Interpolate(input, []byte(`some_part: $( include "includes/missing.yml" )`))
+				Expect(err).To(MatchError(ContainSubstring(`could not include "includes/missing.yml"`)))
+			})
+		})
+
+		Context("when an included file includes itself", func() {
+			It("returns an error", func() {
+				err := ioutil.WriteFile(filepath.Join(tmpDir, "includes", "outer.yml"), []byte(`inner: $( include "includes/inner.yml" )`), 0644)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = ioutil.WriteFile(filepath.Join(tmpDir, "includes", "inner.yml"), []byte(`outer: $( include "includes/outer.yml" )`), 0644)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = interpolator.Interpolate(input, []byte(`some_part: $( include "includes/outer.yml" )`))
+				Expect(err).To(MatchError(ContainSubstring(`could not include "includes/outer.yml": it is already included by include "includes/outer.yml"`)))
+			})
+		})
+
+		Context("when the metadata includes itself", func() {
+			It("returns an error", func() {
+				_, err := interpolator.Interpolate(input, []byte(`some_part: $( include "base.yml" )`))
+				Expect(err).To(MatchError(ContainSubstring(`could not include "base.yml": the metadata cannot include itself`)))
+			})
+		})
+
+		Context("when the included file fails to interpolate", func() {
+			It("names the file in the error", func() {
+				err := ioutil.WriteFile(filepath.Join(tmpDir, "includes", "outer.yml"), []byte(`inner: $( include "includes/inner.yml" )`), 0644)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = ioutil.WriteFile(filepath.Join(tmpDir, "includes", "inner.yml"), []byte(`name: $( required "some-value must be set" .some_missing )`), 0644)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = interpolator.Interpolate(input, []byte(`some_part: $( include "includes/outer.yml" )`))
+				Expect(err).To(MatchError(ContainSubstring(`include "includes/outer.yml"`)))
+				Expect(err).To(MatchError(ContainSubstring(`include "includes/inner.yml"`)))
+				Expect(err).To(MatchError(ContainSubstring("some-value must be set")))
+			})
+		})
+	})
+})
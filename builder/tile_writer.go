@@ -2,13 +2,17 @@ package builder
 
 import (
 	"bytes"
+	"crypto/sha1"
+	"fmt"
 	"gopkg.in/yaml.v2"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
 )
 
 type TileWriter struct {
@@ -32,6 +36,7 @@ type zipper interface {
 	Add(path string, file io.Reader) error
 	AddWithMode(path string, file io.Reader, mode os.FileMode) error
 	CreateFolder(path string) error
+	SetModifiedTime(modifiedTime time.Time)
 	Close() error
 }
 
@@ -51,6 +56,8 @@ type WriteInput struct {
 	MigrationDirectories []string
 	ReleaseDirectories   []string
 	EmbedPaths           []string
+	Reproducible         bool
+	ModifiedTime         time.Time
 }
 
 type tileMetadata struct {
@@ -59,11 +66,19 @@ type tileMetadata struct {
 
 type release struct {
 	File string `yaml:"file"`
+	SHA1 string `yaml:"sha1"`
+}
+
+type releaseTarball struct {
+	path string
+	size int64
 }
 
 func (w TileWriter) Write(generatedMetadataContents []byte, input WriteInput) error {
 	w.logger.Printf("Building %s...", input.OutputFile)
 
+	timings := newPhaseTimings()
+
 	f, err := w.filesystem.Create(input.OutputFile)
 	if err != nil {
 		return err
@@ -71,47 +86,97 @@ func (w TileWriter) Write(generatedMetadataContents []byte, input WriteInput) er
 	defer f.Close()
 
 	w.zipper.SetWriter(f)
+	w.zipper.SetModifiedTime(input.ModifiedTime)
 
 	err = w.addToZipper(filepath.Join("metadata", "metadata.yml"), bytes.NewBuffer(generatedMetadataContents), input.OutputFile)
 	if err != nil {
 		w.removeOutputFile(input.OutputFile)
 		return err
 	}
+	timings.finish("metadata")
 
 	err = w.addMigrations(input.MigrationDirectories, input.OutputFile)
 	if err != nil {
 		w.removeOutputFile(input.OutputFile)
 		return err
 	}
+	timings.finish("migrations")
 
 	if input.StubReleases {
 		err = w.addStubReleases(generatedMetadataContents, input.OutputFile)
 	} else {
-		err = w.addReleases(input.ReleaseDirectories, input.OutputFile)
+		err = w.addReleases(generatedMetadataContents, input.ReleaseDirectories, input.Reproducible, input.OutputFile)
 	}
 	if err != nil {
 		w.removeOutputFile(input.OutputFile)
 		return err
 	}
+	timings.finish("releases")
 
-	err = w.addEmbeddedPaths(input.EmbedPaths, input.OutputFile)
+	err = w.addEmbeddedPaths(input.EmbedPaths, input.Reproducible, input.OutputFile)
 	if err != nil {
 		w.removeOutputFile(input.OutputFile)
 		return err
 	}
+	timings.finish("embeds")
 
 	err = w.zipper.Close()
 	if err != nil {
 		w.removeOutputFile(input.OutputFile)
 		return err
 	}
+	timings.finish("closing")
+
+	w.logger.Println(timings.summary(input.OutputFile))
 
 	return nil
 }
 
-func (w TileWriter) addReleases(releasesDirs []string, outputFile string) error {
+// addReleases streams the release tarballs into the tile, reporting progress
+// and checking each tarball against the SHA1 the metadata was baked with.
+func (w TileWriter) addReleases(generatedMetadataContents []byte, releasesDirs []string, reproducible bool, outputFile string) error {
+	var metadata tileMetadata
+	err := yaml.Unmarshal(generatedMetadataContents, &metadata)
+	if err != nil {
+		return err
+	}
+
+	var tarballs []releaseTarball
 	for _, releasesDirectory := range releasesDirs {
-		err := w.addReleaseTarballs(releasesDirectory, outputFile)
+		releaseTarballs, err := w.findReleaseTarballs(releasesDirectory)
+		if err != nil {
+			return err
+		}
+
+		tarballs = append(tarballs, releaseTarballs...)
+	}
+
+	if reproducible {
+		sort.SliceStable(tarballs, func(i, j int) bool {
+			return filepath.Base(tarballs[i].path) < filepath.Base(tarballs[j].path)
+		})
+	}
+
+	expectedSHA1s := map[string]string{}
+	for _, release := range metadata.Releases {
+		expectedSHA1s[release.File] = release.SHA1
+	}
+
+	var total int64
+	for _, tarball := range tarballs {
+		total += tarball.size
+	}
+
+	progress := startWriteProgress(w.logger, outputFile, total)
+	defer progress.Stop()
+
+	for _, tarball := range tarballs {
+		expectedSHA1, ok := expectedSHA1s[filepath.Base(tarball.path)]
+		if !ok {
+			w.logger.Printf("Warning: release tarball %s is not in the metadata, so its SHA1 is not checked", tarball.path)
+		}
+
+		err := w.addReleaseTarball(tarball.path, expectedSHA1, progress, outputFile)
 		if err != nil {
 			return err
 		}
@@ -137,8 +202,9 @@ func (w TileWriter) addStubReleases(generatedMetadataContents []byte, outputFile
 	return nil
 }
 
-func (w TileWriter) addReleaseTarballs(releasesDir string, outputFile string) error {
-	return w.filesystem.Walk(releasesDir, func(filePath string, info os.FileInfo, err error) error {
+func (w TileWriter) findReleaseTarballs(releasesDir string) ([]releaseTarball, error) {
+	var tarballs []releaseTarball
+	err := w.filesystem.Walk(releasesDir, func(filePath string, info os.FileInfo, err error) error {
 		isTarball, _ := regexp.MatchString("tgz$|tar.gz$", filePath)
 		if !isTarball {
 			return nil
@@ -152,20 +218,38 @@ func (w TileWriter) addReleaseTarballs(releasesDir string, outputFile string) er
 			return nil
 		}
 
-		file := ioutil.NopCloser(strings.NewReader(""))
-		file, err = w.filesystem.Open(filePath)
-		if err != nil {
-			return err
-		}
-		defer file.Close()
+		tarballs = append(tarballs, releaseTarball{path: filePath, size: info.Size()})
 
-		return w.addToZipper(filepath.Join("releases", filepath.Base(filePath)), file, outputFile)
+		return nil
 	})
+
+	return tarballs, err
 }
 
-func (w TileWriter) addEmbeddedPaths(embedPaths []string, outputFile string) error {
+func (w TileWriter) addReleaseTarball(filePath, expectedSHA1 string, progress io.Writer, outputFile string) error {
+	file, err := w.filesystem.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	hash := sha1.New()
+	err = w.addToZipper(filepath.Join("releases", filepath.Base(filePath)), io.TeeReader(file, io.MultiWriter(hash, progress)), outputFile)
+	if err != nil {
+		return err
+	}
+
+	sum := fmt.Sprintf("%x", hash.Sum(nil))
+	if expectedSHA1 != "" && sum != expectedSHA1 {
+		return fmt.Errorf("release tarball %s has SHA1 %s, but the metadata was baked with %s", filePath, sum, expectedSHA1)
+	}
+
+	return nil
+}
+
+func (w TileWriter) addEmbeddedPaths(embedPaths []string, reproducible bool, outputFile string) error {
 	for _, embedPath := range embedPaths {
-		err := w.addEmbeddedPath(embedPath, outputFile)
+		err := w.addEmbeddedPath(embedPath, reproducible, outputFile)
 		if err != nil {
 			return err
 		}
@@ -174,7 +258,7 @@ func (w TileWriter) addEmbeddedPaths(embedPaths []string, outputFile string) err
 	return nil
 }
 
-func (w TileWriter) addEmbeddedPath(pathToEmbed, outputFile string) error {
+func (w TileWriter) addEmbeddedPath(pathToEmbed string, reproducible bool, outputFile string) error {
 	return w.filesystem.Walk(pathToEmbed, func(filePath string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -195,8 +279,13 @@ func (w TileWriter) addEmbeddedPath(pathToEmbed, outputFile string) error {
 			return err //not tested
 		}
 
+		mode := info.Mode()
+		if reproducible {
+			mode = normalizedMode(mode)
+		}
+
 		entryPath := filepath.Join("embed", filepath.Join(filepath.Base(pathToEmbed), relativePath))
-		return w.addToZipperWithMode(entryPath, file, info.Mode(), outputFile)
+		return w.addToZipperWithMode(entryPath, file, mode, outputFile)
 	})
 }
 
@@ -281,3 +370,13 @@ func (w TileWriter) removeOutputFile(path string) {
 		w.logger.Printf("failed cleaning up zip %q: %s", path, err.Error())
 	}
 }
+
+// normalizedMode reduces a file mode to 0755 or 0644 so that entries do not
+// depend on the umask of the machine that produced the files.
+func normalizedMode(mode os.FileMode) os.FileMode {
+	if mode&0111 != 0 {
+		return 0755
+	}
+
+	return 0644
+}
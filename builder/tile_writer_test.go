@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"time"
 
 	. "github.com/pivotal-cf/kiln/builder"
 	"github.com/pivotal-cf/kiln/builder/fakes"
@@ -275,14 +277,16 @@ releases:
 						StubReleases:         false,
 					}
 
-					err := tileWriter.Write([]byte("generated-metadata-contents"), input)
+					err := tileWriter.Write([]byte("name: generated-metadata-contents"), input)
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(logger.PrintfCall.Receives.LogLines).To(Equal([]string{
 						fmt.Sprintf("Building %s...", outputFile),
 						fmt.Sprintf("Adding metadata/metadata.yml to %s...", outputFile),
 						fmt.Sprintf("Creating empty migrations folder in %s...", outputFile),
+						"Warning: release tarball /some/path/releases/release-1.tgz is not in the metadata, so its SHA1 is not checked",
 						fmt.Sprintf("Adding releases/release-1.tgz to %s...", outputFile),
+						"Warning: release tarball /some/path/releases/release-2.tgz is not in the metadata, so its SHA1 is not checked",
 						fmt.Sprintf("Adding releases/release-2.tgz to %s...", outputFile),
 					}))
 
@@ -300,14 +304,16 @@ releases:
 						StubReleases:         false,
 					}
 
-					err := tileWriter.Write([]byte("generated-metadata-contents"), input)
+					err := tileWriter.Write([]byte("name: generated-metadata-contents"), input)
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(logger.PrintfCall.Receives.LogLines).To(Equal([]string{
 						fmt.Sprintf("Building %s...", outputFile),
 						fmt.Sprintf("Adding metadata/metadata.yml to %s...", outputFile),
 						fmt.Sprintf("Creating empty migrations folder in %s...", outputFile),
+						"Warning: release tarball /some/path/releases/release-1.tgz is not in the metadata, so its SHA1 is not checked",
 						fmt.Sprintf("Adding releases/release-1.tgz to %s...", outputFile),
+						"Warning: release tarball /some/path/releases/release-2.tgz is not in the metadata, so its SHA1 is not checked",
 						fmt.Sprintf("Adding releases/release-2.tgz to %s...", outputFile),
 					}))
 
@@ -315,6 +321,95 @@ releases:
 					Expect(zipper.CreateFolderArgsForCall(0)).To(Equal(filepath.Join("migrations", "v1")))
 				})
 			})
+
+			Context("when the metadata has release SHA1s", func() {
+				var input WriteInput
+
+				BeforeEach(func() {
+					zipper.AddStub = func(path string, file io.Reader) error {
+						_, err := io.Copy(ioutil.Discard, file)
+						return err
+					}
+
+					input = WriteInput{
+						ReleaseDirectories: []string{"/some/path/releases"},
+						OutputFile:         outputFile,
+					}
+				})
+
+				It("writes the tile and logs how long each phase took", func() {
+					err := tileWriter.Write([]byte(`---
+releases:
+- file: release-1.tgz
+  sha1: 08dab5929a7c613a839b7707afe7f3fdc1a248cd
+- file: release-2.tgz
+  sha1: 08dab5929a7c613a839b7707afe7f3fdc1a248cd
+`), input)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(logger.PrintlnCall.Receives.LogLines).To(HaveLen(1))
+					Expect(logger.PrintlnCall.Receives.LogLines[0]).To(MatchRegexp(`^Built %s in \S+ \(metadata \S+, migrations \S+, releases \S+, embeds \S+, closing \S+\)$`, outputFile))
+				})
+
+				Context("when writing the releases takes longer than the progress interval", func() {
+					var restoreInterval func()
+
+					BeforeEach(func() {
+						restoreInterval = SetWriteProgressInterval(5 * time.Millisecond)
+
+						zipper.AddStub = func(path string, file io.Reader) error {
+							_, err := io.Copy(ioutil.Discard, file)
+							time.Sleep(50 * time.Millisecond)
+							return err
+						}
+					})
+
+					AfterEach(func() {
+						restoreInterval()
+					})
+
+					It("logs how much of the releases has been written", func() {
+						err := tileWriter.Write([]byte(`---
+releases:
+- file: release-1.tgz
+  sha1: 08dab5929a7c613a839b7707afe7f3fdc1a248cd
+- file: release-2.tgz
+  sha1: 08dab5929a7c613a839b7707afe7f3fdc1a248cd
+`), input)
+						Expect(err).NotTo(HaveOccurred())
+
+						lines := logger.PrintfCall.Receives.LogLines
+						Expect(lines).To(ContainElement(MatchRegexp(`^Wrote 9 B of releases to %s \(\d+ B/s\)\.\.\.$`, outputFile)))
+						Expect(lines).To(ContainElement(MatchRegexp(`^Wrote 18 B of releases to %s \(\d+ B/s\)\.\.\.$`, outputFile)))
+					})
+				})
+
+				It("warns about the release tarballs that are not in the metadata", func() {
+					err := tileWriter.Write([]byte(`---
+releases:
+- file: release-1.tgz
+  sha1: 08dab5929a7c613a839b7707afe7f3fdc1a248cd
+`), input)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(logger.PrintfCall.Receives.LogLines).To(ContainElement("Warning: release tarball /some/path/releases/release-2.tgz is not in the metadata, so its SHA1 is not checked"))
+					Expect(logger.PrintfCall.Receives.LogLines).NotTo(ContainElement(ContainSubstring("release-1.tgz is not in the metadata")))
+				})
+
+				Context("when a release tarball does not match its SHA1", func() {
+					It("returns an error and removes the tile", func() {
+						err := tileWriter.Write([]byte(`---
+releases:
+- file: release-1.tgz
+  sha1: some-other-sha1
+`), input)
+						Expect(err).To(MatchError("release tarball /some/path/releases/release-1.tgz has SHA1 08dab5929a7c613a839b7707afe7f3fdc1a248cd, but the metadata was baked with some-other-sha1"))
+
+						Expect(filesystem.RemoveCallCount()).To(Equal(1))
+						Expect(filesystem.RemoveArgsForCall(0)).To(Equal(outputFile))
+					})
+				})
+			})
 		})
 
 		Context("when a file to embed is provided", func() {
@@ -358,14 +453,16 @@ releases:
 					StubReleases:         false,
 				}
 
-				err := tileWriter.Write([]byte("generated-metadata-contents"), input)
+				err := tileWriter.Write([]byte("name: generated-metadata-contents"), input)
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(logger.PrintfCall.Receives.LogLines).To(Equal([]string{
 					fmt.Sprintf("Building %s...", outputFile),
 					fmt.Sprintf("Adding metadata/metadata.yml to %s...", outputFile),
 					fmt.Sprintf("Creating empty migrations folder in %s...", outputFile),
+					"Warning: release tarball /some/path/releases/release-1.tgz is not in the metadata, so its SHA1 is not checked",
 					fmt.Sprintf("Adding releases/release-1.tgz to %s...", outputFile),
+					"Warning: release tarball /some/path/releases/release-2.tgz is not in the metadata, so its SHA1 is not checked",
 					fmt.Sprintf("Adding releases/release-2.tgz to %s...", outputFile),
 					fmt.Sprintf("Adding embed/my-file.txt to %s...", outputFile),
 				}))
@@ -423,14 +520,16 @@ releases:
 					StubReleases:         false,
 				}
 
-				err := tileWriter.Write([]byte("generated-metadata-contents"), input)
+				err := tileWriter.Write([]byte("name: generated-metadata-contents"), input)
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(logger.PrintfCall.Receives.LogLines).To(Equal([]string{
 					fmt.Sprintf("Building %s...", outputFile),
 					fmt.Sprintf("Adding metadata/metadata.yml to %s...", outputFile),
 					fmt.Sprintf("Creating empty migrations folder in %s...", outputFile),
+					"Warning: release tarball /some/path/releases/release-1.tgz is not in the metadata, so its SHA1 is not checked",
 					fmt.Sprintf("Adding releases/release-1.tgz to %s...", outputFile),
+					"Warning: release tarball /some/path/releases/release-2.tgz is not in the metadata, so its SHA1 is not checked",
 					fmt.Sprintf("Adding releases/release-2.tgz to %s...", outputFile),
 					fmt.Sprintf("Adding embed/to-embed/my-file-1.txt to %s...", outputFile),
 					fmt.Sprintf("Adding embed/to-embed/my-file-2.txt to %s...", outputFile),
@@ -448,6 +547,72 @@ releases:
 			})
 		})
 
+		Context("when the tile is reproducible", func() {
+			var modifiedTime time.Time
+
+			BeforeEach(func() {
+				modifiedTime = time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+				dirInfo := &fakes.FileInfo{}
+				dirInfo.IsDirReturns(true)
+
+				releaseInfo := &fakes.FileInfo{}
+				releaseInfo.IsDirReturns(false)
+
+				executableInfo := &fakes.FileInfo{}
+				executableInfo.ModeReturns(0700)
+
+				privateInfo := &fakes.FileInfo{}
+				privateInfo.ModeReturns(0600)
+
+				filesystem.WalkStub = func(root string, walkFn filepath.WalkFunc) error {
+					switch root {
+					case "/some/path/releases":
+						walkFn(root, dirInfo, nil)
+						walkFn(filepath.Join(root, "release-b.tgz"), releaseInfo, nil)
+					case "/some/other/path/releases":
+						walkFn(root, dirInfo, nil)
+						walkFn(filepath.Join(root, "release-a.tgz"), releaseInfo, nil)
+					case "/some/path/to-embed":
+						walkFn(root, dirInfo, nil)
+						walkFn(filepath.Join(root, "my-script"), executableInfo, nil)
+						walkFn(filepath.Join(root, "my-file.txt"), privateInfo, nil)
+					}
+					return nil
+				}
+
+				filesystem.OpenStub = func(path string) (io.ReadCloser, error) {
+					return NewBuffer(bytes.NewBufferString("some-contents")), nil
+				}
+			})
+
+			It("stamps entries with the given time, sorts releases and normalizes permissions", func() {
+				err := tileWriter.Write([]byte("name: generated-metadata-contents"), WriteInput{
+					ReleaseDirectories: []string{"/some/path/releases", "/some/other/path/releases"},
+					EmbedPaths:         []string{"/some/path/to-embed"},
+					OutputFile:         outputFile,
+					Reproducible:       true,
+					ModifiedTime:       modifiedTime,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(zipper.SetModifiedTimeCallCount()).To(Equal(1))
+				Expect(zipper.SetModifiedTimeArgsForCall(0)).To(Equal(modifiedTime))
+
+				path, _ := zipper.AddArgsForCall(1)
+				Expect(path).To(Equal(filepath.Join("releases", "release-a.tgz")))
+
+				path, _ = zipper.AddArgsForCall(2)
+				Expect(path).To(Equal(filepath.Join("releases", "release-b.tgz")))
+
+				_, _, mode := zipper.AddWithModeArgsForCall(0)
+				Expect(mode).To(Equal(os.FileMode(0755)))
+
+				_, _, mode = zipper.AddWithModeArgsForCall(1)
+				Expect(mode).To(Equal(os.FileMode(0644)))
+			})
+		})
+
 		Context("failure cases", func() {
 			Context("when creating the zip file fails", func() {
 				BeforeEach(func() {
@@ -569,7 +734,7 @@ releases:
 				})
 
 				It("returns an error", func() {
-					err := tileWriter.Write([]byte("generated-metadata-contents"), input)
+					err := tileWriter.Write([]byte("name: generated-metadata-contents"), input)
 					Expect(err).To(MatchError("failed to open release"))
 
 					Expect(filesystem.RemoveCallCount()).To(Equal(1))
@@ -698,7 +863,7 @@ releases:
 				})
 
 				It("returns an error", func() {
-					err := tileWriter.Write([]byte("generated-metadata-contents"), input)
+					err := tileWriter.Write([]byte("name: generated-metadata-contents"), input)
 					Expect(err).To(MatchError("failed to open embed"))
 
 					Expect(filesystem.RemoveCallCount()).To(Equal(1))
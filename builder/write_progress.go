@@ -0,0 +1,58 @@
+package builder
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pivotal-cf/kiln/internal/progress"
+)
+
+var writeProgressInterval = 10 * time.Second
+
+// startWriteProgress periodically logs how much of the release tarballs has
+// been written into the tile, until the returned Meter is stopped.
+func startWriteProgress(logger logger, outputFile string, total int64) *progress.Meter {
+	return progress.Start(writeProgressInterval, func(m *progress.Meter) {
+		written := m.Written()
+
+		wrote := progress.FormatBytes(written)
+		if total > 0 {
+			wrote = fmt.Sprintf("%s / %s (%d%%)", wrote, progress.FormatBytes(total), written*100/total)
+		}
+
+		logger.Printf("Wrote %s of releases to %s (%s/s)...", wrote, outputFile, progress.FormatBytes(m.Throughput()))
+	})
+}
+
+type phaseTiming struct {
+	name     string
+	duration time.Duration
+}
+
+// phaseTimings records how long each phase of writing a tile took.
+type phaseTimings struct {
+	start  time.Time
+	last   time.Time
+	phases []phaseTiming
+}
+
+func newPhaseTimings() *phaseTimings {
+	now := time.Now()
+	return &phaseTimings{start: now, last: now}
+}
+
+func (t *phaseTimings) finish(name string) {
+	now := time.Now()
+	t.phases = append(t.phases, phaseTiming{name: name, duration: now.Sub(t.last)})
+	t.last = now
+}
+
+func (t *phaseTimings) summary(outputFile string) string {
+	var phases []string
+	for _, phase := range t.phases {
+		phases = append(phases, fmt.Sprintf("%s %s", phase.name, phase.duration.Round(time.Millisecond)))
+	}
+
+	return fmt.Sprintf("Built %s in %s (%s)", outputFile, t.last.Sub(t.start).Round(time.Millisecond), strings.Join(phases, ", "))
+}
@@ -11,7 +11,8 @@ import (
 )
 
 type Zipper struct {
-	writer *zip.Writer
+	writer       *zip.Writer
+	modifiedTime time.Time
 }
 
 func NewZipper() Zipper {
@@ -22,6 +23,12 @@ func (z *Zipper) SetWriter(writer io.Writer) {
 	z.writer = zip.NewWriter(writer)
 }
 
+// SetModifiedTime stamps every subsequent entry with the given time instead of
+// the current time. A zero time restores the default behaviour.
+func (z *Zipper) SetModifiedTime(modifiedTime time.Time) {
+	z.modifiedTime = modifiedTime
+}
+
 func (z Zipper) Add(path string, file io.Reader) error {
 	if z.writer == nil {
 		return errors.New("zipper path must be set")
@@ -30,7 +37,7 @@ func (z Zipper) Add(path string, file io.Reader) error {
 	return z.add(&zip.FileHeader{
 		Name:     path,
 		Method:   zip.Store,
-		Modified: time.Now(),
+		Modified: z.modified(),
 	}, file)
 }
 
@@ -42,13 +49,21 @@ func (z Zipper) AddWithMode(path string, file io.Reader, mode os.FileMode) error
 	fh := &zip.FileHeader{
 		Name:     path,
 		Method:   zip.Store,
-		Modified: time.Now(),
+		Modified: z.modified(),
 	}
 	fh.SetMode(mode)
 
 	return z.add(fh, file)
 }
 
+func (z Zipper) modified() time.Time {
+	if z.modifiedTime.IsZero() {
+		return time.Now()
+	}
+
+	return z.modifiedTime
+}
+
 func (z Zipper) add(fh *zip.FileHeader, file io.Reader) error {
 	f, err := z.writer.CreateHeader(fh)
 	if err != nil {
@@ -76,7 +91,7 @@ func (z Zipper) CreateFolder(path string) error {
 
 	fh := &zip.FileHeader{
 		Name:     path,
-		Modified: time.Now(),
+		Modified: z.modified(),
 	}
 	_, err := z.writer.CreateHeader(fh)
 	if err != nil {
@@ -193,4 +193,34 @@ var _ = Describe("Zipper", func() {
 			})
 		})
 	})
+
+	Describe("SetModifiedTime", func() {
+		It("stamps every entry with the given time", func() {
+			modifiedTime := time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+			zipper := NewZipper()
+			zipper.SetWriter(tileFile)
+			zipper.SetModifiedTime(modifiedTime)
+
+			err := zipper.CreateFolder("some/folder")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = zipper.Add("some/file", strings.NewReader("file-contents"))
+			Expect(err).NotTo(HaveOccurred())
+
+			err = zipper.AddWithMode("some/executable", strings.NewReader("executable-contents"), 0755)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = zipper.Close()
+			Expect(err).NotTo(HaveOccurred())
+
+			reader, err := zip.OpenReader(pathToTile)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(reader.File).To(HaveLen(3))
+			for _, file := range reader.File {
+				Expect(file.FileHeader.Modified.Equal(modifiedTime)).To(BeTrue())
+			}
+		})
+	})
 })
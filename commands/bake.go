@@ -4,6 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"os"
+	"strconv"
+	"time"
 
 	"github.com/pivotal-cf/jhanda"
 	"github.com/pivotal-cf/kiln/builder"
@@ -32,6 +35,7 @@ type releasesService interface {
 //go:generate counterfeiter -o ./fakes/stemcell_service.go --fake-name StemcellService . stemcellService
 type stemcellService interface {
 	FromDirectories(directories []string) (stemcell map[string]interface{}, err error)
+	FromDirectoriesWithKilnfile(directories []string, kilnfilePath string) (stemcell map[string]interface{}, err error)
 	FromKilnfile(path string) (stemcell map[string]interface{}, err error)
 	FromTarball(path string) (stemcell interface{}, err error)
 }
@@ -86,6 +90,7 @@ type Bake struct {
 	checksummer       checksummer
 	tileWriter        tileWriter
 	output            *log.Logger
+	errLogger         *log.Logger
 	templateVariables templateVariablesService
 	boshVariables     boshVariablesService
 	releases          releasesService
@@ -99,8 +104,8 @@ type Bake struct {
 	metadata          metadataService
 
 	Options struct {
-		Kilnfile           string   `short:"kf"  long:"kilnfile"                        description:"path to Kilnfile  (NOTE: mutually exclusive with --stemcell-directory)"`
-		Metadata           string   `short:"m"  long:"metadata"           required:"true" description:"path to the metadata file"`
+		Kilnfile           string   `short:"kf"  long:"kilnfile"                        description:"path to Kilnfile  (NOTE: with --stemcells-directory the stemcells are verified against Kilnfile.lock)"`
+		Metadata           string   `short:"m"  long:"metadata"                           description:"path to the metadata file (default: metadata in the bake section of the Kilnfile)"`
 		OutputFile         string   `short:"o"  long:"output-file"                        description:"path to where the tile will be output"`
 		ReleaseDirectories []string `short:"rd" long:"releases-directory"               description:"path to a directory containing release tarballs"`
 
@@ -113,11 +118,13 @@ type Bake struct {
 		MetadataOnly             bool     `short:"mo"  long:"metadata-only"             description:"don't build a tile, output the metadata to stdout"`
 		MigrationDirectories     []string `short:"md"  long:"migrations-directory"      description:"path to a directory containing migrations"`
 		PropertyDirectories      []string `short:"pd"  long:"properties-directory"      description:"path to a directory containing property blueprints"`
+		Reproducible             bool     `            long:"reproducible"              description:"produces a byte-identical tile for identical inputs (NOTE: enabled when SOURCE_DATE_EPOCH is set)"`
 		RuntimeConfigDirectories []string `short:"rcd" long:"runtime-configs-directory" description:"path to a directory containing runtime configs"`
 		Sha256                   bool     `            long:"sha256"                    description:"calculates a SHA256 checksum of the output file"`
 		StemcellTarball          string   `short:"st"  long:"stemcell-tarball"          description:"deprecated -- path to a stemcell tarball  (NOTE: mutually exclusive with --kilnfile)"`
-		StemcellsDirectories     []string `short:"sd"  long:"stemcells-directory"       description:"path to a directory containing stemcells  (NOTE: mutually exclusive with --kilnfile or --stemcell-tarball)"`
+		StemcellsDirectories     []string `short:"sd"  long:"stemcells-directory"       description:"path to a directory containing stemcells  (NOTE: mutually exclusive with --stemcell-tarball)"`
 		StubReleases             bool     `short:"sr"  long:"stub-releases"             description:"skips importing release tarballs into the tile"`
+		Validate                 bool     `            long:"validate"                  description:"validates the interpolated metadata against Ops Manager rules"`
 		VariableFiles            []string `short:"vf"  long:"variables-file"            description:"path to a file containing variables to interpolate"`
 		Variables                []string `short:"vr"  long:"variable"                  description:"key value pairs of variables to interpolate"`
 		Version                  string   `short:"v"   long:"version"                   description:"version of the tile"`
@@ -128,6 +135,7 @@ func NewBake(
 	interpolator interpolator,
 	tileWriter tileWriter,
 	output *log.Logger,
+	errLogger *log.Logger,
 	templateVariablesService templateVariablesService,
 	boshVariablesService boshVariablesService,
 	releasesService releasesService,
@@ -147,6 +155,7 @@ func NewBake(
 		tileWriter:        tileWriter,
 		checksummer:       checksummer,
 		output:            output,
+		errLogger:         errLogger,
 		templateVariables: templateVariablesService,
 		boshVariables:     boshVariablesService,
 		releases:          releasesService,
@@ -167,6 +176,15 @@ func (b Bake) Execute(args []string) error {
 		return err
 	}
 
+	err = b.loadBakeConfig()
+	if err != nil {
+		return err
+	}
+
+	if b.Options.Metadata == "" {
+		return errors.New("--metadata must be provided unless it is set in the bake section of the Kilnfile")
+	}
+
 	if len(b.Options.InstanceGroupDirectories) == 0 && len(b.Options.JobDirectories) > 0 {
 		return errors.New("--jobs-directory flag requires --instance-groups-directory to also be specified")
 	}
@@ -179,10 +197,6 @@ func (b Bake) Execute(args []string) error {
 		return errors.New("--kilnfile cannot be provided when using --stemcell-tarball")
 	}
 
-	if b.Options.Kilnfile != "" && len(b.Options.StemcellsDirectories) > 0 {
-		return errors.New("--kilnfile cannot be provided when using --stemcells-directory")
-	}
-
 	if b.Options.StemcellTarball != "" && len(b.Options.StemcellsDirectories) > 0 {
 		return errors.New("--stemcell-tarball cannot be provided when using --stemcells-directory")
 	}
@@ -206,6 +220,8 @@ func (b Bake) Execute(args []string) error {
 	if b.Options.StemcellTarball != "" {
 		// TODO remove when stemcell tarball is deprecated
 		stemcellManifest, err = b.stemcell.FromTarball(b.Options.StemcellTarball)
+	} else if b.Options.Kilnfile != "" && len(b.Options.StemcellsDirectories) > 0 {
+		stemcellManifests, err = b.stemcell.FromDirectoriesWithKilnfile(b.Options.StemcellsDirectories, b.Options.Kilnfile)
 	} else if b.Options.Kilnfile != "" {
 		stemcellManifests, err = b.stemcell.FromKilnfile(b.Options.Kilnfile)
 	} else if len(b.Options.StemcellsDirectories) > 0 {
@@ -261,6 +277,7 @@ func (b Bake) Execute(args []string) error {
 	}
 
 	interpolatedMetadata, err := b.interpolator.Interpolate(builder.InterpolateInput{
+		MetadataFile:       b.Options.Metadata,
 		Version:            b.Options.Version,
 		Variables:          templateVariables,
 		BOSHVariables:      boshVariables,
@@ -279,17 +296,38 @@ func (b Bake) Execute(args []string) error {
 		return err
 	}
 
+	if b.Options.Validate {
+		// NOTE: warnings go to the error logger, as with --metadata-only the
+		// output is the metadata
+		err = validateMetadata(interpolatedMetadata, b.errLogger)
+		if err != nil {
+			return err
+		}
+	}
+
 	if b.Options.MetadataOnly {
 		b.output.Printf("%s", interpolatedMetadata)
 		return nil
 	}
 
+	modifiedTime, err := sourceDateEpoch()
+	if err != nil {
+		return err
+	}
+
+	reproducible := b.Options.Reproducible || !modifiedTime.IsZero()
+	if reproducible && modifiedTime.IsZero() {
+		modifiedTime = reproducibleModifiedTime
+	}
+
 	err = b.tileWriter.Write(interpolatedMetadata, builder.WriteInput{
 		OutputFile:           b.Options.OutputFile,
 		StubReleases:         b.Options.StubReleases,
 		MigrationDirectories: b.Options.MigrationDirectories,
 		ReleaseDirectories:   b.Options.ReleaseDirectories,
 		EmbedPaths:           b.Options.EmbedPaths,
+		Reproducible:         reproducible,
+		ModifiedTime:         modifiedTime,
 	})
 	if err != nil {
 		return err
@@ -312,3 +350,21 @@ func (b Bake) Usage() jhanda.Usage {
 		Flags:            b.Options,
 	}
 }
+
+// reproducibleModifiedTime is the earliest time that can be represented in a
+// zip file.
+var reproducibleModifiedTime = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+func sourceDateEpoch() (time.Time, error) {
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		return time.Time{}, nil
+	}
+
+	seconds, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse SOURCE_DATE_EPOCH: %s", err)
+	}
+
+	return time.Unix(seconds, 0).UTC(), nil
+}
@@ -0,0 +1,174 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pivotal-cf/kiln/internal/cargo"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const defaultKilnfile = "Kilnfile"
+
+// loadBakeConfig fills in the options from the bake section of the Kilnfile.
+// Flags that take a single path override the Kilnfile, flags that take many
+// are appended to it. With a bake section, directories that are set by neither
+// are discovered next to the metadata by their conventional names. Without
+// one, bake only uses what the flags name.
+func (b *Bake) loadBakeConfig() error {
+	jobsFromFlags := len(b.Options.JobDirectories) > 0
+
+	hasBakeSection, err := b.loadKilnfileBakeSection()
+	if err != nil {
+		return err
+	}
+
+	if !hasBakeSection {
+		return nil
+	}
+
+	b.discoverConventionalPaths()
+
+	if !jobsFromFlags && len(b.Options.JobDirectories) > 0 && len(b.Options.InstanceGroupDirectories) == 0 {
+		return fmt.Errorf("jobs directory %s requires an instance groups directory, set with --instance-groups-directory or next to the metadata", strings.Join(b.Options.JobDirectories, ", "))
+	}
+
+	return nil
+}
+
+func (b *Bake) loadKilnfileBakeSection() (bool, error) {
+	kilnfilePath := b.Options.Kilnfile
+	if kilnfilePath == "" {
+		kilnfilePath = defaultKilnfile
+	}
+
+	// NOTE: a missing --kilnfile is reported when the stemcells are read from
+	// its lock
+	contents, err := ioutil.ReadFile(kilnfilePath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read Kilnfile: %s", err)
+	}
+
+	var kilnfile cargo.Kilnfile
+	err = yaml.Unmarshal(contents, &kilnfile)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse Kilnfile: %s", err)
+	}
+
+	if kilnfile.Bake == nil {
+		return false, nil
+	}
+
+	// NOTE: a Kilnfile found in the working directory is used like --kilnfile,
+	// so that the stemcells are read from its lock, unless the deprecated
+	// --stemcell-tarball flag is given instead
+	if b.Options.Kilnfile == "" && b.Options.StemcellTarball == "" {
+		b.Options.Kilnfile = kilnfilePath
+	}
+
+	config := *kilnfile.Bake
+	dir := filepath.Dir(kilnfilePath)
+
+	if b.Options.Metadata == "" {
+		b.Options.Metadata = relativeTo(dir, config.Metadata)
+	}
+
+	if b.Options.IconPath == "" {
+		b.Options.IconPath = relativeTo(dir, config.Icon)
+	}
+
+	var variables []string
+	for key, value := range config.Variables {
+		variables = append(variables, fmt.Sprintf("%s=%s", key, value))
+	}
+	sort.Strings(variables)
+	b.Options.Variables = append(variables, b.Options.Variables...)
+
+	b.Options.EmbedPaths = append(allRelativeTo(dir, config.EmbedPaths), b.Options.EmbedPaths...)
+	b.Options.VariableFiles = append(allRelativeTo(dir, config.VariableFiles), b.Options.VariableFiles...)
+
+	for _, directories := range []struct {
+		option *[]string
+		config []string
+	}{
+		{option: &b.Options.BOSHVariableDirectories, config: config.BOSHVariableDirectories},
+		{option: &b.Options.FormDirectories, config: config.FormDirectories},
+		{option: &b.Options.InstanceGroupDirectories, config: config.InstanceGroupDirectories},
+		{option: &b.Options.JobDirectories, config: config.JobDirectories},
+		{option: &b.Options.MigrationDirectories, config: config.MigrationDirectories},
+		{option: &b.Options.PropertyDirectories, config: config.PropertyDirectories},
+		{option: &b.Options.ReleaseDirectories, config: config.ReleaseDirectories},
+		{option: &b.Options.RuntimeConfigDirectories, config: config.RuntimeConfigDirectories},
+		{option: &b.Options.StemcellsDirectories, config: config.StemcellsDirectories},
+	} {
+		*directories.option = append(allRelativeTo(dir, directories.config), *directories.option...)
+	}
+
+	return true, nil
+}
+
+func (b *Bake) discoverConventionalPaths() {
+	if b.Options.Metadata == "" {
+		return
+	}
+
+	metadataDir := filepath.Dir(b.Options.Metadata)
+	for _, directories := range []struct {
+		option     *[]string
+		convention string
+	}{
+		{option: &b.Options.BOSHVariableDirectories, convention: "bosh-variables"},
+		{option: &b.Options.FormDirectories, convention: "forms"},
+		{option: &b.Options.InstanceGroupDirectories, convention: "instance-groups"},
+		{option: &b.Options.JobDirectories, convention: "jobs"},
+		{option: &b.Options.MigrationDirectories, convention: "migrations"},
+		{option: &b.Options.PropertyDirectories, convention: "properties"},
+		{option: &b.Options.ReleaseDirectories, convention: "releases"},
+		{option: &b.Options.RuntimeConfigDirectories, convention: "runtime-configs"},
+		{option: &b.Options.StemcellsDirectories, convention: "stemcells"},
+	} {
+		// NOTE: the deprecated --stemcell-tarball flag takes the place of a
+		// stemcells directory
+		if directories.convention == "stemcells" && b.Options.StemcellTarball != "" {
+			continue
+		}
+
+		if len(*directories.option) == 0 {
+			conventional := filepath.Join(metadataDir, directories.convention)
+			if info, err := os.Stat(conventional); err == nil && info.IsDir() {
+				*directories.option = []string{conventional}
+			}
+		}
+	}
+
+	if b.Options.IconPath == "" {
+		conventional := filepath.Join(metadataDir, "icon.png")
+		if _, err := os.Stat(conventional); err == nil {
+			b.Options.IconPath = conventional
+		}
+	}
+}
+
+func relativeTo(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(dir, path)
+}
+
+func allRelativeTo(dir string, paths []string) []string {
+	var relative []string
+	for _, path := range paths {
+		relative = append(relative, relativeTo(dir, path))
+	}
+
+	return relative
+}
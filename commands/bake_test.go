@@ -1,11 +1,14 @@
 package commands_test
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/pivotal-cf/jhanda"
 	"github.com/pivotal-cf/kiln/builder"
@@ -154,6 +157,7 @@ var _ = Describe("Bake", func() {
 			fakeInterpolator,
 			fakeTileWriter,
 			fakeLogger,
+			fakeLogger,
 			fakeTemplateVariablesService,
 			fakeBOSHVariablesService,
 			fakeReleasesService,
@@ -244,7 +248,8 @@ var _ = Describe("Bake", func() {
 
 			input, metadata := fakeInterpolator.InterpolateArgsForCall(0)
 			Expect(input).To(Equal(builder.InterpolateInput{
-				Version: "1.2.3",
+				MetadataFile: "some-metadata",
+				Version:      "1.2.3",
 				BOSHVariables: map[string]interface{}{
 					"some-secret": builder.Metadata{
 						"name": "some-secret",
@@ -369,6 +374,164 @@ var _ = Describe("Bake", func() {
 			})
 		})
 
+		Context("when the --validate flag is specified", func() {
+			It("validates the interpolated metadata before building the tile", func() {
+				fakeInterpolator.InterpolateReturns([]byte(`---
+name: some-product
+label: Some Product
+product_version: 1.2.3
+metadata_version: "2.5"
+stemcell_criteria:
+  os: ubuntu-xenial
+  version: "250.17"
+`), nil)
+
+				err := bake.Execute([]string{
+					"--metadata", "some-metadata",
+					"--releases-directory", someReleasesDirectory,
+					"--output-file", "some-output-dir/some-product-file-1.2.3-build.4",
+					"--version", "1.2.3",
+					"--validate",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeTileWriter.WriteCallCount()).To(Equal(1))
+			})
+
+			Context("when --metadata-only is also specified", func() {
+				It("logs the warnings to the error logger and only prints the metadata", func() {
+					metadata := `---
+name: some-product
+label: Some Product
+product_version: 1.2.3
+metadata_version: "2.5"
+stemcell_criteria:
+  os: ubuntu-xenial
+  version: "250.17"
+property_blueprints:
+- name: some-unused-property
+  type: string
+`
+					fakeInterpolator.InterpolateReturns([]byte(metadata), nil)
+
+					var output, errOutput bytes.Buffer
+					bake = NewBake(
+						fakeInterpolator,
+						fakeTileWriter,
+						log.New(&output, "", 0),
+						log.New(&errOutput, "", 0),
+						fakeTemplateVariablesService,
+						fakeBOSHVariablesService,
+						fakeReleasesService,
+						fakeStemcellService,
+						fakeFormsService,
+						fakeInstanceGroupsService,
+						fakeJobsService,
+						fakePropertiesService,
+						fakeRuntimeConfigsService,
+						fakeIconService,
+						fakeMetadataService,
+						fakeChecksummer,
+					)
+
+					err := bake.Execute([]string{
+						"--metadata", "some-metadata",
+						"--version", "1.2.3",
+						"--validate",
+						"--metadata-only",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(output.String()).To(Equal(metadata))
+					Expect(errOutput.String()).To(ContainSubstring("warning: property blueprint .properties.some-unused-property is never referenced"))
+				})
+			})
+
+			Context("when the interpolated metadata is invalid", func() {
+				It("returns every violation and does not build the tile", func() {
+					fakeInterpolator.InterpolateReturns([]byte(`---
+name: some-product
+label: Some Product
+product_version: 1.2.3
+metadata_version: "2.5"
+stemcell_criteria:
+  os: ubuntu-xenial
+job_types:
+- name: some-instance-group
+  max_in_flight: 1
+  templates:
+  - name: some-job
+`), nil)
+
+					err := bake.Execute([]string{
+						"--metadata", "some-metadata",
+						"--releases-directory", someReleasesDirectory,
+						"--output-file", "some-output-dir/some-product-file-1.2.3-build.4",
+						"--version", "1.2.3",
+						"--validate",
+					})
+					Expect(err).To(MatchError(`metadata is invalid:
+- stemcell_criteria: stemcell criteria version must be present
+- job_types[0]: job type resource_label must be present
+- job_types[0].templates[0]: template release must be present`))
+
+					Expect(fakeTileWriter.WriteCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when the --reproducible flag is specified", func() {
+			It("writes a reproducible tile", func() {
+				err := bake.Execute([]string{
+					"--metadata", "some-metadata",
+					"--releases-directory", someReleasesDirectory,
+					"--output-file", "some-output-dir/some-product-file-1.2.3-build.4",
+					"--reproducible",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				_, writeInput := fakeTileWriter.WriteArgsForCall(0)
+				Expect(writeInput.Reproducible).To(BeTrue())
+				Expect(writeInput.ModifiedTime).To(Equal(time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)))
+			})
+		})
+
+		Context("when SOURCE_DATE_EPOCH is set", func() {
+			BeforeEach(func() {
+				os.Setenv("SOURCE_DATE_EPOCH", "1546300800")
+			})
+
+			AfterEach(func() {
+				os.Unsetenv("SOURCE_DATE_EPOCH")
+			})
+
+			It("writes a reproducible tile stamped with that time", func() {
+				err := bake.Execute([]string{
+					"--metadata", "some-metadata",
+					"--releases-directory", someReleasesDirectory,
+					"--output-file", "some-output-dir/some-product-file-1.2.3-build.4",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				_, writeInput := fakeTileWriter.WriteArgsForCall(0)
+				Expect(writeInput.Reproducible).To(BeTrue())
+				Expect(writeInput.ModifiedTime).To(Equal(time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)))
+			})
+
+			Context("when SOURCE_DATE_EPOCH is not a number", func() {
+				It("returns an error", func() {
+					os.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+
+					err := bake.Execute([]string{
+						"--metadata", "some-metadata",
+						"--releases-directory", someReleasesDirectory,
+						"--output-file", "some-output-dir/some-product-file-1.2.3-build.4",
+					})
+					Expect(err).To(MatchError(ContainSubstring("failed to parse SOURCE_DATE_EPOCH")))
+				})
+			})
+		})
+
 		Context("when multiple variable files are provided", func() {
 			var otherVariableFile *os.File
 
@@ -461,6 +624,191 @@ var _ = Describe("Bake", func() {
 			})
 		})
 
+		Context("when both the Kilnfile and stemcells-directory are specified", func() {
+			It("verifies the stemcells in the directories against the Kilnfile.lock", func() {
+				err := bake.Execute([]string{
+					"--metadata", "some-metadata",
+					"--output-file", "some-output-dir/some-product-file-1.2.3-build.4",
+					"--stemcells-directory", "some-stemcells-directory",
+					"--kilnfile", "Kilnfile",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeStemcellService.FromDirectoriesWithKilnfileCallCount()).To(Equal(1))
+				directories, kilnfile := fakeStemcellService.FromDirectoriesWithKilnfileArgsForCall(0)
+				Expect(directories).To(Equal([]string{"some-stemcells-directory"}))
+				Expect(kilnfile).To(Equal("Kilnfile"))
+
+				Expect(fakeStemcellService.FromKilnfileCallCount()).To(Equal(0))
+				Expect(fakeStemcellService.FromDirectoriesCallCount()).To(Equal(0))
+			})
+
+			Context("when a stemcell does not match the Kilnfile.lock", func() {
+				It("returns an error", func() {
+					fakeStemcellService.FromDirectoriesWithKilnfileReturns(nil, errors.New("stemcell has SHA1 abc"))
+
+					err := bake.Execute([]string{
+						"--metadata", "some-metadata",
+						"--output-file", "some-output-dir/some-product-file-1.2.3-build.4",
+						"--stemcells-directory", "some-stemcells-directory",
+						"--kilnfile", "Kilnfile",
+					})
+					Expect(err).To(MatchError("failed to parse stemcell: stemcell has SHA1 abc"))
+				})
+			})
+		})
+
+		Context("when the Kilnfile has a bake section", func() {
+			var tileDir string
+
+			BeforeEach(func() {
+				tileDir = filepath.Join(tmpDir, "tile")
+				for _, dir := range []string{"forms", "jobs", "instance-groups", "properties", "releases", "migrations", "other-forms"} {
+					Expect(os.MkdirAll(filepath.Join(tileDir, dir), 0755)).To(Succeed())
+				}
+				Expect(ioutil.WriteFile(filepath.Join(tileDir, "icon.png"), []byte("some-icon"), 0644)).To(Succeed())
+
+				Expect(ioutil.WriteFile(filepath.Join(tileDir, "Kilnfile"), []byte(`---
+bake:
+  metadata: base.yml
+  forms_directories: [other-forms]
+  embed: [extra]
+  variables_files: [variables.yml]
+  variables:
+    some-variable: some-value
+`), 0644)).To(Succeed())
+			})
+
+			It("bakes the tile it declares and discovers conventional directories", func() {
+				err := bake.Execute([]string{
+					"--kilnfile", filepath.Join(tileDir, "Kilnfile"),
+					"--output-file", "some-output-dir/some-product-file-1.2.3-build.4",
+					"--forms-directory", "some-forms-directory",
+					"--variable", "other-variable=other-value",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeMetadataService.ReadArgsForCall(0)).To(Equal(filepath.Join(tileDir, "base.yml")))
+				Expect(fakeIconService.EncodeArgsForCall(0)).To(Equal(filepath.Join(tileDir, "icon.png")))
+
+				Expect(fakeFormsService.FromDirectoriesArgsForCall(0)).To(Equal([]string{filepath.Join(tileDir, "other-forms"), "some-forms-directory"}))
+				Expect(fakeJobsService.FromDirectoriesArgsForCall(0)).To(Equal([]string{filepath.Join(tileDir, "jobs")}))
+				Expect(fakeInstanceGroupsService.FromDirectoriesArgsForCall(0)).To(Equal([]string{filepath.Join(tileDir, "instance-groups")}))
+				Expect(fakePropertiesService.FromDirectoriesArgsForCall(0)).To(Equal([]string{filepath.Join(tileDir, "properties")}))
+				Expect(fakeReleasesService.FromDirectoriesArgsForCall(0)).To(Equal([]string{filepath.Join(tileDir, "releases")}))
+				Expect(fakeRuntimeConfigsService.FromDirectoriesArgsForCall(0)).To(BeEmpty())
+
+				varFiles, variables := fakeTemplateVariablesService.FromPathsAndPairsArgsForCall(0)
+				Expect(varFiles).To(Equal([]string{filepath.Join(tileDir, "variables.yml")}))
+				Expect(variables).To(Equal([]string{"some-variable=some-value", "other-variable=other-value"}))
+
+				_, writeInput := fakeTileWriter.WriteArgsForCall(0)
+				Expect(writeInput.MigrationDirectories).To(Equal([]string{filepath.Join(tileDir, "migrations")}))
+				Expect(writeInput.EmbedPaths).To(Equal([]string{filepath.Join(tileDir, "extra")}))
+			})
+
+			Context("when a jobs directory is discovered without an instance groups directory", func() {
+				It("returns an error that names the directory", func() {
+					Expect(os.RemoveAll(filepath.Join(tileDir, "instance-groups"))).To(Succeed())
+
+					err := bake.Execute([]string{
+						"--kilnfile", filepath.Join(tileDir, "Kilnfile"),
+						"--output-file", "some-output-dir/some-product-file-1.2.3-build.4",
+					})
+					Expect(err).To(MatchError(fmt.Sprintf("jobs directory %s requires an instance groups directory, set with --instance-groups-directory or next to the metadata", filepath.Join(tileDir, "jobs"))))
+				})
+			})
+
+			It("lets flags override the metadata and icon", func() {
+				err := bake.Execute([]string{
+					"--kilnfile", filepath.Join(tileDir, "Kilnfile"),
+					"--output-file", "some-output-dir/some-product-file-1.2.3-build.4",
+					"--metadata", "some-metadata",
+					"--icon", "some-icon-path",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeMetadataService.ReadArgsForCall(0)).To(Equal("some-metadata"))
+				Expect(fakeIconService.EncodeArgsForCall(0)).To(Equal("some-icon-path"))
+			})
+
+			Context("when the Kilnfile is in the working directory", func() {
+				var workingDir string
+
+				BeforeEach(func() {
+					var err error
+					workingDir, err = os.Getwd()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(os.Chdir(tileDir)).To(Succeed())
+				})
+
+				AfterEach(func() {
+					Expect(os.Chdir(workingDir)).To(Succeed())
+				})
+
+				It("reads the bake section without the --kilnfile flag", func() {
+					err := bake.Execute([]string{"--output-file", "some-output-dir/some-product-file-1.2.3-build.4"})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeMetadataService.ReadArgsForCall(0)).To(Equal("base.yml"))
+					Expect(fakeStemcellService.FromKilnfileCallCount()).To(Equal(1))
+					Expect(fakeStemcellService.FromKilnfileArgsForCall(0)).To(Equal("Kilnfile"))
+				})
+
+				Context("when the --stemcell-tarball flag is provided", func() {
+					It("does not read the stemcells from the Kilnfile", func() {
+						err := bake.Execute([]string{
+							"--output-file", "some-output-dir/some-product-file-1.2.3-build.4",
+							"--stemcell-tarball", "some-stemcell-tarball",
+						})
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(fakeStemcellService.FromTarballArgsForCall(0)).To(Equal("some-stemcell-tarball"))
+						Expect(fakeStemcellService.FromKilnfileCallCount()).To(Equal(0))
+					})
+				})
+			})
+
+			Context("when the Kilnfile is not valid YAML", func() {
+				It("returns an error", func() {
+					Expect(ioutil.WriteFile(filepath.Join(tileDir, "Kilnfile"), []byte("bake: ["), 0644)).To(Succeed())
+
+					err := bake.Execute([]string{"--kilnfile", filepath.Join(tileDir, "Kilnfile"), "--output-file", "some-output-file"})
+					Expect(err).To(MatchError(ContainSubstring("failed to parse Kilnfile")))
+				})
+			})
+		})
+
+		Context("when there is no bake section in a Kilnfile", func() {
+			var tileDir string
+
+			BeforeEach(func() {
+				tileDir = filepath.Join(tmpDir, "tile")
+				for _, dir := range []string{"forms", "jobs", "migrations", "stemcells"} {
+					Expect(os.MkdirAll(filepath.Join(tileDir, dir), 0755)).To(Succeed())
+				}
+				Expect(ioutil.WriteFile(filepath.Join(tileDir, "icon.png"), []byte("some-icon"), 0644)).To(Succeed())
+			})
+
+			It("does not discover directories next to the metadata", func() {
+				err := bake.Execute([]string{
+					"--metadata", filepath.Join(tileDir, "base.yml"),
+					"--icon", "some-icon-path",
+					"--output-file", "some-output-dir/some-product-file-1.2.3-build.4",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeFormsService.FromDirectoriesArgsForCall(0)).To(BeEmpty())
+				Expect(fakeJobsService.FromDirectoriesArgsForCall(0)).To(BeEmpty())
+				Expect(fakeIconService.EncodeArgsForCall(0)).To(Equal("some-icon-path"))
+				Expect(fakeStemcellService.FromDirectoriesCallCount()).To(Equal(0))
+				Expect(fakeStemcellService.FromDirectoriesWithKilnfileCallCount()).To(Equal(0))
+
+				_, writeInput := fakeTileWriter.WriteArgsForCall(0)
+				Expect(writeInput.MigrationDirectories).To(BeEmpty())
+			})
+		})
+
 		Context("when neither the --kilnfile nor --stemcell-tarball flags are provided", func() {
 			It("does not error", func() {
 				err := bake.Execute([]string{
@@ -680,7 +1028,7 @@ var _ = Describe("Bake", func() {
 						"--version", "1.2.3",
 					})
 
-					Expect(err).To(MatchError("missing required flag \"--metadata\""))
+					Expect(err).To(MatchError("--metadata must be provided unless it is set in the bake section of the Kilnfile"))
 				})
 			})
 
@@ -714,18 +1062,6 @@ var _ = Describe("Bake", func() {
 				})
 			})
 
-			Context("when both the --kilnfile and --stemcells-directory are provided", func() {
-				It("returns an error", func() {
-					err := bake.Execute([]string{
-						"--metadata", "some-metadata",
-						"--output-file", "some-output-dir/some-product-file-1.2.3-build.4",
-						"--stemcells-directory", "some-stemcell-directory",
-						"--kilnfile", "Kilnfile",
-					})
-					Expect(err).To(MatchError("--kilnfile cannot be provided when using --stemcells-directory"))
-				})
-			})
-
 			//todo: When --stemcell-tarball is removed, delete this test
 			Context("when both the --stemcell-tarball and --kilnfile are provided", func() {
 				It("returns an error", func() {
@@ -0,0 +1,142 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pivotal-cf/jhanda"
+	"github.com/pivotal-cf/kiln/fetcher"
+)
+
+type Cache struct {
+	logger *log.Logger
+
+	releaseCache CacheManager
+
+	Options struct {
+		CacheDirectory string        `short:"cd" long:"cache-directory" description:"path to the release cache shared between tiles (default: $KILN_CACHE_DIR or ~/.kiln/cache)"`
+		UnusedFor      time.Duration `short:"u" long:"unused-for" default:"720h" description:"prune removes releases not used by fetch for this long"`
+		All            bool          `long:"all" description:"prune removes all cached releases"`
+	}
+}
+
+//go:generate counterfeiter -o ./fakes/cache_manager.go --fake-name CacheManager . CacheManager
+type CacheManager interface {
+	List(cacheDir string) ([]fetcher.CachedRelease, error)
+	Prune(cacheDir string, unusedSince time.Time) ([]fetcher.CachedRelease, error)
+	Verify(cacheDir string) ([]fetcher.CachedRelease, error)
+}
+
+func NewCache(logger *log.Logger, releaseCache CacheManager) Cache {
+	return Cache{
+		logger:       logger,
+		releaseCache: releaseCache,
+	}
+}
+
+func (c Cache) Execute(args []string) error {
+	args, err := jhanda.Parse(&c.Options, args)
+	if err != nil {
+		return err
+	}
+
+	if len(args) != 1 {
+		return errors.New("cache requires one of the subcommands list, prune or verify")
+	}
+
+	cacheDir := c.Options.CacheDirectory
+	if cacheDir == "" {
+		cacheDir, err = fetcher.DefaultReleaseCacheDirectory()
+		if err != nil {
+			return err
+		}
+	}
+
+	switch args[0] {
+	case "list":
+		return c.list(cacheDir)
+	case "prune":
+		return c.prune(cacheDir)
+	case "verify":
+		return c.verify(cacheDir)
+	default:
+		return fmt.Errorf("unknown cache subcommand %q, expected list, prune or verify", args[0])
+	}
+}
+
+func (c Cache) list(cacheDir string) error {
+	releases, err := c.releaseCache.List(cacheDir)
+	if err != nil {
+		return err
+	}
+
+	if len(releases) == 0 {
+		c.logger.Printf("no releases in %s\n", cacheDir)
+		return nil
+	}
+
+	var table strings.Builder
+	w := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tVERSION\tSTEMCELL\tSHA1\tSIZE\tLAST USED")
+	for _, release := range releases {
+		stemcell := "-"
+		if release.Compiled() {
+			stemcell = release.StemcellOS + "/" + release.StemcellVersion
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n", release.ID.Name, release.ID.Version, stemcell, release.SHA1, release.Size, release.LastUsed.Format(time.RFC3339))
+	}
+	w.Flush()
+
+	c.logger.Print(table.String())
+
+	return nil
+}
+
+func (c Cache) prune(cacheDir string) error {
+	unusedSince := time.Now().Add(-c.Options.UnusedFor)
+	if c.Options.All {
+		unusedSince = time.Now().Add(time.Hour)
+	}
+
+	pruned, err := c.releaseCache.Prune(cacheDir, unusedSince)
+	for _, release := range pruned {
+		c.logger.Printf("removed %s (%s) %s\n", release.ID.Name, release.ID.Version, release.SHA1)
+	}
+	if err != nil {
+		return err
+	}
+
+	c.logger.Printf("pruned %d releases from %s\n", len(pruned), cacheDir)
+
+	return nil
+}
+
+func (c Cache) verify(cacheDir string) error {
+	corrupt, err := c.releaseCache.Verify(cacheDir)
+	for _, release := range corrupt {
+		c.logger.Printf("removed %s (%s): checksum does not match %s\n", release.ID.Name, release.ID.Version, release.SHA1)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(corrupt) > 0 {
+		return fmt.Errorf("%d cached releases failed verification and were removed", len(corrupt))
+	}
+
+	c.logger.Printf("all releases in %s are valid\n", cacheDir)
+
+	return nil
+}
+
+func (c Cache) Usage() jhanda.Usage {
+	return jhanda.Usage{
+		Description:      "This command manages the release cache shared between tiles by \"kiln fetch\". \"kiln cache list\" prints the cached releases, \"kiln cache prune\" removes releases that have not been used recently and \"kiln cache verify\" removes releases whose checksum no longer matches.",
+		ShortDescription: "manages the release cache",
+		Flags:            c.Options,
+	}
+}
@@ -0,0 +1,137 @@
+package commands_test
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pivotal-cf/jhanda"
+	. "github.com/pivotal-cf/kiln/commands"
+	"github.com/pivotal-cf/kiln/commands/fakes"
+	"github.com/pivotal-cf/kiln/fetcher"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cache", func() {
+	var (
+		writer           strings.Builder
+		fakeReleaseCache *fakes.CacheManager
+		cache            Cache
+
+		cachedRelease fetcher.CachedRelease
+	)
+
+	BeforeEach(func() {
+		writer.Reset()
+		fakeReleaseCache = new(fakes.CacheManager)
+		cache = NewCache(log.New(&writer, "", 0), fakeReleaseCache)
+
+		cachedRelease = fetcher.CachedRelease{
+			ID:              fetcher.ReleaseID{Name: "uaa", Version: "1.2.3"},
+			StemcellOS:      "ubuntu-xenial",
+			StemcellVersion: "621.5",
+			SHA1:            "some-sha1",
+			Size:            42,
+			LastUsed:        time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		}
+	})
+
+	Describe("Execute", func() {
+		Describe("list", func() {
+			It("prints the cached releases", func() {
+				fakeReleaseCache.ListReturns([]fetcher.CachedRelease{cachedRelease}, nil)
+
+				err := cache.Execute([]string{"--cache-directory", "some-cache", "list"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeReleaseCache.ListArgsForCall(0)).To(Equal("some-cache"))
+				Expect(writer.String()).To(Equal(
+					"NAME  VERSION  STEMCELL             SHA1       SIZE  LAST USED\n" +
+						"uaa   1.2.3    ubuntu-xenial/621.5  some-sha1  42    2020-01-02T03:04:05Z\n"))
+			})
+		})
+
+		Describe("prune", func() {
+			It("removes releases not used for the given duration", func() {
+				fakeReleaseCache.PruneReturns([]fetcher.CachedRelease{cachedRelease}, nil)
+
+				err := cache.Execute([]string{"--cache-directory", "some-cache", "--unused-for", "48h", "prune"})
+				Expect(err).NotTo(HaveOccurred())
+
+				cacheDir, unusedSince := fakeReleaseCache.PruneArgsForCall(0)
+				Expect(cacheDir).To(Equal("some-cache"))
+				Expect(unusedSince).To(BeTemporally("~", time.Now().Add(-48*time.Hour), time.Minute))
+				Expect(writer.String()).To(ContainSubstring("removed uaa (1.2.3) some-sha1"))
+				Expect(writer.String()).To(ContainSubstring("pruned 1 releases from some-cache"))
+			})
+
+			Context("when --all is passed", func() {
+				It("removes every release", func() {
+					err := cache.Execute([]string{"--cache-directory", "some-cache", "--all", "prune"})
+					Expect(err).NotTo(HaveOccurred())
+
+					_, unusedSince := fakeReleaseCache.PruneArgsForCall(0)
+					Expect(unusedSince).To(BeTemporally(">", time.Now()))
+				})
+			})
+		})
+
+		Describe("verify", func() {
+			It("reports that the cache is valid", func() {
+				err := cache.Execute([]string{"--cache-directory", "some-cache", "verify"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeReleaseCache.VerifyArgsForCall(0)).To(Equal("some-cache"))
+				Expect(writer.String()).To(ContainSubstring("all releases in some-cache are valid"))
+			})
+
+			Context("when some releases are corrupt", func() {
+				It("returns an error", func() {
+					fakeReleaseCache.VerifyReturns([]fetcher.CachedRelease{cachedRelease}, nil)
+
+					err := cache.Execute([]string{"--cache-directory", "some-cache", "verify"})
+					Expect(err).To(MatchError("1 cached releases failed verification and were removed"))
+					Expect(writer.String()).To(ContainSubstring("removed uaa (1.2.3): checksum does not match some-sha1"))
+				})
+			})
+		})
+
+		Context("failure cases", func() {
+			Context("when no subcommand is given", func() {
+				It("returns an error", func() {
+					err := cache.Execute([]string{})
+					Expect(err).To(MatchError("cache requires one of the subcommands list, prune or verify"))
+				})
+			})
+
+			Context("when the subcommand is unknown", func() {
+				It("returns an error", func() {
+					err := cache.Execute([]string{"--cache-directory", "some-cache", "clean"})
+					Expect(err).To(MatchError(`unknown cache subcommand "clean", expected list, prune or verify`))
+				})
+			})
+
+			Context("when the cache can not be listed", func() {
+				It("returns an error", func() {
+					fakeReleaseCache.ListReturns(nil, errors.New("permission denied"))
+
+					err := cache.Execute([]string{"--cache-directory", "some-cache", "list"})
+					Expect(err).To(MatchError("permission denied"))
+				})
+			})
+		})
+	})
+
+	Describe("Usage", func() {
+		It("returns usage information for the command", func() {
+			Expect(cache.Usage()).To(Equal(jhanda.Usage{
+				Description:      "This command manages the release cache shared between tiles by \"kiln fetch\". \"kiln cache list\" prints the cached releases, \"kiln cache prune\" removes releases that have not been used recently and \"kiln cache verify\" removes releases whose checksum no longer matches.",
+				ShortDescription: "manages the release cache",
+				Flags:            cache.Options,
+			}))
+		})
+	})
+})
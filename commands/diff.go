@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/pivotal-cf/jhanda"
+	"github.com/pivotal-cf/kiln/internal/diff"
+)
+
+type Diff struct {
+	logger *log.Logger
+
+	Options struct {
+		Format string `short:"f" long:"format" default:"text" description:"output format, either text or json"`
+	}
+}
+
+func NewDiff(logger *log.Logger) Diff {
+	return Diff{
+		logger: logger,
+	}
+}
+
+func (d Diff) Execute(args []string) error {
+	args, err := jhanda.Parse(&d.Options, args)
+	if err != nil {
+		return err
+	}
+
+	if len(args) != 2 {
+		return errors.New("diff requires the paths to the old and new tiles or metadata files")
+	}
+
+	if d.Options.Format != "text" && d.Options.Format != "json" {
+		return fmt.Errorf("unknown format %q, expected text or json", d.Options.Format)
+	}
+
+	oldTile, err := diff.ReadTile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %s", args[0], err)
+	}
+
+	newTile, err := diff.ReadTile(args[1])
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %s", args[1], err)
+	}
+
+	report := diff.Compare(oldTile, newTile)
+
+	if d.Options.Format == "json" {
+		contents, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode diff: %s", err)
+		}
+
+		d.logger.Printf("%s\n", contents)
+		return nil
+	}
+
+	d.logger.Printf("%s", report)
+
+	return nil
+}
+
+func (d Diff) Usage() jhanda.Usage {
+	return jhanda.Usage{
+		Description:      "This command compares two tiles or metadata files, given as \"kiln diff <old> <new>\", and prints the property blueprints, job types, releases, stemcell criteria, migrations and embedded files that changed.",
+		ShortDescription: "compares two tiles",
+		Flags:            d.Options,
+	}
+}
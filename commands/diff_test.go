@@ -0,0 +1,124 @@
+package commands_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pivotal-cf/jhanda"
+	. "github.com/pivotal-cf/kiln/commands"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Diff", func() {
+	var (
+		writer      strings.Builder
+		tmpDir      string
+		oldMetadata string
+		newMetadata string
+		diff        Diff
+	)
+
+	BeforeEach(func() {
+		writer.Reset()
+
+		var err error
+		tmpDir, err = ioutil.TempDir("", "diff-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		oldMetadata = filepath.Join(tmpDir, "old.yml")
+		Expect(ioutil.WriteFile(oldMetadata, []byte(`---
+releases:
+- name: some-release
+  version: 1.0.0
+stemcell_criteria:
+  os: ubuntu-xenial
+  version: "250.17"
+`), 0644)).To(Succeed())
+
+		newMetadata = filepath.Join(tmpDir, "new.yml")
+		Expect(ioutil.WriteFile(newMetadata, []byte(`---
+releases:
+- name: some-release
+  version: 1.1.0
+stemcell_criteria:
+  os: ubuntu-xenial
+  version: "250.17"
+`), 0644)).To(Succeed())
+
+		diff = NewDiff(log.New(&writer, "", 0))
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	Describe("Execute", func() {
+		It("prints the differences between the tiles", func() {
+			err := diff.Execute([]string{oldMetadata, newMetadata})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(writer.String()).To(Equal(`Releases:
+  ~ some-release
+      version: 1.0.0 -> 1.1.0
+`))
+		})
+
+		Context("when the json format is requested", func() {
+			It("prints the differences as JSON", func() {
+				err := diff.Execute([]string{"--format", "json", oldMetadata, newMetadata})
+				Expect(err).NotTo(HaveOccurred())
+
+				var report map[string]interface{}
+				Expect(json.Unmarshal([]byte(writer.String()), &report)).To(Succeed())
+				Expect(report["releases"]).To(Equal([]interface{}{
+					map[string]interface{}{
+						"name": "some-release",
+						"type": "changed",
+						"fields": []interface{}{
+							map[string]interface{}{"field": "version", "old": "1.0.0", "new": "1.1.0"},
+						},
+					},
+				}))
+			})
+		})
+
+		Context("failure cases", func() {
+			Context("when two paths are not provided", func() {
+				It("returns an error", func() {
+					err := diff.Execute([]string{oldMetadata})
+					Expect(err).To(MatchError("diff requires the paths to the old and new tiles or metadata files"))
+				})
+			})
+
+			Context("when the format is unknown", func() {
+				It("returns an error", func() {
+					err := diff.Execute([]string{"--format", "xml", oldMetadata, newMetadata})
+					Expect(err).To(MatchError(`unknown format "xml", expected text or json`))
+				})
+			})
+
+			Context("when a tile cannot be read", func() {
+				It("returns an error", func() {
+					err := diff.Execute([]string{oldMetadata, "missing.pivotal"})
+					Expect(err).To(MatchError(ContainSubstring("failed to read missing.pivotal")))
+				})
+			})
+		})
+	})
+
+	Describe("Usage", func() {
+		It("returns usage information for the command", func() {
+			Expect(diff.Usage()).To(Equal(jhanda.Usage{
+				Description:      "This command compares two tiles or metadata files, given as \"kiln diff <old> <new>\", and prints the property blueprints, job types, releases, stemcell criteria, migrations and embedded files that changed.",
+				ShortDescription: "compares two tiles",
+				Flags:            diff.Options,
+			}))
+		})
+	})
+})
@@ -35,17 +35,19 @@ type LocalReleaseDirectory struct {
 		result1 fetcher.LocalReleaseSet
 		result2 error
 	}
-	VerifyChecksumsStub        func(fetcher.LocalReleaseSet, cargo.KilnfileLock) error
+	VerifyChecksumsStub        func(fetcher.LocalReleaseSet, cargo.KilnfileLock) (fetcher.LocalReleaseSet, error)
 	verifyChecksumsMutex       sync.RWMutex
 	verifyChecksumsArgsForCall []struct {
 		arg1 fetcher.LocalReleaseSet
 		arg2 cargo.KilnfileLock
 	}
 	verifyChecksumsReturns struct {
-		result1 error
+		result1 fetcher.LocalReleaseSet
+		result2 error
 	}
 	verifyChecksumsReturnsOnCall map[int]struct {
-		result1 error
+		result1 fetcher.LocalReleaseSet
+		result2 error
 	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
@@ -58,15 +60,16 @@ func (fake *LocalReleaseDirectory) DeleteExtraReleases(arg1 fetcher.LocalRelease
 		arg1 fetcher.LocalReleaseSet
 		arg2 bool
 	}{arg1, arg2})
+	stub := fake.DeleteExtraReleasesStub
+	fakeReturns := fake.deleteExtraReleasesReturns
 	fake.recordInvocation("DeleteExtraReleases", []interface{}{arg1, arg2})
 	fake.deleteExtraReleasesMutex.Unlock()
-	if fake.DeleteExtraReleasesStub != nil {
-		return fake.DeleteExtraReleasesStub(arg1, arg2)
+	if stub != nil {
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.deleteExtraReleasesReturns
 	return fakeReturns.result1
 }
 
@@ -118,15 +121,16 @@ func (fake *LocalReleaseDirectory) GetLocalReleases(arg1 string) (fetcher.LocalR
 	fake.getLocalReleasesArgsForCall = append(fake.getLocalReleasesArgsForCall, struct {
 		arg1 string
 	}{arg1})
+	stub := fake.GetLocalReleasesStub
+	fakeReturns := fake.getLocalReleasesReturns
 	fake.recordInvocation("GetLocalReleases", []interface{}{arg1})
 	fake.getLocalReleasesMutex.Unlock()
-	if fake.GetLocalReleasesStub != nil {
-		return fake.GetLocalReleasesStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.getLocalReleasesReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -175,23 +179,24 @@ func (fake *LocalReleaseDirectory) GetLocalReleasesReturnsOnCall(i int, result1
 	}{result1, result2}
 }
 
-func (fake *LocalReleaseDirectory) VerifyChecksums(arg1 fetcher.LocalReleaseSet, arg2 cargo.KilnfileLock) error {
+func (fake *LocalReleaseDirectory) VerifyChecksums(arg1 fetcher.LocalReleaseSet, arg2 cargo.KilnfileLock) (fetcher.LocalReleaseSet, error) {
 	fake.verifyChecksumsMutex.Lock()
 	ret, specificReturn := fake.verifyChecksumsReturnsOnCall[len(fake.verifyChecksumsArgsForCall)]
 	fake.verifyChecksumsArgsForCall = append(fake.verifyChecksumsArgsForCall, struct {
 		arg1 fetcher.LocalReleaseSet
 		arg2 cargo.KilnfileLock
 	}{arg1, arg2})
+	stub := fake.VerifyChecksumsStub
+	fakeReturns := fake.verifyChecksumsReturns
 	fake.recordInvocation("VerifyChecksums", []interface{}{arg1, arg2})
 	fake.verifyChecksumsMutex.Unlock()
-	if fake.VerifyChecksumsStub != nil {
-		return fake.VerifyChecksumsStub(arg1, arg2)
+	if stub != nil {
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
-		return ret.result1
+		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.verifyChecksumsReturns
-	return fakeReturns.result1
+	return fakeReturns.result1, fakeReturns.result2
 }
 
 func (fake *LocalReleaseDirectory) VerifyChecksumsCallCount() int {
@@ -200,7 +205,7 @@ func (fake *LocalReleaseDirectory) VerifyChecksumsCallCount() int {
 	return len(fake.verifyChecksumsArgsForCall)
 }
 
-func (fake *LocalReleaseDirectory) VerifyChecksumsCalls(stub func(fetcher.LocalReleaseSet, cargo.KilnfileLock) error) {
+func (fake *LocalReleaseDirectory) VerifyChecksumsCalls(stub func(fetcher.LocalReleaseSet, cargo.KilnfileLock) (fetcher.LocalReleaseSet, error)) {
 	fake.verifyChecksumsMutex.Lock()
 	defer fake.verifyChecksumsMutex.Unlock()
 	fake.VerifyChecksumsStub = stub
@@ -213,27 +218,30 @@ func (fake *LocalReleaseDirectory) VerifyChecksumsArgsForCall(i int) (fetcher.Lo
 	return argsForCall.arg1, argsForCall.arg2
 }
 
-func (fake *LocalReleaseDirectory) VerifyChecksumsReturns(result1 error) {
+func (fake *LocalReleaseDirectory) VerifyChecksumsReturns(result1 fetcher.LocalReleaseSet, result2 error) {
 	fake.verifyChecksumsMutex.Lock()
 	defer fake.verifyChecksumsMutex.Unlock()
 	fake.VerifyChecksumsStub = nil
 	fake.verifyChecksumsReturns = struct {
-		result1 error
-	}{result1}
+		result1 fetcher.LocalReleaseSet
+		result2 error
+	}{result1, result2}
 }
 
-func (fake *LocalReleaseDirectory) VerifyChecksumsReturnsOnCall(i int, result1 error) {
+func (fake *LocalReleaseDirectory) VerifyChecksumsReturnsOnCall(i int, result1 fetcher.LocalReleaseSet, result2 error) {
 	fake.verifyChecksumsMutex.Lock()
 	defer fake.verifyChecksumsMutex.Unlock()
 	fake.VerifyChecksumsStub = nil
 	if fake.verifyChecksumsReturnsOnCall == nil {
 		fake.verifyChecksumsReturnsOnCall = make(map[int]struct {
-			result1 error
+			result1 fetcher.LocalReleaseSet
+			result2 error
 		})
 	}
 	fake.verifyChecksumsReturnsOnCall[i] = struct {
-		result1 error
-	}{result1}
+		result1 fetcher.LocalReleaseSet
+		result2 error
+	}{result1, result2}
 }
 
 func (fake *LocalReleaseDirectory) Invocations() map[string][][]interface{} {
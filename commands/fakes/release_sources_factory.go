@@ -10,7 +10,7 @@ import (
 )
 
 type ReleaseSourcesFactory struct {
-	ReleaseSourcesStub        func(cargo.Kilnfile, bool) []fetcher.ReleaseSource
+	ReleaseSourcesStub        func(cargo.Kilnfile, bool) ([]fetcher.ReleaseSource, error)
 	releaseSourcesMutex       sync.RWMutex
 	releaseSourcesArgsForCall []struct {
 		arg1 cargo.Kilnfile
@@ -18,31 +18,34 @@ type ReleaseSourcesFactory struct {
 	}
 	releaseSourcesReturns struct {
 		result1 []fetcher.ReleaseSource
+		result2 error
 	}
 	releaseSourcesReturnsOnCall map[int]struct {
 		result1 []fetcher.ReleaseSource
+		result2 error
 	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *ReleaseSourcesFactory) ReleaseSources(arg1 cargo.Kilnfile, arg2 bool) []fetcher.ReleaseSource {
+func (fake *ReleaseSourcesFactory) ReleaseSources(arg1 cargo.Kilnfile, arg2 bool) ([]fetcher.ReleaseSource, error) {
 	fake.releaseSourcesMutex.Lock()
 	ret, specificReturn := fake.releaseSourcesReturnsOnCall[len(fake.releaseSourcesArgsForCall)]
 	fake.releaseSourcesArgsForCall = append(fake.releaseSourcesArgsForCall, struct {
 		arg1 cargo.Kilnfile
 		arg2 bool
 	}{arg1, arg2})
+	stub := fake.ReleaseSourcesStub
+	fakeReturns := fake.releaseSourcesReturns
 	fake.recordInvocation("ReleaseSources", []interface{}{arg1, arg2})
 	fake.releaseSourcesMutex.Unlock()
-	if fake.ReleaseSourcesStub != nil {
-		return fake.ReleaseSourcesStub(arg1, arg2)
+	if stub != nil {
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
-		return ret.result1
+		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.releaseSourcesReturns
-	return fakeReturns.result1
+	return fakeReturns.result1, fakeReturns.result2
 }
 
 func (fake *ReleaseSourcesFactory) ReleaseSourcesCallCount() int {
@@ -51,7 +54,7 @@ func (fake *ReleaseSourcesFactory) ReleaseSourcesCallCount() int {
 	return len(fake.releaseSourcesArgsForCall)
 }
 
-func (fake *ReleaseSourcesFactory) ReleaseSourcesCalls(stub func(cargo.Kilnfile, bool) []fetcher.ReleaseSource) {
+func (fake *ReleaseSourcesFactory) ReleaseSourcesCalls(stub func(cargo.Kilnfile, bool) ([]fetcher.ReleaseSource, error)) {
 	fake.releaseSourcesMutex.Lock()
 	defer fake.releaseSourcesMutex.Unlock()
 	fake.ReleaseSourcesStub = stub
@@ -64,27 +67,30 @@ func (fake *ReleaseSourcesFactory) ReleaseSourcesArgsForCall(i int) (cargo.Kilnf
 	return argsForCall.arg1, argsForCall.arg2
 }
 
-func (fake *ReleaseSourcesFactory) ReleaseSourcesReturns(result1 []fetcher.ReleaseSource) {
+func (fake *ReleaseSourcesFactory) ReleaseSourcesReturns(result1 []fetcher.ReleaseSource, result2 error) {
 	fake.releaseSourcesMutex.Lock()
 	defer fake.releaseSourcesMutex.Unlock()
 	fake.ReleaseSourcesStub = nil
 	fake.releaseSourcesReturns = struct {
 		result1 []fetcher.ReleaseSource
-	}{result1}
+		result2 error
+	}{result1, result2}
 }
 
-func (fake *ReleaseSourcesFactory) ReleaseSourcesReturnsOnCall(i int, result1 []fetcher.ReleaseSource) {
+func (fake *ReleaseSourcesFactory) ReleaseSourcesReturnsOnCall(i int, result1 []fetcher.ReleaseSource, result2 error) {
 	fake.releaseSourcesMutex.Lock()
 	defer fake.releaseSourcesMutex.Unlock()
 	fake.ReleaseSourcesStub = nil
 	if fake.releaseSourcesReturnsOnCall == nil {
 		fake.releaseSourcesReturnsOnCall = make(map[int]struct {
 			result1 []fetcher.ReleaseSource
+			result2 error
 		})
 	}
 	fake.releaseSourcesReturnsOnCall[i] = struct {
 		result1 []fetcher.ReleaseSource
-	}{result1}
+		result2 error
+	}{result1, result2}
 }
 
 func (fake *ReleaseSourcesFactory) Invocations() map[string][][]interface{} {
@@ -19,6 +19,20 @@ type StemcellService struct {
 		result1 map[string]interface{}
 		result2 error
 	}
+	FromDirectoriesWithKilnfileStub        func([]string, string) (map[string]interface{}, error)
+	fromDirectoriesWithKilnfileMutex       sync.RWMutex
+	fromDirectoriesWithKilnfileArgsForCall []struct {
+		arg1 []string
+		arg2 string
+	}
+	fromDirectoriesWithKilnfileReturns struct {
+		result1 map[string]interface{}
+		result2 error
+	}
+	fromDirectoriesWithKilnfileReturnsOnCall map[int]struct {
+		result1 map[string]interface{}
+		result2 error
+	}
 	FromKilnfileStub        func(string) (map[string]interface{}, error)
 	fromKilnfileMutex       sync.RWMutex
 	fromKilnfileArgsForCall []struct {
@@ -60,15 +74,16 @@ func (fake *StemcellService) FromDirectories(arg1 []string) (map[string]interfac
 	fake.fromDirectoriesArgsForCall = append(fake.fromDirectoriesArgsForCall, struct {
 		arg1 []string
 	}{arg1Copy})
+	stub := fake.FromDirectoriesStub
+	fakeReturns := fake.fromDirectoriesReturns
 	fake.recordInvocation("FromDirectories", []interface{}{arg1Copy})
 	fake.fromDirectoriesMutex.Unlock()
-	if fake.FromDirectoriesStub != nil {
-		return fake.FromDirectoriesStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.fromDirectoriesReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -117,21 +132,92 @@ func (fake *StemcellService) FromDirectoriesReturnsOnCall(i int, result1 map[str
 	}{result1, result2}
 }
 
+func (fake *StemcellService) FromDirectoriesWithKilnfile(arg1 []string, arg2 string) (map[string]interface{}, error) {
+	var arg1Copy []string
+	if arg1 != nil {
+		arg1Copy = make([]string, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.fromDirectoriesWithKilnfileMutex.Lock()
+	ret, specificReturn := fake.fromDirectoriesWithKilnfileReturnsOnCall[len(fake.fromDirectoriesWithKilnfileArgsForCall)]
+	fake.fromDirectoriesWithKilnfileArgsForCall = append(fake.fromDirectoriesWithKilnfileArgsForCall, struct {
+		arg1 []string
+		arg2 string
+	}{arg1Copy, arg2})
+	stub := fake.FromDirectoriesWithKilnfileStub
+	fakeReturns := fake.fromDirectoriesWithKilnfileReturns
+	fake.recordInvocation("FromDirectoriesWithKilnfile", []interface{}{arg1Copy, arg2})
+	fake.fromDirectoriesWithKilnfileMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *StemcellService) FromDirectoriesWithKilnfileCallCount() int {
+	fake.fromDirectoriesWithKilnfileMutex.RLock()
+	defer fake.fromDirectoriesWithKilnfileMutex.RUnlock()
+	return len(fake.fromDirectoriesWithKilnfileArgsForCall)
+}
+
+func (fake *StemcellService) FromDirectoriesWithKilnfileCalls(stub func([]string, string) (map[string]interface{}, error)) {
+	fake.fromDirectoriesWithKilnfileMutex.Lock()
+	defer fake.fromDirectoriesWithKilnfileMutex.Unlock()
+	fake.FromDirectoriesWithKilnfileStub = stub
+}
+
+func (fake *StemcellService) FromDirectoriesWithKilnfileArgsForCall(i int) ([]string, string) {
+	fake.fromDirectoriesWithKilnfileMutex.RLock()
+	defer fake.fromDirectoriesWithKilnfileMutex.RUnlock()
+	argsForCall := fake.fromDirectoriesWithKilnfileArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *StemcellService) FromDirectoriesWithKilnfileReturns(result1 map[string]interface{}, result2 error) {
+	fake.fromDirectoriesWithKilnfileMutex.Lock()
+	defer fake.fromDirectoriesWithKilnfileMutex.Unlock()
+	fake.FromDirectoriesWithKilnfileStub = nil
+	fake.fromDirectoriesWithKilnfileReturns = struct {
+		result1 map[string]interface{}
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *StemcellService) FromDirectoriesWithKilnfileReturnsOnCall(i int, result1 map[string]interface{}, result2 error) {
+	fake.fromDirectoriesWithKilnfileMutex.Lock()
+	defer fake.fromDirectoriesWithKilnfileMutex.Unlock()
+	fake.FromDirectoriesWithKilnfileStub = nil
+	if fake.fromDirectoriesWithKilnfileReturnsOnCall == nil {
+		fake.fromDirectoriesWithKilnfileReturnsOnCall = make(map[int]struct {
+			result1 map[string]interface{}
+			result2 error
+		})
+	}
+	fake.fromDirectoriesWithKilnfileReturnsOnCall[i] = struct {
+		result1 map[string]interface{}
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *StemcellService) FromKilnfile(arg1 string) (map[string]interface{}, error) {
 	fake.fromKilnfileMutex.Lock()
 	ret, specificReturn := fake.fromKilnfileReturnsOnCall[len(fake.fromKilnfileArgsForCall)]
 	fake.fromKilnfileArgsForCall = append(fake.fromKilnfileArgsForCall, struct {
 		arg1 string
 	}{arg1})
+	stub := fake.FromKilnfileStub
+	fakeReturns := fake.fromKilnfileReturns
 	fake.recordInvocation("FromKilnfile", []interface{}{arg1})
 	fake.fromKilnfileMutex.Unlock()
-	if fake.FromKilnfileStub != nil {
-		return fake.FromKilnfileStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.fromKilnfileReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -186,15 +272,16 @@ func (fake *StemcellService) FromTarball(arg1 string) (interface{}, error) {
 	fake.fromTarballArgsForCall = append(fake.fromTarballArgsForCall, struct {
 		arg1 string
 	}{arg1})
+	stub := fake.FromTarballStub
+	fakeReturns := fake.fromTarballReturns
 	fake.recordInvocation("FromTarball", []interface{}{arg1})
 	fake.fromTarballMutex.Unlock()
-	if fake.FromTarballStub != nil {
-		return fake.FromTarballStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.fromTarballReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -248,6 +335,8 @@ func (fake *StemcellService) Invocations() map[string][][]interface{} {
 	defer fake.invocationsMutex.RUnlock()
 	fake.fromDirectoriesMutex.RLock()
 	defer fake.fromDirectoriesMutex.RUnlock()
+	fake.fromDirectoriesWithKilnfileMutex.RLock()
+	defer fake.fromDirectoriesWithKilnfileMutex.RUnlock()
 	fake.fromKilnfileMutex.RLock()
 	defer fake.fromKilnfileMutex.RUnlock()
 	fake.fromTarballMutex.RLock()
@@ -3,6 +3,7 @@ package fakes
 type VersionsService struct {
 	VersionsCall struct {
 		CallCount int
+		Stub      func(stemcellOS string) ([]string, error)
 		Receives  struct {
 			StemcellOS string
 		}
@@ -22,6 +23,9 @@ type VersionsService struct {
 func (mock *VersionsService) Versions(stemcellOS string) ([]string, error) {
 	mock.VersionsCall.CallCount++
 	mock.VersionsCall.Receives.StemcellOS = stemcellOS
+	if mock.VersionsCall.Stub != nil {
+		return mock.VersionsCall.Stub(stemcellOS)
+	}
 	return mock.VersionsCall.Returns.Versions, mock.VersionsCall.Returns.Err
 }
 
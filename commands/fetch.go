@@ -40,10 +40,13 @@ func (releases ErrorMissingReleases) Error() string {
 }
 
 type Fetch struct {
-	logger *log.Logger
+	logger     *log.Logger
+	planLogger *log.Logger
 
 	releaseSourcesFactory ReleaseSourcesFactory
 	localReleaseDirectory LocalReleaseDirectory
+	releaseCache          ReleaseCache
+	stemcellDownloader    StemcellDownloader
 
 	Options struct {
 		Kilnfile    string `short:"kf" long:"kilnfile" default:"Kilnfile" description:"path to Kilnfile"`
@@ -52,21 +55,30 @@ type Fetch struct {
 		VariablesFiles               []string `short:"vf" long:"variables-file" description:"path to variables file"`
 		Variables                    []string `short:"vr" long:"variable" description:"variable in key=value format"`
 		DownloadThreads              int      `short:"dt" long:"download-threads" description:"number of parallel threads to download parts from S3"`
+		ParallelDownloads            int      `short:"pd" long:"parallel-downloads" default:"4" description:"number of releases to download concurrently"`
 		NoConfirm                    bool     `short:"n" long:"no-confirm" description:"non-interactive mode, will delete extra releases in releases dir without prompting"`
 		AllowOnlyPublishableReleases bool     `long:"allow-only-publishable-releases" description:"include releases that would not be shipped with the tile (development builds)"`
+		CacheDirectory               string   `short:"cd" long:"cache-directory" description:"path to the release cache shared between tiles (default: $KILN_CACHE_DIR or ~/.kiln/cache)"`
+		NoCache                      bool     `long:"no-cache" description:"do not use the release cache"`
+		DryRun                       bool     `long:"dry-run" description:"print which releases would be kept, downloaded and deleted without changing anything"`
+		Format                       string   `long:"format" default:"table" description:"dry run output format, either table or json"`
+		StemcellsDirectory           string   `short:"sd" long:"stemcells-directory" description:"path to a directory to download the stemcell tarballs pinned in Kilnfile.lock into"`
+		PivNetToken                  string   `short:"pt" env:"PIVOTAL_NETWORK_API_TOKEN" long:"pivotal-network-token" description:"uaa access token for network.pivotal.io"`
 	}
 }
 
 //go:generate counterfeiter -o ./fakes/release_sources_factory.go --fake-name ReleaseSourcesFactory . ReleaseSourcesFactory
 type ReleaseSourcesFactory interface {
-	ReleaseSources(cargo.Kilnfile, bool) []fetcher.ReleaseSource
+	ReleaseSources(cargo.Kilnfile, bool) ([]fetcher.ReleaseSource, error)
 }
 
-func NewFetch(logger *log.Logger, releaseSourcesFactory ReleaseSourcesFactory, localReleaseDirectory LocalReleaseDirectory) Fetch {
+func NewFetch(logger *log.Logger, releaseSourcesFactory ReleaseSourcesFactory, localReleaseDirectory LocalReleaseDirectory, releaseCache ReleaseCache, stemcellDownloader StemcellDownloader) Fetch {
 	return Fetch{
 		logger:                logger,
 		localReleaseDirectory: localReleaseDirectory,
 		releaseSourcesFactory: releaseSourcesFactory,
+		releaseCache:          releaseCache,
+		stemcellDownloader:    stemcellDownloader,
 	}
 }
 
@@ -74,7 +86,20 @@ func NewFetch(logger *log.Logger, releaseSourcesFactory ReleaseSourcesFactory, l
 type LocalReleaseDirectory interface {
 	GetLocalReleases(releasesDir string) (fetcher.LocalReleaseSet, error)
 	DeleteExtraReleases(extraReleases fetcher.LocalReleaseSet, noConfirm bool) error
-	VerifyChecksums(downloadedReleases fetcher.LocalReleaseSet, kilnfileLock cargo.KilnfileLock) error
+	VerifyChecksums(releases fetcher.LocalReleaseSet, kilnfileLock cargo.KilnfileLock) (fetcher.LocalReleaseSet, error)
+}
+
+//go:generate counterfeiter -o ./fakes/release_cache.go --fake-name ReleaseCache . ReleaseCache
+type ReleaseCache interface {
+	FindReleases(cacheDir string, requirements fetcher.ReleaseRequirementSet, kilnfileLock cargo.KilnfileLock) (map[fetcher.ReleaseID]fetcher.CachedRelease, error)
+	GetReleases(cacheDir, releasesDir string, requirements fetcher.ReleaseRequirementSet, kilnfileLock cargo.KilnfileLock) (fetcher.LocalReleaseSet, error)
+	AddReleases(cacheDir string, releases fetcher.LocalReleaseSet) error
+}
+
+//go:generate counterfeiter -o ./fakes/stemcell_downloader.go --fake-name StemcellDownloader . StemcellDownloader
+type StemcellDownloader interface {
+	SetToken(token string)
+	DownloadStemcells(stemcellsDir string, stemcells []cargo.Stemcell) error
 }
 
 func (f Fetch) Execute(args []string) error {
@@ -86,25 +111,84 @@ func (f Fetch) Execute(args []string) error {
 	desiredReleaseSet := fetcher.NewReleaseRequirementSet(kilnfileLock)
 	satisfiedReleaseSet, unsatisfiedReleaseSet, extraReleaseSet := desiredReleaseSet.Partition(availableLocalReleaseSet)
 
+	if f.Options.DryRun {
+		return f.printPlan(kilnfile, kilnfileLock, satisfiedReleaseSet, unsatisfiedReleaseSet, extraReleaseSet)
+	}
+
 	err = f.localReleaseDirectory.DeleteExtraReleases(extraReleaseSet, f.Options.NoConfirm)
 	if err != nil {
 		f.logger.Println("failed deleting some releases: ", err.Error())
 	}
 
+	// NOTE: downloaded releases are verified as they are streamed, only the
+	// releases that were already in the releases directory are read again
+	mismatchedReleaseSet, err := f.localReleaseDirectory.VerifyChecksums(satisfiedReleaseSet, kilnfileLock)
+	if err != nil {
+		return err
+	}
+	for rID := range mismatchedReleaseSet {
+		delete(satisfiedReleaseSet, rID)
+		unsatisfiedReleaseSet[rID] = desiredReleaseSet[rID]
+	}
+
+	var cacheDir string
+	if !f.Options.NoCache && len(unsatisfiedReleaseSet) > 0 {
+		cacheDir, err = f.cacheDirectory()
+		if err == nil {
+			var cachedReleaseSet fetcher.LocalReleaseSet
+			cachedReleaseSet, err = f.releaseCache.GetReleases(cacheDir, f.Options.ReleasesDir, unsatisfiedReleaseSet, kilnfileLock)
+			satisfiedReleaseSet = satisfiedReleaseSet.With(cachedReleaseSet)
+			unsatisfiedReleaseSet = unsatisfiedReleaseSet.WithoutReleases(cachedReleaseSet.ReleaseIDs())
+		}
+		if err != nil {
+			f.logger.Println("failed to use the release cache: ", err.Error())
+			cacheDir = ""
+		}
+	}
+
+	downloadedReleaseSet := make(fetcher.LocalReleaseSet)
 	if len(unsatisfiedReleaseSet) > 0 {
 		f.logger.Printf("Found %d missing releases to download", len(unsatisfiedReleaseSet))
 
-		satisfiedReleaseSet, unsatisfiedReleaseSet, err = f.downloadMissingReleases(kilnfile, satisfiedReleaseSet, unsatisfiedReleaseSet, kilnfileLock.Stemcell)
+		downloadedReleaseSet, unsatisfiedReleaseSet, err = f.downloadMissingReleases(kilnfile, kilnfileLock, downloadedReleaseSet, unsatisfiedReleaseSet)
 		if err != nil {
 			return err
 		}
+		satisfiedReleaseSet = satisfiedReleaseSet.With(downloadedReleaseSet)
 	}
 
 	if len(unsatisfiedReleaseSet) > 0 {
 		return ErrorMissingReleases(unsatisfiedReleaseSet)
 	}
 
-	return f.localReleaseDirectory.VerifyChecksums(satisfiedReleaseSet, kilnfileLock)
+	if cacheDir != "" && len(downloadedReleaseSet) > 0 {
+		err = f.releaseCache.AddReleases(cacheDir, downloadedReleaseSet)
+		if err != nil {
+			f.logger.Println("failed to add releases to the release cache: ", err.Error())
+		}
+	}
+
+	if f.Options.StemcellsDirectory != "" {
+		return f.downloadStemcells(kilnfileLock)
+	}
+
+	return nil
+}
+
+func (f Fetch) downloadStemcells(kilnfileLock cargo.KilnfileLock) error {
+	if err := os.MkdirAll(f.Options.StemcellsDirectory, 0777); err != nil {
+		return fmt.Errorf("error with stemcells directory %s: %s", f.Options.StemcellsDirectory, err)
+	}
+
+	f.stemcellDownloader.SetToken(f.Options.PivNetToken)
+	return f.stemcellDownloader.DownloadStemcells(f.Options.StemcellsDirectory, kilnfileLock.StemcellCriteria())
+}
+
+func (f Fetch) cacheDirectory() (string, error) {
+	if f.Options.CacheDirectory != "" {
+		return f.Options.CacheDirectory, nil
+	}
+	return fetcher.DefaultReleaseCacheDirectory()
 }
 
 func (f *Fetch) setup(args []string) (cargo.Kilnfile, cargo.KilnfileLock, fetcher.LocalReleaseSet, error) {
@@ -113,6 +197,13 @@ func (f *Fetch) setup(args []string) (cargo.Kilnfile, cargo.KilnfileLock, fetche
 	if err != nil {
 		return cargo.Kilnfile{}, cargo.KilnfileLock{}, nil, err
 	}
+	if f.Options.DryRun {
+		if f.Options.Format != "table" && f.Options.Format != "json" {
+			return cargo.Kilnfile{}, cargo.KilnfileLock{}, nil, fmt.Errorf("unknown format %q, expected table or json", f.Options.Format)
+		}
+		// the plan is the only thing written to the logger so that it can be parsed
+		f.planLogger, f.logger = f.logger, log.New(os.Stderr, "", 0)
+	}
 	if !f.Options.AllowOnlyPublishableReleases {
 		f.logger.Println("WARNING - the \"allow-only-publishable-releases\" flag was not set. Some fetched releases may be intended for development/testing only.\nEXERCISE CAUTION WHEN PUBLISHING A TILE WITH THESE RELEASES!")
 	}
@@ -170,18 +261,27 @@ func (f *Fetch) setup(args []string) (cargo.Kilnfile, cargo.KilnfileLock, fetche
 	return kilnfile, kilnfileLock, availableLocalReleaseSet, nil
 }
 
-func (f Fetch) downloadMissingReleases(kilnfile cargo.Kilnfile, satisfiedReleaseSet fetcher.LocalReleaseSet, unsatisfiedReleaseSet fetcher.ReleaseRequirementSet, stemcell cargo.Stemcell) (satisfied fetcher.LocalReleaseSet, unsatisfied fetcher.ReleaseRequirementSet, err error) {
-	releaseSources := f.releaseSourcesFactory.ReleaseSources(kilnfile, f.Options.AllowOnlyPublishableReleases)
+func (f Fetch) downloadMissingReleases(kilnfile cargo.Kilnfile, kilnfileLock cargo.KilnfileLock, satisfiedReleaseSet fetcher.LocalReleaseSet, unsatisfiedReleaseSet fetcher.ReleaseRequirementSet) (satisfied fetcher.LocalReleaseSet, unsatisfied fetcher.ReleaseRequirementSet, err error) {
+	releaseSources, err := f.releaseSourcesFactory.ReleaseSources(kilnfile, f.Options.AllowOnlyPublishableReleases)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	checksums := fetcher.NewReleaseChecksums(kilnfileLock)
 	for _, releaseSource := range releaseSources {
 		if len(unsatisfiedReleaseSet) == 0 {
 			break
 		}
-		remoteReleases, err := releaseSource.GetMatchedReleases(unsatisfiedReleaseSet, stemcell)
+		remoteReleases, err := fetcher.MatchReleases(releaseSource, unsatisfiedReleaseSet)
 		if err != nil {
 			return nil, nil, err
 		}
 
-		localReleases, err := releaseSource.DownloadReleases(f.Options.ReleasesDir, remoteReleases, f.Options.DownloadThreads)
+		localReleases, err := releaseSource.DownloadReleases(f.Options.ReleasesDir, remoteReleases, fetcher.DownloadOptions{
+			Threads:   f.Options.DownloadThreads,
+			Workers:   f.Options.ParallelDownloads,
+			Checksums: checksums,
+		})
 		if err != nil {
 			return nil, nil, err
 		}
@@ -0,0 +1,175 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/pivotal-cf/kiln/fetcher"
+	"github.com/pivotal-cf/kiln/internal/cargo"
+)
+
+// FetchPlan is printed by kiln fetch --dry-run. Satisfied releases are already
+// in the releases directory, cached releases would be taken from the release
+// cache, downloads name the release source that would serve them and extra
+// releases would be deleted. Unsatisfiable releases are not found anywhere.
+type FetchPlan struct {
+	Satisfied     []FetchPlanRelease `json:"satisfied"`
+	Cached        []FetchPlanRelease `json:"cached"`
+	Download      []FetchPlanRelease `json:"download"`
+	Delete        []FetchPlanRelease `json:"delete"`
+	Unsatisfiable []FetchPlanRelease `json:"unsatisfiable"`
+}
+
+type FetchPlanRelease struct {
+	Name            string `json:"name"`
+	Version         string `json:"version"`
+	StemcellOS      string `json:"stemcell_os,omitempty"`
+	StemcellVersion string `json:"stemcell_version,omitempty"`
+	Source          string `json:"source,omitempty"`
+	Path            string `json:"path,omitempty"`
+}
+
+func newFetchPlanRelease(id fetcher.ReleaseID, release interface{}, path string) FetchPlanRelease {
+	planRelease := FetchPlanRelease{Name: id.Name, Version: id.Version, Path: path}
+	if compiled, ok := release.(fetcher.CompiledRelease); ok {
+		planRelease.StemcellOS, planRelease.StemcellVersion = compiled.StemcellOS, compiled.StemcellVersion
+	}
+	return planRelease
+}
+
+func (f Fetch) printPlan(kilnfile cargo.Kilnfile, kilnfileLock cargo.KilnfileLock, satisfiedReleaseSet fetcher.LocalReleaseSet, unsatisfiedReleaseSet fetcher.ReleaseRequirementSet, extraReleaseSet fetcher.LocalReleaseSet) error {
+	var plan FetchPlan
+
+	for id, release := range satisfiedReleaseSet {
+		plan.Satisfied = append(plan.Satisfied, newFetchPlanRelease(id, release, release.LocalPath()))
+	}
+	for id, release := range extraReleaseSet {
+		plan.Delete = append(plan.Delete, newFetchPlanRelease(id, release, release.LocalPath()))
+	}
+
+	if !f.Options.NoCache && len(unsatisfiedReleaseSet) > 0 {
+		cached, err := f.findCachedReleases(unsatisfiedReleaseSet, kilnfileLock)
+		if err != nil {
+			f.logger.Println("failed to use the release cache: ", err.Error())
+		}
+		for id, release := range cached {
+			planRelease := FetchPlanRelease{Name: id.Name, Version: id.Version, StemcellOS: release.StemcellOS, StemcellVersion: release.StemcellVersion, Path: release.Path}
+			plan.Cached = append(plan.Cached, planRelease)
+			delete(unsatisfiedReleaseSet, id)
+		}
+	}
+
+	releaseSources, err := f.releaseSourcesFactory.ReleaseSources(kilnfile, f.Options.AllowOnlyPublishableReleases)
+	if err != nil {
+		return err
+	}
+
+	for _, releaseSource := range releaseSources {
+		if len(unsatisfiedReleaseSet) == 0 {
+			break
+		}
+
+		remoteReleases, err := fetcher.MatchReleases(releaseSource, unsatisfiedReleaseSet)
+		if err != nil {
+			return err
+		}
+
+		for _, release := range remoteReleases {
+			planRelease := newFetchPlanRelease(release.ReleaseID(), release, release.RemotePath())
+			planRelease.Source = releaseSourceName(releaseSource)
+			plan.Download = append(plan.Download, planRelease)
+			unsatisfiedReleaseSet = unsatisfiedReleaseSet.WithoutReleases([]fetcher.ReleaseID{fetcher.ReleaseSetKey(release)})
+		}
+	}
+
+	for id, requirement := range unsatisfiedReleaseSet {
+		plan.Unsatisfiable = append(plan.Unsatisfiable, FetchPlanRelease{
+			Name:            id.Name,
+			Version:         id.Version,
+			StemcellOS:      requirement.StemcellOS,
+			StemcellVersion: requirement.StemcellVersion,
+		})
+	}
+
+	for _, releases := range [][]FetchPlanRelease{plan.Satisfied, plan.Cached, plan.Download, plan.Delete, plan.Unsatisfiable} {
+		sort.Slice(releases, func(i, j int) bool {
+			if releases[i].Name != releases[j].Name {
+				return releases[i].Name < releases[j].Name
+			}
+			if releases[i].Version != releases[j].Version {
+				return releases[i].Version < releases[j].Version
+			}
+			return releases[i].StemcellOS < releases[j].StemcellOS
+		})
+	}
+
+	if f.Options.Format == "json" {
+		contents, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode fetch plan: %s", err)
+		}
+		f.planLogger.Printf("%s\n", contents)
+	} else {
+		f.planLogger.Print(plan.table())
+	}
+
+	if len(unsatisfiedReleaseSet) > 0 {
+		return ErrorMissingReleases(unsatisfiedReleaseSet)
+	}
+
+	return nil
+}
+
+func (f Fetch) findCachedReleases(requirements fetcher.ReleaseRequirementSet, kilnfileLock cargo.KilnfileLock) (map[fetcher.ReleaseID]fetcher.CachedRelease, error) {
+	cacheDir, err := f.cacheDirectory()
+	if err != nil {
+		return nil, err
+	}
+	return f.releaseCache.FindReleases(cacheDir, requirements, kilnfileLock)
+}
+
+func (plan FetchPlan) table() string {
+	var table bytes.Buffer
+	w := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ACTION\tNAME\tVERSION\tSTEMCELL\tSOURCE\tPATH")
+
+	sections := []struct {
+		action   string
+		releases []FetchPlanRelease
+	}{
+		{"keep", plan.Satisfied},
+		{"cache", plan.Cached},
+		{"download", plan.Download},
+		{"delete", plan.Delete},
+		{"missing", plan.Unsatisfiable},
+	}
+	for _, section := range sections {
+		for _, release := range section.releases {
+			stemcell := "-"
+			if release.StemcellOS != "" {
+				stemcell = release.StemcellOS + "/" + release.StemcellVersion
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", section.action, release.Name, release.Version, stemcell, orDash(release.Source), orDash(release.Path))
+		}
+	}
+	w.Flush()
+
+	return table.String()
+}
+
+func releaseSourceName(releaseSource fetcher.ReleaseSource) string {
+	if stringer, ok := releaseSource.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return fmt.Sprintf("%T", releaseSource)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -1,6 +1,8 @@
 package commands_test
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -12,6 +14,7 @@ import (
 
 	"github.com/pivotal-cf/jhanda"
 	"github.com/pivotal-cf/kiln/fetcher"
+	"github.com/pivotal-cf/kiln/internal/cargo"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -34,6 +37,9 @@ var _ = Describe("Fetch", func() {
 		fakeS3BuiltReleaseSource    *fetcherFakes.ReleaseSource
 		fakeReleaseSources          []fetcher.ReleaseSource
 		fakeLocalReleaseDirectory   *fakes.LocalReleaseDirectory
+		fakeReleaseCache            *fakes.ReleaseCache
+		fakeStemcellDownloader      *fakes.StemcellDownloader
+		someCacheDirectory          string
 		releaseSourcesFactory       *fakes.ReleaseSourcesFactory
 
 		fetchExecuteArgs []string
@@ -66,6 +72,9 @@ stemcell_criteria:
 `
 
 			fakeLocalReleaseDirectory = new(fakes.LocalReleaseDirectory)
+			fakeReleaseCache = new(fakes.ReleaseCache)
+			fakeStemcellDownloader = new(fakes.StemcellDownloader)
+			someCacheDirectory = filepath.Join(tmpDir, "cache")
 
 			fakeS3CompiledReleaseSource = new(fetcherFakes.ReleaseSource)
 			fakeBoshIOReleaseSource = new(fetcherFakes.ReleaseSource)
@@ -74,6 +83,7 @@ stemcell_criteria:
 			fetchExecuteArgs = []string{
 				"--releases-directory", someReleasesDirectory,
 				"--kilnfile", someKilnfilePath,
+				"--cache-directory", someCacheDirectory,
 			}
 			releaseSourcesFactory = new(fakes.ReleaseSourcesFactory)
 		})
@@ -84,11 +94,11 @@ stemcell_criteria:
 
 		JustBeforeEach(func() {
 			fakeReleaseSources = []fetcher.ReleaseSource{fakeS3CompiledReleaseSource, fakeBoshIOReleaseSource, fakeS3BuiltReleaseSource}
-			releaseSourcesFactory.ReleaseSourcesReturns(fakeReleaseSources)
+			releaseSourcesFactory.ReleaseSourcesReturns(fakeReleaseSources, nil)
 
 			err := ioutil.WriteFile(someKilnfileLockPath, []byte(lockContents), 0644)
 			Expect(err).NotTo(HaveOccurred())
-			fetch = NewFetch(logger, releaseSourcesFactory, fakeLocalReleaseDirectory)
+			fetch = NewFetch(logger, releaseSourcesFactory, fakeLocalReleaseDirectory, fakeReleaseCache, fakeStemcellDownloader)
 
 			fetchExecuteErr = fetch.Execute(fetchExecuteArgs)
 		})
@@ -195,6 +205,7 @@ stemcell_criteria:
 releases:
 - name: lts-compiled-release
   version: "1.2.4"
+  sha1: some-compiled-sha1
 - name: lts-built-release
   version: "1.3.9"
 - name: boshio-release
@@ -244,9 +255,9 @@ stemcell_criteria:
 			It("fetches compiled release from s3 compiled release source", func() {
 				Expect(fakeS3CompiledReleaseSource.DownloadReleasesCallCount()).To(Equal(1))
 
-				releasesDir, objects, threads := fakeS3CompiledReleaseSource.DownloadReleasesArgsForCall(0)
+				releasesDir, objects, opts := fakeS3CompiledReleaseSource.DownloadReleasesArgsForCall(0)
 				Expect(releasesDir).To(Equal(someReleasesDirectory))
-				Expect(threads).To(Equal(0))
+				Expect(opts.Workers).To(Equal(4))
 				Expect(objects).To(ConsistOf(
 					fetcher.CompiledRelease{
 						ID:              s3CompiledReleaseID,
@@ -256,11 +267,22 @@ stemcell_criteria:
 					}))
 			})
 
+			It("passes the checksums from Kilnfile.lock to the release sources", func() {
+				_, objects, opts := fakeS3CompiledReleaseSource.DownloadReleasesArgsForCall(0)
+				sum, found := opts.Checksums.ExpectedSum(objects[0])
+				Expect(found).To(BeTrue())
+				Expect(sum).To(Equal("some-compiled-sha1"))
+
+				_, objects, opts = fakeS3BuiltReleaseSource.DownloadReleasesArgsForCall(0)
+				_, found = opts.Checksums.ExpectedSum(objects[0])
+				Expect(found).To(BeFalse())
+			})
+
 			It("fetches built release from s3 built release source", func() {
 				Expect(fakeS3BuiltReleaseSource.DownloadReleasesCallCount()).To(Equal(1))
-				releasesDir, objects, threads := fakeS3BuiltReleaseSource.DownloadReleasesArgsForCall(0)
+				releasesDir, objects, opts := fakeS3BuiltReleaseSource.DownloadReleasesArgsForCall(0)
 				Expect(releasesDir).To(Equal(someReleasesDirectory))
-				Expect(threads).To(Equal(0))
+				Expect(opts.Workers).To(Equal(4))
 				Expect(objects).To(ConsistOf(
 					fetcher.BuiltRelease{
 						ID:   s3BuiltReleaseID,
@@ -270,9 +292,9 @@ stemcell_criteria:
 
 			It("fetches bosh.io release from bosh.io release source", func() {
 				Expect(fakeBoshIOReleaseSource.DownloadReleasesCallCount()).To(Equal(1))
-				releasesDir, objects, threads := fakeBoshIOReleaseSource.DownloadReleasesArgsForCall(0)
+				releasesDir, objects, opts := fakeBoshIOReleaseSource.DownloadReleasesArgsForCall(0)
 				Expect(releasesDir).To(Equal(someReleasesDirectory))
-				Expect(threads).To(Equal(0))
+				Expect(opts.Workers).To(Equal(4))
 				Expect(objects).To(ConsistOf(
 					fetcher.BuiltRelease{
 						ID:   boshIOReleaseID,
@@ -281,6 +303,317 @@ stemcell_criteria:
 			})
 		})
 
+		Context("when the Kilnfile.lock has releases compiled against several stemcells", func() {
+			var (
+				xenialReleaseID  = fetcher.ReleaseID{Name: "uaa", Version: "1.2.3"}
+				windowsReleaseID = fetcher.ReleaseID{Name: "hwc", Version: "4.5.6"}
+			)
+
+			BeforeEach(func() {
+				lockContents = `---
+releases:
+- name: uaa
+  version: "1.2.3"
+  stemcell_os: ubuntu-xenial
+  stemcell_version: "621.5"
+- name: hwc
+  version: "4.5.6"
+  stemcell_os: windows
+  stemcell_version: "2019.12"
+stemcells:
+- os: ubuntu-xenial
+  version: "621.5"
+- os: windows
+  version: "2019.12"
+`
+				fakeS3CompiledReleaseSource.GetMatchedReleasesStub = func(requirements fetcher.ReleaseRequirementSet, stemcell cargo.Stemcell) ([]fetcher.RemoteRelease, error) {
+					var matches []fetcher.RemoteRelease
+					for id := range requirements {
+						matches = append(matches, fetcher.CompiledRelease{ID: id, StemcellOS: stemcell.OS, StemcellVersion: stemcell.Version, Path: id.Name})
+					}
+					return matches, nil
+				}
+				fakeS3CompiledReleaseSource.DownloadReleasesStub = func(releasesDir string, remoteReleases []fetcher.RemoteRelease, opts fetcher.DownloadOptions) (fetcher.LocalReleaseSet, error) {
+					localReleases := make(fetcher.LocalReleaseSet)
+					for _, release := range remoteReleases {
+						localReleases[release.ReleaseID()] = release.AsLocal(release.StandardizedFilename())
+					}
+					return localReleases, nil
+				}
+				fakeLocalReleaseDirectory.GetLocalReleasesReturns(fetcher.LocalReleaseSet{}, nil)
+			})
+
+			It("downloads each release compiled against its own stemcell", func() {
+				Expect(fetchExecuteErr).NotTo(HaveOccurred())
+
+				Expect(fakeS3CompiledReleaseSource.GetMatchedReleasesCallCount()).To(Equal(2))
+				_, stemcell := fakeS3CompiledReleaseSource.GetMatchedReleasesArgsForCall(0)
+				Expect(stemcell).To(Equal(cargo.Stemcell{OS: "ubuntu-xenial", Version: "621.5"}))
+				_, stemcell = fakeS3CompiledReleaseSource.GetMatchedReleasesArgsForCall(1)
+				Expect(stemcell).To(Equal(cargo.Stemcell{OS: "windows", Version: "2019.12"}))
+
+				Expect(fakeS3CompiledReleaseSource.DownloadReleasesCallCount()).To(Equal(1))
+				_, objects, _ := fakeS3CompiledReleaseSource.DownloadReleasesArgsForCall(0)
+				Expect(objects).To(ConsistOf(
+					fetcher.CompiledRelease{ID: xenialReleaseID, StemcellOS: "ubuntu-xenial", StemcellVersion: "621.5", Path: "uaa"},
+					fetcher.CompiledRelease{ID: windowsReleaseID, StemcellOS: "windows", StemcellVersion: "2019.12", Path: "hwc"},
+				))
+			})
+		})
+
+		Context("when a stemcells directory is given", func() {
+			var stemcellsDirectory string
+
+			BeforeEach(func() {
+				lockContents = `---
+releases: []
+stemcell_criteria:
+  os: ubuntu-xenial
+  version: "621.5"
+  source: bosh.io
+  url: https://example.com/stemcell.tgz
+  sha1: some-stemcell-sha1
+`
+				stemcellsDirectory = filepath.Join(tmpDir, "stemcells")
+				fetchExecuteArgs = append(fetchExecuteArgs, "--stemcells-directory", stemcellsDirectory, "--pivotal-network-token", "some-token")
+				fakeLocalReleaseDirectory.GetLocalReleasesReturns(fetcher.LocalReleaseSet{}, nil)
+			})
+
+			It("downloads the stemcells pinned in the Kilnfile.lock", func() {
+				Expect(fetchExecuteErr).NotTo(HaveOccurred())
+				Expect(stemcellsDirectory).To(BeADirectory())
+
+				Expect(fakeStemcellDownloader.SetTokenArgsForCall(0)).To(Equal("some-token"))
+				Expect(fakeStemcellDownloader.DownloadStemcellsCallCount()).To(Equal(1))
+				dir, stemcells := fakeStemcellDownloader.DownloadStemcellsArgsForCall(0)
+				Expect(dir).To(Equal(stemcellsDirectory))
+				Expect(stemcells).To(Equal([]cargo.Stemcell{{
+					OS:      "ubuntu-xenial",
+					Version: "621.5",
+					Source:  "bosh.io",
+					URL:     "https://example.com/stemcell.tgz",
+					SHA1:    "some-stemcell-sha1",
+				}}))
+			})
+
+			Context("when downloading a stemcell fails", func() {
+				BeforeEach(func() {
+					fakeStemcellDownloader.DownloadStemcellsReturns(errors.New("some-error"))
+				})
+
+				It("returns the error", func() {
+					Expect(fetchExecuteErr).To(MatchError("some-error"))
+				})
+			})
+		})
+
+		Context("when no stemcells directory is given", func() {
+			BeforeEach(func() {
+				fakeLocalReleaseDirectory.GetLocalReleasesReturns(fetcher.LocalReleaseSet{
+					{Name: "some-release", Version: "1.2.3"}: fetcher.BuiltRelease{ID: fetcher.ReleaseID{Name: "some-release", Version: "1.2.3"}},
+				}, nil)
+			})
+
+			It("does not download stemcells", func() {
+				Expect(fakeStemcellDownloader.DownloadStemcellsCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when --dry-run is given", func() {
+			var (
+				output            *bytes.Buffer
+				localReleaseID    = fetcher.ReleaseID{Name: "local-release", Version: "1.0.0"}
+				extraReleaseID    = fetcher.ReleaseID{Name: "extra-release", Version: "0.1.0"}
+				cachedReleaseID   = fetcher.ReleaseID{Name: "cached-release", Version: "2.0.0"}
+				remoteReleaseID   = fetcher.ReleaseID{Name: "remote-release", Version: "3.0.0"}
+				boshIOReleaseSpec = fetcher.BuiltRelease{ID: remoteReleaseID, Path: "https://bosh.io/d/github.com/cloudfoundry/remote-release?v=3.0.0"}
+			)
+
+			BeforeEach(func() {
+				output = new(bytes.Buffer)
+				logger = log.New(output, "", 0)
+
+				lockContents = `---
+releases:
+- name: local-release
+  version: "1.0.0"
+- name: cached-release
+  version: "2.0.0"
+- name: remote-release
+  version: "3.0.0"
+- name: missing-release
+  version: "4.0.0"
+stemcell_criteria:
+  os: some-os
+  version: "30.1"
+`
+				fakeLocalReleaseDirectory.GetLocalReleasesReturns(fetcher.LocalReleaseSet{
+					{Name: "local-release", Version: "1.0.0", StemcellOS: "some-os"}: fetcher.CompiledRelease{ID: localReleaseID, StemcellOS: "some-os", StemcellVersion: "30.1", Path: "releases/local-release.tgz"},
+					extraReleaseID: fetcher.BuiltRelease{ID: extraReleaseID, Path: "releases/extra-release.tgz"},
+				}, nil)
+
+				fakeReleaseCache.FindReleasesReturns(map[fetcher.ReleaseID]fetcher.CachedRelease{
+					{Name: "cached-release", Version: "2.0.0", StemcellOS: "some-os"}: {ID: cachedReleaseID, Path: "cache/cached-release.tgz"},
+				}, nil)
+
+				fakeBoshIOReleaseSource.GetMatchedReleasesReturns([]fetcher.RemoteRelease{boshIOReleaseSpec}, nil)
+
+				fetchExecuteArgs = append(fetchExecuteArgs, "--dry-run", "--allow-only-publishable-releases")
+			})
+
+			It("does not change anything", func() {
+				Expect(fakeLocalReleaseDirectory.DeleteExtraReleasesCallCount()).To(Equal(0))
+				Expect(fakeReleaseCache.GetReleasesCallCount()).To(Equal(0))
+				Expect(fakeReleaseCache.AddReleasesCallCount()).To(Equal(0))
+				Expect(fakeS3CompiledReleaseSource.DownloadReleasesCallCount()).To(Equal(0))
+				Expect(fakeBoshIOReleaseSource.DownloadReleasesCallCount()).To(Equal(0))
+				Expect(fakeS3BuiltReleaseSource.DownloadReleasesCallCount()).To(Equal(0))
+			})
+
+			It("prints the plan as a table and fails because a release can not be found", func() {
+				Expect(fetchExecuteErr).To(MatchError(ContainSubstring("- missing-release (4.0.0)")))
+
+				Expect(output.String()).To(Equal(`ACTION    NAME             VERSION  STEMCELL      SOURCE                PATH
+keep      local-release    1.0.0    some-os/30.1  -                     releases/local-release.tgz
+cache     cached-release   2.0.0    -             -                     cache/cached-release.tgz
+download  remote-release   3.0.0    -             *fakes.ReleaseSource  https://bosh.io/d/github.com/cloudfoundry/remote-release?v=3.0.0
+delete    extra-release    0.1.0    -             -                     releases/extra-release.tgz
+missing   missing-release  4.0.0    some-os/30.1  -                     -
+`))
+			})
+
+			Context("when the format is json", func() {
+				BeforeEach(func() {
+					fetchExecuteArgs = append(fetchExecuteArgs, "--format", "json")
+				})
+
+				It("prints only the plan as JSON", func() {
+					var plan FetchPlan
+					Expect(json.Unmarshal(output.Bytes(), &plan)).To(Succeed())
+
+					Expect(plan).To(Equal(FetchPlan{
+						Satisfied: []FetchPlanRelease{{Name: "local-release", Version: "1.0.0", StemcellOS: "some-os", StemcellVersion: "30.1", Path: "releases/local-release.tgz"}},
+						Cached:    []FetchPlanRelease{{Name: "cached-release", Version: "2.0.0", Path: "cache/cached-release.tgz"}},
+						Download: []FetchPlanRelease{{
+							Name:    "remote-release",
+							Version: "3.0.0",
+							Source:  "*fakes.ReleaseSource",
+							Path:    "https://bosh.io/d/github.com/cloudfoundry/remote-release?v=3.0.0",
+						}},
+						Delete:        []FetchPlanRelease{{Name: "extra-release", Version: "0.1.0", Path: "releases/extra-release.tgz"}},
+						Unsatisfiable: []FetchPlanRelease{{Name: "missing-release", Version: "4.0.0", StemcellOS: "some-os", StemcellVersion: "30.1"}},
+					}))
+				})
+			})
+
+			Context("when the format is unknown", func() {
+				BeforeEach(func() {
+					fetchExecuteArgs = append(fetchExecuteArgs, "--format", "yaml")
+				})
+
+				It("returns an error", func() {
+					Expect(fetchExecuteErr).To(MatchError(`unknown format "yaml", expected table or json`))
+				})
+			})
+		})
+
+		Context("when some releases are in the release cache", func() {
+			var (
+				cachedReleaseID     = fetcher.ReleaseID{Name: "cached-release", Version: "1.0.0"}
+				downloadedReleaseID = fetcher.ReleaseID{Name: "downloaded-release", Version: "2.0.0"}
+			)
+
+			BeforeEach(func() {
+				lockContents = `---
+releases:
+- name: cached-release
+  version: "1.0.0"
+- name: downloaded-release
+  version: "2.0.0"
+stemcell_criteria:
+  os: some-os
+  version: "30.1"
+`
+				fakeLocalReleaseDirectory.GetLocalReleasesReturns(fetcher.LocalReleaseSet{}, nil)
+
+				fakeReleaseCache.GetReleasesReturns(fetcher.LocalReleaseSet{
+					cachedReleaseID: fetcher.BuiltRelease{ID: cachedReleaseID, Path: "cached-path"},
+				}, nil)
+
+				fakeS3CompiledReleaseSource.GetMatchedReleasesReturns(
+					[]fetcher.RemoteRelease{fetcher.BuiltRelease{ID: downloadedReleaseID, Path: "some-s3-key"}},
+					nil)
+				fakeS3CompiledReleaseSource.DownloadReleasesReturns(
+					fetcher.LocalReleaseSet{
+						downloadedReleaseID: fetcher.BuiltRelease{ID: downloadedReleaseID, Path: "downloaded-path"},
+					},
+					nil)
+			})
+
+			It("only downloads the releases missing from the cache", func() {
+				Expect(fetchExecuteErr).NotTo(HaveOccurred())
+
+				Expect(fakeReleaseCache.GetReleasesCallCount()).To(Equal(1))
+				cacheDir, releasesDir, requirements, _ := fakeReleaseCache.GetReleasesArgsForCall(0)
+				Expect(cacheDir).To(Equal(someCacheDirectory))
+				Expect(releasesDir).To(Equal(someReleasesDirectory))
+				Expect(requirements).To(HaveLen(2))
+
+				requirements, _ = fakeS3CompiledReleaseSource.GetMatchedReleasesArgsForCall(0)
+				Expect(requirements).To(HaveLen(1))
+				Expect(requirements).To(HaveKey(downloadedReleaseID))
+
+			})
+
+			It("adds the downloaded releases to the cache", func() {
+				Expect(fetchExecuteErr).NotTo(HaveOccurred())
+
+				Expect(fakeReleaseCache.AddReleasesCallCount()).To(Equal(1))
+				cacheDir, added := fakeReleaseCache.AddReleasesArgsForCall(0)
+				Expect(cacheDir).To(Equal(someCacheDirectory))
+				Expect(added).To(Equal(fetcher.LocalReleaseSet{
+					downloadedReleaseID: fetcher.BuiltRelease{ID: downloadedReleaseID, Path: "downloaded-path"},
+				}))
+			})
+
+			Context("when a downloaded release does not match its checksum", func() {
+				var mismatch fetcher.ChecksumMismatchError
+
+				BeforeEach(func() {
+					mismatch = fetcher.ChecksumMismatchError{Source: "S3 bucket some-bucket", Release: "downloaded-release-2.0.0.tgz", Expected: "abc", Actual: "def"}
+					fakeS3CompiledReleaseSource.DownloadReleasesReturns(nil, mismatch)
+				})
+
+				It("does not add the downloaded releases to the cache", func() {
+					Expect(fetchExecuteErr).To(MatchError(mismatch))
+					Expect(fakeReleaseCache.AddReleasesCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when the cache can not be read", func() {
+				BeforeEach(func() {
+					fakeReleaseCache.GetReleasesReturns(nil, errors.New("permission denied"))
+				})
+
+				It("tries to download all the releases and does not add them to the cache", func() {
+					requirements, _ := fakeS3CompiledReleaseSource.GetMatchedReleasesArgsForCall(0)
+					Expect(requirements).To(HaveLen(2))
+					Expect(fakeReleaseCache.AddReleasesCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when --no-cache is passed", func() {
+				BeforeEach(func() {
+					fetchExecuteArgs = append(fetchExecuteArgs, "--no-cache")
+				})
+
+				It("does not use the cache", func() {
+					Expect(fakeReleaseCache.GetReleasesCallCount()).To(Equal(0))
+					Expect(fakeReleaseCache.AddReleasesCallCount()).To(Equal(0))
+				})
+			})
+		})
+
 		Context("when one or more releases are not available from release sources", func() {
 			BeforeEach(func() {
 				lockContents = `---
@@ -308,37 +641,85 @@ stemcell_criteria:
 		})
 
 		Context("when all releases are already present in output directory", func() {
+			var (
+				someLocalReleaseID  fetcher.ReleaseID
+				someLocalReleaseKey fetcher.ReleaseID
+				someLocalRelease    fetcher.CompiledRelease
+			)
+
 			BeforeEach(func() {
 				lockContents = `---
 releases:
 - name: some-release-from-local-dir
   version: "1.2.3"
+  sha1: some-sha1
 stemcell_criteria:
   os: some-os
   version: "4.5.6"
 `
 
-				someLocalReleaseID := fetcher.ReleaseID{
+				someLocalReleaseID = fetcher.ReleaseID{
 					Name:    "some-release-from-local-dir",
 					Version: "1.2.3",
 				}
+				someLocalReleaseKey = fetcher.ReleaseID{
+					Name:       "some-release-from-local-dir",
+					Version:    "1.2.3",
+					StemcellOS: "some-os",
+				}
+				someLocalRelease = fetcher.CompiledRelease{
+					ID:              someLocalReleaseID,
+					StemcellOS:      "some-os",
+					StemcellVersion: "4.5.6",
+					Path:            "/path/to/some/release",
+				}
 				fakeLocalReleaseDirectory.GetLocalReleasesReturns(fetcher.LocalReleaseSet{
-					someLocalReleaseID: fetcher.CompiledRelease{
-						ID:              someLocalReleaseID,
-						StemcellOS:      "some-os",
-						StemcellVersion: "4.5.6",
-						Path:            "/path/to/some/release",
-					},
+					someLocalReleaseKey: someLocalRelease,
 				}, nil)
 			})
 
-			It("no-ops", func() {
+			It("verifies their checksums and no-ops", func() {
 				Expect(fetchExecuteErr).NotTo(HaveOccurred())
 
+				Expect(fakeLocalReleaseDirectory.VerifyChecksumsCallCount()).To(Equal(1))
+				releases, kilnfileLock := fakeLocalReleaseDirectory.VerifyChecksumsArgsForCall(0)
+				Expect(releases).To(Equal(fetcher.LocalReleaseSet{someLocalReleaseKey: someLocalRelease}))
+				Expect(kilnfileLock.Releases[0].SHA1).To(Equal("some-sha1"))
+
 				Expect(fakeS3CompiledReleaseSource.DownloadReleasesCallCount()).To(Equal(0))
 				Expect(fakeS3BuiltReleaseSource.DownloadReleasesCallCount()).To(Equal(0))
 				Expect(fakeBoshIOReleaseSource.DownloadReleasesCallCount()).To(Equal(0))
 			})
+
+			Context("when a release does not match its checksum", func() {
+				var remoteRelease fetcher.CompiledRelease
+
+				BeforeEach(func() {
+					fakeLocalReleaseDirectory.VerifyChecksumsReturns(fetcher.LocalReleaseSet{someLocalReleaseKey: someLocalRelease}, nil)
+
+					remoteRelease = fetcher.CompiledRelease{ID: someLocalReleaseID, StemcellOS: "some-os", StemcellVersion: "4.5.6", Path: "some-s3-key"}
+					fakeS3CompiledReleaseSource.GetMatchedReleasesReturns([]fetcher.RemoteRelease{remoteRelease}, nil)
+					fakeS3CompiledReleaseSource.DownloadReleasesReturns(fetcher.LocalReleaseSet{someLocalReleaseKey: remoteRelease.AsLocal("/path/to/some/release")}, nil)
+				})
+
+				It("downloads it again", func() {
+					Expect(fetchExecuteErr).NotTo(HaveOccurred())
+
+					Expect(fakeS3CompiledReleaseSource.DownloadReleasesCallCount()).To(Equal(1))
+					_, objects, _ := fakeS3CompiledReleaseSource.DownloadReleasesArgsForCall(0)
+					Expect(objects).To(ConsistOf(remoteRelease))
+				})
+			})
+
+			Context("when the checksums can not be verified", func() {
+				BeforeEach(func() {
+					fakeLocalReleaseDirectory.VerifyChecksumsReturns(nil, errors.New("some-read-error"))
+				})
+
+				It("returns the error", func() {
+					Expect(fetchExecuteErr).To(MatchError("some-read-error"))
+				})
+			})
 		})
 
 		Context("when some releases are already present in output directory", func() {
@@ -427,6 +808,16 @@ stemcell_criteria:
 					Expect(fetchExecuteErr).To(HaveOccurred())
 				})
 			})
+
+			Context("when the release sources can not be built", func() {
+				BeforeEach(func() {
+					releaseSourcesFactory.ReleaseSourcesReturnsOnCall(0, nil, errors.New(`release_sources[0]: unknown release source type "ftp"`))
+				})
+
+				It("returns the error", func() {
+					Expect(fetchExecuteErr).To(MatchError(`release_sources[0]: unknown release source type "ftp"`))
+				})
+			})
 		})
 
 		Context("when there are extra releases locally that are not in the Kilnfile.lock", func() {
@@ -574,8 +965,24 @@ release_sources:
 
 				It("passes concurrency parameter to DownloadReleases", func() {
 					Expect(fetchExecuteErr).NotTo(HaveOccurred())
-					_, _, threads := fakeS3CompiledReleaseSource.DownloadReleasesArgsForCall(0)
-					Expect(threads).To(Equal(10))
+					_, _, opts := fakeS3CompiledReleaseSource.DownloadReleasesArgsForCall(0)
+					Expect(opts.Threads).To(Equal(10))
+				})
+			})
+
+			Context("when # of parallel downloads is specified", func() {
+				BeforeEach(func() {
+					fetchExecuteArgs = []string{
+						"--releases-directory", someReleasesDirectory,
+						"--kilnfile", someKilnfilePath,
+						"--parallel-downloads", "8",
+					}
+				})
+
+				It("passes the number of workers to DownloadReleases", func() {
+					Expect(fetchExecuteErr).NotTo(HaveOccurred())
+					_, _, opts := fakeS3CompiledReleaseSource.DownloadReleasesArgsForCall(0)
+					Expect(opts.Workers).To(Equal(8))
 				})
 			})
 
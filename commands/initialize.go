@@ -0,0 +1,191 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/pivotal-cf/jhanda"
+)
+
+const defaultStemcellOS = "ubuntu-xenial"
+
+var productNamePattern = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+type Init struct {
+	logger *log.Logger
+
+	Options struct {
+		Name          string   `short:"n"  long:"name"           required:"true" description:"name of the product"`
+		Directory     string   `short:"d"  long:"directory"                      description:"path to the directory to create (default: the product name)"`
+		ServiceBroker bool     `short:"sb" long:"service-broker"                 description:"generates a service broker with register and deregister errands"`
+		StemcellOSes  []string `short:"so" long:"stemcell-os"                    description:"operating system of a stemcell, more than one generates a multi-stemcell tile (default: ubuntu-xenial)"`
+	}
+}
+
+type scaffold struct {
+	Name          string
+	Label         string
+	ServiceBroker bool
+	StemcellOSes  []string
+}
+
+type scaffoldFile struct {
+	path     string
+	template string
+}
+
+func NewInit(logger *log.Logger) Init {
+	return Init{
+		logger: logger,
+	}
+}
+
+func (i Init) Execute(args []string) error {
+	_, err := jhanda.Parse(&i.Options, args)
+	if err != nil {
+		return err
+	}
+
+	if !productNamePattern.MatchString(i.Options.Name) {
+		return fmt.Errorf("--name %q must be lowercase letters, digits and dashes, starting with a letter", i.Options.Name)
+	}
+
+	directory := i.Options.Directory
+	if directory == "" {
+		directory = i.Options.Name
+	}
+
+	if _, err := os.Stat(directory); err == nil {
+		return fmt.Errorf("cannot initialize tile: %s already exists", directory)
+	}
+
+	stemcellOSes := i.Options.StemcellOSes
+	if len(stemcellOSes) == 0 {
+		stemcellOSes = []string{defaultStemcellOS}
+	}
+
+	data := scaffold{
+		Name:          i.Options.Name,
+		Label:         productLabel(i.Options.Name),
+		ServiceBroker: i.Options.ServiceBroker,
+		StemcellOSes:  stemcellOSes,
+	}
+
+	files := append([]scaffoldFile{}, commonScaffoldFiles...)
+	if data.ServiceBroker {
+		files = append(files, serviceBrokerScaffoldFiles...)
+	} else {
+		files = append(files, productScaffoldFiles...)
+	}
+
+	for _, file := range files {
+		path, contents, err := renderScaffoldFile(file, data)
+		if err != nil {
+			return err
+		}
+
+		err = writeScaffoldFile(filepath.Join(directory, path), contents)
+		if err != nil {
+			return err
+		}
+	}
+
+	icon, err := scaffoldIcon()
+	if err != nil {
+		return err
+	}
+
+	err = writeScaffoldFile(filepath.Join(directory, "icon.png"), icon)
+	if err != nil {
+		return err
+	}
+
+	i.logger.Printf("Initialized %s in %s", data.Name, directory)
+	i.logger.Printf("Run `kiln update --kilnfile Kilnfile` to lock the releases and stemcells, `kiln fetch` to download them and `kiln bake` in %s to build the tile.", directory)
+
+	return nil
+}
+
+func (i Init) Usage() jhanda.Usage {
+	return jhanda.Usage{
+		Description:      "This command generates the metadata, parts, migrations and Kilnfile of a new tile.",
+		ShortDescription: "generates a new tile",
+		Flags:            i.Options,
+	}
+}
+
+func renderScaffoldFile(file scaffoldFile, data scaffold) (string, []byte, error) {
+	path, err := executeScaffoldTemplate(file.path, file.path, data)
+	if err != nil {
+		return "", nil, err
+	}
+
+	contents, err := executeScaffoldTemplate(string(path), file.template, data)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return string(path), contents, nil
+}
+
+func executeScaffoldTemplate(name, text string, data scaffold) ([]byte, error) {
+	t, err := template.New(name).Funcs(template.FuncMap{
+		"rest": func(oses []string) []string { return oses[1:] },
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template for %s: %s", name, err)
+	}
+
+	var output bytes.Buffer
+	err = t.Execute(&output, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s: %s", name, err)
+	}
+
+	return output.Bytes(), nil
+}
+
+func writeScaffoldFile(path string, contents []byte) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create directory: %s", err)
+	}
+
+	err = ioutil.WriteFile(path, contents, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %s", path, err)
+	}
+
+	return nil
+}
+
+// scaffoldIcon is a transparent placeholder for the icon_image of the tile.
+func scaffoldIcon() ([]byte, error) {
+	var icon bytes.Buffer
+	err := png.Encode(&icon, image.NewNRGBA(image.Rect(0, 0, 1, 1)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode icon: %s", err)
+	}
+
+	return icon.Bytes(), nil
+}
+
+func productLabel(name string) string {
+	words := strings.Split(name, "-")
+	for i, word := range words {
+		if word != "" {
+			words[i] = strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+
+	return strings.Join(words, " ")
+}
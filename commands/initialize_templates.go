@@ -0,0 +1,377 @@
+package commands
+
+// The scaffold files are text/template templates, as are their paths. The
+// $( ) helpers in them are left for kiln bake.
+
+var commonScaffoldFiles = []scaffoldFile{
+	{path: "base.yml", template: `---
+name: {{ .Name }}
+label: {{ .Label }}
+description: {{ .Label }} for Ops Manager
+
+metadata_version: "2.7"
+minimum_version_for_upgrade: 0.0.0
+product_version: $( version )
+provides_product_versions:
+- name: {{ .Name }}
+  version: $( version )
+
+icon_image: $( icon )
+
+rank: 90
+serial: false
+{{- if .ServiceBroker }}
+service_broker: true
+
+post_deploy_errands:
+- name: register-broker
+
+pre_delete_errands:
+- name: deregister-broker
+{{- end }}
+
+releases:
+- $( release "{{ .Name }}" )
+{{ if eq (len .StemcellOSes) 1 }}
+stemcell_criteria: $( stemcell )
+{{- else }}
+stemcell_criteria: $( stemcell "{{ index .StemcellOSes 0 }}" )
+additional_stemcells_criteria:
+{{- range rest .StemcellOSes }}
+- $( stemcell "{{ . }}" )
+{{- end }}
+{{- end }}
+
+property_blueprints:
+{{- if .ServiceBroker }}
+- $( property "broker_credentials" )
+{{- end }}
+- $( property "log_level" )
+
+form_types:
+- $( form "settings" )
+
+job_types:
+{{- if .ServiceBroker }}
+- $( instance_group "broker" )
+- $( instance_group "register-broker" )
+- $( instance_group "deregister-broker" )
+{{- else }}
+- $( instance_group "{{ .Name }}" )
+{{- end }}
+`},
+	{path: "Kilnfile", template: `---
+# The releases in the tile. kiln update locks each release to the newest version
+# matching its version constraint, found on the release sources below.
+releases:
+- name: {{ .Name }}
+{{ if eq (len .StemcellOSes) 1 }}
+stemcell_criteria:
+  os: {{ index .StemcellOSes 0 }}
+  version: "*"
+{{- else }}
+# Releases are compiled against the first stemcell unless they name another
+# with stemcell_os.
+stemcells:
+{{- range .StemcellOSes }}
+- os: {{ . }}
+  version: "*"
+{{- end }}
+{{- end }}
+
+# kiln fetch downloads the locked releases from the first release source that
+# has them.
+release_sources:
+- type: bosh.io
+# Compiled releases in an S3 bucket:
+# - type: s3
+#   compiled: true
+#   bucket: some-bucket
+#   region: us-west-1
+#   access_key_id: some-access-key-id
+#   secret_access_key: some-secret-access-key
+#   regex: ^(?P<release_name>[a-z-_0-9]+)-(?P<release_version>v?[0-9\.]+)-(?P<stemcell_os>[a-z-_]+)-(?P<stemcell_version>\d+\.\d+)\.tgz$
+# Assets of GitHub releases:
+# - type: github
+#   org: some-org
+# Release tarballs in local directories:
+# - type: directory
+#   directories: [/some/shared/releases]
+#   regex: ^(?P<release_name>[a-z-_0-9]+)-(?P<release_version>v?[0-9\.]+)\.tgz$
+
+# kiln bake finds the other parts of the tile in the directories next to the
+# metadata.
+bake:
+  metadata: base.yml
+`},
+	{path: "Kilnfile.lock", template: `---
+releases: []
+`},
+	{path: ".gitignore", template: `/releases/
+/stemcells/
+*.pivotal
+`},
+	{path: "README.md", template: `# {{ .Label }}
+
+Run kiln update to lock the releases and stemcells in Kilnfile.lock, kiln fetch
+to download them and kiln bake --version <version> to build the tile.
+
+## Migrations
+
+JavaScript migrations in the migrations directory are run by Ops Manager, in
+file name order, when {{ .Label }} is upgraded. Name them after the time they
+were written, for example 201901011200_rename_property.js, and export a migrate
+function that takes and returns the installation properties:
+
+    exports.migrate = function(input) {
+      return input;
+    };
+`},
+	{path: "migrations/.gitkeep", template: ""},
+	{path: "forms/_order.yml", template: `---
+form_order:
+- settings
+`},
+	{path: "forms/settings.yml", template: `---
+name: settings
+label: Settings
+description: Settings of {{ .Label }}.
+property_inputs:
+- reference: .properties.log_level
+  label: Log level
+  description: The level of the job logs.
+`},
+	{path: "properties/log_level.yml", template: `---
+name: log_level
+type: dropdown_select
+configurable: true
+default: info
+options:
+- name: debug
+  label: Debug
+- name: info
+  label: Info
+- name: error
+  label: Error
+`},
+}
+
+var productScaffoldFiles = []scaffoldFile{
+	{path: "properties/_order.yml", template: `---
+property_order:
+- log_level
+`},
+	{path: "jobs/_order.yml", template: `---
+job_order:
+- {{ .Name }}
+`},
+	{path: "jobs/{{ .Name }}.yml", template: `---
+name: {{ .Name }}
+release: {{ .Name }}
+manifest: |
+  log_level: (( .properties.log_level.value ))
+`},
+	{path: "instance-groups/_order.yml", template: `---
+instance_group_order:
+- {{ .Name }}
+`},
+	{path: "instance-groups/{{ .Name }}.yml", template: `---
+name: {{ .Name }}
+label: {{ .Label }}
+resource_label: {{ .Label }}
+description: The {{ .Label }} VMs.
+
+templates:
+- $( job "{{ .Name }}" )
+
+static_ip: 0
+dynamic_ip: 1
+
+max_in_flight: 1
+
+instance_definition:
+  name: instances
+  type: integer
+  label: Instances
+  configurable: true
+  default: 1
+  constraints:
+    min: 1
+
+resource_definitions:
+- name: ram
+  type: integer
+  label: RAM
+  configurable: true
+  default: 1024
+
+- name: ephemeral_disk
+  type: integer
+  label: Ephemeral Disk
+  configurable: true
+  default: 4096
+
+- name: persistent_disk
+  type: integer
+  label: Persistent Disk
+  configurable: true
+  default: 10240
+
+- name: cpu
+  type: integer
+  label: CPU
+  configurable: true
+  default: 1
+`},
+}
+
+var serviceBrokerScaffoldFiles = []scaffoldFile{
+	{path: "properties/_order.yml", template: `---
+property_order:
+- broker_credentials
+- log_level
+`},
+	{path: "properties/broker_credentials.yml", template: `---
+name: broker_credentials
+type: simple_credentials
+configurable: false
+`},
+	{path: "jobs/_order.yml", template: `---
+job_order:
+- broker
+- register-broker
+- deregister-broker
+`},
+	{path: "jobs/broker.yml", template: `---
+name: broker
+release: {{ .Name }}
+manifest: |
+  username: (( .properties.broker_credentials.identity ))
+  password: (( .properties.broker_credentials.password ))
+  log_level: (( .properties.log_level.value ))
+`},
+	{path: "jobs/register-broker.yml", template: `---
+name: register-broker
+release: {{ .Name }}
+manifest: |
+  broker_name: {{ .Name }}
+  username: (( .properties.broker_credentials.identity ))
+  password: (( .properties.broker_credentials.password ))
+  cf:
+    api_url: https://api.(( ..cf.cloud_controller.system_domain.value ))
+`},
+	{path: "jobs/deregister-broker.yml", template: `---
+name: deregister-broker
+release: {{ .Name }}
+manifest: |
+  broker_name: {{ .Name }}
+  cf:
+    api_url: https://api.(( ..cf.cloud_controller.system_domain.value ))
+`},
+	{path: "instance-groups/_order.yml", template: `---
+instance_group_order:
+- broker
+- register-broker
+- deregister-broker
+`},
+	{path: "instance-groups/broker.yml", template: `---
+name: broker
+label: Service Broker
+resource_label: Service Broker
+description: The {{ .Label }} service broker.
+
+templates:
+- $( job "broker" )
+
+static_ip: 0
+dynamic_ip: 1
+
+max_in_flight: 1
+
+instance_definition:
+  name: instances
+  type: integer
+  label: Instances
+  configurable: true
+  default: 1
+  constraints:
+    min: 1
+
+resource_definitions:
+- name: ram
+  type: integer
+  label: RAM
+  configurable: true
+  default: 1024
+
+- name: ephemeral_disk
+  type: integer
+  label: Ephemeral Disk
+  configurable: true
+  default: 4096
+
+- name: persistent_disk
+  type: integer
+  label: Persistent Disk
+  configurable: false
+  default: 0
+
+- name: cpu
+  type: integer
+  label: CPU
+  configurable: true
+  default: 1
+`},
+	{path: "instance-groups/register-broker.yml", template: errandScaffoldTemplate("register-broker", "Register Broker", "Registers the service broker with Cloud Foundry.")},
+	{path: "instance-groups/deregister-broker.yml", template: errandScaffoldTemplate("deregister-broker", "Deregister Broker", "Removes the service broker from Cloud Foundry.")},
+}
+
+func errandScaffoldTemplate(name, label, description string) string {
+	return `---
+name: ` + name + `
+label: ` + label + `
+resource_label: ` + label + `
+description: ` + description + `
+errand: true
+
+templates:
+- $( job "` + name + `" )
+
+static_ip: 0
+dynamic_ip: 1
+
+max_in_flight: 1
+
+instance_definition:
+  name: instances
+  type: integer
+  label: Instances
+  configurable: false
+  default: 1
+
+resource_definitions:
+- name: ram
+  type: integer
+  label: RAM
+  configurable: false
+  default: 1024
+
+- name: ephemeral_disk
+  type: integer
+  label: Ephemeral Disk
+  configurable: false
+  default: 1024
+
+- name: persistent_disk
+  type: integer
+  label: Persistent Disk
+  configurable: false
+  default: 0
+
+- name: cpu
+  type: integer
+  label: CPU
+  configurable: false
+  default: 1
+`
+}
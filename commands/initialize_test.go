@@ -0,0 +1,202 @@
+package commands_test
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/jhanda"
+	. "github.com/pivotal-cf/kiln/commands"
+	"github.com/pivotal-cf/kiln/internal/cargo"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var _ = Describe("Init", func() {
+	var (
+		writer      strings.Builder
+		tmpDir      string
+		directory   string
+		initCommand Init
+	)
+
+	BeforeEach(func() {
+		writer.Reset()
+
+		var err error
+		tmpDir, err = ioutil.TempDir("", "init-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		directory = filepath.Join(tmpDir, "some-product")
+
+		initCommand = NewInit(log.New(&writer, "", 0))
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	readFile := func(path string) string {
+		contents, err := ioutil.ReadFile(filepath.Join(directory, path))
+		Expect(err).NotTo(HaveOccurred())
+		return string(contents)
+	}
+
+	Describe("Execute", func() {
+		It("generates a tile", func() {
+			err := initCommand.Execute([]string{"--name", "some-product", "--directory", directory})
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, path := range []string{
+				"base.yml",
+				"icon.png",
+				"Kilnfile.lock",
+				"forms/_order.yml",
+				"forms/settings.yml",
+				"instance-groups/_order.yml",
+				"instance-groups/some-product.yml",
+				"jobs/_order.yml",
+				"jobs/some-product.yml",
+				"migrations/.gitkeep",
+				"README.md",
+				"properties/_order.yml",
+				"properties/log_level.yml",
+			} {
+				Expect(filepath.Join(directory, path)).To(BeAnExistingFile())
+			}
+
+			base := readFile("base.yml")
+			Expect(base).To(ContainSubstring("name: some-product\nlabel: Some Product\n"))
+			Expect(base).To(ContainSubstring(`- $( release "some-product" )`))
+			Expect(base).To(ContainSubstring("stemcell_criteria: $( stemcell )\n"))
+			Expect(base).To(ContainSubstring(`- $( property "log_level" )`))
+			Expect(base).To(ContainSubstring(`- $( form "settings" )`))
+			Expect(base).To(ContainSubstring(`- $( instance_group "some-product" )`))
+			Expect(base).NotTo(ContainSubstring("service_broker"))
+			Expect(base).NotTo(ContainSubstring("additional_stemcells_criteria"))
+
+			var kilnfile cargo.Kilnfile
+			Expect(yaml.UnmarshalStrict([]byte(readFile("Kilnfile")), &kilnfile)).To(Succeed())
+			Expect(kilnfile.Releases).To(Equal([]cargo.ReleaseConstraint{{Name: "some-product"}}))
+			Expect(kilnfile.Stemcell).To(Equal(cargo.Stemcell{OS: "ubuntu-xenial", Version: "*"}))
+			Expect(kilnfile.ReleaseSources).To(Equal([]cargo.ReleaseSourceConfig{{Type: "bosh.io"}}))
+			Expect(kilnfile.Bake).To(Equal(&cargo.BakeConfig{Metadata: "base.yml"}))
+
+			var kilnfileLock cargo.KilnfileLock
+			Expect(yaml.UnmarshalStrict([]byte(readFile("Kilnfile.lock")), &kilnfileLock)).To(Succeed())
+			Expect(kilnfileLock.Releases).To(BeEmpty())
+
+			Expect(readFile("instance-groups/_order.yml")).To(Equal("---\ninstance_group_order:\n- some-product\n"))
+
+			migrations, err := ioutil.ReadDir(filepath.Join(directory, "migrations"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(migrations).To(HaveLen(1))
+			Expect(migrations[0].Name()).To(Equal(".gitkeep"))
+			Expect(migrations[0].Size()).To(BeZero())
+			Expect(readFile("README.md")).To(ContainSubstring("exports.migrate = function(input) {"))
+
+			Expect(writer.String()).To(ContainSubstring("Initialized some-product in " + directory))
+		})
+
+		Context("when the directory is not provided", func() {
+			var workingDir string
+
+			BeforeEach(func() {
+				var err error
+				workingDir, err = os.Getwd()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(os.Chdir(tmpDir)).To(Succeed())
+			})
+
+			AfterEach(func() {
+				Expect(os.Chdir(workingDir)).To(Succeed())
+			})
+
+			It("generates the tile in a directory named after the product", func() {
+				err := initCommand.Execute([]string{"--name", "some-product"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(filepath.Join(tmpDir, "some-product", "base.yml")).To(BeAnExistingFile())
+			})
+		})
+
+		Context("when --service-broker is provided", func() {
+			It("generates a service broker tile", func() {
+				err := initCommand.Execute([]string{"--name", "some-product", "--directory", directory, "--service-broker"})
+				Expect(err).NotTo(HaveOccurred())
+
+				base := readFile("base.yml")
+				Expect(base).To(ContainSubstring("service_broker: true\n"))
+				Expect(base).To(ContainSubstring("post_deploy_errands:\n- name: register-broker\n"))
+				Expect(base).To(ContainSubstring("pre_delete_errands:\n- name: deregister-broker\n"))
+				Expect(base).To(ContainSubstring(`- $( property "broker_credentials" )`))
+				Expect(base).To(ContainSubstring(`- $( instance_group "broker" )`))
+				Expect(base).NotTo(ContainSubstring(`- $( instance_group "some-product" )`))
+
+				Expect(readFile("instance-groups/register-broker.yml")).To(ContainSubstring("errand: true\n"))
+				Expect(readFile("jobs/broker.yml")).To(ContainSubstring("release: some-product\n"))
+				Expect(filepath.Join(directory, "jobs", "some-product.yml")).NotTo(BeAnExistingFile())
+			})
+		})
+
+		Context("when more than one --stemcell-os is provided", func() {
+			It("generates a multi-stemcell tile", func() {
+				err := initCommand.Execute([]string{"--name", "some-product", "--directory", directory, "--stemcell-os", "ubuntu-xenial", "--stemcell-os", "windows2019"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(readFile("base.yml")).To(ContainSubstring(`stemcell_criteria: $( stemcell "ubuntu-xenial" )
+additional_stemcells_criteria:
+- $( stemcell "windows2019" )
+`))
+
+				var kilnfile cargo.Kilnfile
+				Expect(yaml.UnmarshalStrict([]byte(readFile("Kilnfile")), &kilnfile)).To(Succeed())
+				Expect(kilnfile.Stemcell).To(Equal(cargo.Stemcell{}))
+				Expect(kilnfile.Stemcells).To(Equal([]cargo.Stemcell{
+					{OS: "ubuntu-xenial", Version: "*"},
+					{OS: "windows2019", Version: "*"},
+				}))
+			})
+		})
+
+		Context("failure cases", func() {
+			Context("when the name is missing", func() {
+				It("returns an error", func() {
+					err := initCommand.Execute([]string{})
+					Expect(err).To(MatchError("missing required flag \"--name\""))
+				})
+			})
+
+			Context("when the name is not a product name", func() {
+				It("returns an error", func() {
+					err := initCommand.Execute([]string{"--name", "Some Product"})
+					Expect(err).To(MatchError(`--name "Some Product" must be lowercase letters, digits and dashes, starting with a letter`))
+				})
+			})
+
+			Context("when the directory already exists", func() {
+				It("returns an error without changing it", func() {
+					Expect(os.Mkdir(directory, 0755)).To(Succeed())
+
+					err := initCommand.Execute([]string{"--name", "some-product", "--directory", directory})
+					Expect(err).To(MatchError("cannot initialize tile: " + directory + " already exists"))
+
+					Expect(filepath.Join(directory, "base.yml")).NotTo(BeAnExistingFile())
+				})
+			})
+		})
+	})
+
+	Describe("Usage", func() {
+		It("returns usage information for the command", func() {
+			Expect(initCommand.Usage()).To(Equal(jhanda.Usage{
+				Description:      "This command generates the metadata, parts, migrations and Kilnfile of a new tile.",
+				ShortDescription: "generates a new tile",
+				Flags:            initCommand.Options,
+			}))
+		})
+	})
+})
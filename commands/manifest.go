@@ -0,0 +1,142 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/pivotal-cf/jhanda"
+	"github.com/pivotal-cf/kiln/internal/cargo"
+	"github.com/pivotal-cf/kiln/internal/cargo/opsman"
+	"github.com/pivotal-cf/kiln/proofing"
+	yaml "gopkg.in/yaml.v2"
+)
+
+//go:generate counterfeiter -o ./fakes/manifest_generator.go --fake-name ManifestGenerator . manifestGenerator
+type manifestGenerator interface {
+	Execute(template proofing.ProductTemplate, config cargo.OpsManagerConfig) (cargo.Manifest, error)
+}
+
+type Manifest struct {
+	logger    *log.Logger
+	generator manifestGenerator
+
+	Options struct {
+		Metadata                string `short:"m"   long:"metadata"                  required:"true" description:"path to the baked metadata file"`
+		Config                  string `short:"c"   long:"config"                    required:"true" description:"path to a file with the deployment name, availability zones, stemcells, resource configs and property values"`
+		OutputFile              string `short:"o"   long:"output-file"                               description:"path to where the manifest will be written (default: stdout)"`
+		RuntimeConfigsDirectory string `short:"rcd" long:"runtime-configs-directory"                 description:"path to a directory to write the runtime configs into (default: the directory of --output-file)"`
+	}
+}
+
+func NewManifest(logger *log.Logger, generator manifestGenerator) Manifest {
+	return Manifest{
+		logger:    logger,
+		generator: generator,
+	}
+}
+
+func (m Manifest) Execute(args []string) error {
+	_, err := jhanda.Parse(&m.Options, args)
+	if err != nil {
+		return err
+	}
+
+	metadata, err := os.Open(m.Options.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to read metadata: %s", err)
+	}
+	defer metadata.Close()
+
+	productTemplate, err := proofing.Parse(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to parse metadata: %s", err)
+	}
+
+	config, err := readOpsManagerConfig(m.Options.Config)
+	if err != nil {
+		return err
+	}
+
+	if config.DeploymentName == "" {
+		config.DeploymentName = productTemplate.Name
+	}
+
+	if len(config.Stemcells) == 0 {
+		config.Stemcells = []opsman.Stemcell{{
+			Name:    "default",
+			OS:      productTemplate.StemcellCriteria.OS,
+			Version: productTemplate.StemcellCriteria.Version,
+		}}
+	}
+
+	generated, err := m.generator.Execute(productTemplate, config)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := yaml.Marshal(generated)
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %s", err)
+	}
+
+	runtimeConfigsDirectory := m.Options.RuntimeConfigsDirectory
+	if runtimeConfigsDirectory == "" && m.Options.OutputFile != "" {
+		runtimeConfigsDirectory = filepath.Dir(m.Options.OutputFile)
+	}
+
+	if m.Options.OutputFile == "" {
+		m.logger.Printf("%s", manifest)
+	} else {
+		err = ioutil.WriteFile(m.Options.OutputFile, manifest, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to write manifest: %s", err)
+		}
+	}
+
+	for _, runtimeConfig := range generated.RuntimeConfigs {
+		contents, err := yaml.Marshal(runtimeConfig.Config)
+		if err != nil {
+			return fmt.Errorf("failed to encode runtime config %s: %s", runtimeConfig.Name, err)
+		}
+
+		// NOTE: without a directory the runtime configs follow the manifest as
+		// separate YAML documents
+		if runtimeConfigsDirectory == "" {
+			m.logger.Printf("---\n# runtime config %s\n%s", runtimeConfig.Name, contents)
+			continue
+		}
+
+		err = ioutil.WriteFile(filepath.Join(runtimeConfigsDirectory, fmt.Sprintf("runtime-config-%s.yml", runtimeConfig.Name)), contents, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to write runtime config %s: %s", runtimeConfig.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (m Manifest) Usage() jhanda.Usage {
+	return jhanda.Usage{
+		Description:      "This command generates a BOSH deployment manifest and runtime configs from baked metadata, so that the jobs of a tile can be deployed to a BOSH director without Ops Manager.",
+		ShortDescription: "generates a BOSH manifest",
+		Flags:            m.Options,
+	}
+}
+
+func readOpsManagerConfig(path string) (cargo.OpsManagerConfig, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return cargo.OpsManagerConfig{}, fmt.Errorf("failed to read config: %s", err)
+	}
+
+	var config cargo.OpsManagerConfig
+	err = yaml.UnmarshalStrict(contents, &config)
+	if err != nil {
+		return cargo.OpsManagerConfig{}, fmt.Errorf("failed to parse config: %s", err)
+	}
+
+	return config, nil
+}
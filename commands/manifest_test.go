@@ -0,0 +1,209 @@
+package commands_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/pivotal-cf/kiln/commands"
+	"github.com/pivotal-cf/kiln/commands/fakes"
+	"github.com/pivotal-cf/kiln/internal/cargo"
+	"github.com/pivotal-cf/kiln/internal/cargo/opsman"
+)
+
+var _ = Describe("Manifest", func() {
+	var (
+		writer       strings.Builder
+		tmpDir       string
+		metadataPath string
+		configPath   string
+		generator    *fakes.ManifestGenerator
+		manifest     Manifest
+	)
+
+	BeforeEach(func() {
+		writer.Reset()
+
+		var err error
+		tmpDir, err = ioutil.TempDir("", "manifest-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		metadataPath = filepath.Join(tmpDir, "metadata.yml")
+		Expect(ioutil.WriteFile(metadataPath, []byte(`---
+name: some-product
+stemcell_criteria:
+  os: ubuntu-xenial
+  version: "250.17"
+`), 0644)).To(Succeed())
+
+		configPath = filepath.Join(tmpDir, "opsman.yml")
+		Expect(ioutil.WriteFile(configPath, []byte(`---
+deployment_name: some-deployment
+availability_zones: [z1, z2]
+singleton_availability_zone: z2
+stemcells:
+- name: some-stemcell
+  os: ubuntu-xenial
+  version: "250.17"
+resource_configs:
+- name: some-job-type
+  instances: 3
+  vm_type: large
+  persistent_disk_type: ssd
+- name: other-job-type
+  instances: automatic
+property_values:
+  .properties.some-property: some-value
+`), 0644)).To(Succeed())
+
+		generator = &fakes.ManifestGenerator{}
+		generator.ExecuteReturns(cargo.Manifest{Name: "some-deployment"}, nil)
+
+		manifest = NewManifest(log.New(&writer, "", 0), generator)
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	Describe("Execute", func() {
+		It("generates a manifest from the metadata and config", func() {
+			err := manifest.Execute([]string{"--metadata", metadataPath, "--config", configPath})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(generator.ExecuteCallCount()).To(Equal(1))
+			template, config := generator.ExecuteArgsForCall(0)
+			Expect(template.Name).To(Equal("some-product"))
+			Expect(config).To(Equal(cargo.OpsManagerConfig{
+				DeploymentName:            "some-deployment",
+				AvailabilityZones:         []string{"z1", "z2"},
+				SingletonAvailabilityZone: "z2",
+				Stemcells: []opsman.Stemcell{
+					{Name: "some-stemcell", OS: "ubuntu-xenial", Version: "250.17"},
+				},
+				ResourceConfigs: []opsman.ResourceConfig{
+					{Name: "some-job-type", Instances: opsman.ResourceConfigInstances{Value: 3}, VMType: "large", PersistentDiskType: "ssd"},
+					{Name: "other-job-type", Instances: opsman.ResourceConfigInstances{Value: -1}},
+				},
+				PropertyValues: map[string]interface{}{
+					".properties.some-property": "some-value",
+				},
+			}))
+
+			Expect(writer.String()).To(ContainSubstring("name: some-deployment\n"))
+		})
+
+		It("writes the manifest to the output file", func() {
+			outputFile := filepath.Join(tmpDir, "manifest.yml")
+
+			err := manifest.Execute([]string{"--metadata", metadataPath, "--config", configPath, "--output-file", outputFile})
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := ioutil.ReadFile(outputFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring("name: some-deployment\n"))
+			Expect(writer.String()).To(BeEmpty())
+		})
+
+		Context("when the product has runtime configs", func() {
+			BeforeEach(func() {
+				generator.ExecuteReturns(cargo.Manifest{
+					Name: "some-deployment",
+					RuntimeConfigs: []cargo.RuntimeConfig{
+						{Name: "some-addon", Config: map[string]interface{}{"addons": []interface{}{}}},
+					},
+				}, nil)
+			})
+
+			It("prints them after the manifest", func() {
+				err := manifest.Execute([]string{"--metadata", metadataPath, "--config", configPath})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(writer.String()).To(HaveSuffix("---\n# runtime config some-addon\naddons: []\n"))
+			})
+
+			It("writes them next to the output file", func() {
+				err := manifest.Execute([]string{"--metadata", metadataPath, "--config", configPath, "--output-file", filepath.Join(tmpDir, "manifest.yml")})
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := ioutil.ReadFile(filepath.Join(tmpDir, "runtime-config-some-addon.yml"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(Equal("addons: []\n"))
+			})
+
+			It("writes them to the runtime configs directory", func() {
+				runtimeConfigsDirectory := filepath.Join(tmpDir, "runtime-configs")
+				Expect(os.Mkdir(runtimeConfigsDirectory, 0755)).To(Succeed())
+
+				err := manifest.Execute([]string{"--metadata", metadataPath, "--config", configPath, "--runtime-configs-directory", runtimeConfigsDirectory})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(filepath.Join(runtimeConfigsDirectory, "runtime-config-some-addon.yml")).To(BeARegularFile())
+				Expect(writer.String()).NotTo(ContainSubstring("runtime config"))
+			})
+		})
+
+		Context("when the config leaves out the deployment name and stemcells", func() {
+			BeforeEach(func() {
+				Expect(ioutil.WriteFile(configPath, []byte("availability_zones: [z1]\n"), 0644)).To(Succeed())
+			})
+
+			It("uses the product name and the stemcell criteria", func() {
+				err := manifest.Execute([]string{"--metadata", metadataPath, "--config", configPath})
+				Expect(err).NotTo(HaveOccurred())
+
+				_, config := generator.ExecuteArgsForCall(0)
+				Expect(config.DeploymentName).To(Equal("some-product"))
+				Expect(config.Stemcells).To(Equal([]opsman.Stemcell{
+					{Name: "default", OS: "ubuntu-xenial", Version: "250.17"},
+				}))
+			})
+		})
+
+		Context("when the config has unknown keys", func() {
+			BeforeEach(func() {
+				Expect(ioutil.WriteFile(configPath, []byte("azs: [z1]\n"), 0644)).To(Succeed())
+			})
+
+			It("returns an error", func() {
+				err := manifest.Execute([]string{"--metadata", metadataPath, "--config", configPath})
+				Expect(err).To(MatchError(ContainSubstring("failed to parse config")))
+			})
+		})
+
+		Context("when resource config instances are not a number", func() {
+			BeforeEach(func() {
+				Expect(ioutil.WriteFile(configPath, []byte("resource_configs:\n- name: some-job-type\n  instances: lots\n"), 0644)).To(Succeed())
+			})
+
+			It("returns an error", func() {
+				err := manifest.Execute([]string{"--metadata", metadataPath, "--config", configPath})
+				Expect(err).To(MatchError(ContainSubstring(`resource config instances must be a number or "automatic"`)))
+			})
+		})
+
+		Context("when the manifest cannot be generated", func() {
+			BeforeEach(func() {
+				generator.ExecuteReturns(cargo.Manifest{}, errors.New("failed to generate manifest"))
+			})
+
+			It("returns the error", func() {
+				err := manifest.Execute([]string{"--metadata", metadataPath, "--config", configPath})
+				Expect(err).To(MatchError("failed to generate manifest"))
+				Expect(writer.String()).To(BeEmpty())
+			})
+		})
+
+		Context("when the metadata does not exist", func() {
+			It("returns an error", func() {
+				err := manifest.Execute([]string{"--metadata", "missing.yml", "--config", configPath})
+				Expect(err).To(MatchError(ContainSubstring("failed to read metadata")))
+			})
+		})
+	})
+})
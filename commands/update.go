@@ -1,8 +1,11 @@
 package commands
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"sort"
@@ -11,17 +14,12 @@ import (
 	"github.com/Masterminds/semver"
 	"github.com/pivotal-cf/jhanda"
 	"github.com/pivotal-cf/kiln/builder"
+	"github.com/pivotal-cf/kiln/fetcher"
 	"github.com/pivotal-cf/kiln/internal/baking"
 	"github.com/pivotal-cf/kiln/internal/cargo"
 	"gopkg.in/yaml.v2"
 )
 
-const (
-	stemcellSlugWindows = "stemcells-windows-server"
-	stemcellSlugXenial  = "stemcells-ubuntu-xenial"
-	stemcellSlugTrusty  = "stemcells"
-)
-
 // Update wraps the dependancies and flag options for the `kiln update` command
 type Update struct {
 	Options struct {
@@ -29,11 +27,21 @@ type Update struct {
 		VariablesFiles []string `short:"vf" long:"variables-file" description:"path to variables file"`
 		Variables      []string `short:"vr" long:"variable" description:"variable in key=value format"`
 		PivNetToken    string   `short:"pt" env:"PIVOTAL_NETWORK_API_TOKEN" long:"pivotal-network-token" description:"uaa access token for network.pivotal.io"`
+
+		DownloadThreads              int  `short:"dt" long:"download-threads" description:"number of parallel threads to download parts from S3"`
+		AllowOnlyPublishableReleases bool `long:"allow-only-publishable-releases" description:"include releases that would not be shipped with the tile (development builds)"`
 	}
 	StemcellsVersionsService interface {
 		Versions(string) ([]string, error)
 		SetToken(string)
 	}
+	StemcellTarballs      StemcellTarballLocker
+	ReleaseSourcesFactory ReleaseSourcesFactory
+}
+
+//go:generate counterfeiter -o ./fakes/stemcell_tarball_locker.go --fake-name StemcellTarballLocker . StemcellTarballLocker
+type StemcellTarballLocker interface {
+	Lock(stemcell cargo.Stemcell) (cargo.Stemcell, error)
 }
 
 // Execute expects a Kilnfile to exist and be passed as a flag
@@ -49,26 +57,35 @@ func (update Update) Execute(args []string) error {
 	if err != nil {
 		return errors.New("could not read kilnfile")
 	}
+	templateVariablesService := baking.NewTemplateVariablesService()
+	templateVariables, err := templateVariablesService.FromPathsAndPairs(update.Options.VariablesFiles, update.Options.Variables)
+	if err != nil {
+		return fmt.Errorf("failed to parse template variables: %s", err)
+	}
+	interpolator := builder.NewInterpolator()
+	interpolatedKilnfile, err := interpolator.Interpolate(builder.InterpolateInput{
+		Variables: templateVariables,
+	}, kilnfileYAML)
+	if err != nil {
+		return fmt.Errorf("could not parse yaml in kilnfile: %s", err)
+	}
 	var kilnfile cargo.Kilnfile
-	if err := yaml.Unmarshal(kilnfileYAML, &kilnfile); err != nil {
+	if err := yaml.Unmarshal(interpolatedKilnfile, &kilnfile); err != nil {
 		return fmt.Errorf("could not parse yaml in kilnfile: %s", err)
 	}
 
-	if kilnfile.Stemcell.OS == "" && kilnfile.Stemcell.Version == "" {
+	if kilnfile.Stemcell.OS == "" && kilnfile.Stemcell.Version == "" && len(kilnfile.Stemcells) == 0 {
 		return fmt.Errorf("stemcell OS (%q) and/or version constraint (%q) are not set", kilnfile.Stemcell.OS, kilnfile.Stemcell.Version)
 	}
+	if kilnfile.Stemcell != (cargo.Stemcell{}) && len(kilnfile.Stemcells) > 0 {
+		return errors.New("kilnfile must not set both stemcell_criteria and stemcells")
+	}
 
 	kilnfileLockPath := fmt.Sprintf("%s.lock", update.Options.Kilnfile)
 	kilnfileLockYAML, err := ioutil.ReadFile(kilnfileLockPath)
 	if err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("could not read kilnfile: %s", err)
 	}
-	templateVariablesService := baking.NewTemplateVariablesService()
-	templateVariables, err := templateVariablesService.FromPathsAndPairs(update.Options.VariablesFiles, update.Options.Variables)
-	if err != nil {
-		return fmt.Errorf("failed to parse template variables: %s", err)
-	}
-	interpolator := builder.NewInterpolator()
 	interpolatedMetadata, err := interpolator.Interpolate(builder.InterpolateInput{
 		Variables: templateVariables,
 	}, kilnfileLockYAML)
@@ -81,26 +98,72 @@ func (update Update) Execute(args []string) error {
 		return fmt.Errorf("could not parse yaml in Kilnfile.lock: %s", err)
 	}
 
-	stemcellConstraint, err := semver.NewConstraint(kilnfile.Stemcell.Version)
+	lockedStemcells := KilnfileLock.StemcellCriteria()
+
+	var stemcells []cargo.Stemcell
+	for _, criteria := range kilnfile.StemcellCriteria() {
+		if _, duplicate := cargo.FindStemcell(stemcells, criteria.OS); duplicate {
+			return fmt.Errorf("stemcell os %s is listed more than once", criteria.OS)
+		}
+
+		locked, _ := cargo.FindStemcell(lockedStemcells, criteria.OS)
+		version, err := update.newestStemcellVersion(criteria, locked.Version)
+		if err != nil {
+			return err
+		}
+
+		stemcell, err := update.lockStemcellTarball(criteria, version, locked)
+		if err != nil {
+			return err
+		}
+		stemcells = append(stemcells, stemcell)
+	}
+
+	previousKilnfileLock := KilnfileLock
+	if len(kilnfile.Stemcells) > 0 {
+		KilnfileLock.Stemcell, KilnfileLock.Stemcells = cargo.Stemcell{}, stemcells
+	} else {
+		KilnfileLock.Stemcell, KilnfileLock.Stemcells = stemcells[0], nil
+	}
+
+	if len(kilnfile.Releases) > 0 {
+		KilnfileLock.Releases, err = update.updateReleases(kilnfile, KilnfileLock, previousKilnfileLock)
+		if err != nil {
+			return err
+		}
+	}
+
+	os.Remove(kilnfileLockPath)
+	lockFile, err := os.Create(kilnfileLockPath)
 	if err != nil {
-		return fmt.Errorf("stemcell_constraint version error: %s", err)
+		return err
 	}
 
-	var stemcellSlug string
-	switch kilnfile.Stemcell.OS {
-	case "windows":
-		stemcellSlug = stemcellSlugWindows
-	case "ubuntu-xenial":
-		stemcellSlug = stemcellSlugXenial
-	case "ubuntu-trusty":
-		stemcellSlug = stemcellSlugTrusty
-	default:
-		return fmt.Errorf("stemcell_constraint os not supported: %s", kilnfile.Stemcell.OS)
+	updatedLockFileYAML, err := yaml.Marshal(KilnfileLock)
+	if err != nil {
+		return err
+	}
+	lockFile.Write([]byte(lockFileYAMLHeader))
+	lockFile.Write(updatedLockFileYAML)
+	return nil
+}
+
+// newestStemcellVersion returns the newest version of the stemcell OS matching
+// the criteria, or lockedVersion when no version matches.
+func (update Update) newestStemcellVersion(criteria cargo.Stemcell, lockedVersion string) (string, error) {
+	stemcellConstraint, err := semver.NewConstraint(criteria.Version)
+	if err != nil {
+		return "", fmt.Errorf("stemcell_constraint version error: %s", err)
+	}
+
+	stemcellSlug, ok := fetcher.StemcellSlug(criteria.OS)
+	if !ok {
+		return "", fmt.Errorf("stemcell_constraint os not supported: %s", criteria.OS)
 	}
 
 	stemcellVersionsStrings, err := update.StemcellsVersionsService.Versions(stemcellSlug)
 	if err != nil {
-		return fmt.Errorf("could not get stemcell versions: %s", err)
+		return "", fmt.Errorf("could not get stemcell versions: %s", err)
 	}
 	stemcellVersions := make([]*semver.Version, 0, len(stemcellVersionsStrings))
 	for _, str := range stemcellVersionsStrings {
@@ -114,24 +177,178 @@ func (update Update) Execute(args []string) error {
 	}
 	sort.Sort(semver.Collection(stemcellVersions))
 
-	if len(stemcellVersions) > 0 {
-		KilnfileLock.Stemcell.Version = strings.TrimSuffix(stemcellVersions[len(stemcellVersions)-1].String(), ".0")
+	if len(stemcellVersions) == 0 {
+		return lockedVersion, nil
 	}
-	KilnfileLock.Stemcell.OS = kilnfile.Stemcell.OS
+	return strings.TrimSuffix(stemcellVersions[len(stemcellVersions)-1].String(), ".0"), nil
+}
 
-	os.Remove(kilnfileLockPath)
-	lockFile, err := os.Create(kilnfileLockPath)
+// lockStemcellTarball pins the tarball of the stemcell version when the
+// criteria have a source. The locked tarball is kept while the version, source
+// and infrastructure do not change.
+func (update Update) lockStemcellTarball(criteria cargo.Stemcell, version string, locked cargo.Stemcell) (cargo.Stemcell, error) {
+	stemcell := cargo.Stemcell{
+		OS:             criteria.OS,
+		Version:        version,
+		Source:         criteria.Source,
+		Infrastructure: criteria.Infrastructure,
+	}
+	if stemcell.Source == "" {
+		return stemcell, nil
+	}
+
+	if locked.Version == stemcell.Version && locked.Source == stemcell.Source && locked.Infrastructure == stemcell.Infrastructure && (locked.SHA1 != "" || locked.SHA256 != "") {
+		stemcell.URL, stemcell.SHA1, stemcell.SHA256 = locked.URL, locked.SHA1, locked.SHA256
+		return stemcell, nil
+	}
+
+	stemcell, err := update.StemcellTarballs.Lock(stemcell)
 	if err != nil {
-		return err
+		return cargo.Stemcell{}, fmt.Errorf("could not lock stemcell %s %s: %s", criteria.OS, version, err)
 	}
+	return stemcell, nil
+}
 
-	updatedLockFileYAML, err := yaml.Marshal(KilnfileLock)
+func (update Update) updateReleases(kilnfile cargo.Kilnfile, kilnfileLock, previousKilnfileLock cargo.KilnfileLock) ([]cargo.Release, error) {
+	releaseSources, err := update.ReleaseSourcesFactory.ReleaseSources(kilnfile, update.Options.AllowOnlyPublishableReleases)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	lockFile.Write([]byte(lockFileYAMLHeader))
-	lockFile.Write(updatedLockFileYAML)
-	return nil
+
+	downloadDir, err := ioutil.TempDir("", "kiln-update")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(downloadDir)
+
+	var (
+		releases   []cargo.Release
+		unresolved []string
+	)
+	for _, spec := range kilnfile.Releases {
+		stemcellOS := kilnfile.ReleaseStemcellOS(spec)
+		stemcell, ok := cargo.FindStemcell(kilnfileLock.StemcellCriteria(), stemcellOS)
+		if !ok {
+			return nil, fmt.Errorf("release %q is compiled against stemcell os %q, which is not in the Kilnfile stemcells", spec.Name, stemcellOS)
+		}
+
+		stemcell = cargo.Stemcell{OS: stemcell.OS, Version: stemcell.Version}
+
+		constraint := fetcher.ReleaseVersionConstraint{
+			Name:            spec.Name,
+			StemcellOS:      stemcell.OS,
+			StemcellVersion: stemcell.Version,
+		}
+		if spec.Version != "" {
+			constraint.Constraint, err = semver.NewConstraint(spec.Version)
+			if err != nil {
+				return nil, fmt.Errorf("release %q version constraint error: %s", spec.Name, err)
+			}
+		}
+
+		remoteRelease, releaseSource, err := findNewestRelease(releaseSources, constraint)
+		if err != nil {
+			return nil, fmt.Errorf("could not get versions of release %q: %s", spec.Name, err)
+		}
+		if remoteRelease == nil {
+			unresolved = append(unresolved, fmt.Sprintf("- %s (%s)", spec.Name, spec.Version))
+			continue
+		}
+		version := remoteRelease.ReleaseID().Version
+
+		locked, ok := findLockedRelease(previousKilnfileLock, spec.Name, stemcell.OS)
+		if ok && locked.Version == version && locked.SHA1 != "" && previousKilnfileLock.ReleaseStemcell(locked) == stemcell {
+			locked.StemcellOS, locked.StemcellVersion = stemcell.OS, stemcell.Version
+			releases = append(releases, locked)
+			continue
+		}
+
+		sum, err := update.releaseSHA1(releaseSource, remoteRelease, downloadDir)
+		if err != nil {
+			return nil, fmt.Errorf("could not calculate checksum of release %q: %s", spec.Name, err)
+		}
+
+		releases = append(releases, cargo.Release{
+			Name:            spec.Name,
+			Version:         version,
+			SHA1:            sum,
+			StemcellOS:      stemcell.OS,
+			StemcellVersion: stemcell.Version,
+		})
+	}
+
+	if len(unresolved) > 0 {
+		return nil, fmt.Errorf("could not find a version matching the constraints of the following releases\n%s", strings.Join(unresolved, "\n"))
+	}
+
+	return releases, nil
+}
+
+func (update Update) releaseSHA1(releaseSource fetcher.ReleaseSource, remoteRelease fetcher.RemoteRelease, downloadDir string) (string, error) {
+	localReleases, err := releaseSource.DownloadReleases(downloadDir, []fetcher.RemoteRelease{remoteRelease}, fetcher.DownloadOptions{Threads: update.Options.DownloadThreads})
+	if err != nil {
+		return "", err
+	}
+
+	localRelease, ok := localReleases[remoteRelease.ReleaseID()]
+	if !ok {
+		return "", fmt.Errorf("release %s was not downloaded", remoteRelease.RemotePath())
+	}
+	defer os.Remove(localRelease.LocalPath())
+
+	f, err := os.Open(localRelease.LocalPath())
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha1.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func findNewestRelease(releaseSources []fetcher.ReleaseSource, constraint fetcher.ReleaseVersionConstraint) (fetcher.RemoteRelease, fetcher.ReleaseSource, error) {
+	var (
+		newest        fetcher.RemoteRelease
+		newestVersion *semver.Version
+		newestSource  fetcher.ReleaseSource
+	)
+
+	for _, releaseSource := range releaseSources {
+		remoteRelease, found, err := releaseSource.FindReleaseVersion(constraint)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !found {
+			continue
+		}
+
+		version, err := semver.NewVersion(remoteRelease.ReleaseID().Version)
+		if err != nil {
+			continue
+		}
+
+		// release sources are listed in order of preference so only a strictly newer version replaces an earlier match
+		if newestVersion == nil || version.GreaterThan(newestVersion) {
+			newest, newestVersion, newestSource = remoteRelease, version, releaseSource
+		}
+	}
+
+	return newest, newestSource, nil
+}
+
+// findLockedRelease finds the release locked against the stemcell OS, so that
+// a release compiled against more than one stemcell is locked for each.
+func findLockedRelease(kilnfileLock cargo.KilnfileLock, name, stemcellOS string) (cargo.Release, bool) {
+	for _, release := range kilnfileLock.Releases {
+		if release.Name == name && kilnfileLock.ReleaseStemcell(release).OS == stemcellOS {
+			return release, true
+		}
+	}
+	return cargo.Release{}, false
 }
 
 // Usage implements the Usage part of the jhanda.Command interface
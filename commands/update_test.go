@@ -7,11 +7,16 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/Masterminds/semver"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/pivotal-cf/jhanda"
 	. "github.com/pivotal-cf/kiln/commands"
 	"github.com/pivotal-cf/kiln/commands/fakes"
+	"github.com/pivotal-cf/kiln/fetcher"
+	fetcherFakes "github.com/pivotal-cf/kiln/fetcher/fakes"
+	"github.com/pivotal-cf/kiln/internal/cargo"
+	"gopkg.in/yaml.v2"
 )
 
 var _ = Describe("Update", func() {
@@ -178,6 +183,455 @@ var _ = Describe("Update", func() {
 					})
 				})
 			})
+			When("the stemcell criteria have a tarball source", func() {
+				var stemcellTarballs *fakes.StemcellTarballLocker
+
+				BeforeEach(func() {
+					Expect(ioutil.WriteFile(someKilnfilePath, []byte(`---
+stemcell_criteria:
+  os: ubuntu-trusty
+  version: "3586.*"
+  source: bosh.io
+  infrastructure: aws-xen-hvm
+`), 0644)).To(Succeed())
+
+					stemcellTarballs = new(fakes.StemcellTarballLocker)
+					stemcellTarballs.LockStub = func(stemcell cargo.Stemcell) (cargo.Stemcell, error) {
+						stemcell.URL = "https://example.com/stemcell.tgz"
+						stemcell.SHA1 = "some-stemcell-sha1"
+						return stemcell, nil
+					}
+					update.StemcellTarballs = stemcellTarballs
+				})
+
+				readKilnfileLock := func() cargo.KilnfileLock {
+					contents, err := ioutil.ReadFile(someKilfileLockPath)
+					Expect(err).NotTo(HaveOccurred())
+					var kilnfileLock cargo.KilnfileLock
+					Expect(yaml.Unmarshal(contents, &kilnfileLock)).To(Succeed())
+					return kilnfileLock
+				}
+
+				It("pins the stemcell tarball in the Kilnfile.lock", func() {
+					Expect(updateErr).NotTo(HaveOccurred())
+
+					Expect(stemcellTarballs.LockArgsForCall(0)).To(Equal(cargo.Stemcell{
+						OS: "ubuntu-trusty", Version: "3586.7", Source: "bosh.io", Infrastructure: "aws-xen-hvm",
+					}))
+					Expect(readKilnfileLock().Stemcell).To(Equal(cargo.Stemcell{
+						OS:             "ubuntu-trusty",
+						Version:        "3586.7",
+						Source:         "bosh.io",
+						Infrastructure: "aws-xen-hvm",
+						URL:            "https://example.com/stemcell.tgz",
+						SHA1:           "some-stemcell-sha1",
+					}))
+				})
+
+				When("the same stemcell tarball is already pinned", func() {
+					BeforeEach(func() {
+						Expect(ioutil.WriteFile(someKilfileLockPath, []byte(`---
+stemcell_criteria:
+  os: ubuntu-trusty
+  version: "3586.7"
+  source: bosh.io
+  infrastructure: aws-xen-hvm
+  url: https://example.com/locked-stemcell.tgz
+  sha1: some-locked-sha1
+`), 0644)).To(Succeed())
+					})
+
+					It("keeps the pinned tarball", func() {
+						Expect(updateErr).NotTo(HaveOccurred())
+
+						Expect(stemcellTarballs.LockCallCount()).To(Equal(0))
+						Expect(readKilnfileLock().Stemcell.SHA1).To(Equal("some-locked-sha1"))
+					})
+				})
+
+				When("the same stemcell tarball is already pinned by SHA256", func() {
+					BeforeEach(func() {
+						Expect(ioutil.WriteFile(someKilfileLockPath, []byte(`---
+stemcell_criteria:
+  os: ubuntu-trusty
+  version: "3586.7"
+  source: bosh.io
+  infrastructure: aws-xen-hvm
+  url: https://example.com/locked-stemcell.tgz
+  sha256: some-locked-sha256
+`), 0644)).To(Succeed())
+					})
+
+					It("keeps the pinned tarball", func() {
+						Expect(updateErr).NotTo(HaveOccurred())
+
+						Expect(stemcellTarballs.LockCallCount()).To(Equal(0))
+						Expect(readKilnfileLock().Stemcell.URL).To(Equal("https://example.com/locked-stemcell.tgz"))
+						Expect(readKilnfileLock().Stemcell.SHA256).To(Equal("some-locked-sha256"))
+					})
+				})
+
+				When("the stemcell tarball can not be found", func() {
+					BeforeEach(func() {
+						stemcellTarballs.LockStub = nil
+						stemcellTarballs.LockReturns(cargo.Stemcell{}, errors.New("some-error"))
+					})
+
+					It("returns a descriptive error", func() {
+						Expect(updateErr).To(MatchError("could not lock stemcell ubuntu-trusty 3586.7: some-error"))
+					})
+				})
+			})
+
+			When("the Kilnfile declares releases", func() {
+				var (
+					releaseSourcesFactory *fakes.ReleaseSourcesFactory
+					s3ReleaseSource       *fetcherFakes.ReleaseSource
+					boshIOReleaseSource   *fetcherFakes.ReleaseSource
+				)
+
+				BeforeEach(func() {
+					Expect(
+						ioutil.WriteFile(someKilnfilePath, []byte(initallKilnfileYAMLFileContents+kilnfileReleasesYAMLFileContents), 0644),
+					).NotTo(HaveOccurred())
+
+					s3ReleaseSource = new(fetcherFakes.ReleaseSource)
+					boshIOReleaseSource = new(fetcherFakes.ReleaseSource)
+
+					s3ReleaseSource.FindReleaseVersionStub = func(constraint fetcher.ReleaseVersionConstraint) (fetcher.RemoteRelease, bool, error) {
+						switch constraint.Name {
+						case "bpm":
+							return fetcher.CompiledRelease{
+								ID:              fetcher.ReleaseID{Name: "bpm", Version: "1.1.5"},
+								StemcellOS:      constraint.StemcellOS,
+								StemcellVersion: constraint.StemcellVersion,
+								Path:            "2.8/bpm/bpm-1.1.5-ubuntu-trusty-3586.7.tgz",
+							}, true, nil
+						case "uaa":
+							return fetcher.CompiledRelease{
+								ID:              fetcher.ReleaseID{Name: "uaa", Version: "73.4.0"},
+								StemcellOS:      constraint.StemcellOS,
+								StemcellVersion: constraint.StemcellVersion,
+								Path:            "2.8/uaa/uaa-73.4.0-ubuntu-trusty-3586.7.tgz",
+							}, true, nil
+						}
+						return nil, false, nil
+					}
+					boshIOReleaseSource.FindReleaseVersionStub = func(constraint fetcher.ReleaseVersionConstraint) (fetcher.RemoteRelease, bool, error) {
+						if constraint.Name == "bpm" {
+							return fetcher.BuiltRelease{
+								ID:   fetcher.ReleaseID{Name: "bpm", Version: "1.1.6"},
+								Path: "https://bosh.io/d/github.com/cloudfoundry/bpm-release?v=1.1.6",
+							}, true, nil
+						}
+						return nil, false, nil
+					}
+
+					downloadStub := func(releaseDir string, remoteReleases []fetcher.RemoteRelease, opts fetcher.DownloadOptions) (fetcher.LocalReleaseSet, error) {
+						localReleases := make(fetcher.LocalReleaseSet)
+						for _, remoteRelease := range remoteReleases {
+							path := filepath.Join(releaseDir, remoteRelease.StandardizedFilename())
+							if err := ioutil.WriteFile(path, []byte(remoteRelease.RemotePath()), 0644); err != nil {
+								return nil, err
+							}
+							localReleases[remoteRelease.ReleaseID()] = remoteRelease.AsLocal(path)
+						}
+						return localReleases, nil
+					}
+					s3ReleaseSource.DownloadReleasesStub = downloadStub
+					boshIOReleaseSource.DownloadReleasesStub = downloadStub
+
+					releaseSourcesFactory = new(fakes.ReleaseSourcesFactory)
+					releaseSourcesFactory.ReleaseSourcesReturns([]fetcher.ReleaseSource{s3ReleaseSource, boshIOReleaseSource}, nil)
+					update.ReleaseSourcesFactory = releaseSourcesFactory
+				})
+
+				readKilnfileLock := func() cargo.KilnfileLock {
+					contents, err := ioutil.ReadFile(someKilfileLockPath)
+					Expect(err).NotTo(HaveOccurred())
+					var kilnfileLock cargo.KilnfileLock
+					Expect(yaml.Unmarshal(contents, &kilnfileLock)).To(Succeed())
+					return kilnfileLock
+				}
+
+				It("locks the newest version of each release found in any release source", func() {
+					Expect(updateErr).NotTo(HaveOccurred())
+
+					Expect(readKilnfileLock().Releases).To(Equal([]cargo.Release{
+						{Name: "bpm", Version: "1.1.6", SHA1: "d762c5da776f450c6aec5dad1a3c83ed9caa2059", StemcellOS: "ubuntu-trusty", StemcellVersion: "3586.7"},
+						{Name: "uaa", Version: "73.4.0", SHA1: "488a8f8ad92728a91879c70704c0f690e8b2a1a4", StemcellOS: "ubuntu-trusty", StemcellVersion: "3586.7"},
+					}))
+				})
+
+				It("queries release sources using the updated stemcell and the version constraints", func() {
+					Expect(updateErr).NotTo(HaveOccurred())
+
+					Expect(s3ReleaseSource.FindReleaseVersionCallCount()).To(Equal(2))
+					constraint := s3ReleaseSource.FindReleaseVersionArgsForCall(0)
+					Expect(constraint.Name).To(Equal("bpm"))
+					Expect(constraint.StemcellOS).To(Equal("ubuntu-trusty"))
+					Expect(constraint.StemcellVersion).To(Equal("3586.7"))
+					Expect(constraint.Constraint.Check(semver.MustParse("1.1.9"))).To(BeTrue())
+					Expect(constraint.Constraint.Check(semver.MustParse("1.2.0"))).To(BeFalse())
+
+					constraint = s3ReleaseSource.FindReleaseVersionArgsForCall(1)
+					Expect(constraint.Name).To(Equal("uaa"))
+					Expect(constraint.Constraint).To(BeNil())
+				})
+
+				It("downloads each release from the source which had the newest version", func() {
+					Expect(updateErr).NotTo(HaveOccurred())
+
+					Expect(boshIOReleaseSource.DownloadReleasesCallCount()).To(Equal(1))
+					_, remoteReleases, _ := boshIOReleaseSource.DownloadReleasesArgsForCall(0)
+					Expect(remoteReleases).To(HaveLen(1))
+					Expect(remoteReleases[0].ReleaseID()).To(Equal(fetcher.ReleaseID{Name: "bpm", Version: "1.1.6"}))
+
+					Expect(s3ReleaseSource.DownloadReleasesCallCount()).To(Equal(1))
+					_, remoteReleases, _ = s3ReleaseSource.DownloadReleasesArgsForCall(0)
+					Expect(remoteReleases).To(HaveLen(1))
+					Expect(remoteReleases[0].ReleaseID()).To(Equal(fetcher.ReleaseID{Name: "uaa", Version: "73.4.0"}))
+				})
+
+				When("the Kilnfile.lock already has the release version locked for the same stemcell", func() {
+					BeforeEach(func() {
+						Expect(ioutil.WriteFile(someKilfileLockPath, []byte(`---
+releases:
+- name: bpm
+  version: 1.1.6
+  sha1: some-locked-sha
+stemcell_criteria:
+  os: ubuntu-trusty
+  version: "3586.7"
+`), 0644)).To(Succeed())
+					})
+
+					It("keeps the locked checksum without downloading the release", func() {
+						Expect(updateErr).NotTo(HaveOccurred())
+
+						Expect(readKilnfileLock().Releases[0]).To(Equal(cargo.Release{Name: "bpm", Version: "1.1.6", SHA1: "some-locked-sha", StemcellOS: "ubuntu-trusty", StemcellVersion: "3586.7"}))
+						Expect(boshIOReleaseSource.DownloadReleasesCallCount()).To(Equal(0))
+					})
+				})
+
+				When("the Kilnfile.lock has the release version locked for a different stemcell", func() {
+					BeforeEach(func() {
+						Expect(ioutil.WriteFile(someKilfileLockPath, []byte(`---
+releases:
+- name: uaa
+  version: 73.4.0
+  sha1: some-locked-sha
+stemcell_criteria:
+  os: ubuntu-trusty
+  version: "3586.1"
+`), 0644)).To(Succeed())
+					})
+
+					It("recalculates the checksum", func() {
+						Expect(updateErr).NotTo(HaveOccurred())
+
+						Expect(readKilnfileLock().Releases[1].SHA1).To(Equal("488a8f8ad92728a91879c70704c0f690e8b2a1a4"))
+					})
+				})
+
+				When("the Kilnfile lists several stemcells", func() {
+					BeforeEach(func() {
+						Expect(ioutil.WriteFile(someKilnfilePath, []byte(`---
+stemcells:
+- os: ubuntu-trusty
+  version: "3586.*"
+- os: windows
+  version: "2019.*"
+releases:
+- name: bpm
+  version: "~1.1"
+- name: uaa
+  stemcell_os: windows
+`), 0644)).To(Succeed())
+
+						stemcellsVersionsService.VersionsCall.Stub = func(slug string) ([]string, error) {
+							if slug == "stemcells-windows-server" {
+								return []string{"2019.7", "2019.12", "1803.9"}, nil
+							}
+							return []string{"3586.2", "3586.7"}, nil
+						}
+					})
+
+					It("locks the newest version of each stemcell", func() {
+						Expect(updateErr).NotTo(HaveOccurred())
+
+						kilnfileLock := readKilnfileLock()
+						Expect(kilnfileLock.Stemcell).To(Equal(cargo.Stemcell{}))
+						Expect(kilnfileLock.Stemcells).To(Equal([]cargo.Stemcell{
+							{OS: "ubuntu-trusty", Version: "3586.7"},
+							{OS: "windows", Version: "2019.12"},
+						}))
+					})
+
+					It("locks each release against its stemcell", func() {
+						Expect(updateErr).NotTo(HaveOccurred())
+
+						releases := readKilnfileLock().Releases
+						Expect(releases[0].StemcellOS).To(Equal("ubuntu-trusty"))
+						Expect(releases[0].StemcellVersion).To(Equal("3586.7"))
+						Expect(releases[1].StemcellOS).To(Equal("windows"))
+						Expect(releases[1].StemcellVersion).To(Equal("2019.12"))
+
+						constraint := s3ReleaseSource.FindReleaseVersionArgsForCall(1)
+						Expect(constraint.Name).To(Equal("uaa"))
+						Expect(constraint.StemcellOS).To(Equal("windows"))
+						Expect(constraint.StemcellVersion).To(Equal("2019.12"))
+					})
+
+					When("only the windows stemcell changed", func() {
+						BeforeEach(func() {
+							Expect(ioutil.WriteFile(someKilfileLockPath, []byte(`---
+releases:
+- name: bpm
+  version: 1.1.6
+  sha1: some-locked-sha
+  stemcell_os: ubuntu-trusty
+  stemcell_version: "3586.7"
+stemcells:
+- os: ubuntu-trusty
+  version: "3586.7"
+- os: windows
+  version: "2019.7"
+`), 0644)).To(Succeed())
+						})
+
+						It("keeps the releases locked against the unchanged stemcell", func() {
+							Expect(updateErr).NotTo(HaveOccurred())
+
+							Expect(readKilnfileLock().Releases[0].SHA1).To(Equal("some-locked-sha"))
+							Expect(boshIOReleaseSource.DownloadReleasesCallCount()).To(Equal(0))
+						})
+					})
+
+					When("a release is compiled against both stemcells", func() {
+						BeforeEach(func() {
+							Expect(ioutil.WriteFile(someKilnfilePath, []byte(`---
+stemcells:
+- os: ubuntu-trusty
+  version: "3586.*"
+- os: windows
+  version: "2019.*"
+releases:
+- name: uaa
+- name: uaa
+  stemcell_os: windows
+`), 0644)).To(Succeed())
+
+							Expect(ioutil.WriteFile(someKilfileLockPath, []byte(`---
+releases:
+- name: uaa
+  version: 73.4.0
+  sha1: some-trusty-sha
+  stemcell_os: ubuntu-trusty
+  stemcell_version: "3586.7"
+- name: uaa
+  version: 73.4.0
+  sha1: some-windows-sha
+  stemcell_os: windows
+  stemcell_version: "2019.12"
+stemcells:
+- os: ubuntu-trusty
+  version: "3586.7"
+- os: windows
+  version: "2019.12"
+`), 0644)).To(Succeed())
+						})
+
+						It("keeps the release locked against each stemcell", func() {
+							Expect(updateErr).NotTo(HaveOccurred())
+
+							Expect(readKilnfileLock().Releases).To(Equal([]cargo.Release{
+								{Name: "uaa", Version: "73.4.0", SHA1: "some-trusty-sha", StemcellOS: "ubuntu-trusty", StemcellVersion: "3586.7"},
+								{Name: "uaa", Version: "73.4.0", SHA1: "some-windows-sha", StemcellOS: "windows", StemcellVersion: "2019.12"},
+							}))
+							Expect(s3ReleaseSource.DownloadReleasesCallCount()).To(Equal(0))
+						})
+					})
+
+					When("a release names a stemcell the Kilnfile does not list", func() {
+						BeforeEach(func() {
+							contents, err := ioutil.ReadFile(someKilnfilePath)
+							Expect(err).NotTo(HaveOccurred())
+							contents = []byte(strings.Replace(string(contents), "stemcell_os: windows", "stemcell_os: ubuntu-bionic", 1))
+							Expect(ioutil.WriteFile(someKilnfilePath, contents, 0644)).To(Succeed())
+						})
+
+						It("returns a descriptive error", func() {
+							Expect(updateErr).To(MatchError(`release "uaa" is compiled against stemcell os "ubuntu-bionic", which is not in the Kilnfile stemcells`))
+						})
+					})
+				})
+
+				When("the Kilnfile sets both stemcell_criteria and stemcells", func() {
+					BeforeEach(func() {
+						contents := initallKilnfileYAMLFileContents + "stemcells:\n- os: windows\n  version: \"2019.*\"\n"
+						Expect(ioutil.WriteFile(someKilnfilePath, []byte(contents), 0644)).To(Succeed())
+					})
+
+					It("returns a descriptive error", func() {
+						Expect(updateErr).To(MatchError("kilnfile must not set both stemcell_criteria and stemcells"))
+					})
+				})
+
+				When("no release source has a matching version", func() {
+					BeforeEach(func() {
+						boshIOReleaseSource.FindReleaseVersionReturns(nil, false, nil)
+						boshIOReleaseSource.FindReleaseVersionStub = nil
+						s3ReleaseSource.FindReleaseVersionStub = nil
+						s3ReleaseSource.FindReleaseVersionReturns(nil, false, nil)
+					})
+
+					It("returns an error listing the unresolved releases", func() {
+						Expect(updateErr).To(MatchError(ContainSubstring("could not find a version matching the constraints")))
+						Expect(updateErr).To(MatchError(ContainSubstring("- bpm (~1.1)")))
+						Expect(updateErr).To(MatchError(ContainSubstring("- uaa ()")))
+					})
+
+					It("does not write the Kilnfile.lock", func() {
+						Expect(someKilfileLockPath).NotTo(BeAnExistingFile())
+					})
+				})
+
+				When("a release source returns an error", func() {
+					BeforeEach(func() {
+						s3ReleaseSource.FindReleaseVersionStub = nil
+						s3ReleaseSource.FindReleaseVersionReturns(nil, false, errors.New("some-error"))
+					})
+
+					It("returns a descriptive error", func() {
+						Expect(updateErr).To(MatchError(ContainSubstring(`could not get versions of release "bpm"`)))
+						Expect(updateErr).To(MatchError(ContainSubstring("some-error")))
+					})
+				})
+
+				When("downloading a release fails", func() {
+					BeforeEach(func() {
+						boshIOReleaseSource.DownloadReleasesStub = nil
+						boshIOReleaseSource.DownloadReleasesReturns(nil, errors.New("some-error"))
+					})
+
+					It("returns a descriptive error", func() {
+						Expect(updateErr).To(MatchError(ContainSubstring(`could not calculate checksum of release "bpm"`)))
+						Expect(updateErr).To(MatchError(ContainSubstring("some-error")))
+					})
+				})
+
+				When("a release version constraint is bad", func() {
+					BeforeEach(func() {
+						contents := strings.ReplaceAll(initallKilnfileYAMLFileContents+kilnfileReleasesYAMLFileContents, "~1.1", "not-a-constraint")
+						Expect(ioutil.WriteFile(someKilnfilePath, []byte(contents), 0644)).To(Succeed())
+					})
+
+					It("returns a descriptive error", func() {
+						Expect(updateErr).To(MatchError(ContainSubstring(`release "bpm" version constraint error`)))
+					})
+				})
+			})
 			When("Kilnfile is missing", func() {
 				BeforeEach(func() {
 					Expect(os.Remove(someKilnfilePath)).NotTo(HaveOccurred())
@@ -226,6 +680,11 @@ const (
 stemcell_criteria:
   os: ubuntu-trusty
   version: "3586.*"
+`
+	kilnfileReleasesYAMLFileContents = `releases:
+- name: bpm
+  version: "~1.1"
+- name: uaa
 `
 	initallKilnfileYAMLWithoutStemcellCriteraFileContents = `---
 `
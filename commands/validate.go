@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+
+	"github.com/pivotal-cf/jhanda"
+	"github.com/pivotal-cf/kiln/proofing"
+)
+
+type Validate struct {
+	logger *log.Logger
+
+	Options struct {
+		Metadata string `short:"m" long:"metadata" required:"true" description:"path to the baked metadata file"`
+	}
+}
+
+func NewValidate(logger *log.Logger) Validate {
+	return Validate{
+		logger: logger,
+	}
+}
+
+func (v Validate) Execute(args []string) error {
+	_, err := jhanda.Parse(&v.Options, args)
+	if err != nil {
+		return err
+	}
+
+	metadata, err := ioutil.ReadFile(v.Options.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to read metadata: %s", err)
+	}
+
+	err = validateMetadata(metadata, v.logger)
+	if err != nil {
+		return err
+	}
+
+	v.logger.Printf("%s is valid\n", v.Options.Metadata)
+
+	return nil
+}
+
+func (v Validate) Usage() jhanda.Usage {
+	return jhanda.Usage{
+		Description:      "This command validates baked metadata against the rules Ops Manager applies when importing a tile and checks that property references match property blueprints.",
+		ShortDescription: "validates baked metadata",
+		Flags:            v.Options,
+	}
+}
+
+func validateMetadata(metadata []byte, logger *log.Logger) error {
+	productTemplate, err := proofing.Parse(bytes.NewReader(metadata))
+	if err != nil {
+		return fmt.Errorf("failed to parse metadata: %s", err)
+	}
+
+	var problems proofing.CompoundError
+	for _, err := range []error{productTemplate.Validate(), productTemplate.ValidatePropertyReferences()} {
+		switch e := err.(type) {
+		case nil:
+		case *proofing.CompoundError:
+			problems = append(problems, *e...)
+		default:
+			problems = append(problems, e)
+		}
+	}
+
+	for _, propertyBlueprint := range productTemplate.UnusedPropertyBlueprints() {
+		logger.Printf("warning: property blueprint %s is never referenced\n", propertyBlueprint.Property)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("metadata is invalid:\n%s", &problems)
+	}
+
+	return nil
+}
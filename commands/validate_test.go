@@ -0,0 +1,151 @@
+package commands_test
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pivotal-cf/jhanda"
+	. "github.com/pivotal-cf/kiln/commands"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Validate", func() {
+	var (
+		writer       strings.Builder
+		tmpDir       string
+		metadataPath string
+		validate     Validate
+	)
+
+	BeforeEach(func() {
+		writer.Reset()
+
+		var err error
+		tmpDir, err = ioutil.TempDir("", "validate-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		metadataPath = filepath.Join(tmpDir, "metadata.yml")
+
+		validate = NewValidate(log.New(&writer, "", 0))
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	Describe("Execute", func() {
+		It("reports that valid metadata is valid", func() {
+			Expect(ioutil.WriteFile(metadataPath, []byte(`---
+name: some-product
+label: Some Product
+product_version: 1.2.3
+metadata_version: "2.5"
+stemcell_criteria:
+  os: ubuntu-xenial
+  version: "250.17"
+`), 0644)).To(Succeed())
+
+			err := validate.Execute([]string{"--metadata", metadataPath})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(writer.String()).To(Equal(metadataPath + " is valid\n"))
+		})
+
+		Context("when the metadata has violations", func() {
+			It("returns every violation with its path", func() {
+				Expect(ioutil.WriteFile(metadataPath, []byte(`---
+name: some-product
+product_version: 1.2.3
+metadata_version: "2.5"
+stemcell_criteria:
+  os: ubuntu-xenial
+  version: "250.17"
+property_blueprints:
+- name: some-property
+  type: some-type
+`), 0644)).To(Succeed())
+
+				err := validate.Execute([]string{"--metadata", metadataPath})
+				Expect(err).To(MatchError(`metadata is invalid:
+- product template label must be present
+- property_blueprints[0]: simple property blueprint type "some-type" is not a known property blueprint type`))
+			})
+		})
+
+		Context("when the metadata references properties that do not exist", func() {
+			It("returns the unmatched references and warns about unused property blueprints", func() {
+				Expect(ioutil.WriteFile(metadataPath, []byte(`---
+name: some-product
+label: Some Product
+product_version: 1.2.3
+metadata_version: "2.5"
+stemcell_criteria:
+  os: ubuntu-xenial
+  version: "250.17"
+releases:
+- name: some-release
+  version: 1.0.0
+  file: some-release-1.0.0.tgz
+property_blueprints:
+- name: some-property
+  type: string
+job_types:
+- name: some-instance-group
+  resource_label: Some Instance Group
+  max_in_flight: 1
+  templates:
+  - name: some-job
+    release: some-release
+    manifest: |
+      some-property: (( .properties.some-other-property.value ))
+`), 0644)).To(Succeed())
+
+				err := validate.Execute([]string{"--metadata", metadataPath})
+				Expect(err).To(MatchError(`metadata is invalid:
+- job_types[0].templates[0].manifest: property reference ".properties.some-other-property.value" does not match a property blueprint`))
+
+				Expect(writer.String()).To(Equal("warning: property blueprint .properties.some-property is never referenced\n"))
+			})
+		})
+
+		Context("failure cases", func() {
+			Context("when the metadata flag is missing", func() {
+				It("returns an error", func() {
+					err := validate.Execute([]string{})
+					Expect(err).To(MatchError("missing required flag \"--metadata\""))
+				})
+			})
+
+			Context("when the metadata file does not exist", func() {
+				It("returns an error", func() {
+					err := validate.Execute([]string{"--metadata", "missing-metadata.yml"})
+					Expect(err).To(MatchError(ContainSubstring("failed to read metadata: open missing-metadata.yml")))
+				})
+			})
+
+			Context("when the metadata is not valid YAML", func() {
+				It("returns an error", func() {
+					Expect(ioutil.WriteFile(metadataPath, []byte("%%%"), 0644)).To(Succeed())
+
+					err := validate.Execute([]string{"--metadata", metadataPath})
+					Expect(err).To(MatchError(ContainSubstring("failed to parse metadata")))
+				})
+			})
+		})
+	})
+
+	Describe("Usage", func() {
+		It("returns usage information for the command", func() {
+			Expect(validate.Usage()).To(Equal(jhanda.Usage{
+				Description:      "This command validates baked metadata against the rules Ops Manager applies when importing a tile and checks that property references match property blueprints.",
+				ShortDescription: "validates baked metadata",
+				Flags:            validate.Options,
+			}))
+		})
+	})
+})
@@ -0,0 +1,159 @@
+package fetcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/pivotal-cf/kiln/internal/cargo"
+)
+
+// ArtifactoryReleaseSource finds release tarballs in an Artifactory repository.
+// The paths of the files in the repository are matched against Regex, which
+// uses the same capture groups as the S3 release sources.
+type ArtifactoryReleaseSource struct {
+	logger *log.Logger
+
+	ServerURL string
+	Repo      string
+	Regex     string
+	Username  string
+	Password  string
+	Token     string
+}
+
+type ArtifactoryReleaseSourceConfig struct {
+	ArtifactoryHost  string `yaml:"artifactory_host" required:"true"`
+	Repo             string `yaml:"repo" required:"true"`
+	Regex            string `yaml:"regex" required:"true"`
+	Username         string `yaml:"username"`
+	Password         string `yaml:"password"`
+	ArtifactoryToken string `yaml:"artifactory_token"`
+}
+
+func NewArtifactoryReleaseSource(logger *log.Logger, config ArtifactoryReleaseSourceConfig) *ArtifactoryReleaseSource {
+	return &ArtifactoryReleaseSource{
+		logger:    logger,
+		ServerURL: strings.TrimSuffix(config.ArtifactoryHost, "/"),
+		Repo:      config.Repo,
+		Regex:     config.Regex,
+		Username:  config.Username,
+		Password:  config.Password,
+		Token:     config.ArtifactoryToken,
+	}
+}
+
+func (source ArtifactoryReleaseSource) String() string {
+	return fmt.Sprintf("artifactory repository %s/%s", source.ServerURL, source.Repo)
+}
+
+func (source ArtifactoryReleaseSource) GetMatchedReleases(desiredReleaseSet ReleaseRequirementSet, stemcell cargo.Stemcell) ([]RemoteRelease, error) {
+	releases, err := source.listReleases()
+	if err != nil {
+		return nil, err
+	}
+
+	return matchReleases(releases, desiredReleaseSet), nil
+}
+
+func (source ArtifactoryReleaseSource) FindReleaseVersion(constraint ReleaseVersionConstraint) (RemoteRelease, bool, error) {
+	releases, err := source.listReleases()
+	if err != nil {
+		return nil, false, err
+	}
+
+	release, found := newestReleaseMatch(releases, constraint)
+	return release, found, nil
+}
+
+func (source ArtifactoryReleaseSource) DownloadReleases(releaseDir string, remoteReleases []RemoteRelease, opts DownloadOptions) (LocalReleaseSet, error) {
+	source.logger.Printf("downloading %d objects from artifactory...", len(remoteReleases))
+
+	return downloadReleasesConcurrently(remoteReleases, opts.workers(), func(release RemoteRelease) (LocalRelease, error) {
+		filePath := filepath.Join(releaseDir, release.StandardizedFilename())
+
+		sum, err := downloadFile(filePath, source.String(), opts.expectedSum(release), func(file downloadTarget, offset int64) error {
+			req, err := source.newRequest(fmt.Sprintf("%s/%s/%s", source.ServerURL, source.Repo, release.RemotePath()))
+			if err != nil {
+				return err
+			}
+			return downloadHTTP(source.logger, req, file, offset)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return withSHA1(release.AsLocal(filePath), sum), nil
+	})
+}
+
+func (source ArtifactoryReleaseSource) listReleases() (map[ReleaseID][]RemoteRelease, error) {
+	exp, err := newReleaseRegexp(source.Regex)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := source.newRequest(fmt.Sprintf("%s/api/storage/%s?list&deep=1&listFolders=0", source.ServerURL, source.Repo))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list artifactory repository %s: %w", source.Repo, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, (*ResponseStatusCodeError)(resp)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var listing struct {
+		Files []struct {
+			URI    string `json:"uri"`
+			Folder bool   `json:"folder"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal(body, &listing); err != nil {
+		return nil, fmt.Errorf("failed to parse artifactory listing of %s: %s", source.Repo, err)
+	}
+
+	releases := make(map[ReleaseID][]RemoteRelease)
+	for _, file := range listing.Files {
+		if file.Folder {
+			continue
+		}
+
+		path := strings.TrimPrefix(file.URI, "/")
+		if release, ok := exp.release(path, path); ok {
+			releases[release.ReleaseID()] = append(releases[release.ReleaseID()], release)
+		}
+	}
+
+	return releases, nil
+}
+
+func (source ArtifactoryReleaseSource) newRequest(url string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case source.Token != "":
+		req.Header.Set("Authorization", "Bearer "+source.Token)
+	case source.Username != "":
+		req.SetBasicAuth(source.Username, source.Password)
+	}
+
+	return req, nil
+}
@@ -0,0 +1,160 @@
+package fetcher_test
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/Masterminds/semver"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	. "github.com/pivotal-cf/kiln/fetcher"
+	"github.com/pivotal-cf/kiln/internal/cargo"
+)
+
+var _ = Describe("ArtifactoryReleaseSource", func() {
+	const listing = `{
+  "uri": "https://artifactory.example.com/artifactory/api/storage/bosh-releases",
+  "files": [
+    {"uri": "/compiled/uaa-1.2.3-ubuntu-xenial-621.5.tgz", "size": 9, "folder": false},
+    {"uri": "/compiled/uaa-1.3.0-ubuntu-xenial-621.5.tgz", "size": 9, "folder": false},
+    {"uri": "/compiled/uaa-1.4.0-ubuntu-xenial-456.7.tgz", "size": 9, "folder": false},
+    {"uri": "/compiled/README.md", "size": 9, "folder": false}
+  ]
+}`
+
+	var (
+		testServer    *ghttp.Server
+		config        ArtifactoryReleaseSourceConfig
+		releaseSource *ArtifactoryReleaseSource
+		stemcell      cargo.Stemcell
+	)
+
+	BeforeEach(func() {
+		testServer = ghttp.NewServer()
+		config = ArtifactoryReleaseSourceConfig{
+			ArtifactoryHost: testServer.URL(),
+			Repo:            "bosh-releases",
+			Username:        "some-user",
+			Password:        "some-password",
+			Regex:           `^compiled/(?P<release_name>[a-z-_0-9]+)-(?P<release_version>[0-9\.]+)-(?P<stemcell_os>[a-z-_]+)-(?P<stemcell_version>\d+\.\d+)\.tgz$`,
+		}
+		stemcell = cargo.Stemcell{OS: "ubuntu-xenial", Version: "621.5"}
+
+		testServer.RouteToHandler("GET", "/api/storage/bosh-releases", ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/api/storage/bosh-releases", "list&deep=1&listFolders=0"),
+			ghttp.VerifyBasicAuth("some-user", "some-password"),
+			ghttp.RespondWith(http.StatusOK, listing),
+		))
+	})
+
+	JustBeforeEach(func() {
+		releaseSource = NewArtifactoryReleaseSource(log.New(GinkgoWriter, "", 0), config)
+	})
+
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	Describe("GetMatchedReleases", func() {
+		It("matches the files in the repository", func() {
+			uaaID := ReleaseID{Name: "uaa", Version: "1.2.3"}
+			matches, err := releaseSource.GetMatchedReleases(ReleaseRequirementSet{
+				uaaID: {Name: "uaa", Version: "1.2.3", StemcellOS: "ubuntu-xenial", StemcellVersion: "621.5"},
+			}, stemcell)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(matches).To(ConsistOf(CompiledRelease{
+				ID:              uaaID,
+				StemcellOS:      "ubuntu-xenial",
+				StemcellVersion: "621.5",
+				Path:            "compiled/uaa-1.2.3-ubuntu-xenial-621.5.tgz",
+			}))
+		})
+
+		Context("when a token is configured", func() {
+			BeforeEach(func() {
+				config.Username = ""
+				config.ArtifactoryToken = "some-token"
+				testServer.RouteToHandler("GET", "/api/storage/bosh-releases", ghttp.CombineHandlers(
+					ghttp.VerifyHeaderKV("Authorization", "Bearer some-token"),
+					ghttp.RespondWith(http.StatusOK, listing),
+				))
+			})
+
+			It("authenticates with the token", func() {
+				_, err := releaseSource.GetMatchedReleases(ReleaseRequirementSet{}, stemcell)
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		Context("when the repository can not be listed", func() {
+			BeforeEach(func() {
+				testServer.RouteToHandler("GET", "/api/storage/bosh-releases", ghttp.RespondWith(http.StatusUnauthorized, ""))
+			})
+
+			It("returns an error", func() {
+				_, err := releaseSource.GetMatchedReleases(ReleaseRequirementSet{}, stemcell)
+				Expect(err).To(MatchError(ContainSubstring("got status 401")))
+			})
+		})
+	})
+
+	Describe("FindReleaseVersion", func() {
+		It("returns the newest release for the stemcell matching the constraint", func() {
+			constraint, err := semver.NewConstraint("~1")
+			Expect(err).NotTo(HaveOccurred())
+
+			release, found, err := releaseSource.FindReleaseVersion(ReleaseVersionConstraint{
+				Name:            "uaa",
+				Constraint:      constraint,
+				StemcellOS:      "ubuntu-xenial",
+				StemcellVersion: "621.5",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(release.ReleaseID()).To(Equal(ReleaseID{Name: "uaa", Version: "1.3.0"}))
+		})
+	})
+
+	Describe("DownloadReleases", func() {
+		var releaseDir string
+
+		BeforeEach(func() {
+			var err error
+			releaseDir, err = ioutil.TempDir("", "kiln-artifactory-release-source-test")
+			Expect(err).NotTo(HaveOccurred())
+
+			testServer.RouteToHandler("GET", "/bosh-releases/compiled/uaa-1.2.3-ubuntu-xenial-621.5.tgz", ghttp.CombineHandlers(
+				ghttp.VerifyBasicAuth("some-user", "some-password"),
+				ghttp.RespondWith(http.StatusOK, "uaa-1.2.3"),
+			))
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(releaseDir)).To(Succeed())
+		})
+
+		It("downloads the releases into the release dir", func() {
+			uaaID := ReleaseID{Name: "uaa", Version: "1.2.3"}
+			localReleases, err := releaseSource.DownloadReleases(releaseDir, []RemoteRelease{
+				CompiledRelease{
+					ID:              uaaID,
+					StemcellOS:      "ubuntu-xenial",
+					StemcellVersion: "621.5",
+					Path:            "compiled/uaa-1.2.3-ubuntu-xenial-621.5.tgz",
+				},
+			}, DownloadOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			releasePath := filepath.Join(releaseDir, "uaa-1.2.3-ubuntu-xenial-621.5.tgz")
+			Expect(localReleases).To(Equal(LocalReleaseSet{
+				ReleaseID{Name: "uaa", Version: "1.2.3", StemcellOS: "ubuntu-xenial"}: CompiledRelease{ID: uaaID, StemcellOS: "ubuntu-xenial", StemcellVersion: "621.5", Path: releasePath, SHA1: "c5de3a4e65151fb9b30edf8954050bf669b10ae3"},
+			}))
+			Expect(ioutil.ReadFile(releasePath)).To(BeEquivalentTo("uaa-1.2.3"))
+		})
+	})
+})
@@ -4,11 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/pivotal-cf/kiln/internal/cargo"
-	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
-	"os"
 	"path/filepath"
 )
 
@@ -61,6 +59,10 @@ func NewBOSHIOReleaseSource(logger *log.Logger, customServerURI string) *BOSHIOR
 	}
 }
 
+func (r BOSHIOReleaseSource) String() string {
+	return "bosh.io"
+}
+
 func (r *BOSHIOReleaseSource) Configure(kilnfile cargo.Kilnfile) {
 	return
 }
@@ -90,40 +92,51 @@ func (source BOSHIOReleaseSource) GetMatchedReleases(desiredReleaseSet ReleaseRe
 	return matches, nil //no foreseen error to return to a higher level
 }
 
-func (r BOSHIOReleaseSource) DownloadReleases(releaseDir string, remoteReleases []RemoteRelease, downloadThreads int) (LocalReleaseSet, error) {
-	localReleases := make(LocalReleaseSet)
-
-	r.logger.Printf("downloading %d objects from bosh.io...", len(remoteReleases))
+func (source BOSHIOReleaseSource) FindReleaseVersion(constraint ReleaseVersionConstraint) (RemoteRelease, bool, error) {
+	for _, repo := range repos {
+		for _, suf := range suffixes {
+			fullName := repo + "/" + constraint.Name + suf
+			versions, err := source.releaseVersionsOnBoshio(fullName)
+			if err != nil {
+				return nil, false, err
+			}
+			if len(versions) == 0 {
+				continue
+			}
 
-	for _, release := range remoteReleases {
+			candidates := make([]RemoteRelease, 0, len(versions))
+			for _, version := range versions {
+				downloadURL := fmt.Sprintf("%s/d/github.com/%s?v=%s", source.serverURI, fullName, version)
+				candidates = append(candidates, BuiltRelease{ID: ReleaseID{Name: constraint.Name, Version: version}, Path: downloadURL})
+			}
 
-		downloadURL := release.RemotePath()
-		r.logger.Printf("downloading %s...\n", downloadURL)
-		// Get the data
-		resp, err := http.Get(downloadURL)
-		if err != nil {
-			return nil, err
+			release, found := constraint.newestMatch(candidates)
+			return release, found, nil
 		}
+	}
 
-		filePath := filepath.Join(releaseDir, release.StandardizedFilename())
+	return nil, false, nil
+}
 
-		out, err := os.Create(filePath)
-		if err != nil {
-			return nil, err
-		}
+func (r BOSHIOReleaseSource) DownloadReleases(releaseDir string, remoteReleases []RemoteRelease, opts DownloadOptions) (LocalReleaseSet, error) {
+	r.logger.Printf("downloading %d objects from bosh.io...", len(remoteReleases))
+
+	return downloadReleasesConcurrently(remoteReleases, opts.workers(), func(release RemoteRelease) (LocalRelease, error) {
+		filePath := filepath.Join(releaseDir, release.StandardizedFilename())
 
-		// Write the body to file
-		_, err = io.Copy(out, resp.Body)
-		resp.Body.Close()
-		out.Close()
+		sum, err := downloadFile(filePath, r.String(), opts.expectedSum(release), func(file downloadTarget, offset int64) error {
+			req, err := http.NewRequest(http.MethodGet, release.RemotePath(), nil)
+			if err != nil {
+				return err
+			}
+			return downloadHTTP(r.logger, req, file, offset)
+		})
 		if err != nil {
 			return nil, err
 		}
 
-		localReleases[release.ReleaseID()] = release.AsLocal(filePath)
-	}
-
-	return localReleases, nil
+		return withSHA1(release.AsLocal(filePath), sum), nil
+	})
 }
 
 type ResponseStatusCodeError http.Response
@@ -133,36 +146,48 @@ func (err ResponseStatusCodeError) Error() string {
 }
 
 func (r BOSHIOReleaseSource) releaseExistOnBoshio(name, version string) (bool, error) {
+	versions, err := r.releaseVersionsOnBoshio(name)
+	if err != nil {
+		return false, err
+	}
+	for _, v := range versions {
+		if v == version {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r BOSHIOReleaseSource) releaseVersionsOnBoshio(name string) ([]string, error) {
 	resp, err := http.Get(fmt.Sprintf("%s/api/v1/releases/github.com/%s", r.serverURI, name))
 	if err != nil {
-		return false, fmt.Errorf("bosh.io API is down with error: %w", err)
+		return nil, fmt.Errorf("bosh.io API is down with error: %w", err)
 	}
 	if resp.StatusCode >= 500 {
-		return false, (*ResponseStatusCodeError)(resp)
+		return nil, (*ResponseStatusCodeError)(resp)
 	}
 	if resp.StatusCode == http.StatusNotFound {
-		return false, nil
+		return nil, nil
 	}
 	if resp.StatusCode >= 300 {
 		// we don't handle redirects yet
 		// also this will catch other client request errors (>= 400)
-		return false, (*ResponseStatusCodeError)(resp)
+		return nil, (*ResponseStatusCodeError)(resp)
 	}
 	defer resp.Body.Close()
 	body, err := ioutil.ReadAll(resp.Body)
 	if string(body) == "null" {
-		return false, nil
+		return nil, nil
 	}
 	var releases []struct {
 		Version string `json:"version"`
 	}
 	if err := json.Unmarshal(body, &releases); err != nil {
-		return false, err
+		return nil, err
 	}
+	versions := make([]string, 0, len(releases))
 	for _, rel := range releases {
-		if rel.Version == version {
-			return true, nil
-		}
+		versions = append(versions, rel.Version)
 	}
-	return false, nil
+	return versions, nil
 }
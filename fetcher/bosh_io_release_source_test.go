@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"regexp"
 
+	"github.com/Masterminds/semver"
 	"github.com/pivotal-cf/kiln/internal/cargo"
 
 	. "github.com/onsi/ginkgo/extensions/table"
@@ -197,6 +198,72 @@ var _ = Describe("GetMatchedReleases from bosh.io", func() {
 	})
 })
 
+var _ = Describe("FindReleaseVersion from bosh.io", func() {
+	var (
+		releaseSource *BOSHIOReleaseSource
+		testServer    *ghttp.Server
+		constraint    ReleaseVersionConstraint
+	)
+
+	BeforeEach(func() {
+		testServer = ghttp.NewServer()
+
+		testServer.RouteToHandler("GET", "/api/v1/releases/github.com/cloudfoundry/uaa-release",
+			ghttp.RespondWith(http.StatusOK, `[{"version": "74.0.0"}, {"version": "73.4.0"}, {"version": "73.3.0"}, {"version": "not-semver"}]`))
+
+		path, _ := regexp.Compile("/api/v1/releases/github.com/\\S+/.*")
+		testServer.RouteToHandler("GET", path, ghttp.RespondWith(http.StatusOK, `null`))
+
+		releaseSource = NewBOSHIOReleaseSource(
+			log.New(GinkgoWriter, "", 0),
+			testServer.URL(),
+		)
+
+		versionConstraint, err := semver.NewConstraint("~73")
+		Expect(err).NotTo(HaveOccurred())
+
+		constraint = ReleaseVersionConstraint{Name: "uaa", Constraint: versionConstraint}
+	})
+
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	It("returns the newest release matching the constraint", func() {
+		release, found, err := releaseSource.FindReleaseVersion(constraint)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(release).To(Equal(BuiltRelease{
+			ID:   ReleaseID{Name: "uaa", Version: "73.4.0"},
+			Path: fmt.Sprintf("%s/d/github.com/cloudfoundry/uaa-release?v=73.4.0", testServer.URL()),
+		}))
+	})
+
+	When("the release does not exist on bosh.io", func() {
+		BeforeEach(func() {
+			constraint.Name = "zzz"
+		})
+
+		It("does not find a release", func() {
+			_, found, err := releaseSource.FindReleaseVersion(constraint)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	When("the bosh.io API returns a server error", func() {
+		BeforeEach(func() {
+			testServer.RouteToHandler("GET", "/api/v1/releases/github.com/cloudfoundry/uaa-release",
+				ghttp.RespondWith(http.StatusInternalServerError, ``))
+		})
+
+		It("returns an error", func() {
+			_, _, err := releaseSource.FindReleaseVersion(constraint)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
 var _ = Describe("DownloadReleases", func() {
 	var (
 		releaseDir    string
@@ -261,7 +328,7 @@ var _ = Describe("DownloadReleases", func() {
 		matchedReleases := []RemoteRelease{release1, release2}
 		localReleases, err := releaseSource.DownloadReleases(releaseDir,
 			matchedReleases,
-			1,
+			DownloadOptions{},
 		)
 
 		Expect(err).NotTo(HaveOccurred())
@@ -282,13 +349,116 @@ var _ = Describe("DownloadReleases", func() {
 		Expect(localReleases).To(HaveLen(2))
 		Expect(localReleases).To(HaveKeyWithValue(
 			release1ID, BuiltRelease{
-				ID:              release1ID,
-				Path:            fullRelease1Path,
+				ID:   release1ID,
+				Path: fullRelease1Path,
+				SHA1: "6211bc6a8ab8c45dbc5f5ba6dec5d8606b13a282",
 			}))
 		Expect(localReleases).To(HaveKeyWithValue(
 			release2ID, BuiltRelease{
-				ID:              release2ID,
-				Path:            fullRelease2Path,
+				ID:   release2ID,
+				Path: fullRelease2Path,
+				SHA1: "d3d7e4ae379eb0ca003bc639567e37e371b74251",
 			}))
 	})
+
+	Context("when a partial download of a release exists", func() {
+		BeforeEach(func() {
+			err := ioutil.WriteFile(filepath.Join(releaseDir, release1Filename+".partial"), []byte("totes-a-"), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			testServer.RouteToHandler("GET", release1ServerPath, ghttp.CombineHandlers(
+				ghttp.VerifyHeaderKV("Range", "bytes=8-"),
+				ghttp.RespondWith(http.StatusPartialContent, "real-release"),
+			))
+		})
+
+		It("resumes the download", func() {
+			_, err := releaseSource.DownloadReleases(releaseDir, []RemoteRelease{release1}, DownloadOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			fullRelease1Path := filepath.Join(releaseDir, release1Filename)
+			Expect(ioutil.ReadFile(fullRelease1Path)).To(BeEquivalentTo(release1ServerFileContents))
+			Expect(fullRelease1Path + ".partial").NotTo(BeAnExistingFile())
+		})
+	})
+
+	Context("when the server does not support resuming downloads", func() {
+		BeforeEach(func() {
+			err := ioutil.WriteFile(filepath.Join(releaseDir, release1Filename+".partial"), []byte("stale-contents"), 0644)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("downloads the whole release again", func() {
+			_, err := releaseSource.DownloadReleases(releaseDir, []RemoteRelease{release1}, DownloadOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(ioutil.ReadFile(filepath.Join(releaseDir, release1Filename))).To(BeEquivalentTo(release1ServerFileContents))
+		})
+	})
+
+	Context("when the Kilnfile.lock has checksums for the releases", func() {
+		var checksums ReleaseChecksums
+
+		BeforeEach(func() {
+			checksums = NewReleaseChecksums(cargo.KilnfileLock{
+				Releases: []cargo.Release{
+					{Name: "some", Version: "1.2.3", SHA1: "6211bc6a8ab8c45dbc5f5ba6dec5d8606b13a282"},
+					{Name: "another", Version: "2.3.4", SHA1: "not-the-sha1-of-another"},
+				},
+			})
+		})
+
+		It("verifies the releases while downloading them", func() {
+			_, err := releaseSource.DownloadReleases(releaseDir, []RemoteRelease{release1}, DownloadOptions{Checksums: checksums})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(filepath.Join(releaseDir, release1Filename)).To(BeAnExistingFile())
+		})
+
+		Context("when a partial download of the release exists", func() {
+			BeforeEach(func() {
+				err := ioutil.WriteFile(filepath.Join(releaseDir, release1Filename+".partial"), []byte("totes-a-"), 0644)
+				Expect(err).NotTo(HaveOccurred())
+
+				testServer.RouteToHandler("GET", release1ServerPath, ghttp.RespondWith(http.StatusPartialContent, "real-release"))
+			})
+
+			It("includes the partial download in the checksum", func() {
+				_, err := releaseSource.DownloadReleases(releaseDir, []RemoteRelease{release1}, DownloadOptions{Checksums: checksums})
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		It("rejects a release that does not match before it is put in the release dir", func() {
+			_, err := releaseSource.DownloadReleases(releaseDir, []RemoteRelease{release2}, DownloadOptions{Checksums: checksums})
+			Expect(err).To(MatchError(ChecksumMismatchError{
+				Source:    "bosh.io",
+				Release:   release2Filename,
+				Algorithm: "SHA1",
+				Expected:  "not-the-sha1-of-another",
+				Actual:    "d3d7e4ae379eb0ca003bc639567e37e371b74251",
+			}))
+
+			Expect(filepath.Join(releaseDir, release2Filename)).NotTo(BeAnExistingFile())
+			Expect(filepath.Join(releaseDir, release2Filename+".partial")).NotTo(BeAnExistingFile())
+		})
+
+		It("does not use the checksum of another version of the release", func() {
+			otherVersion := BuiltRelease{ID: ReleaseID{Name: "another", Version: "9.9.9"}, Path: release2.Path}
+			_, err := releaseSource.DownloadReleases(releaseDir, []RemoteRelease{otherVersion}, DownloadOptions{Checksums: checksums})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("when a download fails", func() {
+		BeforeEach(func() {
+			testServer.RouteToHandler("GET", release1ServerPath, ghttp.RespondWith(http.StatusServiceUnavailable, ""))
+		})
+
+		It("returns an error and does not leave the release in the release dir", func() {
+			_, err := releaseSource.DownloadReleases(releaseDir, []RemoteRelease{release1, release2}, DownloadOptions{Workers: 1})
+			Expect(err).To(MatchError(ContainSubstring("got status 503")))
+
+			Expect(filepath.Join(releaseDir, release1Filename)).NotTo(BeAnExistingFile())
+		})
+	})
 })
@@ -5,6 +5,10 @@ import "fmt"
 type BuiltRelease struct {
 	ID   ReleaseID
 	Path string
+
+	// SHA1 is the checksum of the local tarball when it was taken while
+	// downloading it, and empty otherwise.
+	SHA1 string
 }
 
 func (br BuiltRelease) RemotePath() string {
@@ -0,0 +1,238 @@
+package fetcher
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pivotal-cf/kiln/internal/cargo"
+)
+
+type releaseChecksumKey struct {
+	ID                          ReleaseID
+	StemcellOS, StemcellVersion string
+}
+
+// ReleaseChecksums holds the SHA1 the Kilnfile.lock expects for each release,
+// keyed on the release and the stemcell it is locked against.
+type ReleaseChecksums map[releaseChecksumKey]string
+
+func NewReleaseChecksums(kilnfileLock cargo.KilnfileLock) ReleaseChecksums {
+	sums := make(ReleaseChecksums)
+	for _, release := range kilnfileLock.Releases {
+		if release.SHA1 == "" {
+			continue
+		}
+		stemcell := kilnfileLock.ReleaseStemcell(release)
+		key := releaseChecksumKey{
+			ID:              ReleaseID{Name: release.Name, Version: release.Version},
+			StemcellOS:      stemcell.OS,
+			StemcellVersion: stemcell.Version,
+		}
+		sums[key] = release.SHA1
+	}
+	return sums
+}
+
+// ExpectedSum returns the SHA1 expected for release. A compiled release must
+// be locked against the stemcell it was compiled for, while a built release
+// does not depend on the stemcell.
+func (sums ReleaseChecksums) ExpectedSum(release RemoteRelease) (string, bool) {
+	if compiled, ok := release.(CompiledRelease); ok {
+		return sums.sum(compiled.ID, compiled.StemcellOS, compiled.StemcellVersion)
+	}
+	return sums.sum(release.ReleaseID(), "", "")
+}
+
+// sum looks up the SHA1 of a release locked against the given stemcell. An
+// empty stemcell matches the release locked against any stemcell.
+func (sums ReleaseChecksums) sum(id ReleaseID, stemcellOS, stemcellVersion string) (string, bool) {
+	if stemcellOS != "" || stemcellVersion != "" {
+		sum, ok := sums[releaseChecksumKey{ID: id, StemcellOS: stemcellOS, StemcellVersion: stemcellVersion}]
+		return sum, ok
+	}
+
+	for key, sum := range sums {
+		if key.ID == id {
+			return sum, true
+		}
+	}
+	return "", false
+}
+
+// checksumAlgorithm is the hash a downloaded file is checked with. Releases
+// and the stemcells from bosh.io are pinned by SHA1, the stemcells from
+// network.pivotal.io by the SHA256 it publishes.
+type checksumAlgorithm struct {
+	name string
+	new  func() hash.Hash
+}
+
+var (
+	sha1Algorithm   = checksumAlgorithm{name: "SHA1", new: sha1.New}
+	sha256Algorithm = checksumAlgorithm{name: "SHA256", new: sha256.New}
+)
+
+// ChecksumMismatchError is returned when a release served by Source does not
+// have the checksum the Kilnfile.lock expects. The file is removed before it
+// is moved into the releases directory.
+type ChecksumMismatchError struct {
+	Source    string
+	Release   string
+	Algorithm string
+	Expected  string
+	Actual    string
+}
+
+func (err ChecksumMismatchError) Error() string {
+	algorithm := err.Algorithm
+	if algorithm == "" {
+		algorithm = sha1Algorithm.name
+	}
+	return fmt.Sprintf("%s served %s with %s %s, but Kilnfile.lock expects %s", err.Source, err.Release, algorithm, err.Actual, err.Expected)
+}
+
+// checksumFile hashes the bytes written to a partial download as they arrive.
+// Writes that do not continue the hashed prefix, such as the later parts of a
+// multi-part S3 download, are read back from disk when the sum is taken.
+type checksumFile struct {
+	file *os.File
+
+	mu     sync.Mutex
+	hash   hash.Hash
+	hashed int64
+}
+
+func newChecksumFile(file *os.File, algorithm checksumAlgorithm) *checksumFile {
+	return &checksumFile{file: file, hash: algorithm.new()}
+}
+
+func (f *checksumFile) WriteAt(p []byte, off int64) (int, error) {
+	n, err := f.file.WriteAt(p, off)
+
+	f.mu.Lock()
+	if off == f.hashed {
+		f.hash.Write(p[:n])
+		f.hashed += int64(n)
+	}
+	f.mu.Unlock()
+
+	return n, err
+}
+
+func (f *checksumFile) Truncate(size int64) error {
+	f.mu.Lock()
+	if size < f.hashed {
+		f.hash.Reset()
+		f.hashed = 0
+	}
+	f.mu.Unlock()
+
+	return f.file.Truncate(size)
+}
+
+// catchUp hashes the part of the file that has not been hashed yet.
+func (f *checksumFile) catchUp() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := f.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	n, err := io.Copy(f.hash, io.NewSectionReader(f.file, f.hashed, info.Size()-f.hashed))
+	f.hashed += n
+	return err
+}
+
+func (f *checksumFile) sum() (string, error) {
+	if err := f.catchUp(); err != nil {
+		return "", err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return hex.EncodeToString(f.hash.Sum(nil)), nil
+}
+
+// verifyFile checks that the file at path, served by source, has expectedSum
+// and returns its SHA1. Nothing is checked, and no SHA1 is returned, when
+// expectedSum is empty.
+func verifyFile(path, source, expectedSum string) (string, error) {
+	return verifyFileWith(sha1Algorithm, path, source, expectedSum)
+}
+
+func verifyFileWith(algorithm checksumAlgorithm, path, source, expectedSum string) (string, error) {
+	if expectedSum == "" {
+		return "", nil
+	}
+
+	sum, err := calculateSumWith(algorithm, path)
+	if err != nil {
+		return "", fmt.Errorf("error while calculating checksum: %s", err)
+	}
+
+	if sum != expectedSum {
+		return "", ChecksumMismatchError{Source: source, Release: filepath.Base(path), Algorithm: algorithm.name, Expected: expectedSum, Actual: sum}
+	}
+
+	return sum, nil
+}
+
+// withSHA1 records the SHA1 taken of a local release, so that it is not read
+// again to be added to the release cache.
+func withSHA1(release LocalRelease, sum string) LocalRelease {
+	switch r := release.(type) {
+	case BuiltRelease:
+		r.SHA1 = sum
+		return r
+	case CompiledRelease:
+		r.SHA1 = sum
+		return r
+	}
+	return release
+}
+
+// localSHA1 returns the SHA1 recorded by withSHA1, or calculates it when none
+// was.
+func localSHA1(release LocalRelease) (string, error) {
+	var sum string
+	switch r := release.(type) {
+	case BuiltRelease:
+		sum = r.SHA1
+	case CompiledRelease:
+		sum = r.SHA1
+	}
+	if sum != "" {
+		return sum, nil
+	}
+
+	return calculateSum(release.LocalPath())
+}
+
+func calculateSum(releasePath string) (string, error) {
+	return calculateSumWith(sha1Algorithm, releasePath)
+}
+
+func calculateSumWith(algorithm checksumAlgorithm, path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := algorithm.new()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
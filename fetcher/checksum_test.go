@@ -0,0 +1,65 @@
+package fetcher_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/pivotal-cf/kiln/fetcher"
+	"github.com/pivotal-cf/kiln/internal/cargo"
+)
+
+var _ = Describe("ReleaseChecksums", func() {
+	var checksums ReleaseChecksums
+
+	BeforeEach(func() {
+		checksums = NewReleaseChecksums(cargo.KilnfileLock{
+			Releases: []cargo.Release{
+				{Name: "uaa", Version: "1.2.3", SHA1: "uaa-sha1"},
+				{Name: "bpm", Version: "1.1.0"},
+			},
+			Stemcell: cargo.Stemcell{OS: "ubuntu-xenial", Version: "621.5"},
+		})
+	})
+
+	expectedSum := func(release RemoteRelease) string {
+		sum, _ := checksums.ExpectedSum(release)
+		return sum
+	}
+
+	It("returns the checksum of a compiled release for the locked stemcell", func() {
+		release := CompiledRelease{ID: ReleaseID{Name: "uaa", Version: "1.2.3"}, StemcellOS: "ubuntu-xenial", StemcellVersion: "621.5"}
+		Expect(expectedSum(release)).To(Equal("uaa-sha1"))
+	})
+
+	It("returns the checksum of a built release", func() {
+		release := BuiltRelease{ID: ReleaseID{Name: "uaa", Version: "1.2.3"}}
+		Expect(expectedSum(release)).To(Equal("uaa-sha1"))
+	})
+
+	It("does not return the checksum for another version", func() {
+		_, found := checksums.ExpectedSum(BuiltRelease{ID: ReleaseID{Name: "uaa", Version: "1.2.4"}})
+		Expect(found).To(BeFalse())
+	})
+
+	It("does not return the checksum for a release compiled against another stemcell", func() {
+		release := CompiledRelease{ID: ReleaseID{Name: "uaa", Version: "1.2.3"}, StemcellOS: "ubuntu-xenial", StemcellVersion: "456.7"}
+		_, found := checksums.ExpectedSum(release)
+		Expect(found).To(BeFalse())
+	})
+
+	It("returns the checksum of a release locked against its own stemcell", func() {
+		checksums = NewReleaseChecksums(cargo.KilnfileLock{
+			Releases: []cargo.Release{
+				{Name: "hwc", Version: "1.0.0", SHA1: "hwc-sha1", StemcellOS: "windows", StemcellVersion: "2019.12"},
+			},
+			Stemcells: []cargo.Stemcell{{OS: "ubuntu-xenial", Version: "621.5"}, {OS: "windows", Version: "2019.12"}},
+		})
+
+		release := CompiledRelease{ID: ReleaseID{Name: "hwc", Version: "1.0.0"}, StemcellOS: "windows", StemcellVersion: "2019.12"}
+		Expect(expectedSum(release)).To(Equal("hwc-sha1"))
+	})
+
+	It("does not return a checksum for a release locked without one", func() {
+		_, found := checksums.ExpectedSum(BuiltRelease{ID: ReleaseID{Name: "bpm", Version: "1.1.0"}})
+		Expect(found).To(BeFalse())
+	})
+})
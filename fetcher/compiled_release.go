@@ -7,6 +7,10 @@ type CompiledRelease struct {
 	StemcellOS      string
 	StemcellVersion string
 	Path            string
+
+	// SHA1 is the checksum of the local tarball when it was taken while
+	// downloading it, and empty otherwise.
+	SHA1 string
 }
 
 func (cr CompiledRelease) RemotePath() string {
@@ -0,0 +1,118 @@
+package fetcher
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pivotal-cf/kiln/internal/cargo"
+)
+
+// DirectoryReleaseSource finds release tarballs in directories on the local
+// file system, such as a shared mount of compiled releases. The paths of the
+// tarballs relative to each directory are matched against Regex, which uses the
+// same capture groups as the S3 release sources. When Regex has stemcell_os and
+// stemcell_version groups, the tarballs are compiled releases.
+type DirectoryReleaseSource struct {
+	logger *log.Logger
+
+	Directories []string
+	Regex       string
+}
+
+type DirectoryReleaseSourceConfig struct {
+	Directories []string `yaml:"directories" required:"true"`
+	Regex       string   `yaml:"regex" required:"true"`
+}
+
+func NewDirectoryReleaseSource(logger *log.Logger, config DirectoryReleaseSourceConfig) *DirectoryReleaseSource {
+	return &DirectoryReleaseSource{
+		logger:      logger,
+		Directories: config.Directories,
+		Regex:       config.Regex,
+	}
+}
+
+func (source DirectoryReleaseSource) String() string {
+	return "directory " + strings.Join(source.Directories, ", ")
+}
+
+func (source DirectoryReleaseSource) GetMatchedReleases(desiredReleaseSet ReleaseRequirementSet, stemcell cargo.Stemcell) ([]RemoteRelease, error) {
+	releases, err := source.listReleases()
+	if err != nil {
+		return nil, err
+	}
+
+	return matchReleases(releases, desiredReleaseSet), nil
+}
+
+func (source DirectoryReleaseSource) FindReleaseVersion(constraint ReleaseVersionConstraint) (RemoteRelease, bool, error) {
+	releases, err := source.listReleases()
+	if err != nil {
+		return nil, false, err
+	}
+
+	release, found := newestReleaseMatch(releases, constraint)
+	return release, found, nil
+}
+
+func (source DirectoryReleaseSource) DownloadReleases(releaseDir string, remoteReleases []RemoteRelease, opts DownloadOptions) (LocalReleaseSet, error) {
+	source.logger.Printf("copying %d releases from local directories...", len(remoteReleases))
+
+	return downloadReleasesConcurrently(remoteReleases, opts.workers(), func(release RemoteRelease) (LocalRelease, error) {
+		filePath := filepath.Join(releaseDir, release.StandardizedFilename())
+
+		sum, err := verifyFile(release.RemotePath(), "directory "+filepath.Dir(release.RemotePath()), opts.expectedSum(release))
+		if err != nil {
+			return nil, err
+		}
+
+		source.logger.Printf("copying %s...\n", release.RemotePath())
+		if err := linkOrCopy(release.RemotePath(), filePath); err != nil {
+			return nil, fmt.Errorf("failed to copy %s: %s", release.RemotePath(), err)
+		}
+
+		return withSHA1(release.AsLocal(filePath), sum), nil
+	})
+}
+
+func (source DirectoryReleaseSource) listReleases() (map[ReleaseID][]RemoteRelease, error) {
+	exp, err := newReleaseRegexp(source.Regex)
+	if err != nil {
+		return nil, err
+	}
+
+	releases := make(map[ReleaseID][]RemoteRelease)
+	for _, dir := range source.Directories {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+
+			absPath, err := filepath.Abs(path)
+			if err != nil {
+				return err
+			}
+
+			if release, ok := exp.release(filepath.ToSlash(rel), absPath); ok {
+				releases[release.ReleaseID()] = append(releases[release.ReleaseID()], release)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list releases in %s: %s", dir, err)
+		}
+	}
+
+	return releases, nil
+}
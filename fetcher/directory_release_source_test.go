@@ -0,0 +1,177 @@
+package fetcher_test
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/Masterminds/semver"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/pivotal-cf/kiln/fetcher"
+	"github.com/pivotal-cf/kiln/internal/cargo"
+)
+
+var _ = Describe("DirectoryReleaseSource", func() {
+	const (
+		compiledRegex = `^(?P<release_name>[a-z-_0-9]+)-(?P<release_version>[0-9\.]+)-(?P<stemcell_os>[a-z-_]+)-(?P<stemcell_version>\d+\.\d+)\.tgz$`
+		builtRegex    = `^built/(?P<release_name>[a-z-_0-9]+)-(?P<release_version>[0-9\.]+)\.tgz$`
+	)
+
+	var (
+		tmpDir        string
+		directory     string
+		releaseDir    string
+		config        DirectoryReleaseSourceConfig
+		releaseSource *DirectoryReleaseSource
+		stemcell      cargo.Stemcell
+	)
+
+	writeFile := func(path, contents string) {
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(path, []byte(contents), 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "kiln-directory-release-source-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		directory = filepath.Join(tmpDir, "shared")
+		releaseDir = filepath.Join(tmpDir, "releases")
+		Expect(os.Mkdir(releaseDir, 0755)).To(Succeed())
+
+		writeFile(filepath.Join(directory, "uaa-1.2.3-ubuntu-xenial-621.5.tgz"), "uaa-621.5")
+		writeFile(filepath.Join(directory, "uaa-1.2.3-ubuntu-xenial-456.7.tgz"), "uaa-456.7")
+		writeFile(filepath.Join(directory, "uaa-1.3.0-ubuntu-xenial-621.5.tgz"), "uaa-1.3.0")
+		writeFile(filepath.Join(directory, "built", "bpm-1.1.0.tgz"), "bpm")
+		writeFile(filepath.Join(directory, "README.md"), "not a release")
+
+		config = DirectoryReleaseSourceConfig{Directories: []string{directory}, Regex: compiledRegex}
+		stemcell = cargo.Stemcell{OS: "ubuntu-xenial", Version: "621.5"}
+	})
+
+	JustBeforeEach(func() {
+		releaseSource = NewDirectoryReleaseSource(log.New(GinkgoWriter, "", 0), config)
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	Describe("GetMatchedReleases", func() {
+		It("matches the compiled releases for the stemcell", func() {
+			uaaID := ReleaseID{Name: "uaa", Version: "1.2.3"}
+			matches, err := releaseSource.GetMatchedReleases(ReleaseRequirementSet{
+				uaaID: {Name: "uaa", Version: "1.2.3", StemcellOS: "ubuntu-xenial", StemcellVersion: "621.5"},
+			}, stemcell)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(matches).To(ConsistOf(CompiledRelease{
+				ID:              uaaID,
+				StemcellOS:      "ubuntu-xenial",
+				StemcellVersion: "621.5",
+				Path:            filepath.Join(directory, "uaa-1.2.3-ubuntu-xenial-621.5.tgz"),
+			}))
+		})
+
+		Context("when the regex has no stemcell capture groups", func() {
+			BeforeEach(func() {
+				config.Regex = builtRegex
+			})
+
+			It("matches built releases", func() {
+				bpmID := ReleaseID{Name: "bpm", Version: "1.1.0"}
+				matches, err := releaseSource.GetMatchedReleases(ReleaseRequirementSet{
+					bpmID: {Name: "bpm", Version: "1.1.0", StemcellOS: "ubuntu-xenial", StemcellVersion: "621.5"},
+				}, stemcell)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(matches).To(ConsistOf(BuiltRelease{
+					ID:   bpmID,
+					Path: filepath.Join(directory, "built", "bpm-1.1.0.tgz"),
+				}))
+			})
+		})
+
+		Context("when the regex is missing capture groups", func() {
+			BeforeEach(func() {
+				config.Regex = `^(?P<release_name>[a-z]+)-(?P<stemcell_os>[a-z]+)\.tgz$`
+			})
+
+			It("returns an error", func() {
+				_, err := releaseSource.GetMatchedReleases(ReleaseRequirementSet{}, stemcell)
+				Expect(err).To(MatchError(ContainSubstring("Missing some capture group")))
+			})
+		})
+
+		Context("when a directory does not exist", func() {
+			BeforeEach(func() {
+				config.Directories = append(config.Directories, filepath.Join(tmpDir, "missing"))
+			})
+
+			It("returns an error", func() {
+				_, err := releaseSource.GetMatchedReleases(ReleaseRequirementSet{}, stemcell)
+				Expect(err).To(MatchError(ContainSubstring("failed to list releases in " + filepath.Join(tmpDir, "missing"))))
+			})
+		})
+	})
+
+	Describe("FindReleaseVersion", func() {
+		It("returns the newest release for the stemcell matching the constraint", func() {
+			constraint, err := semver.NewConstraint("~1")
+			Expect(err).NotTo(HaveOccurred())
+
+			release, found, err := releaseSource.FindReleaseVersion(ReleaseVersionConstraint{
+				Name:            "uaa",
+				Constraint:      constraint,
+				StemcellOS:      "ubuntu-xenial",
+				StemcellVersion: "621.5",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(release.ReleaseID()).To(Equal(ReleaseID{Name: "uaa", Version: "1.3.0"}))
+		})
+	})
+
+	Describe("DownloadReleases", func() {
+		It("copies the releases into the release dir", func() {
+			uaaID := ReleaseID{Name: "uaa", Version: "1.2.3"}
+			localReleases, err := releaseSource.DownloadReleases(releaseDir, []RemoteRelease{
+				CompiledRelease{
+					ID:              uaaID,
+					StemcellOS:      "ubuntu-xenial",
+					StemcellVersion: "621.5",
+					Path:            filepath.Join(directory, "uaa-1.2.3-ubuntu-xenial-621.5.tgz"),
+				},
+			}, DownloadOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			releasePath := filepath.Join(releaseDir, "uaa-1.2.3-ubuntu-xenial-621.5.tgz")
+			Expect(localReleases).To(Equal(LocalReleaseSet{
+				ReleaseID{Name: "uaa", Version: "1.2.3", StemcellOS: "ubuntu-xenial"}: CompiledRelease{ID: uaaID, StemcellOS: "ubuntu-xenial", StemcellVersion: "621.5", Path: releasePath},
+			}))
+			Expect(ioutil.ReadFile(releasePath)).To(BeEquivalentTo("uaa-621.5"))
+		})
+
+		Context("when a release does not match its checksum", func() {
+			It("returns an error and does not copy the release", func() {
+				uaaRelease := CompiledRelease{
+					ID:              ReleaseID{Name: "uaa", Version: "1.2.3"},
+					StemcellOS:      "ubuntu-xenial",
+					StemcellVersion: "621.5",
+					Path:            filepath.Join(directory, "uaa-1.2.3-ubuntu-xenial-621.5.tgz"),
+				}
+				checksums := NewReleaseChecksums(cargo.KilnfileLock{
+					Releases: []cargo.Release{{Name: "uaa", Version: "1.2.3", SHA1: "some-other-sha1"}},
+					Stemcell: stemcell,
+				})
+
+				_, err := releaseSource.DownloadReleases(releaseDir, []RemoteRelease{uaaRelease}, DownloadOptions{Checksums: checksums})
+				Expect(err).To(MatchError(ContainSubstring("directory " + directory + " served uaa-1.2.3-ubuntu-xenial-621.5.tgz")))
+				Expect(filepath.Join(releaseDir, "uaa-1.2.3-ubuntu-xenial-621.5.tgz")).NotTo(BeAnExistingFile())
+			})
+		})
+	})
+})
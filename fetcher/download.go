@@ -0,0 +1,280 @@
+package fetcher
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pivotal-cf/kiln/internal/progress"
+)
+
+const (
+	DefaultDownloadWorkers = 4
+
+	partialDownloadSuffix = ".partial"
+)
+
+// progressInterval is how often in-flight downloads report their progress.
+var progressInterval = 10 * time.Second
+
+var errRangeNotSatisfiable = errors.New("requested range not satisfiable")
+
+type DownloadOptions struct {
+	// Threads is the number of parallel threads used to download parts of a single S3 object.
+	Threads int
+	// Workers is the number of releases downloaded concurrently.
+	Workers int
+	// Checksums are verified while the releases are downloaded. Releases
+	// without a checksum are not verified.
+	Checksums ReleaseChecksums
+}
+
+func (opts DownloadOptions) workers() int {
+	if opts.Workers > 0 {
+		return opts.Workers
+	}
+	return DefaultDownloadWorkers
+}
+
+func (opts DownloadOptions) expectedSum(release RemoteRelease) string {
+	sum, _ := opts.Checksums.ExpectedSum(release)
+	return sum
+}
+
+// downloadTarget is the partial file a download writes to.
+type downloadTarget interface {
+	io.WriterAt
+	Truncate(size int64) error
+}
+
+type releaseDownloadFunc func(release RemoteRelease) (LocalRelease, error)
+
+// downloadReleasesConcurrently runs download for each release using a pool of
+// workers. When any download fails, no new downloads are started and the
+// error for the first failing release (in the order given) is returned.
+func downloadReleasesConcurrently(releases []RemoteRelease, workers int, download releaseDownloadFunc) (LocalReleaseSet, error) {
+	if workers > len(releases) {
+		workers = len(releases)
+	}
+
+	type result struct {
+		local LocalRelease
+		err   error
+	}
+
+	var (
+		results = make([]result, len(releases))
+		indexes = make(chan int)
+		failed  int32
+		wg      sync.WaitGroup
+	)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				local, err := download(releases[i])
+				if err != nil {
+					atomic.StoreInt32(&failed, 1)
+				}
+				results[i] = result{local: local, err: err}
+			}
+		}()
+	}
+
+	for i := range releases {
+		if atomic.LoadInt32(&failed) != 0 {
+			break
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	localReleases := make(LocalReleaseSet)
+	for i, release := range releases {
+		if results[i].err != nil {
+			return nil, results[i].err
+		}
+		if results[i].local != nil {
+			localReleases[ReleaseSetKey(release)] = results[i].local
+		}
+	}
+
+	return localReleases, nil
+}
+
+// downloadFile writes to a partial file next to path and renames it to path once
+// download succeeds. A partial file left behind by an earlier attempt is resumed:
+// download is given the number of bytes already on disk and is expected to write
+// the remainder of the file from that offset. If the source can not serve that
+// range, the partial file is discarded and the download starts over.
+//
+// The file is hashed as it is written and its SHA1 is returned. When
+// expectedSum is set, a file with a different SHA1 is removed instead of being
+// renamed to path.
+func downloadFile(path, source, expectedSum string, download func(file downloadTarget, offset int64) error) (string, error) {
+	return downloadFileWith(sha1Algorithm, path, source, expectedSum, download)
+}
+
+// downloadFileWith is downloadFile with the checksum of algorithm in place of
+// SHA1.
+func downloadFileWith(algorithm checksumAlgorithm, path, source, expectedSum string, download func(file downloadTarget, offset int64) error) (string, error) {
+	partialPath := path + partialDownloadSuffix
+
+	file, err := os.OpenFile(partialPath, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to create file %q: %w", partialPath, err)
+	}
+
+	checksum := newChecksumFile(file, algorithm)
+	err = checksum.catchUp()
+	if err == nil {
+		err = download(checksum, checksum.hashed)
+	}
+	if errors.Is(err, errRangeNotSatisfiable) {
+		err = checksum.Truncate(0)
+		if err == nil {
+			err = download(checksum, 0)
+		}
+	}
+
+	var sum string
+	if err == nil {
+		sum, err = checksum.sum()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if expectedSum != "" && sum != expectedSum {
+		os.Remove(partialPath)
+		return "", ChecksumMismatchError{Source: source, Release: filepath.Base(path), Algorithm: algorithm.name, Expected: expectedSum, Actual: sum}
+	}
+
+	return sum, os.Rename(partialPath, path)
+}
+
+// downloadHTTP sends req and writes the response body to file. When offset is
+// greater than zero only the remainder of the file from offset is requested.
+func downloadHTTP(logger *log.Logger, req *http.Request, file downloadTarget, offset int64) error {
+	return downloadHTTPWith(logger, http.DefaultClient.Do, req, file, offset)
+}
+
+// downloadHTTPWith is downloadHTTP for requests that must be sent by do, such
+// as those needing authorization.
+func downloadHTTPWith(logger *log.Logger, do func(*http.Request) (*http.Response, error), req *http.Request, file downloadTarget, offset int64) error {
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		// the server ignored the range so the whole file is being sent again
+		if err := file.Truncate(0); err != nil {
+			return err
+		}
+		offset = 0
+	case http.StatusRequestedRangeNotSatisfiable:
+		return errRangeNotSatisfiable
+	default:
+		return (*ResponseStatusCodeError)(resp)
+	}
+
+	var total int64
+	if resp.ContentLength > 0 {
+		total = offset + resp.ContentLength
+	}
+
+	progress := startDownloadProgress(logger, req.URL.String(), offset, total)
+	_, err = io.Copy(io.MultiWriter(&sequentialWriter{w: file, offset: offset}, progress), resp.Body)
+	progress.finish(err)
+
+	return err
+}
+
+// offsetWriterAt shifts writes by offset so that a ranged download starting at
+// offset lands in the right place of a partially downloaded file.
+type offsetWriterAt struct {
+	w      io.WriterAt
+	offset int64
+}
+
+func (w offsetWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	return w.w.WriteAt(p, off+w.offset)
+}
+
+// sequentialWriter writes a stream to w starting at offset.
+type sequentialWriter struct {
+	w      io.WriterAt
+	offset int64
+}
+
+func (w *sequentialWriter) Write(p []byte) (int, error) {
+	n, err := w.w.WriteAt(p, w.offset)
+	w.offset += int64(n)
+	return n, err
+}
+
+type downloadProgress struct {
+	*progress.Meter
+	logger  *log.Logger
+	name    string
+	resumed int64
+}
+
+// startDownloadProgress periodically logs the progress of the named download
+// until finish is called. total may be zero when the size is not known.
+func startDownloadProgress(logger *log.Logger, name string, resumed, total int64) *downloadProgress {
+	if resumed > 0 {
+		logger.Printf("resuming %s from %s\n", name, progress.FormatBytes(resumed))
+	} else {
+		logger.Printf("downloading %s...\n", name)
+	}
+
+	meter := progress.Start(progressInterval, func(m *progress.Meter) {
+		downloaded := progress.FormatBytes(resumed + m.Written())
+		if total > 0 {
+			downloaded = fmt.Sprintf("%s / %s", downloaded, progress.FormatBytes(total))
+		}
+		logger.Printf("%s: %s (%s/s)\n", name, downloaded, progress.FormatBytes(m.Throughput()))
+	})
+
+	return &downloadProgress{
+		Meter:   meter,
+		logger:  logger,
+		name:    name,
+		resumed: resumed,
+	}
+}
+
+func (p *downloadProgress) WriteAt(b []byte, _ int64) (int, error) {
+	return p.Write(b)
+}
+
+func (p *downloadProgress) finish(err error) {
+	p.Stop()
+	if err != nil {
+		return
+	}
+	elapsed := p.Elapsed().Round(time.Millisecond)
+	p.logger.Printf("downloaded %s: %s in %s (%s/s)\n", p.name, progress.FormatBytes(p.resumed+p.Written()), elapsed, progress.FormatBytes(p.Throughput()))
+}
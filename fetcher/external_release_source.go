@@ -0,0 +1,223 @@
+package fetcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"sort"
+
+	"github.com/pivotal-cf/kiln/internal/cargo"
+	"github.com/pivotal-cf/kiln/internal/jsonvalue"
+)
+
+// ExternalReleaseSource delegates to an executable that implements a release
+// source type kiln does not know about. Each call runs the executable with a
+// JSON request on stdin and reads a JSON response from stdout. The executable
+// may log to stderr.
+//
+// Requests have a "command" (get_matched_releases, find_release_version or
+// download_releases) and the "options" of the release_sources entry. Responses
+// have a list of "releases", or an "error" describing why the command failed.
+type ExternalReleaseSource struct {
+	logger *log.Logger
+
+	Type    string
+	Path    string
+	Options map[string]interface{}
+}
+
+func NewExternalReleaseSource(logger *log.Logger, sourceType, path string, options map[string]interface{}) *ExternalReleaseSource {
+	return &ExternalReleaseSource{
+		logger:  logger,
+		Type:    sourceType,
+		Path:    path,
+		Options: options,
+	}
+}
+
+type externalRequest struct {
+	Command           string                 `json:"command"`
+	Options           map[string]interface{} `json:"options"`
+	Stemcell          *externalStemcell      `json:"stemcell,omitempty"`
+	Requirements      []externalRelease      `json:"requirements,omitempty"`
+	Name              string                 `json:"name,omitempty"`
+	ReleasesDirectory string                 `json:"releases_directory,omitempty"`
+	Releases          []externalRelease      `json:"releases,omitempty"`
+}
+
+type externalStemcell struct {
+	OS      string `json:"os"`
+	Version string `json:"version"`
+}
+
+type externalRelease struct {
+	Name            string `json:"name"`
+	Version         string `json:"version"`
+	StemcellOS      string `json:"stemcell_os,omitempty"`
+	StemcellVersion string `json:"stemcell_version,omitempty"`
+	Path            string `json:"path,omitempty"`
+}
+
+type externalResponse struct {
+	Releases []externalRelease `json:"releases"`
+	Error    string            `json:"error"`
+}
+
+func (er externalRelease) remoteRelease() RemoteRelease {
+	id := ReleaseID{Name: er.Name, Version: er.Version}
+	if er.StemcellOS != "" {
+		return CompiledRelease{ID: id, StemcellOS: er.StemcellOS, StemcellVersion: er.StemcellVersion, Path: er.Path}
+	}
+	return BuiltRelease{ID: id, Path: er.Path}
+}
+
+func newExternalRelease(release RemoteRelease) externalRelease {
+	id := release.ReleaseID()
+	er := externalRelease{Name: id.Name, Version: id.Version, Path: release.RemotePath()}
+	if compiled, ok := release.(CompiledRelease); ok {
+		er.StemcellOS, er.StemcellVersion = compiled.StemcellOS, compiled.StemcellVersion
+	}
+	return er
+}
+
+func (source ExternalReleaseSource) String() string {
+	return source.Type + " release source"
+}
+
+func (source ExternalReleaseSource) GetMatchedReleases(desiredReleaseSet ReleaseRequirementSet, stemcell cargo.Stemcell) ([]RemoteRelease, error) {
+	request := externalRequest{
+		Command:  "get_matched_releases",
+		Stemcell: &externalStemcell{OS: stemcell.OS, Version: stemcell.Version},
+	}
+	for _, requirement := range desiredReleaseSet {
+		request.Requirements = append(request.Requirements, externalRelease{
+			Name:            requirement.Name,
+			Version:         requirement.Version,
+			StemcellOS:      requirement.StemcellOS,
+			StemcellVersion: requirement.StemcellVersion,
+		})
+	}
+	sort.Slice(request.Requirements, func(i, j int) bool {
+		return request.Requirements[i].Name < request.Requirements[j].Name
+	})
+
+	response, err := source.run(request)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]RemoteRelease, 0, len(response.Releases))
+	for _, release := range response.Releases {
+		matches = append(matches, release.remoteRelease())
+	}
+	return matches, nil
+}
+
+// FindReleaseVersion asks the executable for the releases it has with the
+// constraint's name; the version constraint is applied by kiln.
+func (source ExternalReleaseSource) FindReleaseVersion(constraint ReleaseVersionConstraint) (RemoteRelease, bool, error) {
+	response, err := source.run(externalRequest{
+		Command:  "find_release_version",
+		Name:     constraint.Name,
+		Stemcell: &externalStemcell{OS: constraint.StemcellOS, Version: constraint.StemcellVersion},
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	releases := make(map[ReleaseID][]RemoteRelease)
+	for _, er := range response.Releases {
+		release := er.remoteRelease()
+		releases[release.ReleaseID()] = append(releases[release.ReleaseID()], release)
+	}
+
+	release, found := newestReleaseMatch(releases, constraint)
+	return release, found, nil
+}
+
+func (source ExternalReleaseSource) DownloadReleases(releaseDir string, remoteReleases []RemoteRelease, opts DownloadOptions) (LocalReleaseSet, error) {
+	source.logger.Printf("downloading %d releases with %s...", len(remoteReleases), source.Path)
+
+	request := externalRequest{
+		Command:           "download_releases",
+		ReleasesDirectory: releaseDir,
+	}
+	for _, release := range remoteReleases {
+		request.Releases = append(request.Releases, newExternalRelease(release))
+	}
+
+	response, err := source.run(request)
+	if err != nil {
+		return nil, err
+	}
+
+	downloaded := make(map[ReleaseID]string)
+	for _, release := range response.Releases {
+		downloaded[ReleaseID{Name: release.Name, Version: release.Version}] = release.Path
+	}
+
+	localReleases := make(LocalReleaseSet)
+	for _, release := range remoteReleases {
+		path, ok := downloaded[release.ReleaseID()]
+		if !ok {
+			return nil, fmt.Errorf("%s release source did not download %s/%s", source.Type, release.ReleaseID().Name, release.ReleaseID().Version)
+		}
+
+		// the executable writes the file itself, so it can only be verified afterwards
+		sum, err := verifyFile(path, source.String(), opts.expectedSum(release))
+		if err != nil {
+			os.Remove(path)
+			return nil, err
+		}
+		localReleases[ReleaseSetKey(release)] = withSHA1(release.AsLocal(path), sum)
+	}
+
+	return localReleases, nil
+}
+
+func (source ExternalReleaseSource) run(request externalRequest) (externalResponse, error) {
+	request.Options = jsonCompatibleOptions(source.Options)
+
+	input, err := json.Marshal(request)
+	if err != nil {
+		return externalResponse{}, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(source.Path)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	runErr := cmd.Run()
+	if stderr.Len() > 0 {
+		source.logger.Print(stderr.String())
+	}
+
+	var response externalResponse
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil && runErr == nil {
+		return externalResponse{}, fmt.Errorf("%s release source returned an invalid response to %s: %s", source.Type, request.Command, err)
+	}
+
+	if response.Error != "" {
+		return externalResponse{}, fmt.Errorf("%s release source failed to %s: %s", source.Type, request.Command, response.Error)
+	}
+	if runErr != nil {
+		return externalResponse{}, fmt.Errorf("%s release source failed to %s: %s", source.Type, request.Command, runErr)
+	}
+
+	return response, nil
+}
+
+// jsonCompatibleOptions converts the maps decoded from YAML, which have
+// interface{} keys, into maps that can be encoded as JSON.
+func jsonCompatibleOptions(options map[string]interface{}) map[string]interface{} {
+	converted := make(map[string]interface{}, len(options))
+	for key, value := range options {
+		converted[key] = jsonvalue.FromYAML(value)
+	}
+	return converted
+}
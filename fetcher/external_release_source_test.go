@@ -0,0 +1,167 @@
+package fetcher_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/Masterminds/semver"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/pivotal-cf/kiln/fetcher"
+	"github.com/pivotal-cf/kiln/internal/cargo"
+)
+
+var _ = Describe("ExternalReleaseSource", func() {
+	const executable = `#!/bin/sh
+dir=$(dirname "$0")
+cat > "$dir/request.json"
+echo "some progress" >&2
+cat "$dir/response.json"
+exit $(cat "$dir/exit-status" 2>/dev/null || echo 0)
+`
+
+	var (
+		tmpDir        string
+		releaseSource *ExternalReleaseSource
+	)
+
+	respondWith := func(response string) {
+		Expect(ioutil.WriteFile(filepath.Join(tmpDir, "response.json"), []byte(response), 0644)).To(Succeed())
+	}
+
+	lastRequest := func() map[string]interface{} {
+		contents, err := ioutil.ReadFile(filepath.Join(tmpDir, "request.json"))
+		Expect(err).NotTo(HaveOccurred())
+
+		var request map[string]interface{}
+		Expect(json.Unmarshal(contents, &request)).To(Succeed())
+		return request
+	}
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "kiln-external-release-source-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		path := filepath.Join(tmpDir, "kiln-release-source-internal")
+		Expect(ioutil.WriteFile(path, []byte(executable), 0755)).To(Succeed())
+
+		releaseSource = NewExternalReleaseSource(log.New(GinkgoWriter, "", 0), "internal", path, map[string]interface{}{
+			"endpoint": "https://releases.example.com",
+			"auth":     map[interface{}]interface{}{"user": "some-user"},
+		})
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	Describe("GetMatchedReleases", func() {
+		It("sends the requirements and returns the releases the executable matched", func() {
+			respondWith(`{"releases": [{"name": "uaa", "version": "1.2.3", "stemcell_os": "ubuntu-xenial", "stemcell_version": "621.5", "path": "uaa/1.2.3"}]}`)
+
+			uaaID := ReleaseID{Name: "uaa", Version: "1.2.3"}
+			matches, err := releaseSource.GetMatchedReleases(ReleaseRequirementSet{
+				uaaID: {Name: "uaa", Version: "1.2.3", StemcellOS: "ubuntu-xenial", StemcellVersion: "621.5"},
+			}, cargo.Stemcell{OS: "ubuntu-xenial", Version: "621.5"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(matches).To(ConsistOf(CompiledRelease{ID: uaaID, StemcellOS: "ubuntu-xenial", StemcellVersion: "621.5", Path: "uaa/1.2.3"}))
+			Expect(lastRequest()).To(Equal(map[string]interface{}{
+				"command": "get_matched_releases",
+				"options": map[string]interface{}{
+					"endpoint": "https://releases.example.com",
+					"auth":     map[string]interface{}{"user": "some-user"},
+				},
+				"stemcell": map[string]interface{}{"os": "ubuntu-xenial", "version": "621.5"},
+				"requirements": []interface{}{
+					map[string]interface{}{"name": "uaa", "version": "1.2.3", "stemcell_os": "ubuntu-xenial", "stemcell_version": "621.5"},
+				},
+			}))
+		})
+
+		Context("when the executable responds with an error", func() {
+			It("returns the error", func() {
+				respondWith(`{"error": "bad credentials"}`)
+
+				_, err := releaseSource.GetMatchedReleases(ReleaseRequirementSet{}, cargo.Stemcell{})
+				Expect(err).To(MatchError("internal release source failed to get_matched_releases: bad credentials"))
+			})
+		})
+
+		Context("when the executable exits with a non-zero status", func() {
+			It("returns an error", func() {
+				respondWith("")
+				Expect(ioutil.WriteFile(filepath.Join(tmpDir, "exit-status"), []byte("3"), 0644)).To(Succeed())
+
+				_, err := releaseSource.GetMatchedReleases(ReleaseRequirementSet{}, cargo.Stemcell{})
+				Expect(err).To(MatchError(ContainSubstring("exit status 3")))
+			})
+		})
+
+		Context("when the executable writes something other than JSON", func() {
+			It("returns an error", func() {
+				respondWith("not json")
+
+				_, err := releaseSource.GetMatchedReleases(ReleaseRequirementSet{}, cargo.Stemcell{})
+				Expect(err).To(MatchError(ContainSubstring("internal release source returned an invalid response to get_matched_releases")))
+			})
+		})
+	})
+
+	Describe("FindReleaseVersion", func() {
+		It("returns the newest release the executable has that matches the constraint", func() {
+			respondWith(`{"releases": [
+  {"name": "uaa", "version": "1.2.3", "path": "uaa/1.2.3"},
+  {"name": "uaa", "version": "1.3.0", "path": "uaa/1.3.0"},
+  {"name": "uaa", "version": "2.0.0", "path": "uaa/2.0.0"}
+]}`)
+
+			constraint, err := semver.NewConstraint("~1")
+			Expect(err).NotTo(HaveOccurred())
+
+			release, found, err := releaseSource.FindReleaseVersion(ReleaseVersionConstraint{Name: "uaa", Constraint: constraint})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(release).To(Equal(BuiltRelease{ID: ReleaseID{Name: "uaa", Version: "1.3.0"}, Path: "uaa/1.3.0"}))
+
+			Expect(lastRequest()).To(HaveKeyWithValue("command", "find_release_version"))
+			Expect(lastRequest()).To(HaveKeyWithValue("name", "uaa"))
+		})
+	})
+
+	Describe("DownloadReleases", func() {
+		var uaaRelease BuiltRelease
+
+		BeforeEach(func() {
+			uaaRelease = BuiltRelease{ID: ReleaseID{Name: "uaa", Version: "1.2.3"}, Path: "uaa/1.2.3"}
+		})
+
+		It("returns the local paths of the releases the executable downloaded", func() {
+			respondWith(`{"releases": [{"name": "uaa", "version": "1.2.3", "path": "/releases/uaa-1.2.3.tgz"}]}`)
+
+			localReleases, err := releaseSource.DownloadReleases("/releases", []RemoteRelease{uaaRelease}, DownloadOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(localReleases).To(Equal(LocalReleaseSet{
+				uaaRelease.ID: BuiltRelease{ID: uaaRelease.ID, Path: "/releases/uaa-1.2.3.tgz"},
+			}))
+			Expect(lastRequest()).To(HaveKeyWithValue("releases_directory", "/releases"))
+			Expect(lastRequest()).To(HaveKeyWithValue("releases", []interface{}{
+				map[string]interface{}{"name": "uaa", "version": "1.2.3", "path": "uaa/1.2.3"},
+			}))
+		})
+
+		Context("when the executable does not download a release", func() {
+			It("returns an error", func() {
+				respondWith(`{"releases": []}`)
+
+				_, err := releaseSource.DownloadReleases("/releases", []RemoteRelease{uaaRelease}, DownloadOptions{})
+				Expect(err).To(MatchError("internal release source did not download uaa/1.2.3"))
+			})
+		})
+	})
+})
@@ -9,12 +9,12 @@ import (
 )
 
 type ReleaseSource struct {
-	DownloadReleasesStub        func(string, []fetcher.RemoteRelease, int) (fetcher.LocalReleaseSet, error)
+	DownloadReleasesStub        func(string, []fetcher.RemoteRelease, fetcher.DownloadOptions) (fetcher.LocalReleaseSet, error)
 	downloadReleasesMutex       sync.RWMutex
 	downloadReleasesArgsForCall []struct {
 		arg1 string
 		arg2 []fetcher.RemoteRelease
-		arg3 int
+		arg3 fetcher.DownloadOptions
 	}
 	downloadReleasesReturns struct {
 		result1 fetcher.LocalReleaseSet
@@ -24,6 +24,21 @@ type ReleaseSource struct {
 		result1 fetcher.LocalReleaseSet
 		result2 error
 	}
+	FindReleaseVersionStub        func(fetcher.ReleaseVersionConstraint) (fetcher.RemoteRelease, bool, error)
+	findReleaseVersionMutex       sync.RWMutex
+	findReleaseVersionArgsForCall []struct {
+		arg1 fetcher.ReleaseVersionConstraint
+	}
+	findReleaseVersionReturns struct {
+		result1 fetcher.RemoteRelease
+		result2 bool
+		result3 error
+	}
+	findReleaseVersionReturnsOnCall map[int]struct {
+		result1 fetcher.RemoteRelease
+		result2 bool
+		result3 error
+	}
 	GetMatchedReleasesStub        func(fetcher.ReleaseRequirementSet, cargo.Stemcell) ([]fetcher.RemoteRelease, error)
 	getMatchedReleasesMutex       sync.RWMutex
 	getMatchedReleasesArgsForCall []struct {
@@ -42,7 +57,7 @@ type ReleaseSource struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *ReleaseSource) DownloadReleases(arg1 string, arg2 []fetcher.RemoteRelease, arg3 int) (fetcher.LocalReleaseSet, error) {
+func (fake *ReleaseSource) DownloadReleases(arg1 string, arg2 []fetcher.RemoteRelease, arg3 fetcher.DownloadOptions) (fetcher.LocalReleaseSet, error) {
 	var arg2Copy []fetcher.RemoteRelease
 	if arg2 != nil {
 		arg2Copy = make([]fetcher.RemoteRelease, len(arg2))
@@ -53,17 +68,18 @@ func (fake *ReleaseSource) DownloadReleases(arg1 string, arg2 []fetcher.RemoteRe
 	fake.downloadReleasesArgsForCall = append(fake.downloadReleasesArgsForCall, struct {
 		arg1 string
 		arg2 []fetcher.RemoteRelease
-		arg3 int
+		arg3 fetcher.DownloadOptions
 	}{arg1, arg2Copy, arg3})
+	stub := fake.DownloadReleasesStub
+	fakeReturns := fake.downloadReleasesReturns
 	fake.recordInvocation("DownloadReleases", []interface{}{arg1, arg2Copy, arg3})
 	fake.downloadReleasesMutex.Unlock()
-	if fake.DownloadReleasesStub != nil {
-		return fake.DownloadReleasesStub(arg1, arg2, arg3)
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.downloadReleasesReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -73,13 +89,13 @@ func (fake *ReleaseSource) DownloadReleasesCallCount() int {
 	return len(fake.downloadReleasesArgsForCall)
 }
 
-func (fake *ReleaseSource) DownloadReleasesCalls(stub func(string, []fetcher.RemoteRelease, int) (fetcher.LocalReleaseSet, error)) {
+func (fake *ReleaseSource) DownloadReleasesCalls(stub func(string, []fetcher.RemoteRelease, fetcher.DownloadOptions) (fetcher.LocalReleaseSet, error)) {
 	fake.downloadReleasesMutex.Lock()
 	defer fake.downloadReleasesMutex.Unlock()
 	fake.DownloadReleasesStub = stub
 }
 
-func (fake *ReleaseSource) DownloadReleasesArgsForCall(i int) (string, []fetcher.RemoteRelease, int) {
+func (fake *ReleaseSource) DownloadReleasesArgsForCall(i int) (string, []fetcher.RemoteRelease, fetcher.DownloadOptions) {
 	fake.downloadReleasesMutex.RLock()
 	defer fake.downloadReleasesMutex.RUnlock()
 	argsForCall := fake.downloadReleasesArgsForCall[i]
@@ -112,6 +128,73 @@ func (fake *ReleaseSource) DownloadReleasesReturnsOnCall(i int, result1 fetcher.
 	}{result1, result2}
 }
 
+func (fake *ReleaseSource) FindReleaseVersion(arg1 fetcher.ReleaseVersionConstraint) (fetcher.RemoteRelease, bool, error) {
+	fake.findReleaseVersionMutex.Lock()
+	ret, specificReturn := fake.findReleaseVersionReturnsOnCall[len(fake.findReleaseVersionArgsForCall)]
+	fake.findReleaseVersionArgsForCall = append(fake.findReleaseVersionArgsForCall, struct {
+		arg1 fetcher.ReleaseVersionConstraint
+	}{arg1})
+	stub := fake.FindReleaseVersionStub
+	fakeReturns := fake.findReleaseVersionReturns
+	fake.recordInvocation("FindReleaseVersion", []interface{}{arg1})
+	fake.findReleaseVersionMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *ReleaseSource) FindReleaseVersionCallCount() int {
+	fake.findReleaseVersionMutex.RLock()
+	defer fake.findReleaseVersionMutex.RUnlock()
+	return len(fake.findReleaseVersionArgsForCall)
+}
+
+func (fake *ReleaseSource) FindReleaseVersionCalls(stub func(fetcher.ReleaseVersionConstraint) (fetcher.RemoteRelease, bool, error)) {
+	fake.findReleaseVersionMutex.Lock()
+	defer fake.findReleaseVersionMutex.Unlock()
+	fake.FindReleaseVersionStub = stub
+}
+
+func (fake *ReleaseSource) FindReleaseVersionArgsForCall(i int) fetcher.ReleaseVersionConstraint {
+	fake.findReleaseVersionMutex.RLock()
+	defer fake.findReleaseVersionMutex.RUnlock()
+	argsForCall := fake.findReleaseVersionArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *ReleaseSource) FindReleaseVersionReturns(result1 fetcher.RemoteRelease, result2 bool, result3 error) {
+	fake.findReleaseVersionMutex.Lock()
+	defer fake.findReleaseVersionMutex.Unlock()
+	fake.FindReleaseVersionStub = nil
+	fake.findReleaseVersionReturns = struct {
+		result1 fetcher.RemoteRelease
+		result2 bool
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *ReleaseSource) FindReleaseVersionReturnsOnCall(i int, result1 fetcher.RemoteRelease, result2 bool, result3 error) {
+	fake.findReleaseVersionMutex.Lock()
+	defer fake.findReleaseVersionMutex.Unlock()
+	fake.FindReleaseVersionStub = nil
+	if fake.findReleaseVersionReturnsOnCall == nil {
+		fake.findReleaseVersionReturnsOnCall = make(map[int]struct {
+			result1 fetcher.RemoteRelease
+			result2 bool
+			result3 error
+		})
+	}
+	fake.findReleaseVersionReturnsOnCall[i] = struct {
+		result1 fetcher.RemoteRelease
+		result2 bool
+		result3 error
+	}{result1, result2, result3}
+}
+
 func (fake *ReleaseSource) GetMatchedReleases(arg1 fetcher.ReleaseRequirementSet, arg2 cargo.Stemcell) ([]fetcher.RemoteRelease, error) {
 	fake.getMatchedReleasesMutex.Lock()
 	ret, specificReturn := fake.getMatchedReleasesReturnsOnCall[len(fake.getMatchedReleasesArgsForCall)]
@@ -119,15 +202,16 @@ func (fake *ReleaseSource) GetMatchedReleases(arg1 fetcher.ReleaseRequirementSet
 		arg1 fetcher.ReleaseRequirementSet
 		arg2 cargo.Stemcell
 	}{arg1, arg2})
+	stub := fake.GetMatchedReleasesStub
+	fakeReturns := fake.getMatchedReleasesReturns
 	fake.recordInvocation("GetMatchedReleases", []interface{}{arg1, arg2})
 	fake.getMatchedReleasesMutex.Unlock()
-	if fake.GetMatchedReleasesStub != nil {
-		return fake.GetMatchedReleasesStub(arg1, arg2)
+	if stub != nil {
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.getMatchedReleasesReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -181,6 +265,8 @@ func (fake *ReleaseSource) Invocations() map[string][][]interface{} {
 	defer fake.invocationsMutex.RUnlock()
 	fake.downloadReleasesMutex.RLock()
 	defer fake.downloadReleasesMutex.RUnlock()
+	fake.findReleaseVersionMutex.RLock()
+	defer fake.findReleaseVersionMutex.RUnlock()
 	fake.getMatchedReleasesMutex.RLock()
 	defer fake.getMatchedReleasesMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
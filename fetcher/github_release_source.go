@@ -0,0 +1,281 @@
+package fetcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pivotal-cf/kiln/internal/cargo"
+)
+
+const defaultGitHubAssetRegex = `\.tgz$`
+
+type GitHubReleaseSource struct {
+	serverURI string
+	logger    *log.Logger
+
+	Org   string
+	Repo  string
+	Token string
+	Regex string
+}
+
+type GitHubReleaseSourceConfig struct {
+	Org         string `yaml:"org" required:"true"`
+	Repo        string `yaml:"repo"`
+	GitHubToken string `yaml:"github_token"`
+	Regex       string `yaml:"regex"`
+}
+
+func NewGitHubReleaseSource(logger *log.Logger, customServerURI string, config GitHubReleaseSourceConfig) *GitHubReleaseSource {
+	if customServerURI == "" {
+		customServerURI = "https://api.github.com"
+	}
+
+	return &GitHubReleaseSource{
+		logger:    logger,
+		serverURI: strings.TrimSuffix(customServerURI, "/"),
+		Org:       config.Org,
+		Repo:      config.Repo,
+		Token:     config.GitHubToken,
+		Regex:     config.Regex,
+	}
+}
+
+type gitHubRelease struct {
+	TagName    string        `json:"tag_name"`
+	Draft      bool          `json:"draft"`
+	Prerelease bool          `json:"prerelease"`
+	Assets     []gitHubAsset `json:"assets"`
+}
+
+type gitHubAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+func (release gitHubRelease) version() string {
+	return strings.TrimPrefix(release.TagName, "v")
+}
+
+func (source GitHubReleaseSource) String() string {
+	return "GitHub organization " + source.Org
+}
+
+func (source GitHubReleaseSource) GetMatchedReleases(desiredReleaseSet ReleaseRequirementSet, stemcell cargo.Stemcell) ([]RemoteRelease, error) {
+	assetRegex, err := source.assetRegex()
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]RemoteRelease, 0)
+
+	for rID := range desiredReleaseSet {
+		for _, repo := range source.repos(rID.Name) {
+			release, found, err := source.releaseForTag(repo, rID.Version)
+			if err != nil {
+				return nil, err
+			}
+			if !found {
+				continue
+			}
+
+			asset, found := findGitHubAsset(release.Assets, assetRegex)
+			if found {
+				matches = append(matches, BuiltRelease{ID: rID, Path: asset.URL})
+				break
+			}
+		}
+	}
+
+	return matches, nil
+}
+
+func (source GitHubReleaseSource) FindReleaseVersion(constraint ReleaseVersionConstraint) (RemoteRelease, bool, error) {
+	assetRegex, err := source.assetRegex()
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, repo := range source.repos(constraint.Name) {
+		releases, err := source.releases(repo)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(releases) == 0 {
+			continue
+		}
+
+		candidates := make([]RemoteRelease, 0, len(releases))
+		for _, release := range releases {
+			if release.Draft || release.Prerelease {
+				continue
+			}
+			asset, found := findGitHubAsset(release.Assets, assetRegex)
+			if !found {
+				continue
+			}
+			candidates = append(candidates, BuiltRelease{ID: ReleaseID{Name: constraint.Name, Version: release.version()}, Path: asset.URL})
+		}
+
+		release, found := constraint.newestMatch(candidates)
+		return release, found, nil
+	}
+
+	return nil, false, nil
+}
+
+func (source GitHubReleaseSource) DownloadReleases(releaseDir string, remoteReleases []RemoteRelease, opts DownloadOptions) (LocalReleaseSet, error) {
+	source.logger.Printf("downloading %d objects from github...", len(remoteReleases))
+
+	return downloadReleasesConcurrently(remoteReleases, opts.workers(), func(release RemoteRelease) (LocalRelease, error) {
+		filePath := filepath.Join(releaseDir, release.StandardizedFilename())
+
+		sum, err := downloadFile(filePath, source.String(), opts.expectedSum(release), func(file downloadTarget, offset int64) error {
+			req, err := source.newRequest(release.RemotePath())
+			if err != nil {
+				return err
+			}
+			// asks the assets API for the file rather than its description
+			req.Header.Set("Accept", "application/octet-stream")
+
+			return downloadHTTP(source.logger, req, file, offset)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return withSHA1(release.AsLocal(filePath), sum), nil
+	})
+}
+
+// repos returns the repositories that may hold the named release: the
+// configured repo, or else the usual names of a BOSH release repository.
+func (source GitHubReleaseSource) repos(releaseName string) []string {
+	if source.Repo != "" {
+		return []string{source.Repo}
+	}
+
+	repos := make([]string, 0, len(suffixes))
+	for _, suf := range suffixes {
+		repos = append(repos, releaseName+suf)
+	}
+	return repos
+}
+
+func (source GitHubReleaseSource) assetRegex() (*regexp.Regexp, error) {
+	expr := source.Regex
+	if expr == "" {
+		expr = defaultGitHubAssetRegex
+	}
+
+	assetRegex, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile github asset regex %q: %s", expr, err)
+	}
+	return assetRegex, nil
+}
+
+func (source GitHubReleaseSource) releaseForTag(repo, version string) (gitHubRelease, bool, error) {
+	for _, tag := range []string{"v" + version, version} {
+		var release gitHubRelease
+		found, err := source.get(fmt.Sprintf("/repos/%s/%s/releases/tags/%s", source.Org, repo, tag), &release)
+		if err != nil || found {
+			return release, found, err
+		}
+	}
+	return gitHubRelease{}, false, nil
+}
+
+// releases lists all the releases of the repo, following the Link header of
+// each page of the GitHub API to the next one.
+func (source GitHubReleaseSource) releases(repo string) ([]gitHubRelease, error) {
+	var releases []gitHubRelease
+	url := fmt.Sprintf("%s/repos/%s/%s/releases?per_page=100", source.serverURI, source.Org, repo)
+	for url != "" {
+		var page []gitHubRelease
+		found, next, err := source.getPage(url, &page)
+		if err != nil || !found {
+			return releases, err
+		}
+		releases = append(releases, page...)
+		url = next
+	}
+	return releases, nil
+}
+
+func (source GitHubReleaseSource) get(path string, v interface{}) (bool, error) {
+	found, _, err := source.getPage(source.serverURI+path, v)
+	return found, err
+}
+
+// getPage decodes the response from url into v and returns the URL of the next
+// page, if there is one.
+func (source GitHubReleaseSource) getPage(url string, v interface{}) (bool, string, error) {
+	req, err := source.newRequest(url)
+	if err != nil {
+		return false, "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, "", fmt.Errorf("github API is down with error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, "", nil
+	}
+	if resp.StatusCode >= 300 {
+		return false, "", (*ResponseStatusCodeError)(resp)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return false, "", err
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return false, "", fmt.Errorf("failed to parse response from %s: %s", req.URL, err)
+	}
+	return true, nextPageURL(resp.Header.Get("Link")), nil
+}
+
+var gitHubNextPageLink = regexp.MustCompile(`<([^>]*)>\s*;\s*rel="next"`)
+
+// nextPageURL finds the rel="next" URL in a Link header such as
+// <https://api.github.com/repositories/1/releases?page=2>; rel="next",
+// <https://api.github.com/repositories/1/releases?page=5>; rel="last".
+func nextPageURL(link string) string {
+	match := gitHubNextPageLink.FindStringSubmatch(link)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+func (source GitHubReleaseSource) newRequest(url string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if source.Token != "" {
+		req.Header.Set("Authorization", "token "+source.Token)
+	}
+	return req, nil
+}
+
+func findGitHubAsset(assets []gitHubAsset, assetRegex *regexp.Regexp) (gitHubAsset, bool) {
+	for _, asset := range assets {
+		if assetRegex.MatchString(asset.Name) {
+			return asset, true
+		}
+	}
+	return gitHubAsset{}, false
+}
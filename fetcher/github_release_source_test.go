@@ -0,0 +1,228 @@
+package fetcher_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/Masterminds/semver"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	. "github.com/pivotal-cf/kiln/fetcher"
+	"github.com/pivotal-cf/kiln/internal/cargo"
+)
+
+var _ = Describe("GitHubReleaseSource", func() {
+	var (
+		testServer    *ghttp.Server
+		releaseSource *GitHubReleaseSource
+		config        GitHubReleaseSourceConfig
+	)
+
+	BeforeEach(func() {
+		testServer = ghttp.NewServer()
+		testServer.AllowUnhandledRequests = true
+		testServer.UnhandledRequestStatusCode = http.StatusNotFound
+		config = GitHubReleaseSourceConfig{Org: "cloudfoundry"}
+	})
+
+	JustBeforeEach(func() {
+		releaseSource = NewGitHubReleaseSource(log.New(GinkgoWriter, "", 0), testServer.URL(), config)
+	})
+
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	releaseJSON := func(tag string, assets ...string) string {
+		var assetsJSON string
+		for i, asset := range assets {
+			if i > 0 {
+				assetsJSON += ","
+			}
+			assetsJSON += fmt.Sprintf(`{"name": %q, "url": "%s/assets/%s"}`, asset, testServer.URL(), asset)
+		}
+		return fmt.Sprintf(`{"tag_name": %q, "assets": [%s]}`, tag, assetsJSON)
+	}
+
+	Describe("GetMatchedReleases", func() {
+		var desiredReleaseSet ReleaseRequirementSet
+
+		BeforeEach(func() {
+			desiredReleaseSet = ReleaseRequirementSet{
+				ReleaseID{Name: "uaa", Version: "74.16.0"}: ReleaseRequirement{Name: "uaa", Version: "74.16.0"},
+			}
+			testServer.RouteToHandler("GET", "/repos/cloudfoundry/uaa-release/releases/tags/v74.16.0",
+				ghttp.RespondWith(http.StatusOK, releaseJSON("v74.16.0", "checksums.txt", "uaa-release-74.16.0.tgz")))
+		})
+
+		It("finds the release asset in the repository named after the release", func() {
+			matches, err := releaseSource.GetMatchedReleases(desiredReleaseSet, cargo.Stemcell{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(matches).To(ConsistOf(BuiltRelease{
+				ID:   ReleaseID{Name: "uaa", Version: "74.16.0"},
+				Path: testServer.URL() + "/assets/uaa-release-74.16.0.tgz",
+			}))
+		})
+
+		Context("when a repo and token are configured", func() {
+			BeforeEach(func() {
+				config.Repo = "identity"
+				config.GitHubToken = "some-token"
+				testServer.RouteToHandler("GET", "/repos/cloudfoundry/identity/releases/tags/v74.16.0", ghttp.CombineHandlers(
+					ghttp.VerifyHeaderKV("Authorization", "token some-token"),
+					ghttp.RespondWith(http.StatusOK, releaseJSON("v74.16.0", "uaa-74.16.0.tgz")),
+				))
+			})
+
+			It("looks for the release in that repository", func() {
+				matches, err := releaseSource.GetMatchedReleases(desiredReleaseSet, cargo.Stemcell{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(matches).To(ConsistOf(BuiltRelease{
+					ID:   ReleaseID{Name: "uaa", Version: "74.16.0"},
+					Path: testServer.URL() + "/assets/uaa-74.16.0.tgz",
+				}))
+			})
+		})
+
+		Context("when an asset regex is configured", func() {
+			BeforeEach(func() {
+				config.Regex = `^uaa-release-.*-compiled\.tgz$`
+			})
+
+			It("only matches assets with that name", func() {
+				matches, err := releaseSource.GetMatchedReleases(desiredReleaseSet, cargo.Stemcell{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(matches).To(BeEmpty())
+			})
+		})
+
+		Context("when the release does not exist", func() {
+			BeforeEach(func() {
+				desiredReleaseSet = ReleaseRequirementSet{
+					ReleaseID{Name: "missing", Version: "1.0.0"}: ReleaseRequirement{Name: "missing", Version: "1.0.0"},
+				}
+			})
+
+			It("does not match anything", func() {
+				matches, err := releaseSource.GetMatchedReleases(desiredReleaseSet, cargo.Stemcell{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(matches).To(BeEmpty())
+			})
+		})
+
+		Context("when the API returns an error", func() {
+			BeforeEach(func() {
+				testServer.RouteToHandler("GET", "/repos/cloudfoundry/uaa-release/releases/tags/v74.16.0",
+					ghttp.RespondWith(http.StatusForbidden, `{"message": "API rate limit exceeded"}`))
+			})
+
+			It("returns an error", func() {
+				_, err := releaseSource.GetMatchedReleases(desiredReleaseSet, cargo.Stemcell{})
+				Expect(err).To(MatchError(ContainSubstring("got status 403")))
+			})
+		})
+
+		Context("when the asset regex is invalid", func() {
+			BeforeEach(func() {
+				config.Regex = `(`
+			})
+
+			It("returns an error", func() {
+				_, err := releaseSource.GetMatchedReleases(desiredReleaseSet, cargo.Stemcell{})
+				Expect(err).To(MatchError(ContainSubstring("failed to compile github asset regex")))
+			})
+		})
+	})
+
+	Describe("FindReleaseVersion", func() {
+		BeforeEach(func() {
+			testServer.RouteToHandler("GET", "/repos/cloudfoundry/uaa-release/releases",
+				ghttp.RespondWith(http.StatusOK, fmt.Sprintf(`[%s, %s, %s, {"tag_name": "v75.0.0", "prerelease": true, "assets": []}]`,
+					releaseJSON("v74.15.0", "uaa-release-74.15.0.tgz"),
+					releaseJSON("v74.16.0", "uaa-release-74.16.0.tgz"),
+					releaseJSON("v73.0.0", "uaa-release-73.0.0.tgz"),
+				)))
+		})
+
+		It("returns the newest release matching the constraint", func() {
+			constraint, err := semver.NewConstraint("~74")
+			Expect(err).NotTo(HaveOccurred())
+
+			release, found, err := releaseSource.FindReleaseVersion(ReleaseVersionConstraint{Name: "uaa", Constraint: constraint})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(release).To(Equal(BuiltRelease{
+				ID:   ReleaseID{Name: "uaa", Version: "74.16.0"},
+				Path: testServer.URL() + "/assets/uaa-release-74.16.0.tgz",
+			}))
+		})
+
+		Context("when the releases span more than one page", func() {
+			BeforeEach(func() {
+				testServer.RouteToHandler("GET", "/repos/cloudfoundry/uaa-release/releases", ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/repos/cloudfoundry/uaa-release/releases", "per_page=100"),
+					ghttp.RespondWith(http.StatusOK, fmt.Sprintf(`[%s]`, releaseJSON("v74.15.0", "uaa-release-74.15.0.tgz")), http.Header{
+						"Link": {fmt.Sprintf(`<%[1]s/repositories/1/releases?per_page=100&page=2>; rel="next", <%[1]s/repositories/1/releases?per_page=100&page=2>; rel="last"`, testServer.URL())},
+					}),
+				))
+				testServer.RouteToHandler("GET", "/repositories/1/releases", ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/repositories/1/releases", "per_page=100&page=2"),
+					ghttp.RespondWith(http.StatusOK, fmt.Sprintf(`[%s]`, releaseJSON("v74.16.0", "uaa-release-74.16.0.tgz")), http.Header{
+						"Link": {fmt.Sprintf(`<%[1]s/repos/cloudfoundry/uaa-release/releases?per_page=100>; rel="first", <%[1]s/repos/cloudfoundry/uaa-release/releases?per_page=100>; rel="prev"`, testServer.URL())},
+					}),
+				))
+			})
+
+			It("follows the next links to the releases on the later pages", func() {
+				constraint, err := semver.NewConstraint("~74")
+				Expect(err).NotTo(HaveOccurred())
+
+				release, found, err := releaseSource.FindReleaseVersion(ReleaseVersionConstraint{Name: "uaa", Constraint: constraint})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(found).To(BeTrue())
+				Expect(release.ReleaseID()).To(Equal(ReleaseID{Name: "uaa", Version: "74.16.0"}))
+				Expect(testServer.ReceivedRequests()).To(HaveLen(2))
+			})
+		})
+	})
+
+	Describe("DownloadReleases", func() {
+		var releaseDir string
+
+		BeforeEach(func() {
+			var err error
+			releaseDir, err = ioutil.TempDir("", "kiln-github-release-source-test")
+			Expect(err).NotTo(HaveOccurred())
+
+			config.GitHubToken = "some-token"
+			testServer.RouteToHandler("GET", "/assets/uaa-release-74.16.0.tgz", ghttp.CombineHandlers(
+				ghttp.VerifyHeaderKV("Accept", "application/octet-stream"),
+				ghttp.VerifyHeaderKV("Authorization", "token some-token"),
+				ghttp.RespondWith(http.StatusOK, "uaa-release-contents"),
+			))
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(releaseDir)).To(Succeed())
+		})
+
+		It("downloads the release assets into the release dir", func() {
+			releaseID := ReleaseID{Name: "uaa", Version: "74.16.0"}
+			localReleases, err := releaseSource.DownloadReleases(releaseDir, []RemoteRelease{
+				BuiltRelease{ID: releaseID, Path: testServer.URL() + "/assets/uaa-release-74.16.0.tgz"},
+			}, DownloadOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			releasePath := filepath.Join(releaseDir, "uaa-74.16.0.tgz")
+			Expect(localReleases).To(Equal(LocalReleaseSet{
+				releaseID: BuiltRelease{ID: releaseID, Path: releasePath, SHA1: "6a309be372f1b747dd0bcdb7fa59adc2d21e6264"},
+			}))
+			Expect(ioutil.ReadFile(releasePath)).To(BeEquivalentTo("uaa-release-contents"))
+		})
+	})
+})
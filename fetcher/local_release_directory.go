@@ -1,14 +1,10 @@
 package fetcher
 
 import (
-	"crypto/sha1"
-	"encoding/hex"
 	"fmt"
-	"io"
 	"log"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/pivotal-cf/kiln/builder"
 	"github.com/pivotal-cf/kiln/internal/baking"
@@ -39,7 +35,7 @@ func (l LocalReleaseDirectory) GetLocalReleases(releasesDir string) (LocalReleas
 		releaseManifest := release.(builder.ReleaseManifest)
 		id := ReleaseID{Name: releaseManifest.Name, Version: releaseManifest.Version}
 
-		var rel LocalRelease
+		var rel RemoteRelease
 		// see implementation of ReleaseManifestReader.Read for why we can assume that
 		// stemcell metadata are empty strings
 		if releaseManifest.StemcellOS != "" && releaseManifest.StemcellVersion != "" {
@@ -55,7 +51,7 @@ func (l LocalReleaseDirectory) GetLocalReleases(releasesDir string) (LocalReleas
 				Path: filepath.Join(releasesDir, releaseManifest.File),
 			}
 		}
-		outputReleases[id] = rel
+		outputReleases[ReleaseSetKey(rel)] = rel.(LocalRelease)
 	}
 	return outputReleases, nil
 }
@@ -90,85 +86,66 @@ func (l LocalReleaseDirectory) DeleteExtraReleases(extraReleaseSet LocalReleaseS
 	return nil
 }
 
-func (l LocalReleaseDirectory) deleteReleases(releasesToDelete LocalReleaseSet) error {
-	for releaseID, release := range releasesToDelete {
-		err := os.Remove(release.LocalPath())
-
-		if err != nil {
-			l.logger.Printf("error removing release %s: %v\n", releaseID.Name, err)
-			return fmt.Errorf("failed to delete release %s", releaseID.Name)
+// VerifyChecksums checks the releases already in the releases directory
+// against the SHA1 in Kilnfile.lock, as they were not verified while they
+// were downloaded. The releases that do not match are removed and returned, so
+// that they can be fetched again.
+func (l LocalReleaseDirectory) VerifyChecksums(releases LocalReleaseSet, kilnfileLock cargo.KilnfileLock) (LocalReleaseSet, error) {
+	checksums := NewReleaseChecksums(kilnfileLock)
+
+	// NOTE: a built release may satisfy the requirements for several
+	// stemcells, its tarball is only checked and removed once
+	mismatched, toDelete := make(LocalReleaseSet), make(LocalReleaseSet)
+	checked := make(map[string]bool)
+	for releaseID, release := range releases {
+		remote, ok := release.(RemoteRelease)
+		if !ok {
+			continue
 		}
 
-		l.logger.Printf("removed release %s\n", releaseID.Name)
-	}
-
-	return nil
-}
-
-func (l LocalReleaseDirectory) VerifyChecksums(downloadedReleaseSet LocalReleaseSet, kilnfileLock cargo.KilnfileLock) error {
-	if len(downloadedReleaseSet) == 0 {
-		return nil
-	}
-
-	l.logger.Printf("verifying checksums")
-
-	var badReleases []string
-
-	var errs []error
-	for releaseID, release := range downloadedReleaseSet {
-		expectedSum, found := findExpectedSum(releaseID, kilnfileLock.Releases)
-
-		if !found {
-			return fmt.Errorf("release %s is not in Kilnfile.lock file", releaseID.Name)
-		}
+		expectedSum, _ := checksums.ExpectedSum(remote)
 		if expectedSum == "" {
 			continue
 		}
 
-		sum, err := calculateSum(release.LocalPath())
-		if err != nil {
-			return fmt.Errorf("error while calculating checksum: %s", err)
-		}
+		matches, seen := checked[release.LocalPath()]
+		if !seen {
+			sum, err := localSHA1(release)
+			if err != nil {
+				return nil, fmt.Errorf("error while calculating checksum: %s", err)
+			}
 
-		if expectedSum != sum {
-			l.deleteReleases(LocalReleaseSet{releaseID: release})
-			badReleases = append(badReleases, fmt.Sprintf("%+v", release.LocalPath()))
+			matches = sum == expectedSum
+			checked[release.LocalPath()] = matches
+			if !matches {
+				l.logger.Printf("%s has SHA1 %s, but Kilnfile.lock expects %s\n", release.LocalPath(), sum, expectedSum)
+				toDelete[releaseID] = release
+			}
 		}
-	}
 
-	if len(errs) > 0 {
-		return multipleErrors(errs)
+		if !matches {
+			mismatched[releaseID] = release
+		}
 	}
 
-	if len(badReleases) != 0 {
-		return fmt.Errorf("These downloaded releases do not match the checksum and were removed:\n%s", strings.Join(badReleases, "\n"))
+	if err := l.deleteReleases(toDelete); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return mismatched, nil
 }
 
-func findExpectedSum(release ReleaseID, desiredReleases []cargo.Release) (string, bool) {
-	for _, r := range desiredReleases {
-		if r.Name == release.Name {
-			return r.SHA1, true
-		}
-	}
-
-	return "", false
-}
+func (l LocalReleaseDirectory) deleteReleases(releasesToDelete LocalReleaseSet) error {
+	for releaseID, release := range releasesToDelete {
+		err := os.Remove(release.LocalPath())
 
-func calculateSum(releasePath string) (string, error) {
-	f, err := os.Open(releasePath)
-	if err != nil {
-		return "", err
-	}
-	defer f.Close()
+		if err != nil {
+			l.logger.Printf("error removing release %s: %v\n", releaseID.Name, err)
+			return fmt.Errorf("failed to delete release %s", releaseID.Name)
+		}
 
-	h := sha1.New()
-	_, err = io.Copy(h, f)
-	if err != nil {
-		return "", err
+		l.logger.Printf("removed release %s\n", releaseID.Name)
 	}
 
-	return hex.EncodeToString(h.Sum(nil)), nil
+	return nil
 }
@@ -57,8 +57,9 @@ var _ = Describe("LocalReleaseDirectory", func() {
 				Expect(releases).To(HaveLen(1))
 				Expect(releases).To(HaveKeyWithValue(
 					ReleaseID{
-						Name:    "some-release",
-						Version: "1.2.3",
+						Name:       "some-release",
+						Version:    "1.2.3",
+						StemcellOS: "some-os",
 					},
 					CompiledRelease{
 						ID: ReleaseID{
@@ -138,116 +139,65 @@ var _ = Describe("LocalReleaseDirectory", func() {
 
 	Describe("VerifyChecksums", func() {
 		var (
-			downloadedReleases map[ReleaseID]LocalRelease
-			kilnfileLock       cargo.KilnfileLock
-			goodFilePath       string
-			badFilePath        string
-			err                error
+			goodFilePath, badFilePath string
+			goodRelease, badRelease   BuiltRelease
+			kilnfileLock              cargo.KilnfileLock
 		)
 
 		BeforeEach(func() {
-			goodFilePath = filepath.Join(releasesDir, "good-1.2.3-ubuntu-xenial-190.0.0.tgz")
-			err = ioutil.WriteFile(goodFilePath, []byte("abc"), 0644)
-			Expect(err).NotTo(HaveOccurred())
+			goodFilePath = filepath.Join(releasesDir, "good-1.2.3.tgz")
+			Expect(ioutil.WriteFile(goodFilePath, []byte("good-contents"), 0644)).To(Succeed())
+			badFilePath = filepath.Join(releasesDir, "bad-1.2.3.tgz")
+			Expect(ioutil.WriteFile(badFilePath, []byte("bad-contents"), 0644)).To(Succeed())
 
-			badFilePath = filepath.Join(releasesDir, "bad-1.2.3-ubuntu-xenial-190.0.0.tgz")
-			err = ioutil.WriteFile(badFilePath, []byte("some bad sha file"), 0644)
-			Expect(err).NotTo(HaveOccurred())
+			goodRelease = BuiltRelease{ID: ReleaseID{Name: "good", Version: "1.2.3"}, Path: goodFilePath}
+			badRelease = BuiltRelease{ID: ReleaseID{Name: "bad", Version: "1.2.3"}, Path: badFilePath}
 
 			kilnfileLock = cargo.KilnfileLock{
 				Releases: []cargo.Release{
-					{
-						Name:    "good",
-						Version: "1.2.3",
-						SHA1:    "a9993e364706816aba3e25717850c26c9cd0d89d", // sha1 for string "abc"
-					},
-					{
-						Name:    "bad",
-						Version: "1.2.3",
-						SHA1:    "a9993e364706816aba3e25717850c26c9cd0d89d", // sha1 for string "abc"
-					},
-				},
-				Stemcell: cargo.Stemcell{
-					OS:      "ubuntu-xenial",
-					Version: "190.0.0",
+					{Name: "good", Version: "1.2.3", SHA1: "7641665c6193d6acc45d3713428260e07a094fce"},
+					{Name: "bad", Version: "1.2.3", SHA1: "some-other-sha1"},
 				},
 			}
 		})
 
-		Context("when all the checksums on the downloaded releases match their checksums in Kilnfile.lock", func() {
-			It("succeeds", func() {
-				downloadedReleases = map[ReleaseID]LocalRelease{
-					ReleaseID{Name: "good", Version: "1.2.3"}: CompiledRelease{
-						ID:              ReleaseID{Name: "good", Version: "1.2.3"},
-						StemcellOS:      "ubuntu-xenial",
-						StemcellVersion: "190.0.0",
-						Path:            goodFilePath,
-					}}
-				err := localReleaseDirectory.VerifyChecksums(downloadedReleases, kilnfileLock)
-				Expect(err).NotTo(HaveOccurred())
-			})
+		It("removes and returns the releases that do not match Kilnfile.lock", func() {
+			mismatched, err := localReleaseDirectory.VerifyChecksums(LocalReleaseSet{
+				goodRelease.ID: goodRelease,
+				badRelease.ID:  badRelease,
+			}, kilnfileLock)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(mismatched).To(Equal(LocalReleaseSet{badRelease.ID: badRelease}))
+			Expect(goodFilePath).To(BeAnExistingFile())
+			Expect(badFilePath).NotTo(BeAnExistingFile())
 		})
 
-		Context("when at least one checksum on the downloaded releases does not match the checksum in Kilnfile.lock", func() {
-			It("returns an error and deletes the bad release", func() {
-				downloadedReleases = map[ReleaseID]LocalRelease{
-					ReleaseID{Name: "bad", Version: "1.2.3"}: CompiledRelease{
-						ID:              ReleaseID{Name: "bad", Version: "1.2.3"},
-						StemcellOS:      "ubuntu-xenial",
-						StemcellVersion: "190.0.0",
-						Path:            badFilePath,
-					}}
-				err := localReleaseDirectory.VerifyChecksums(downloadedReleases, kilnfileLock)
-				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(ContainSubstring("These downloaded releases do not match the checksum"))
+		Context("when Kilnfile.lock does not have a checksum for a release", func() {
+			It("keeps the release", func() {
+				kilnfileLock.Releases[1].SHA1 = ""
+
+				mismatched, err := localReleaseDirectory.VerifyChecksums(LocalReleaseSet{badRelease.ID: badRelease}, kilnfileLock)
+				Expect(err).NotTo(HaveOccurred())
 
-				_, err = os.Stat(badFilePath)
-				Expect(os.IsNotExist(err)).To(BeTrue())
+				Expect(mismatched).To(BeEmpty())
+				Expect(badFilePath).To(BeAnExistingFile())
 			})
 		})
 
-		Context("when no checksum is specified for a release (and the release file is not in the normal place)", func() {
-			var (
-				nonStandardFilePath string
-			)
+		Context("when a built release satisfies the requirements for two stemcells", func() {
+			It("checks and removes its tarball once and returns it for both", func() {
+				xenialID := ReleaseID{Name: "bad", Version: "1.2.3", StemcellOS: "ubuntu-xenial"}
+				windowsID := ReleaseID{Name: "bad", Version: "1.2.3", StemcellOS: "windows"}
 
-			BeforeEach(func() {
-				nonStandardFilePath = filepath.Join(releasesDir, "uaa-release-73.0.0.tgz") // bosh.io name, different from s3
-				err = ioutil.WriteFile(nonStandardFilePath, []byte("some release file"), 0644)
+				mismatched, err := localReleaseDirectory.VerifyChecksums(LocalReleaseSet{
+					xenialID:  badRelease,
+					windowsID: badRelease,
+				}, kilnfileLock)
 				Expect(err).NotTo(HaveOccurred())
-				kilnfileLock = cargo.KilnfileLock{
-					Releases: []cargo.Release{
-						{
-							Name:    "good",
-							Version: "1.2.3",
-							SHA1:    "a9993e364706816aba3e25717850c26c9cd0d89d",
-						},
-						{Name: "uaa", Version: "7.3.0"},
-					},
-					Stemcell: cargo.Stemcell{
-						OS:      "ubuntu-xenial",
-						Version: "190.0.0",
-					},
-				}
-			})
 
-			It("does not validate its checksum", func() {
-				downloadedReleases = map[ReleaseID]LocalRelease{
-					ReleaseID{Name: "good", Version: "1.2.3"}: CompiledRelease{
-						ID:              ReleaseID{Name: "good", Version: "1.2.3"},
-						StemcellOS:      "ubuntu-xenial",
-						StemcellVersion: "190.0.0",
-						Path:            goodFilePath,
-					},
-					ReleaseID{Name: "uaa", Version: "7.3.0"}: CompiledRelease{
-						ID:              ReleaseID{Name: "uaa", Version: "7.3.0"},
-						StemcellOS:      "ubuntu-xenial",
-						StemcellVersion: "190.0.0",
-						Path:            nonStandardFilePath,
-					},
-				}
-				err := localReleaseDirectory.VerifyChecksums(downloadedReleases, kilnfileLock)
-				Expect(err).NotTo(HaveOccurred())
+				Expect(mismatched).To(Equal(LocalReleaseSet{xenialID: badRelease, windowsID: badRelease}))
+				Expect(badFilePath).NotTo(BeAnExistingFile())
 			})
 		})
 	})
@@ -1,7 +1,33 @@
 package fetcher
 
+// ReleaseID names a release. Release sources key releases by name and version
+// only, while the keys of release sets also have the stemcell OS of release
+// requirements and compiled releases, so that a release locked against two
+// stemcell OSes is required and fetched once for each.
 type ReleaseID struct {
 	Name, Version string
+	StemcellOS    string
+}
+
+func (id ReleaseID) withoutStemcell() ReleaseID {
+	return ReleaseID{Name: id.Name, Version: id.Version}
+}
+
+// matches reports whether two keys name the same release. A key without a
+// stemcell OS, such as that of a built release, matches the release for any
+// stemcell OS.
+func (id ReleaseID) matches(other ReleaseID) bool {
+	return id.withoutStemcell() == other.withoutStemcell() &&
+		(id.StemcellOS == "" || other.StemcellOS == "" || id.StemcellOS == other.StemcellOS)
+}
+
+// ReleaseSetKey is the key of the release in a LocalReleaseSet.
+func ReleaseSetKey(release RemoteRelease) ReleaseID {
+	id := release.ReleaseID()
+	if compiled, ok := release.(CompiledRelease); ok {
+		id.StemcellOS = compiled.StemcellOS
+	}
+	return id
 }
 
 //go:generate counterfeiter -o ./fakes/local_release.go --fake-name LocalRelease . LocalRelease
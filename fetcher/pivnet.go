@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
 	"strings"
 )
 
@@ -82,6 +83,81 @@ func (pivnet *Pivnet) Versions(slug string) ([]string, error) {
 	return versions, nil
 }
 
+// PivnetProductFile is a file attached to a release on network.pivotal.io.
+type PivnetProductFile struct {
+	ID           int    `json:"id"`
+	AWSObjectKey string `json:"aws_object_key"`
+	SHA256       string `json:"sha256"`
+	Links        struct {
+		Download struct {
+			Href string `json:"href"`
+		} `json:"download"`
+	} `json:"_links"`
+}
+
+// ProductFiles lists the files of the release of the product with the given
+// version.
+func (pivnet *Pivnet) ProductFiles(slug, version string) ([]PivnetProductFile, error) {
+	if slug == "" {
+		return nil, ErrProductSlugMustNotBeEmpty
+	}
+
+	var releases struct {
+		Releases []struct {
+			ID      int    `json:"id"`
+			Version string `json:"version"`
+		} `json:"releases"`
+	}
+	if err := pivnet.getJSON(path.Join("/api/v2/products", slug, "releases"), &releases); err != nil {
+		return nil, err
+	}
+
+	for _, release := range releases.Releases {
+		if release.Version != version {
+			continue
+		}
+
+		var files struct {
+			ProductFiles []PivnetProductFile `json:"product_files"`
+		}
+		err := pivnet.getJSON(path.Join("/api/v2/products", slug, "releases", strconv.Itoa(release.ID), "product_files"), &files)
+		if err != nil {
+			return nil, err
+		}
+		return files.ProductFiles, nil
+	}
+
+	return nil, fmt.Errorf("%s has no release with version %s", slug, version)
+}
+
+func (pivnet *Pivnet) getJSON(apiPath string, v interface{}) error {
+	locator := url.URL{
+		Scheme: "https",
+		Host:   "network.pivotal.io",
+		Path:   apiPath,
+	}
+
+	req, err := http.NewRequest(http.MethodGet, locator.String(), nil)
+	if err != nil {
+		return ErrCouldNotCreateRequest
+	}
+
+	response, err := pivnet.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		if response.StatusCode == http.StatusUnauthorized {
+			return fmt.Errorf("could not make pivnet request: endpoint requires authorization (set --pivotal-network-token with UAA token)")
+		}
+		return fmt.Errorf("request was not successful, response had status %s (%d)", response.Status, response.StatusCode)
+	}
+
+	return json.NewDecoder(response.Body).Decode(v)
+}
+
 // Do sets required headers for requests to network.pivotal.io.
 // If pivnet.Client is nil, it uses http.DefaultClient.
 func (pivnet Pivnet) Do(req *http.Request) (*http.Response, error) {
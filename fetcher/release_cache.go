@@ -0,0 +1,329 @@
+package fetcher
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pivotal-cf/kiln/internal/cargo"
+)
+
+const builtReleaseCacheKey = "built"
+
+// CachedRelease is a release tarball stored in the release cache. Tarballs are
+// kept at <cache>/<name>/<version>/<stemcell os>-<stemcell version>/<sha1>.tgz
+// for compiled releases and at <cache>/<name>/<version>/built/<sha1>.tgz for
+// built releases.
+type CachedRelease struct {
+	ID              ReleaseID
+	StemcellOS      string
+	StemcellVersion string
+	SHA1            string
+	Path            string
+	Size            int64
+	LastUsed        time.Time
+}
+
+func (cr CachedRelease) Compiled() bool {
+	return cr.StemcellOS != ""
+}
+
+func (cr CachedRelease) remoteRelease() RemoteRelease {
+	if cr.Compiled() {
+		return CompiledRelease{ID: cr.ID, StemcellOS: cr.StemcellOS, StemcellVersion: cr.StemcellVersion, Path: cr.Path, SHA1: cr.SHA1}
+	}
+	return BuiltRelease{ID: cr.ID, Path: cr.Path, SHA1: cr.SHA1}
+}
+
+type ReleaseCache struct {
+	logger *log.Logger
+}
+
+func NewReleaseCache(logger *log.Logger) ReleaseCache {
+	return ReleaseCache{
+		logger: logger,
+	}
+}
+
+// DefaultReleaseCacheDirectory returns $KILN_CACHE_DIR when it is set and
+// ~/.kiln/cache otherwise.
+func DefaultReleaseCacheDirectory() (string, error) {
+	if dir := os.Getenv("KILN_CACHE_DIR"); dir != "" {
+		return dir, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the release cache directory: %s", err)
+	}
+
+	return filepath.Join(home, ".kiln", "cache"), nil
+}
+
+func (c ReleaseCache) List(cacheDir string) ([]CachedRelease, error) {
+	var releases []CachedRelease
+
+	err := filepath.Walk(cacheDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == cacheDir {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != ".tgz" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(cacheDir, path)
+		if err != nil {
+			return err
+		}
+
+		parts := strings.Split(rel, string(filepath.Separator))
+		if len(parts) != 4 {
+			return nil
+		}
+
+		release := CachedRelease{
+			ID:       ReleaseID{Name: parts[0], Version: parts[1]},
+			SHA1:     strings.TrimSuffix(parts[3], ".tgz"),
+			Path:     path,
+			Size:     info.Size(),
+			LastUsed: info.ModTime(),
+		}
+		if parts[2] != builtReleaseCacheKey {
+			i := strings.LastIndex(parts[2], "-")
+			if i < 0 {
+				return nil
+			}
+			release.StemcellOS, release.StemcellVersion = parts[2][:i], parts[2][i+1:]
+		}
+
+		releases = append(releases, release)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list release cache %s: %s", cacheDir, err)
+	}
+
+	sort.Slice(releases, func(i, j int) bool {
+		return releases[i].Path < releases[j].Path
+	})
+
+	return releases, nil
+}
+
+// FindReleases returns the cached releases satisfying the requirements. A
+// compiled release for the required stemcell is preferred over a built
+// release. When Kilnfile.lock has a checksum for a release, only a cached
+// tarball with that checksum is used.
+func (c ReleaseCache) FindReleases(cacheDir string, requirements ReleaseRequirementSet, kilnfileLock cargo.KilnfileLock) (map[ReleaseID]CachedRelease, error) {
+	cached, err := c.List(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+
+	checksums := NewReleaseChecksums(kilnfileLock)
+
+	found := make(map[ReleaseID]CachedRelease)
+	for rID, requirement := range requirements {
+		expectedSum, _ := checksums.sum(requirement.releaseID(), requirement.StemcellOS, requirement.StemcellVersion)
+
+		if release, ok := findCachedRelease(cached, requirement, expectedSum); ok {
+			found[rID] = release
+		}
+	}
+
+	return found, nil
+}
+
+// GetReleases links the cached releases found by FindReleases into
+// releasesDir.
+func (c ReleaseCache) GetReleases(cacheDir, releasesDir string, requirements ReleaseRequirementSet, kilnfileLock cargo.KilnfileLock) (LocalReleaseSet, error) {
+	found, err := c.FindReleases(cacheDir, requirements, kilnfileLock)
+	if err != nil {
+		return nil, err
+	}
+
+	localReleases := make(LocalReleaseSet)
+	for rID, release := range found {
+		remote := release.remoteRelease()
+		path := filepath.Join(releasesDir, remote.StandardizedFilename())
+		if err := linkOrCopy(release.Path, path); err != nil {
+			return nil, fmt.Errorf("failed to copy %s from the release cache: %s", release.Path, err)
+		}
+
+		now := time.Now()
+		_ = os.Chtimes(release.Path, now, now)
+
+		c.logger.Printf("using cached release %s (%s)\n", rID.Name, rID.Version)
+		localReleases[rID] = remote.AsLocal(path)
+	}
+
+	return localReleases, nil
+}
+
+// AddReleases stores the given releases in the cache. The SHA1 taken while a
+// release was downloaded is reused, so that the tarball is not read again.
+func (c ReleaseCache) AddReleases(cacheDir string, releases LocalReleaseSet) error {
+	for rID, release := range releases {
+		stemcellKey := builtReleaseCacheKey
+		if compiled, ok := release.(CompiledRelease); ok {
+			stemcellKey = compiled.StemcellOS + "-" + compiled.StemcellVersion
+		}
+
+		sum, err := localSHA1(release)
+		if err != nil {
+			return fmt.Errorf("error while calculating checksum: %s", err)
+		}
+
+		path := filepath.Join(cacheDir, rID.Name, rID.Version, stemcellKey, sum+".tgz")
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to add %s to the release cache: %s", release.LocalPath(), err)
+		}
+
+		if err := linkOrCopy(release.LocalPath(), path); err != nil {
+			return fmt.Errorf("failed to add %s to the release cache: %s", release.LocalPath(), err)
+		}
+	}
+
+	return nil
+}
+
+// Prune removes the cached releases that have not been used since before the
+// given time.
+func (c ReleaseCache) Prune(cacheDir string, unusedSince time.Time) ([]CachedRelease, error) {
+	cached, err := c.List(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []CachedRelease
+	for _, release := range cached {
+		if !release.LastUsed.Before(unusedSince) {
+			continue
+		}
+
+		if err := c.remove(cacheDir, release); err != nil {
+			return pruned, err
+		}
+		pruned = append(pruned, release)
+	}
+
+	return pruned, nil
+}
+
+// Verify recalculates the checksum of each cached release and removes the
+// releases whose contents no longer match.
+func (c ReleaseCache) Verify(cacheDir string) ([]CachedRelease, error) {
+	cached, err := c.List(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var corrupt []CachedRelease
+	for _, release := range cached {
+		sum, err := calculateSum(release.Path)
+		if err != nil {
+			return corrupt, fmt.Errorf("error while calculating checksum: %s", err)
+		}
+		if sum == release.SHA1 {
+			continue
+		}
+
+		if err := c.remove(cacheDir, release); err != nil {
+			return corrupt, err
+		}
+		corrupt = append(corrupt, release)
+	}
+
+	return corrupt, nil
+}
+
+func (c ReleaseCache) remove(cacheDir string, release CachedRelease) error {
+	if err := os.Remove(release.Path); err != nil {
+		return fmt.Errorf("failed to remove %s from the release cache: %s", release.Path, err)
+	}
+
+	// remove the directories left empty, stopping at the first one that is not
+	for dir := filepath.Dir(release.Path); dir != cacheDir && strings.HasPrefix(dir, cacheDir); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+
+	return nil
+}
+
+func findCachedRelease(cached []CachedRelease, requirement ReleaseRequirement, expectedSum string) (CachedRelease, bool) {
+	var built, compiled []CachedRelease
+	for _, release := range cached {
+		if release.ID.Name != requirement.Name || release.ID.Version != requirement.Version {
+			continue
+		}
+		if expectedSum != "" && release.SHA1 != expectedSum {
+			continue
+		}
+
+		if !release.Compiled() {
+			built = append(built, release)
+		} else if release.StemcellOS == requirement.StemcellOS && release.StemcellVersion == requirement.StemcellVersion {
+			compiled = append(compiled, release)
+		}
+	}
+
+	for _, candidates := range [][]CachedRelease{compiled, built} {
+		if len(candidates) > 0 {
+			sort.Slice(candidates, func(i, j int) bool {
+				return candidates[i].LastUsed.After(candidates[j].LastUsed)
+			})
+			return candidates[0], true
+		}
+	}
+
+	return CachedRelease{}, false
+}
+
+// linkOrCopy hardlinks src to dst, falling back to copying when they are on
+// different file systems.
+func linkOrCopy(src, dst string) error {
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if os.Link(src, dst) == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	partialPath := dst + partialDownloadSuffix
+	out, err := os.Create(partialPath)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(partialPath)
+		return err
+	}
+
+	return os.Rename(partialPath, dst)
+}
@@ -0,0 +1,243 @@
+package fetcher_test
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/pivotal-cf/kiln/fetcher"
+	"github.com/pivotal-cf/kiln/internal/cargo"
+)
+
+var _ = Describe("ReleaseCache", func() {
+	const (
+		// sha1 of "built-contents" and "compiled-contents"
+		builtSHA1    = "7085b10c0ef560138d14cc3fc74ef91bb0c25d0e"
+		compiledSHA1 = "c07ed6263d3f5b2a84502e57a1b2d0e59f0e529a"
+	)
+
+	var (
+		releaseCache ReleaseCache
+		tmpDir       string
+		cacheDir     string
+		releasesDir  string
+
+		releaseID ReleaseID
+	)
+
+	writeFile := func(path, contents string) {
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(path, []byte(contents), 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "kiln-release-cache-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		cacheDir = filepath.Join(tmpDir, "cache")
+		releasesDir = filepath.Join(tmpDir, "releases")
+		Expect(os.Mkdir(releasesDir, 0755)).To(Succeed())
+
+		releaseCache = NewReleaseCache(log.New(GinkgoWriter, "", 0))
+		releaseID = ReleaseID{Name: "uaa", Version: "1.2.3"}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	Describe("AddReleases and List", func() {
+		It("stores releases by name, version, stemcell and checksum", func() {
+			builtPath := filepath.Join(releasesDir, "uaa-1.2.3.tgz")
+			writeFile(builtPath, "built-contents")
+			compiledPath := filepath.Join(releasesDir, "bpm-1.1.0-ubuntu-xenial-621.5.tgz")
+			writeFile(compiledPath, "compiled-contents")
+
+			err := releaseCache.AddReleases(cacheDir, LocalReleaseSet{
+				releaseID: BuiltRelease{ID: releaseID, Path: builtPath},
+				{Name: "bpm", Version: "1.1.0"}: CompiledRelease{
+					ID:              ReleaseID{Name: "bpm", Version: "1.1.0"},
+					StemcellOS:      "ubuntu-xenial",
+					StemcellVersion: "621.5",
+					Path:            compiledPath,
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			releases, err := releaseCache.List(cacheDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(releases).To(HaveLen(2))
+
+			Expect(releases[0].ID).To(Equal(ReleaseID{Name: "bpm", Version: "1.1.0"}))
+			Expect(releases[0].StemcellOS).To(Equal("ubuntu-xenial"))
+			Expect(releases[0].StemcellVersion).To(Equal("621.5"))
+			Expect(releases[0].Path).To(Equal(filepath.Join(cacheDir, "bpm", "1.1.0", "ubuntu-xenial-621.5", compiledSHA1+".tgz")))
+			Expect(releases[0].Size).To(Equal(int64(len("compiled-contents"))))
+
+			Expect(releases[1].ID).To(Equal(releaseID))
+			Expect(releases[1].Compiled()).To(BeFalse())
+			Expect(releases[1].Path).To(Equal(filepath.Join(cacheDir, "uaa", "1.2.3", "built", builtSHA1+".tgz")))
+			Expect(ioutil.ReadFile(releases[1].Path)).To(BeEquivalentTo("built-contents"))
+		})
+
+		Context("when the checksum was taken while downloading", func() {
+			It("stores the release under that checksum without reading it again", func() {
+				builtPath := filepath.Join(releasesDir, "uaa-1.2.3.tgz")
+				writeFile(builtPath, "built-contents")
+
+				err := releaseCache.AddReleases(cacheDir, LocalReleaseSet{
+					releaseID: BuiltRelease{ID: releaseID, Path: builtPath, SHA1: "some-downloaded-sha1"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(filepath.Join(cacheDir, "uaa", "1.2.3", "built", "some-downloaded-sha1.tgz")).To(BeAnExistingFile())
+			})
+		})
+
+		Context("when the cache directory does not exist", func() {
+			It("lists no releases", func() {
+				releases, err := releaseCache.List(filepath.Join(tmpDir, "missing"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(releases).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("GetReleases", func() {
+		var (
+			requirements ReleaseRequirementSet
+			kilnfileLock cargo.KilnfileLock
+		)
+
+		BeforeEach(func() {
+			requirements = ReleaseRequirementSet{
+				releaseID: {Name: "uaa", Version: "1.2.3", StemcellOS: "ubuntu-xenial", StemcellVersion: "621.5"},
+			}
+			kilnfileLock = cargo.KilnfileLock{
+				Releases: []cargo.Release{{Name: "uaa", Version: "1.2.3"}},
+				Stemcell: cargo.Stemcell{OS: "ubuntu-xenial", Version: "621.5"},
+			}
+		})
+
+		Context("when a compiled release for the stemcell is cached", func() {
+			BeforeEach(func() {
+				writeFile(filepath.Join(cacheDir, "uaa", "1.2.3", "built", builtSHA1+".tgz"), "built-contents")
+				writeFile(filepath.Join(cacheDir, "uaa", "1.2.3", "ubuntu-xenial-621.5", compiledSHA1+".tgz"), "compiled-contents")
+			})
+
+			It("finds the compiled release without touching the releases directory", func() {
+				releases, err := releaseCache.FindReleases(cacheDir, requirements, kilnfileLock)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(releases).To(HaveLen(1))
+				Expect(releases[releaseID].Path).To(Equal(filepath.Join(cacheDir, "uaa", "1.2.3", "ubuntu-xenial-621.5", compiledSHA1+".tgz")))
+				Expect(ioutil.ReadDir(releasesDir)).To(BeEmpty())
+			})
+
+			It("puts the compiled release into the releases directory", func() {
+				releases, err := releaseCache.GetReleases(cacheDir, releasesDir, requirements, kilnfileLock)
+				Expect(err).NotTo(HaveOccurred())
+
+				path := filepath.Join(releasesDir, "uaa-1.2.3-ubuntu-xenial-621.5.tgz")
+				Expect(releases).To(Equal(LocalReleaseSet{
+					releaseID: CompiledRelease{ID: releaseID, StemcellOS: "ubuntu-xenial", StemcellVersion: "621.5", Path: path, SHA1: compiledSHA1},
+				}))
+				Expect(ioutil.ReadFile(path)).To(BeEquivalentTo("compiled-contents"))
+			})
+		})
+
+		Context("when only a built release is cached", func() {
+			BeforeEach(func() {
+				writeFile(filepath.Join(cacheDir, "uaa", "1.2.3", "built", builtSHA1+".tgz"), "built-contents")
+				writeFile(filepath.Join(cacheDir, "uaa", "1.2.3", "ubuntu-trusty-3586.1", compiledSHA1+".tgz"), "compiled-contents")
+			})
+
+			It("puts the built release into the releases directory", func() {
+				releases, err := releaseCache.GetReleases(cacheDir, releasesDir, requirements, kilnfileLock)
+				Expect(err).NotTo(HaveOccurred())
+
+				path := filepath.Join(releasesDir, "uaa-1.2.3.tgz")
+				Expect(releases).To(Equal(LocalReleaseSet{
+					releaseID: BuiltRelease{ID: releaseID, Path: path, SHA1: builtSHA1},
+				}))
+				Expect(ioutil.ReadFile(path)).To(BeEquivalentTo("built-contents"))
+			})
+		})
+
+		Context("when the cached release does not match the checksum in the Kilnfile.lock", func() {
+			BeforeEach(func() {
+				writeFile(filepath.Join(cacheDir, "uaa", "1.2.3", "built", builtSHA1+".tgz"), "built-contents")
+				kilnfileLock.Releases[0].SHA1 = "some-other-sha1"
+			})
+
+			It("does not use the cached release", func() {
+				releases, err := releaseCache.GetReleases(cacheDir, releasesDir, requirements, kilnfileLock)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(releases).To(BeEmpty())
+			})
+		})
+
+		Context("when the release is not cached", func() {
+			It("returns no releases", func() {
+				releases, err := releaseCache.GetReleases(cacheDir, releasesDir, requirements, kilnfileLock)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(releases).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("Prune", func() {
+		var oldPath, newPath string
+
+		BeforeEach(func() {
+			oldPath = filepath.Join(cacheDir, "uaa", "1.2.3", "built", builtSHA1+".tgz")
+			writeFile(oldPath, "built-contents")
+			lastMonth := time.Now().Add(-31 * 24 * time.Hour)
+			Expect(os.Chtimes(oldPath, lastMonth, lastMonth)).To(Succeed())
+
+			newPath = filepath.Join(cacheDir, "bpm", "1.1.0", "built", compiledSHA1+".tgz")
+			writeFile(newPath, "compiled-contents")
+		})
+
+		It("removes the releases not used since the given time", func() {
+			pruned, err := releaseCache.Prune(cacheDir, time.Now().Add(-24*time.Hour))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(pruned).To(HaveLen(1))
+			Expect(pruned[0].Path).To(Equal(oldPath))
+			Expect(oldPath).NotTo(BeAnExistingFile())
+			Expect(filepath.Join(cacheDir, "uaa")).NotTo(BeADirectory())
+			Expect(newPath).To(BeAnExistingFile())
+		})
+	})
+
+	Describe("Verify", func() {
+		It("removes the releases whose checksum does not match", func() {
+			releasePath := filepath.Join(releasesDir, "uaa-1.2.3.tgz")
+			writeFile(releasePath, "built-contents")
+			Expect(releaseCache.AddReleases(cacheDir, LocalReleaseSet{
+				releaseID: BuiltRelease{ID: releaseID, Path: releasePath},
+			})).To(Succeed())
+
+			corruptPath := filepath.Join(cacheDir, "bpm", "1.1.0", "built", "some-sha1.tgz")
+			writeFile(corruptPath, "corrupt-contents")
+
+			corrupt, err := releaseCache.Verify(cacheDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(corrupt).To(HaveLen(1))
+			Expect(corrupt[0].Path).To(Equal(corruptPath))
+			Expect(corruptPath).NotTo(BeAnExistingFile())
+
+			releases, err := releaseCache.List(cacheDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(releases).To(HaveLen(1))
+			Expect(releases[0].ID).To(Equal(releaseID))
+		})
+	})
+})
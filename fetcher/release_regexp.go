@@ -0,0 +1,85 @@
+package fetcher
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// releaseRegexp matches file paths using the release_name and release_version
+// capture groups, and optionally the stemcell_os and stemcell_version groups.
+// Paths are converted to compiled releases when the stemcell groups are present
+// and to built releases otherwise.
+type releaseRegexp struct {
+	exp      *regexp.Regexp
+	compiled bool
+}
+
+func newReleaseRegexp(expr string) (releaseRegexp, error) {
+	exp, err := regexp.Compile(expr)
+	if err != nil {
+		return releaseRegexp{}, err
+	}
+
+	groups := make(map[string]bool)
+	for _, name := range exp.SubexpNames() {
+		groups[name] = true
+	}
+	if !groups[ReleaseName] || !groups[ReleaseVersion] || groups[StemcellOS] != groups[StemcellVersion] {
+		return releaseRegexp{}, fmt.Errorf("Missing some capture group. Required capture groups: %s, %s and optionally %s, %s", ReleaseName, ReleaseVersion, StemcellOS, StemcellVersion)
+	}
+
+	return releaseRegexp{exp: exp, compiled: groups[StemcellOS]}, nil
+}
+
+// release converts name to a release located at path, returning false when
+// name does not match.
+func (rr releaseRegexp) release(name, path string) (RemoteRelease, bool) {
+	matches := rr.exp.FindStringSubmatch(name)
+	if matches == nil {
+		return nil, false
+	}
+
+	subgroup := make(map[string]string)
+	for i, group := range rr.exp.SubexpNames() {
+		if i != 0 && group != "" {
+			subgroup[group] = matches[i]
+		}
+	}
+
+	rID := ReleaseID{Name: subgroup[ReleaseName], Version: subgroup[ReleaseVersion]}
+	if rr.compiled {
+		return CompiledRelease{ID: rID, StemcellOS: subgroup[StemcellOS], StemcellVersion: subgroup[StemcellVersion], Path: path}, true
+	}
+	return BuiltRelease{ID: rID, Path: path}, true
+}
+
+// matchReleases returns the first release satisfying each requirement.
+func matchReleases(releases map[ReleaseID][]RemoteRelease, desiredReleaseSet ReleaseRequirementSet) []RemoteRelease {
+	matches := make([]RemoteRelease, 0)
+	for rID, requirement := range desiredReleaseSet {
+		for _, release := range releases[rID] {
+			if release.(LocalRelease).Satisfies(requirement) {
+				matches = append(matches, release)
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// newestReleaseMatch returns the newest release satisfying the constraint,
+// ignoring compiled releases for other stemcells.
+func newestReleaseMatch(releases map[ReleaseID][]RemoteRelease, constraint ReleaseVersionConstraint) (RemoteRelease, bool) {
+	var candidates []RemoteRelease
+	for _, rs := range releases {
+		for _, release := range rs {
+			if compiled, ok := release.(CompiledRelease); ok &&
+				(compiled.StemcellOS != constraint.StemcellOS || compiled.StemcellVersion != constraint.StemcellVersion) {
+				continue
+			}
+			candidates = append(candidates, release)
+		}
+	}
+
+	return constraint.newestMatch(candidates)
+}
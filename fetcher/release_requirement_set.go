@@ -1,15 +1,18 @@
 package fetcher
 
-import "github.com/pivotal-cf/kiln/internal/cargo"
+import (
+	"sort"
+
+	"github.com/pivotal-cf/kiln/internal/cargo"
+)
 
 type ReleaseRequirementSet map[ReleaseID]ReleaseRequirement
 
 func NewReleaseRequirementSet(kilnfileLock cargo.KilnfileLock) ReleaseRequirementSet {
 	set := make(ReleaseRequirementSet)
-	stemcell := kilnfileLock.Stemcell
 	for _, release := range kilnfileLock.Releases {
-		requirement := newReleaseRequirement(release, stemcell)
-		set[requirement.releaseID()] = requirement
+		requirement := newReleaseRequirement(release, kilnfileLock.ReleaseStemcell(release))
+		set[requirement.key()] = requirement
 	}
 	return set
 }
@@ -20,10 +23,18 @@ func (rrs ReleaseRequirementSet) Partition(other LocalReleaseSet) (intersection
 	extra = other.copy()
 
 	for rID, requirement := range rrs {
-		otherRelease, ok := other[rID]
+		// NOTE: a built release, keyed without a stemcell OS, satisfies the
+		// requirement for any stemcell
+		otherID := rID
+		otherRelease, ok := other[otherID]
+		if !ok {
+			otherID = rID.withoutStemcell()
+			otherRelease, ok = other[otherID]
+		}
+
 		if ok && otherRelease.Satisfies(requirement) {
 			intersection[rID] = otherRelease
-			delete(extra, rID)
+			delete(extra, otherID)
 		} else {
 			missing[rID] = requirement
 		}
@@ -31,16 +42,47 @@ func (rrs ReleaseRequirementSet) Partition(other LocalReleaseSet) (intersection
 	return intersection, missing, extra
 }
 
+// WithoutReleases removes the requirements that the releases with the given
+// LocalReleaseSet keys satisfy.
 func (rrs ReleaseRequirementSet) WithoutReleases(toRemove []ReleaseID) ReleaseRequirementSet {
 	result := rrs.copy()
 
 	for _, rID := range toRemove {
-		delete(result, rID)
+		for key := range result {
+			if key.matches(rID) {
+				delete(result, key)
+			}
+		}
 	}
 
 	return result
 }
 
+// ByStemcell splits the requirements by the stemcell they are compiled
+// against, ordered by stemcell OS and version. The requirements for a stemcell
+// are keyed by release name and version, as release sources look them up.
+func (rrs ReleaseRequirementSet) ByStemcell() ([]cargo.Stemcell, map[cargo.Stemcell]ReleaseRequirementSet) {
+	var stemcells []cargo.Stemcell
+	sets := make(map[cargo.Stemcell]ReleaseRequirementSet)
+	for _, requirement := range rrs {
+		stemcell := cargo.Stemcell{OS: requirement.StemcellOS, Version: requirement.StemcellVersion}
+		if _, ok := sets[stemcell]; !ok {
+			stemcells = append(stemcells, stemcell)
+			sets[stemcell] = make(ReleaseRequirementSet)
+		}
+		sets[stemcell][requirement.releaseID()] = requirement
+	}
+
+	sort.Slice(stemcells, func(i, j int) bool {
+		if stemcells[i].OS != stemcells[j].OS {
+			return stemcells[i].OS < stemcells[j].OS
+		}
+		return stemcells[i].Version < stemcells[j].Version
+	})
+
+	return stemcells, sets
+}
+
 func (rrs ReleaseRequirementSet) copy() ReleaseRequirementSet {
 	dup := make(ReleaseRequirementSet)
 	for releaseID, release := range rrs {
@@ -65,3 +107,8 @@ type ReleaseRequirement struct {
 func (rr ReleaseRequirement) releaseID() ReleaseID {
 	return ReleaseID{Name: rr.Name, Version: rr.Version}
 }
+
+// key is the key of the requirement in a ReleaseRequirementSet.
+func (rr ReleaseRequirement) key() ReleaseID {
+	return ReleaseID{Name: rr.Name, Version: rr.Version, StemcellOS: rr.StemcellOS}
+}
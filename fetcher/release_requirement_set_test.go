@@ -32,8 +32,8 @@ var _ = Describe("ReleaseRequirementSet", func() {
 			Stemcell: cargo.Stemcell{OS: stemcellName, Version: stemcellVersion},
 		}
 		rrs = NewReleaseRequirementSet(kilnfileLock)
-		release1ID = ReleaseID{Name: release1Name, Version: release1Version}
-		release2ID = ReleaseID{Name: release2Name, Version: release2Version}
+		release1ID = ReleaseID{Name: release1Name, Version: release1Version, StemcellOS: stemcellName}
+		release2ID = ReleaseID{Name: release2Name, Version: release2Version, StemcellOS: stemcellName}
 	})
 
 	Describe("NewReleaseRequirementSet", func() {
@@ -48,6 +48,99 @@ var _ = Describe("ReleaseRequirementSet", func() {
 		})
 	})
 
+	Context("when the Kilnfile.lock has several stemcells", func() {
+		BeforeEach(func() {
+			rrs = NewReleaseRequirementSet(cargo.KilnfileLock{
+				Releases: []cargo.Release{
+					{Name: release1Name, Version: release1Version},
+					{Name: release2Name, Version: release2Version, StemcellOS: "windows", StemcellVersion: "2019.12"},
+				},
+				Stemcells: []cargo.Stemcell{
+					{OS: stemcellName, Version: stemcellVersion},
+					{OS: "windows", Version: "2019.12"},
+				},
+			})
+		})
+
+		It("requires each release to be compiled against its own stemcell", func() {
+			Expect(rrs).To(HaveKeyWithValue(release1ID,
+				ReleaseRequirement{Name: release1Name, Version: release1Version, StemcellOS: stemcellName, StemcellVersion: stemcellVersion},
+			))
+			Expect(rrs).To(HaveKeyWithValue(ReleaseID{Name: release2Name, Version: release2Version, StemcellOS: "windows"},
+				ReleaseRequirement{Name: release2Name, Version: release2Version, StemcellOS: "windows", StemcellVersion: "2019.12"},
+			))
+		})
+
+		It("splits the requirements by stemcell, keyed by release name and version", func() {
+			stemcells, sets := rrs.ByStemcell()
+			Expect(stemcells).To(Equal([]cargo.Stemcell{
+				{OS: stemcellName, Version: stemcellVersion},
+				{OS: "windows", Version: "2019.12"},
+			}))
+			Expect(sets[stemcells[0]]).To(HaveLen(1))
+			Expect(sets[stemcells[0]]).To(HaveKey(ReleaseID{Name: release1Name, Version: release1Version}))
+			Expect(sets[stemcells[1]]).To(HaveLen(1))
+			Expect(sets[stemcells[1]]).To(HaveKey(ReleaseID{Name: release2Name, Version: release2Version}))
+		})
+	})
+
+	Context("when a release is locked against two stemcell OSes", func() {
+		var xenialID, windowsID ReleaseID
+
+		BeforeEach(func() {
+			rrs = NewReleaseRequirementSet(cargo.KilnfileLock{
+				Releases: []cargo.Release{
+					{Name: release1Name, Version: release1Version, StemcellOS: stemcellName, StemcellVersion: stemcellVersion},
+					{Name: release1Name, Version: release1Version, StemcellOS: "windows", StemcellVersion: "2019.12"},
+				},
+				Stemcells: []cargo.Stemcell{
+					{OS: stemcellName, Version: stemcellVersion},
+					{OS: "windows", Version: "2019.12"},
+				},
+			})
+			xenialID = ReleaseID{Name: release1Name, Version: release1Version, StemcellOS: stemcellName}
+			windowsID = ReleaseID{Name: release1Name, Version: release1Version, StemcellOS: "windows"}
+		})
+
+		It("requires the release once for each stemcell", func() {
+			Expect(rrs).To(HaveLen(2))
+			Expect(rrs).To(HaveKeyWithValue(xenialID,
+				ReleaseRequirement{Name: release1Name, Version: release1Version, StemcellOS: stemcellName, StemcellVersion: stemcellVersion},
+			))
+			Expect(rrs).To(HaveKeyWithValue(windowsID,
+				ReleaseRequirement{Name: release1Name, Version: release1Version, StemcellOS: "windows", StemcellVersion: "2019.12"},
+			))
+		})
+
+		It("is satisfied by a built release for both stemcells", func() {
+			builtRelease := BuiltRelease{ID: ReleaseID{Name: release1Name, Version: release1Version}, Path: "release-1.tgz"}
+
+			intersection, missing, extra := rrs.Partition(LocalReleaseSet{ReleaseSetKey(builtRelease): builtRelease})
+			Expect(intersection).To(Equal(LocalReleaseSet{xenialID: builtRelease, windowsID: builtRelease}))
+			Expect(missing).To(BeEmpty())
+			Expect(extra).To(BeEmpty())
+		})
+
+		It("is satisfied by a compiled release for each stemcell", func() {
+			xenialRelease := CompiledRelease{ID: ReleaseID{Name: release1Name, Version: release1Version}, StemcellOS: stemcellName, StemcellVersion: stemcellVersion, Path: "xenial.tgz"}
+			windowsRelease := CompiledRelease{ID: ReleaseID{Name: release1Name, Version: release1Version}, StemcellOS: "windows", StemcellVersion: "2019.12", Path: "windows.tgz"}
+
+			intersection, missing, _ := rrs.Partition(LocalReleaseSet{
+				ReleaseSetKey(xenialRelease): xenialRelease,
+			})
+			Expect(intersection).To(Equal(LocalReleaseSet{xenialID: xenialRelease}))
+			Expect(missing).To(HaveKey(windowsID))
+
+			Expect(rrs.WithoutReleases([]ReleaseID{ReleaseSetKey(windowsRelease)})).To(HaveKey(xenialID))
+			Expect(rrs.WithoutReleases([]ReleaseID{ReleaseSetKey(windowsRelease)})).NotTo(HaveKey(windowsID))
+		})
+
+		It("removes the requirements for both stemcells when a built release is found", func() {
+			builtRelease := BuiltRelease{ID: ReleaseID{Name: release1Name, Version: release1Version}}
+			Expect(rrs.WithoutReleases([]ReleaseID{ReleaseSetKey(builtRelease)})).To(BeEmpty())
+		})
+	})
+
 	Describe("Partition", func() {
 		var (
 			releaseSet                             LocalReleaseSet
@@ -0,0 +1,168 @@
+package fetcher
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pivotal-cf/kiln/internal/cargo"
+	"gopkg.in/yaml.v2"
+)
+
+// ExternalReleaseSourcePrefix is prepended to the type of a release source that
+// is not registered to find an executable implementing it on the PATH.
+const ExternalReleaseSourcePrefix = "kiln-release-source-"
+
+// ReleaseSourceType builds the release sources of one type. NewConfig returns a
+// pointer to the typed configuration that the options of a release_sources
+// entry are decoded into, and New builds the release source from it. Fields of
+// the configuration tagged `required:"true"` must be set in the Kilnfile.
+type ReleaseSourceType struct {
+	NewConfig func() interface{}
+	New       func(logger *log.Logger, config interface{}) (ReleaseSource, error)
+}
+
+var releaseSourceTypes = make(map[string]ReleaseSourceType)
+
+func init() {
+	RegisterReleaseSourceType("bosh.io", ReleaseSourceType{
+		NewConfig: func() interface{} { return &struct{}{} },
+		New: func(logger *log.Logger, _ interface{}) (ReleaseSource, error) {
+			return NewBOSHIOReleaseSource(logger, ""), nil
+		},
+	})
+
+	RegisterReleaseSourceType("s3", ReleaseSourceType{
+		NewConfig: func() interface{} { return new(S3ReleaseSourceConfig) },
+		New: func(logger *log.Logger, config interface{}) (ReleaseSource, error) {
+			s3Config := *config.(*S3ReleaseSourceConfig)
+
+			s3ReleaseSource := S3ReleaseSource{Logger: logger}
+			s3ReleaseSource.Configure(s3Config)
+			if s3Config.Compiled {
+				return S3CompiledReleaseSource(s3ReleaseSource), nil
+			}
+			return S3BuiltReleaseSource(s3ReleaseSource), nil
+		},
+	})
+
+	RegisterReleaseSourceType("github", ReleaseSourceType{
+		NewConfig: func() interface{} { return new(GitHubReleaseSourceConfig) },
+		New: func(logger *log.Logger, config interface{}) (ReleaseSource, error) {
+			return NewGitHubReleaseSource(logger, "", *config.(*GitHubReleaseSourceConfig)), nil
+		},
+	})
+
+	RegisterReleaseSourceType("directory", ReleaseSourceType{
+		NewConfig: func() interface{} { return new(DirectoryReleaseSourceConfig) },
+		New: func(logger *log.Logger, config interface{}) (ReleaseSource, error) {
+			return NewDirectoryReleaseSource(logger, *config.(*DirectoryReleaseSourceConfig)), nil
+		},
+	})
+
+	RegisterReleaseSourceType("artifactory", ReleaseSourceType{
+		NewConfig: func() interface{} { return new(ArtifactoryReleaseSourceConfig) },
+		New: func(logger *log.Logger, config interface{}) (ReleaseSource, error) {
+			return NewArtifactoryReleaseSource(logger, *config.(*ArtifactoryReleaseSourceConfig)), nil
+		},
+	})
+}
+
+// RegisterReleaseSourceType makes a release source type available to the
+// Kilnfile under name. It panics when name is already registered.
+func RegisterReleaseSourceType(name string, sourceType ReleaseSourceType) {
+	if _, ok := releaseSourceTypes[name]; ok {
+		panic(fmt.Sprintf("release source type %q is already registered", name))
+	}
+	releaseSourceTypes[name] = sourceType
+}
+
+// ReleaseSourceTypes returns the names of the registered release source types.
+func ReleaseSourceTypes() []string {
+	var names []string
+	for name := range releaseSourceTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewReleaseSource builds the release source configured by an entry in the
+// release_sources section of the Kilnfile. Types that are not registered are
+// looked up as executables named kiln-release-source-<type> on the PATH.
+func NewReleaseSource(logger *log.Logger, config cargo.ReleaseSourceConfig) (ReleaseSource, error) {
+	if config.Type == "" {
+		return nil, fmt.Errorf("release source is missing a type")
+	}
+
+	sourceType, ok := releaseSourceTypes[config.Type]
+	if !ok {
+		path, err := exec.LookPath(ExternalReleaseSourcePrefix + config.Type)
+		if err != nil {
+			return nil, fmt.Errorf("unknown release source type %q (expected one of %s, or an executable named %s%s on the PATH)",
+				config.Type, strings.Join(ReleaseSourceTypes(), ", "), ExternalReleaseSourcePrefix, config.Type)
+		}
+		return NewExternalReleaseSource(logger, config.Type, path, config.Options), nil
+	}
+
+	sourceConfig := sourceType.NewConfig()
+	if err := decodeReleaseSourceOptions(config.Options, sourceConfig); err != nil {
+		return nil, fmt.Errorf("invalid %s release source: %s", config.Type, err)
+	}
+
+	if missing := missingRequiredKeys(sourceConfig); len(missing) > 0 {
+		return nil, fmt.Errorf("%s release source is missing required keys: %s", config.Type, strings.Join(missing, ", "))
+	}
+
+	return sourceType.New(logger, sourceConfig)
+}
+
+func decodeReleaseSourceOptions(options map[string]interface{}, config interface{}) error {
+	if len(options) == 0 {
+		return nil
+	}
+
+	contents, err := yaml.Marshal(options)
+	if err != nil {
+		return err
+	}
+
+	return yaml.UnmarshalStrict(contents, config)
+}
+
+func missingRequiredKeys(config interface{}) []string {
+	value := reflect.Indirect(reflect.ValueOf(config))
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var missing []string
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if field.Tag.Get("required") != "true" {
+			continue
+		}
+
+		if isZero(value.Field(i)) {
+			key := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if key == "" {
+				key = strings.ToLower(field.Name)
+			}
+			missing = append(missing, key)
+		}
+	}
+
+	return missing
+}
+
+func isZero(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	default:
+		return reflect.DeepEqual(value.Interface(), reflect.Zero(value.Type()).Interface())
+	}
+}
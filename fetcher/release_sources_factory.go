@@ -10,44 +10,57 @@ import (
 //go:generate counterfeiter -o ./fakes/release_source.go --fake-name ReleaseSource . ReleaseSource
 type ReleaseSource interface {
 	GetMatchedReleases(ReleaseRequirementSet, cargo.Stemcell) ([]RemoteRelease, error)
-	DownloadReleases(releasesDir string, matchedS3Objects []RemoteRelease, downloadThreads int) (LocalReleaseSet, error)
+	DownloadReleases(releasesDir string, matchedS3Objects []RemoteRelease, opts DownloadOptions) (LocalReleaseSet, error)
+	FindReleaseVersion(ReleaseVersionConstraint) (RemoteRelease, bool, error)
 }
 
-type releaseSourceFunction func(cargo.Kilnfile, bool) []ReleaseSource
+// MatchReleases asks releaseSource for the releases matching requirements,
+// once for each stemcell the requirements are compiled against. A built
+// release matching the requirements for more than one stemcell is returned
+// once.
+func MatchReleases(releaseSource ReleaseSource, requirements ReleaseRequirementSet) ([]RemoteRelease, error) {
+	var matches []RemoteRelease
 
-func (rsf releaseSourceFunction) ReleaseSources(kilnfile cargo.Kilnfile, allowOnlyPublishable bool) []ReleaseSource {
+	matched := make(map[ReleaseID]bool)
+	stemcells, requirementsByStemcell := requirements.ByStemcell()
+	for _, stemcell := range stemcells {
+		remoteReleases, err := releaseSource.GetMatchedReleases(requirementsByStemcell[stemcell], stemcell)
+		if err != nil {
+			return nil, err
+		}
+		for _, release := range remoteReleases {
+			if key := ReleaseSetKey(release); !matched[key] {
+				matched[key] = true
+				matches = append(matches, release)
+			}
+		}
+	}
+
+	return matches, nil
+}
+
+type releaseSourceFunction func(cargo.Kilnfile, bool) ([]ReleaseSource, error)
+
+func (rsf releaseSourceFunction) ReleaseSources(kilnfile cargo.Kilnfile, allowOnlyPublishable bool) ([]ReleaseSource, error) {
 	return rsf(kilnfile, allowOnlyPublishable)
 }
 
 func NewReleaseSourcesFactory(outLogger *log.Logger) releaseSourceFunction {
-	return func(kilnfile cargo.Kilnfile, allowOnlyPublishable bool) []ReleaseSource {
+	return func(kilnfile cargo.Kilnfile, allowOnlyPublishable bool) ([]ReleaseSource, error) {
 		var releaseSources []ReleaseSource
 
-		for _, releaseConfig := range kilnfile.ReleaseSources {
+		for i, releaseConfig := range kilnfile.ReleaseSources {
 			if allowOnlyPublishable && !releaseConfig.Publishable {
 				continue
 			}
-			releaseSources = append(releaseSources, releaseSourceFor(releaseConfig, outLogger))
-		}
 
-		return releaseSources
-	}
-}
-
-func releaseSourceFor(releaseConfig cargo.ReleaseSourceConfig, outLogger *log.Logger) ReleaseSource {
-	if releaseConfig.Type == "bosh.io" {
-		return NewBOSHIOReleaseSource(outLogger, "")
-	}
-
-	if releaseConfig.Type != "s3" {
-		panic(fmt.Sprintf("unknown release config: %v", releaseConfig))
-	}
+			releaseSource, err := NewReleaseSource(outLogger, releaseConfig)
+			if err != nil {
+				return nil, fmt.Errorf("release_sources[%d]: %s", i, err)
+			}
+			releaseSources = append(releaseSources, releaseSource)
+		}
 
-	s3ReleaseSource := S3ReleaseSource{Logger: outLogger}
-	s3ReleaseSource.Configure(releaseConfig)
-	if releaseConfig.Compiled {
-		return S3CompiledReleaseSource(s3ReleaseSource)
-	} else {
-		return S3BuiltReleaseSource(s3ReleaseSource)
+		return releaseSources, nil
 	}
 }
@@ -1,14 +1,19 @@
 package fetcher_test
 
 import (
+	"io/ioutil"
 	"log"
+	"os"
+	"path/filepath"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gstruct"
 	"github.com/pivotal-cf/kiln/commands"
 	. "github.com/pivotal-cf/kiln/fetcher"
+	"github.com/pivotal-cf/kiln/fetcher/fakes"
 	"github.com/pivotal-cf/kiln/internal/cargo"
+	"gopkg.in/yaml.v2"
 )
 
 var _ = Describe("NewReleaseSourcesFactory()", func() {
@@ -21,77 +26,251 @@ var _ = Describe("NewReleaseSourcesFactory()", func() {
 		rsFactory = NewReleaseSourcesFactory(log.New(GinkgoWriter, "", log.LstdFlags))
 	})
 
+	parseKilnfile := func(contents string) cargo.Kilnfile {
+		var k cargo.Kilnfile
+		Expect(yaml.UnmarshalStrict([]byte(contents), &k)).To(Succeed())
+		return k
+	}
+
 	Context("when allow-only-publishable-releases is false", func() {
 		BeforeEach(func() {
-			kilnfile = cargo.Kilnfile{
-				ReleaseSources: []cargo.ReleaseSourceConfig{
-					{Type: "s3", Compiled: true, Bucket: "bucket-1", Region: "us-west-1", AccessKeyId: "ak1", SecretAccessKey: "shhhh!",
-						Regex: `^2.8/.+/(?P<release_name>[a-z-_0-9]+)-(?P<release_version>v?[0-9\.]+)-(?P<stemcell_os>[a-z-_]+)-(?P<stemcell_version>\d+\.\d+)(\.0)?\.tgz$`},
-					{Type: "s3", Compiled: false, Bucket: "bucket-2", Region: "us-west-2", AccessKeyId: "aki", SecretAccessKey: "shhhh!",
-						Regex: `^2.8/.+/(?P<release_name>[a-z-_0-9]+)-(?P<release_version>v?[0-9\.]+)\.tgz$`},
-					{Type: "bosh.io"},
-					{Type: "s3", Compiled: false, Bucket: "bucket-2", Region: "us-west-2", AccessKeyId: "aki", SecretAccessKey: "shhhh!",
-						Regex: `^(?P<release_name>[a-z-_0-9]+)-(?P<release_version>v?[0-9\.]+-?[a-zA-Z0-9]\.?[0-9]*)\.tgz$`},
-				},
-			}
+			kilnfile = parseKilnfile(`---
+release_sources:
+- type: s3
+  compiled: true
+  bucket: bucket-1
+  region: us-west-1
+  access_key_id: ak1
+  secret_access_key: shhhh!
+  regex: ^2.8/.+/(?P<release_name>[a-z-_0-9]+)-(?P<release_version>v?[0-9\.]+)-(?P<stemcell_os>[a-z-_]+)-(?P<stemcell_version>\d+\.\d+)(\.0)?\.tgz$
+- type: s3
+  compiled: false
+  bucket: bucket-2
+  region: us-west-2
+  access_key_id: aki
+  secret_access_key: shhhh!
+  regex: ^2.8/.+/(?P<release_name>[a-z-_0-9]+)-(?P<release_version>v?[0-9\.]+)\.tgz$
+- type: bosh.io
+- type: s3
+  bucket: bucket-3
+  region: us-west-2
+  access_key_id: aki
+  secret_access_key: shhhh!
+  regex: ^(?P<release_name>[a-z-_0-9]+)-(?P<release_version>v?[0-9\.]+-?[a-zA-Z0-9]\.?[0-9]*)\.tgz$
+- type: github
+  org: cloudfoundry
+  repo: uaa-release
+  github_token: some-token
+- type: directory
+  directories: [/mnt/releases]
+  regex: ^(?P<release_name>[a-z-_0-9]+)-(?P<release_version>[0-9\.]+)\.tgz$
+- type: artifactory
+  artifactory_host: https://artifactory.example.com/artifactory/
+  repo: bosh-releases
+  username: some-user
+  password: some-password
+  regex: ^(?P<release_name>[a-z-_0-9]+)-(?P<release_version>[0-9\.]+)\.tgz$
+`)
 		})
 
 		It("builds the correct release sources", func() {
-			releaseSources := rsFactory.ReleaseSources(kilnfile, false)
-			Expect(releaseSources).To(HaveLen(4))
+			releaseSources, err := rsFactory.ReleaseSources(kilnfile, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(releaseSources).To(HaveLen(7))
 			var (
-				s3CompiledReleaseSource S3CompiledReleaseSource
-				s3BuiltReleaseSource    S3BuiltReleaseSource
-				boshIOReleaseSource     *BOSHIOReleaseSource
+				s3CompiledReleaseSource  S3CompiledReleaseSource
+				s3BuiltReleaseSource     S3BuiltReleaseSource
+				boshIOReleaseSource      *BOSHIOReleaseSource
+				gitHubReleaseSource      *GitHubReleaseSource
+				directoryReleaseSource   *DirectoryReleaseSource
+				artifactoryReleaseSource *ArtifactoryReleaseSource
 			)
 
 			Expect(releaseSources[0]).To(BeAssignableToTypeOf(s3CompiledReleaseSource))
 			Expect(releaseSources[0]).To(MatchFields(IgnoreExtras, Fields{
-				"Bucket": Equal(kilnfile.ReleaseSources[0].Bucket),
-				"Regex":  Equal(kilnfile.ReleaseSources[0].Regex),
+				"Bucket": Equal("bucket-1"),
+				"Regex":  Equal(kilnfile.ReleaseSources[0].Options["regex"]),
 			}))
 
 			Expect(releaseSources[1]).To(BeAssignableToTypeOf(s3BuiltReleaseSource))
 			Expect(releaseSources[1]).To(MatchFields(IgnoreExtras, Fields{
-				"Bucket": Equal(kilnfile.ReleaseSources[1].Bucket),
-				"Regex":  Equal(kilnfile.ReleaseSources[1].Regex),
+				"Bucket": Equal("bucket-2"),
+				"Regex":  Equal(kilnfile.ReleaseSources[1].Options["regex"]),
 			}))
 
 			Expect(releaseSources[2]).To(BeAssignableToTypeOf(boshIOReleaseSource))
 
 			Expect(releaseSources[3]).To(BeAssignableToTypeOf(s3BuiltReleaseSource))
 			Expect(releaseSources[3]).To(MatchFields(IgnoreExtras, Fields{
-				"Bucket": Equal(kilnfile.ReleaseSources[3].Bucket),
-				"Regex":  Equal(kilnfile.ReleaseSources[3].Regex),
+				"Bucket": Equal("bucket-3"),
+				"Regex":  Equal(kilnfile.ReleaseSources[3].Options["regex"]),
 			}))
+
+			Expect(releaseSources[4]).To(BeAssignableToTypeOf(gitHubReleaseSource))
+			Expect(releaseSources[4]).To(PointTo(MatchFields(IgnoreExtras, Fields{
+				"Org":   Equal("cloudfoundry"),
+				"Repo":  Equal("uaa-release"),
+				"Token": Equal("some-token"),
+			})))
+
+			Expect(releaseSources[5]).To(BeAssignableToTypeOf(directoryReleaseSource))
+			Expect(releaseSources[5]).To(PointTo(MatchFields(IgnoreExtras, Fields{
+				"Directories": Equal([]string{"/mnt/releases"}),
+				"Regex":       Equal(kilnfile.ReleaseSources[5].Options["regex"]),
+			})))
+
+			Expect(releaseSources[6]).To(BeAssignableToTypeOf(artifactoryReleaseSource))
+			Expect(releaseSources[6]).To(PointTo(MatchFields(IgnoreExtras, Fields{
+				"ServerURL": Equal("https://artifactory.example.com/artifactory"),
+				"Repo":      Equal("bosh-releases"),
+				"Username":  Equal("some-user"),
+				"Password":  Equal("some-password"),
+			})))
 		})
 	})
 
 	Context("when allow-only-publishable-releases is true", func() {
 		BeforeEach(func() {
-			kilnfile = cargo.Kilnfile{
-				ReleaseSources: []cargo.ReleaseSourceConfig{
-					{Publishable: true, Type: "s3", Compiled: true, Bucket: "bucket-1", Region: "us-west-1", AccessKeyId: "ak1", SecretAccessKey: "shhhh!",
-						Regex: `^2.8/.+/(?P<release_name>[a-z-_0-9]+)-(?P<release_version>v?[0-9\.]+)-(?P<stemcell_os>[a-z-_]+)-(?P<stemcell_version>\d+\.\d+)(\.0)?\.tgz$`},
-					{Type: "s3", Compiled: false, Bucket: "bucket-2", Region: "us-west-2", AccessKeyId: "aki", SecretAccessKey: "shhhh!",
-						Regex: `^2.8/.+/(?P<release_name>[a-z-_0-9]+)-(?P<release_version>v?[0-9\.]+)\.tgz$`},
-					{Type: "bosh.io"},
-					{Type: "s3", Compiled: false, Bucket: "bucket-2", Region: "us-west-2", AccessKeyId: "aki", SecretAccessKey: "shhhh!",
-						Regex: `^(?P<release_name>[a-z-_0-9]+)-(?P<release_version>v?[0-9\.]+-?[a-zA-Z0-9]\.?[0-9]*)\.tgz$`},
-				},
-			}
+			kilnfile = parseKilnfile(`---
+release_sources:
+- type: s3
+  publishable: true
+  compiled: true
+  bucket: bucket-1
+  region: us-west-1
+  access_key_id: ak1
+  secret_access_key: shhhh!
+  regex: ^(?P<release_name>[a-z-_0-9]+)-(?P<release_version>v?[0-9\.]+)-(?P<stemcell_os>[a-z-_]+)-(?P<stemcell_version>\d+\.\d+)\.tgz$
+- type: s3
+  bucket: bucket-2
+  region: us-west-2
+  access_key_id: aki
+  secret_access_key: shhhh!
+  regex: ^(?P<release_name>[a-z-_0-9]+)-(?P<release_version>v?[0-9\.]+)\.tgz$
+- type: bosh.io
+- type: not-publishable-so-never-built
+`)
 		})
 
-		It("builds the correct release sources", func() {
-			releaseSources := rsFactory.ReleaseSources(kilnfile, true)
+		It("builds only the publishable release sources", func() {
+			releaseSources, err := rsFactory.ReleaseSources(kilnfile, true)
+			Expect(err).NotTo(HaveOccurred())
 			Expect(releaseSources).To(HaveLen(1))
 			var s3CompiledReleaseSource S3CompiledReleaseSource
 
 			Expect(releaseSources[0]).To(BeAssignableToTypeOf(s3CompiledReleaseSource))
 			Expect(releaseSources[0]).To(MatchFields(IgnoreExtras, Fields{
-				"Bucket": Equal(kilnfile.ReleaseSources[0].Bucket),
-				"Regex":  Equal(kilnfile.ReleaseSources[0].Regex),
+				"Bucket": Equal("bucket-1"),
 			}))
 		})
 	})
+
+	Context("when a release source has an unknown type", func() {
+		BeforeEach(func() {
+			kilnfile = parseKilnfile(`{release_sources: [{type: bosh.io}, {type: ftp}]}`)
+		})
+
+		It("returns an error", func() {
+			_, err := rsFactory.ReleaseSources(kilnfile, false)
+			Expect(err).To(MatchError(ContainSubstring(`release_sources[1]: unknown release source type "ftp"`)))
+			Expect(err).To(MatchError(ContainSubstring("kiln-release-source-ftp")))
+		})
+	})
+
+	Context("when a release source has no type", func() {
+		BeforeEach(func() {
+			kilnfile = parseKilnfile(`{release_sources: [{bucket: some-bucket}]}`)
+		})
+
+		It("returns an error", func() {
+			_, err := rsFactory.ReleaseSources(kilnfile, false)
+			Expect(err).To(MatchError("release_sources[0]: release source is missing a type"))
+		})
+	})
+
+	Context("when a release source is missing required keys", func() {
+		BeforeEach(func() {
+			kilnfile = parseKilnfile(`{release_sources: [{type: s3, bucket: some-bucket}]}`)
+		})
+
+		It("returns an error naming the keys", func() {
+			_, err := rsFactory.ReleaseSources(kilnfile, false)
+			Expect(err).To(MatchError("release_sources[0]: s3 release source is missing required keys: region, access_key_id, secret_access_key, regex"))
+		})
+	})
+
+	Context("when a release source has a key its type does not know", func() {
+		BeforeEach(func() {
+			kilnfile = parseKilnfile(`{release_sources: [{type: github, org: cloudfoundry, bucket: some-bucket}]}`)
+		})
+
+		It("returns an error", func() {
+			_, err := rsFactory.ReleaseSources(kilnfile, false)
+			Expect(err).To(MatchError(ContainSubstring("release_sources[0]: invalid github release source")))
+			Expect(err).To(MatchError(ContainSubstring("field bucket not found")))
+		})
+	})
+
+	Context("when an executable for the type is on the PATH", func() {
+		var (
+			binDir  string
+			oldPath string
+		)
+
+		BeforeEach(func() {
+			var err error
+			binDir, err = ioutil.TempDir("", "kiln-release-sources-factory-test")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ioutil.WriteFile(filepath.Join(binDir, "kiln-release-source-internal"), []byte("#!/bin/sh\n"), 0755)).To(Succeed())
+
+			oldPath = os.Getenv("PATH")
+			Expect(os.Setenv("PATH", binDir+string(os.PathListSeparator)+oldPath)).To(Succeed())
+
+			kilnfile = parseKilnfile(`{release_sources: [{type: internal, endpoint: https://releases.example.com}]}`)
+		})
+
+		AfterEach(func() {
+			Expect(os.Setenv("PATH", oldPath)).To(Succeed())
+			Expect(os.RemoveAll(binDir)).To(Succeed())
+		})
+
+		It("builds an external release source", func() {
+			releaseSources, err := rsFactory.ReleaseSources(kilnfile, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(releaseSources).To(HaveLen(1))
+			Expect(releaseSources[0]).To(PointTo(MatchFields(IgnoreExtras, Fields{
+				"Type":    Equal("internal"),
+				"Path":    Equal(filepath.Join(binDir, "kiln-release-source-internal")),
+				"Options": Equal(map[string]interface{}{"endpoint": "https://releases.example.com"}),
+			})))
+		})
+	})
+})
+
+var _ = Describe("MatchReleases", func() {
+	It("asks the release source for the releases of each stemcell separately", func() {
+		xenialRelease := CompiledRelease{ID: ReleaseID{Name: "uaa", Version: "1.2.3"}, StemcellOS: "ubuntu-xenial", StemcellVersion: "621.5"}
+		windowsRelease := CompiledRelease{ID: ReleaseID{Name: "hwc", Version: "1.0.0"}, StemcellOS: "windows", StemcellVersion: "2019.12"}
+
+		releaseSource := new(fakes.ReleaseSource)
+		releaseSource.GetMatchedReleasesReturnsOnCall(0, []RemoteRelease{xenialRelease}, nil)
+		releaseSource.GetMatchedReleasesReturnsOnCall(1, []RemoteRelease{windowsRelease}, nil)
+
+		matches, err := MatchReleases(releaseSource, ReleaseRequirementSet{
+			xenialRelease.ID:  {Name: "uaa", Version: "1.2.3", StemcellOS: "ubuntu-xenial", StemcellVersion: "621.5"},
+			windowsRelease.ID: {Name: "hwc", Version: "1.0.0", StemcellOS: "windows", StemcellVersion: "2019.12"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(matches).To(Equal([]RemoteRelease{xenialRelease, windowsRelease}))
+
+		Expect(releaseSource.GetMatchedReleasesCallCount()).To(Equal(2))
+		requirements, stemcell := releaseSource.GetMatchedReleasesArgsForCall(0)
+		Expect(stemcell).To(Equal(cargo.Stemcell{OS: "ubuntu-xenial", Version: "621.5"}))
+		Expect(requirements).To(HaveLen(1))
+		Expect(requirements).To(HaveKey(xenialRelease.ID))
+
+		requirements, stemcell = releaseSource.GetMatchedReleasesArgsForCall(1)
+		Expect(stemcell).To(Equal(cargo.Stemcell{OS: "windows", Version: "2019.12"}))
+		Expect(requirements).To(HaveKey(windowsRelease.ID))
+	})
 })
@@ -0,0 +1,42 @@
+package fetcher
+
+import "github.com/Masterminds/semver"
+
+// ReleaseVersionConstraint describes which versions of a release satisfy an
+// entry in the Kilnfile. The stemcell is only considered by sources that
+// serve compiled releases.
+type ReleaseVersionConstraint struct {
+	Name            string
+	Constraint      *semver.Constraints
+	StemcellOS      string
+	StemcellVersion string
+}
+
+func (c ReleaseVersionConstraint) newestMatch(releases []RemoteRelease) (RemoteRelease, bool) {
+	var (
+		newest        RemoteRelease
+		newestVersion *semver.Version
+	)
+
+	for _, release := range releases {
+		id := release.ReleaseID()
+		if id.Name != c.Name {
+			continue
+		}
+
+		version, err := semver.NewVersion(id.Version)
+		if err != nil {
+			continue
+		}
+
+		if c.Constraint != nil && !c.Constraint.Check(version) {
+			continue
+		}
+
+		if newestVersion == nil || version.GreaterThan(newestVersion) {
+			newest, newestVersion = release, version
+		}
+	}
+
+	return newest, newest != nil
+}
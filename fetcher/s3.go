@@ -1,15 +1,17 @@
 package fetcher
 
 import (
+	"fmt"
 	"io"
 	"log"
+	"path/filepath"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
-	"github.com/pivotal-cf/kiln/internal/cargo"
 )
 
 //go:generate counterfeiter -o ./fakes/s3_downloader.go --fake-name S3Downloader . S3Downloader
@@ -22,6 +24,15 @@ type S3ObjectLister interface {
 	ListObjectsPages(*s3.ListObjectsInput, func(*s3.ListObjectsOutput, bool) bool) error
 }
 
+type S3ReleaseSourceConfig struct {
+	Compiled        bool   `yaml:"compiled"`
+	Bucket          string `yaml:"bucket" required:"true"`
+	Region          string `yaml:"region" required:"true"`
+	AccessKeyId     string `yaml:"access_key_id" required:"true"`
+	SecretAccessKey string `yaml:"secret_access_key" required:"true"`
+	Regex           string `yaml:"regex" required:"true"`
+}
+
 type S3ReleaseSource struct {
 	Logger       *log.Logger
 	S3Client     S3ObjectLister
@@ -30,7 +41,7 @@ type S3ReleaseSource struct {
 	Regex        string
 }
 
-func (r *S3ReleaseSource) Configure(config cargo.ReleaseSourceConfig) {
+func (r *S3ReleaseSource) Configure(config S3ReleaseSourceConfig) {
 	// https://docs.aws.amazon.com/sdk-for-go/api/service/s3/
 	sess := session.Must(session.NewSession(&aws.Config{
 		Region: aws.String(config.Region),
@@ -48,3 +59,56 @@ func (r *S3ReleaseSource) Configure(config cargo.ReleaseSourceConfig) {
 	r.Bucket = config.Bucket
 	r.Regex = config.Regex
 }
+
+func (r S3ReleaseSource) String() string {
+	return "S3 bucket " + r.Bucket
+}
+
+func (r S3ReleaseSource) downloadReleases(releaseDir string, remoteReleases []RemoteRelease, opts DownloadOptions) (LocalReleaseSet, error) {
+	setConcurrency := func(dl *s3manager.Downloader) {
+		if opts.Threads > 0 {
+			dl.Concurrency = opts.Threads
+		} else {
+			dl.Concurrency = s3manager.DefaultDownloadConcurrency
+		}
+	}
+
+	return downloadReleasesConcurrently(remoteReleases, opts.workers(), func(release RemoteRelease) (LocalRelease, error) {
+		outputFile := filepath.Join(releaseDir, release.StandardizedFilename())
+
+		sum, err := downloadFile(outputFile, r.String(), opts.expectedSum(release), func(file downloadTarget, offset int64) error {
+			input := &s3.GetObjectInput{
+				Bucket: aws.String(r.Bucket),
+				Key:    aws.String(release.RemotePath()),
+			}
+			if offset > 0 {
+				input.Range = aws.String(fmt.Sprintf("bytes=%d-", offset))
+			}
+
+			progress := startDownloadProgress(r.Logger, release.RemotePath(), offset, 0)
+			_, err := r.S3Downloader.Download(progressWriterAt{offsetWriterAt{file, offset}, progress}, input, setConcurrency)
+			progress.finish(err)
+
+			if aerr, ok := err.(awserr.Error); ok && offset > 0 && aerr.Code() == "InvalidRange" {
+				return errRangeNotSatisfiable
+			}
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to download file: %w\n", err)
+		}
+
+		return withSHA1(release.AsLocal(outputFile), sum), nil
+	})
+}
+
+type progressWriterAt struct {
+	w        io.WriterAt
+	progress *downloadProgress
+}
+
+func (w progressWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	n, err := w.w.WriteAt(p, off)
+	w.progress.Write(p[:n])
+	return n, err
+}
@@ -2,21 +2,52 @@ package fetcher
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 	"regexp"
 
 	"github.com/pivotal-cf/kiln/internal/cargo"
 
-	"github.com/aws/aws-sdk-go/service/s3/s3manager"
-
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/s3"
 )
 
 type S3BuiltReleaseSource S3ReleaseSource
 
+func (src S3BuiltReleaseSource) String() string {
+	return S3ReleaseSource(src).String()
+}
+
 func (src S3BuiltReleaseSource) GetMatchedReleases(desiredReleaseSet ReleaseRequirementSet, stemcell cargo.Stemcell) ([]RemoteRelease, error) {
+	matchedS3Objects, err := src.listReleases()
+	if err != nil {
+		return nil, err
+	}
+
+	matchingReleases := make([]RemoteRelease, 0)
+	for expectedReleaseID := range desiredReleaseSet {
+		if rel, ok := matchedS3Objects[expectedReleaseID]; ok {
+			matchingReleases = append(matchingReleases, rel)
+		}
+	}
+
+	return matchingReleases, nil
+}
+
+func (src S3BuiltReleaseSource) FindReleaseVersion(constraint ReleaseVersionConstraint) (RemoteRelease, bool, error) {
+	matchedS3Objects, err := src.listReleases()
+	if err != nil {
+		return nil, false, err
+	}
+
+	candidates := make([]RemoteRelease, 0, len(matchedS3Objects))
+	for _, release := range matchedS3Objects {
+		candidates = append(candidates, release)
+	}
+
+	release, found := constraint.newestMatch(candidates)
+	return release, found, nil
+}
+
+func (src S3BuiltReleaseSource) listReleases() (map[ReleaseID]BuiltRelease, error) {
 	matchedS3Objects := make(map[ReleaseID]BuiltRelease)
 
 	exp, err := regexp.Compile(src.Regex)
@@ -54,52 +85,12 @@ func (src S3BuiltReleaseSource) GetMatchedReleases(desiredReleaseSet ReleaseRequ
 		return nil, err
 	}
 
-	matchingReleases := make([]RemoteRelease, 0)
-	for expectedReleaseID := range desiredReleaseSet {
-		if rel, ok := matchedS3Objects[expectedReleaseID]; ok {
-			matchingReleases = append(matchingReleases, rel)
-		}
-	}
-
-	return matchingReleases, err
+	return matchedS3Objects, nil
 }
 
-func (src S3BuiltReleaseSource) DownloadReleases(releaseDir string, remoteReleases []RemoteRelease, downloadThreads int) (LocalReleaseSet, error) {
-	localReleases := make(LocalReleaseSet)
-
+func (src S3BuiltReleaseSource) DownloadReleases(releaseDir string, remoteReleases []RemoteRelease, opts DownloadOptions) (LocalReleaseSet, error) {
 	src.Logger.Printf("downloading %d objects from built s3...", len(remoteReleases))
-	setConcurrency := func(dl *s3manager.Downloader) {
-		if downloadThreads > 0 {
-			dl.Concurrency = downloadThreads
-		} else {
-			dl.Concurrency = s3manager.DefaultDownloadConcurrency
-		}
-	}
-
-	var errs []error
-	for _, release := range remoteReleases {
-		outputFile := filepath.Join(releaseDir, release.StandardizedFilename())
-		file, err := os.Create(outputFile)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create file %q: %w", outputFile, err)
-		}
-
-		src.Logger.Printf("downloading %s...\n", release.RemotePath())
-		_, err = src.S3Downloader.Download(file, &s3.GetObjectInput{
-			Bucket: aws.String(src.Bucket),
-			Key:    aws.String(release.RemotePath()),
-		}, setConcurrency)
-
-		if err != nil {
-			return nil, fmt.Errorf("failed to download file: %w\n", err)
-		}
-		rID := release.ReleaseID()
-		localReleases[rID] = release.AsLocal(outputFile)
-	}
-	if len(errs) > 0 {
-		return nil, multipleErrors(errs)
-	}
-	return localReleases, nil
+	return S3ReleaseSource(src).downloadReleases(releaseDir, remoteReleases, opts)
 }
 
 func createBuiltReleaseFromS3Key(exp *regexp.Regexp, s3Key string) (BuiltRelease, error) {
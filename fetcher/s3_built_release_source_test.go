@@ -11,6 +11,7 @@ import (
 
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 
+	"github.com/Masterminds/semver"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/s3"
 	. "github.com/onsi/ginkgo"
@@ -169,7 +170,7 @@ var _ = Describe("S3BuiltReleaseSource DownloadReleases from Built source", func
 	})
 
 	It("downloads the appropriate versions of built releases listed in matchedS3Objects", func() {
-		localReleases, err := releaseSource.DownloadReleases(releaseDir, matchedS3Objects, 7)
+		localReleases, err := releaseSource.DownloadReleases(releaseDir, matchedS3Objects, DownloadOptions{Threads: 7})
 		Expect(err).NotTo(HaveOccurred())
 		Expect(fakeS3Downloader.DownloadCallCount()).To(Equal(2))
 
@@ -193,17 +194,19 @@ var _ = Describe("S3BuiltReleaseSource DownloadReleases from Built source", func
 			uaaReleaseID, BuiltRelease{
 				ID:   uaaReleaseID,
 				Path: uaaReleasePath,
+				SHA1: "cbfffd2506e6b00dfda2ee8d9760ceeb9379485f",
 			}))
 		Expect(localReleases).To(HaveKeyWithValue(
 			bpmReleaseID, BuiltRelease{
 				ID:   bpmReleaseID,
 				Path: bpmReleasePath,
+				SHA1: "d3e0b6eb285b4834d15bcc221ac14f673da35afa",
 			}))
 	})
 
 	Context("when the matchedS3Objects argument is empty", func() {
 		It("does not download anything from S3", func() {
-			_, err := releaseSource.DownloadReleases(releaseDir, nil, 0)
+			_, err := releaseSource.DownloadReleases(releaseDir, nil, DownloadOptions{})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(fakeS3Downloader.DownloadCallCount()).To(Equal(0))
 		})
@@ -211,7 +214,7 @@ var _ = Describe("S3BuiltReleaseSource DownloadReleases from Built source", func
 
 	Context("when number of threads is not specified", func() {
 		It("uses the s3manager package's default download concurrency", func() {
-			_, err := releaseSource.DownloadReleases(releaseDir, matchedS3Objects, 0)
+			_, err := releaseSource.DownloadReleases(releaseDir, matchedS3Objects, DownloadOptions{})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(fakeS3Downloader.DownloadCallCount()).To(Equal(2))
 
@@ -223,10 +226,87 @@ var _ = Describe("S3BuiltReleaseSource DownloadReleases from Built source", func
 		})
 	})
 
+	Context("when a partial download of a release exists", func() {
+		BeforeEach(func() {
+			err := ioutil.WriteFile(filepath.Join(releaseDir, "bpm-1.2.3.tgz.partial"), []byte("some-bucket/"), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			fakeS3Downloader.DownloadStub = func(writer io.WriterAt, objectInput *s3.GetObjectInput, setConcurrency ...func(dl *s3manager.Downloader)) (int64, error) {
+				contents := fmt.Sprintf("%s/%s", *objectInput.Bucket, *objectInput.Key)
+				if objectInput.Range != nil {
+					var offset int
+					_, err := fmt.Sscanf(*objectInput.Range, "bytes=%d-", &offset)
+					Expect(err).NotTo(HaveOccurred())
+					contents = contents[offset:]
+				}
+				n, err := writer.WriteAt([]byte(contents), 0)
+				return int64(n), err
+			}
+		})
+
+		It("resumes the download from the end of the partial file", func() {
+			_, err := releaseSource.DownloadReleases(releaseDir, matchedS3Objects, DownloadOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			bpmContents, err := ioutil.ReadFile(filepath.Join(releaseDir, "bpm-1.2.3.tgz"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bpmContents).To(Equal([]byte("some-bucket/some-bpm-key")))
+			Expect(filepath.Join(releaseDir, "bpm-1.2.3.tgz.partial")).NotTo(BeAnExistingFile())
+
+			var ranges []string
+			for i := 0; i < fakeS3Downloader.DownloadCallCount(); i++ {
+				_, input, _ := fakeS3Downloader.DownloadArgsForCall(i)
+				if input.Range != nil {
+					ranges = append(ranges, *input.Key+" "+*input.Range)
+				}
+			}
+			Expect(ranges).To(ConsistOf("some-bpm-key bytes=12-"))
+		})
+	})
+
+	Context("when the parts of a release are downloaded out of order", func() {
+		BeforeEach(func() {
+			fakeS3Downloader.DownloadStub = func(writer io.WriterAt, objectInput *s3.GetObjectInput, setConcurrency ...func(dl *s3manager.Downloader)) (int64, error) {
+				contents := []byte(fmt.Sprintf("%s/%s", *objectInput.Bucket, *objectInput.Key))
+				half := len(contents) / 2
+				if _, err := writer.WriteAt(contents[half:], int64(half)); err != nil {
+					return 0, err
+				}
+				n, err := writer.WriteAt(contents[:half], 0)
+				return int64(half + n), err
+			}
+		})
+
+		It("still verifies the checksums", func() {
+			checksums := NewReleaseChecksums(cargo.KilnfileLock{Releases: []cargo.Release{
+				{Name: "bpm", Version: "1.2.3", SHA1: "d3e0b6eb285b4834d15bcc221ac14f673da35afa"},
+				{Name: "uaa", Version: "1.2.3", SHA1: "cbfffd2506e6b00dfda2ee8d9760ceeb9379485f"},
+			}})
+
+			_, err := releaseSource.DownloadReleases(releaseDir, matchedS3Objects, DownloadOptions{Checksums: checksums})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
 	Context("failure cases", func() {
+		Context("when a release does not match its checksum", func() {
+			It("returns an error naming the bucket", func() {
+				checksums := NewReleaseChecksums(cargo.KilnfileLock{Releases: []cargo.Release{
+					{Name: "bpm", Version: "1.2.3", SHA1: "some-other-sha1"},
+				}})
+
+				_, err := releaseSource.DownloadReleases(releaseDir, matchedS3Objects, DownloadOptions{Checksums: checksums})
+				Expect(err).To(MatchError(ContainSubstring("S3 bucket some-bucket served bpm-1.2.3.tgz with SHA1 d3e0b6eb285b4834d15bcc221ac14f673da35afa, but Kilnfile.lock expects some-other-sha1")))
+
+				var mismatch ChecksumMismatchError
+				Expect(errors.As(err, &mismatch)).To(BeTrue())
+				Expect(filepath.Join(releaseDir, "bpm-1.2.3.tgz")).NotTo(BeAnExistingFile())
+			})
+		})
+
 		Context("when a file can't be created", func() {
 			It("returns an error", func() {
-				_, err := releaseSource.DownloadReleases("/non-existent-folder", matchedS3Objects, 0)
+				_, err := releaseSource.DownloadReleases("/non-existent-folder", matchedS3Objects, DownloadOptions{})
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("/non-existent-folder"))
 			})
@@ -240,10 +320,83 @@ var _ = Describe("S3BuiltReleaseSource DownloadReleases from Built source", func
 			})
 
 			It("returns an error", func() {
-				_, err := releaseSource.DownloadReleases(releaseDir, matchedS3Objects, 0)
+				_, err := releaseSource.DownloadReleases(releaseDir, matchedS3Objects, DownloadOptions{})
 				Expect(err).To(HaveOccurred())
 				Expect(err).To(MatchError("failed to download file: 503 Service Unavailable\n"))
 			})
+
+			It("does not leave the release in the release dir", func() {
+				_, err := releaseSource.DownloadReleases(releaseDir, matchedS3Objects, DownloadOptions{})
+				Expect(err).To(HaveOccurred())
+
+				Expect(filepath.Join(releaseDir, "bpm-1.2.3.tgz")).NotTo(BeAnExistingFile())
+				Expect(filepath.Join(releaseDir, "uaa-1.2.3.tgz")).NotTo(BeAnExistingFile())
+			})
+		})
+	})
+})
+
+var _ = Describe("FindReleaseVersion from S3 built source", func() {
+	var (
+		releaseSource S3BuiltReleaseSource
+		constraint    ReleaseVersionConstraint
+	)
+
+	BeforeEach(func() {
+		fakeS3Client := new(fakes.S3ObjectLister)
+
+		keys := []string{
+			"2.5/bpm/bpm-1.1.0.tgz",
+			"2.5/bpm/bpm-1.1.9.tgz",
+			"2.5/bpm/bpm-1.2.0.tgz",
+			"2.5/uaa/uaa-1.1.11.tgz",
+		}
+		fakeS3Client.ListObjectsPagesStub = func(input *s3.ListObjectsInput, fn func(*s3.ListObjectsOutput, bool) bool) error {
+			var objects []*s3.Object
+			for i := range keys {
+				objects = append(objects, &s3.Object{Key: &keys[i]})
+			}
+			fn(&s3.ListObjectsOutput{Contents: objects}, true)
+			return nil
+		}
+
+		releaseSource = S3BuiltReleaseSource{
+			Logger:   log.New(GinkgoWriter, "", 0),
+			S3Client: fakeS3Client,
+			Regex:    `^2.5/.+/(?P<release_name>[a-z-_]+)-(?P<release_version>[0-9\.]+)\.tgz$`,
+			Bucket:   "built-bucket",
+		}
+
+		versionConstraint, err := semver.NewConstraint("~1.1")
+		Expect(err).NotTo(HaveOccurred())
+
+		constraint = ReleaseVersionConstraint{
+			Name:            "bpm",
+			Constraint:      versionConstraint,
+			StemcellOS:      "ignored",
+			StemcellVersion: "ignored",
+		}
+	})
+
+	It("returns the newest release matching the constraint", func() {
+		release, found, err := releaseSource.FindReleaseVersion(constraint)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(release).To(Equal(BuiltRelease{
+			ID:   ReleaseID{Name: "bpm", Version: "1.1.9"},
+			Path: "2.5/bpm/bpm-1.1.9.tgz",
+		}))
+	})
+
+	When("no release matches", func() {
+		BeforeEach(func() {
+			constraint.Name = "capi"
+		})
+
+		It("does not find a release", func() {
+			_, found, err := releaseSource.FindReleaseVersion(constraint)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeFalse())
 		})
 	})
 })
@@ -2,15 +2,12 @@ package fetcher
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 	"regexp"
 
 	"github.com/pivotal-cf/kiln/internal/cargo"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/s3"
-	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 )
 
 const (
@@ -22,7 +19,51 @@ const (
 
 type S3CompiledReleaseSource S3ReleaseSource
 
+func (r S3CompiledReleaseSource) String() string {
+	return S3ReleaseSource(r).String()
+}
+
 func (r S3CompiledReleaseSource) GetMatchedReleases(desiredReleaseSet ReleaseRequirementSet, stemcell cargo.Stemcell) ([]RemoteRelease, error) {
+	matchedS3Objects, err := r.listReleases()
+	if err != nil {
+		return nil, err
+	}
+
+	matchingReleases := make([]RemoteRelease, 0)
+	for expectedReleaseID := range desiredReleaseSet {
+		if releases, ok := matchedS3Objects[expectedReleaseID]; ok {
+			for _, release := range releases {
+				if release.StemcellVersion == stemcell.Version && release.StemcellOS == stemcell.OS {
+					matchingReleases = append(matchingReleases, release)
+					break
+				}
+			}
+		}
+	}
+
+	return matchingReleases, nil
+}
+
+func (r S3CompiledReleaseSource) FindReleaseVersion(constraint ReleaseVersionConstraint) (RemoteRelease, bool, error) {
+	matchedS3Objects, err := r.listReleases()
+	if err != nil {
+		return nil, false, err
+	}
+
+	var candidates []RemoteRelease
+	for _, releases := range matchedS3Objects {
+		for _, release := range releases {
+			if release.StemcellVersion == constraint.StemcellVersion && release.StemcellOS == constraint.StemcellOS {
+				candidates = append(candidates, release)
+			}
+		}
+	}
+
+	release, found := constraint.newestMatch(candidates)
+	return release, found, nil
+}
+
+func (r S3CompiledReleaseSource) listReleases() (map[ReleaseID][]CompiledRelease, error) {
 	matchedS3Objects := make(map[ReleaseID][]CompiledRelease)
 
 	exp, err := regexp.Compile(r.Regex)
@@ -62,58 +103,12 @@ func (r S3CompiledReleaseSource) GetMatchedReleases(desiredReleaseSet ReleaseReq
 		return nil, err
 	}
 
-	matchingReleases := make([]RemoteRelease, 0)
-	for expectedReleaseID := range desiredReleaseSet {
-		if releases, ok := matchedS3Objects[expectedReleaseID]; ok {
-			for _, release := range releases {
-				if release.StemcellVersion == stemcell.Version && release.StemcellOS == stemcell.OS {
-					matchingReleases = append(matchingReleases, release)
-					break
-				}
-			}
-		}
-	}
-
-	return matchingReleases, nil
+	return matchedS3Objects, nil
 }
 
-func (r S3CompiledReleaseSource) DownloadReleases(releaseDir string, remoteReleases []RemoteRelease, downloadThreads int) (LocalReleaseSet, error) {
-	localReleases := make(LocalReleaseSet)
-
+func (r S3CompiledReleaseSource) DownloadReleases(releaseDir string, remoteReleases []RemoteRelease, opts DownloadOptions) (LocalReleaseSet, error) {
 	r.Logger.Printf("downloading %d objects from compiled s3...", len(remoteReleases))
-	setConcurrency := func(dl *s3manager.Downloader) {
-		if downloadThreads > 0 {
-			dl.Concurrency = downloadThreads
-		} else {
-			dl.Concurrency = s3manager.DefaultDownloadConcurrency
-		}
-	}
-
-	var errs []error
-	for _, release := range remoteReleases {
-		outputFile := filepath.Join(releaseDir, release.StandardizedFilename())
-		file, err := os.Create(outputFile)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create file %q: %w", outputFile, err)
-		}
-
-		r.Logger.Printf("downloading %s...\n", release.RemotePath())
-		_, err = r.S3Downloader.Download(file, &s3.GetObjectInput{
-			Bucket: aws.String(r.Bucket),
-			Key:    aws.String(release.RemotePath()),
-		}, setConcurrency)
-
-		if err != nil {
-			return nil, fmt.Errorf("failed to download file: %w\n", err)
-		}
-
-		rID := release.ReleaseID()
-		localReleases[rID] = release.AsLocal(outputFile)
-	}
-	if len(errs) > 0 {
-		return nil, multipleErrors(errs)
-	}
-	return localReleases, nil
+	return S3ReleaseSource(r).downloadReleases(releaseDir, remoteReleases, opts)
 }
 
 func createCompiledReleaseFromS3Key(exp *regexp.Regexp, s3Key string) (CompiledRelease, error) {
@@ -11,6 +11,7 @@ import (
 
 	"github.com/pivotal-cf/kiln/internal/cargo"
 
+	"github.com/Masterminds/semver"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
@@ -274,7 +275,7 @@ var _ = Describe("S3CompiledReleaseSource DownloadReleases from compiled source"
 	})
 
 	It("downloads the appropriate versions of releases listed in matchedS3Objects", func() {
-		localReleases, err := releaseSource.DownloadReleases(releaseDir, matchedS3Objects, 7)
+		localReleases, err := releaseSource.DownloadReleases(releaseDir, matchedS3Objects, DownloadOptions{Threads: 7})
 		Expect(err).NotTo(HaveOccurred())
 		Expect(fakeS3Downloader.DownloadCallCount()).To(Equal(2))
 
@@ -295,27 +296,29 @@ var _ = Describe("S3CompiledReleaseSource DownloadReleases from compiled source"
 
 		Expect(localReleases).To(HaveLen(2))
 		Expect(localReleases).To(HaveKeyWithValue(
-			uaaReleaseID,
+			ReleaseID{Name: "uaa", Version: "1.2.3", StemcellOS: expectedStemcellOS},
 			CompiledRelease{
 				ID:              uaaReleaseID,
 				StemcellOS:      expectedStemcellOS,
 				StemcellVersion: expectedStemcellVersion,
 				Path:            uaaReleasePath,
+				SHA1:            "cbfffd2506e6b00dfda2ee8d9760ceeb9379485f",
 			}))
 		Expect(localReleases).To(HaveKeyWithValue(
-			bpmReleaseID,
+			ReleaseID{Name: "bpm", Version: "1.2.3", StemcellOS: expectedStemcellOS},
 			CompiledRelease{
 				ID:              bpmReleaseID,
 				StemcellOS:      expectedStemcellOS,
 				StemcellVersion: expectedStemcellVersion,
 				Path:            bpmReleasePath,
+				SHA1:            "d3e0b6eb285b4834d15bcc221ac14f673da35afa",
 			}))
 
 	})
 
 	Context("when the matchedS3Objects argument is empty", func() {
 		It("does not download anything from S3", func() {
-			_, err := releaseSource.DownloadReleases(releaseDir, nil, 0)
+			_, err := releaseSource.DownloadReleases(releaseDir, nil, DownloadOptions{})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(fakeS3Downloader.DownloadCallCount()).To(Equal(0))
 		})
@@ -323,7 +326,7 @@ var _ = Describe("S3CompiledReleaseSource DownloadReleases from compiled source"
 
 	Context("when number of threads is not specified", func() {
 		It("uses the s3manager package's default download concurrency", func() {
-			_, err := releaseSource.DownloadReleases(releaseDir, matchedS3Objects, 0)
+			_, err := releaseSource.DownloadReleases(releaseDir, matchedS3Objects, DownloadOptions{})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(fakeS3Downloader.DownloadCallCount()).To(Equal(2))
 
@@ -338,7 +341,7 @@ var _ = Describe("S3CompiledReleaseSource DownloadReleases from compiled source"
 	Context("failure cases", func() {
 		Context("when a file can't be created", func() {
 			It("returns an error", func() {
-				_, err := releaseSource.DownloadReleases("/non-existent-folder", matchedS3Objects, 0)
+				_, err := releaseSource.DownloadReleases("/non-existent-folder", matchedS3Objects, DownloadOptions{})
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("/non-existent-folder"))
 			})
@@ -352,10 +355,104 @@ var _ = Describe("S3CompiledReleaseSource DownloadReleases from compiled source"
 			})
 
 			It("returns an error", func() {
-				_, err := releaseSource.DownloadReleases(releaseDir, matchedS3Objects, 0)
+				_, err := releaseSource.DownloadReleases(releaseDir, matchedS3Objects, DownloadOptions{})
 				Expect(err).To(HaveOccurred())
 				Expect(err).To(MatchError("failed to download file: 503 Service Unavailable\n"))
 			})
 		})
 	})
 })
+
+var _ = Describe("FindReleaseVersion from S3 compiled source", func() {
+	var (
+		releaseSource S3CompiledReleaseSource
+		fakeS3Client  *fakes.S3ObjectLister
+		constraint    ReleaseVersionConstraint
+	)
+
+	BeforeEach(func() {
+		fakeS3Client = new(fakes.S3ObjectLister)
+
+		keys := []string{
+			"2.5/bpm/bpm-1.1.0-ubuntu-xenial-190.0.0.tgz",
+			"2.5/bpm/bpm-1.1.9-ubuntu-xenial-190.0.0.tgz",
+			"2.5/bpm/bpm-1.1.10-ubuntu-xenial-191.0.0.tgz",
+			"2.5/bpm/bpm-1.2.0-ubuntu-xenial-190.0.0.tgz",
+			"2.5/uaa/uaa-1.1.11-ubuntu-xenial-190.0.0.tgz",
+		}
+		fakeS3Client.ListObjectsPagesStub = func(input *s3.ListObjectsInput, fn func(*s3.ListObjectsOutput, bool) bool) error {
+			var objects []*s3.Object
+			for i := range keys {
+				objects = append(objects, &s3.Object{Key: &keys[i]})
+			}
+			fn(&s3.ListObjectsOutput{Contents: objects}, true)
+			return nil
+		}
+
+		releaseSource = S3CompiledReleaseSource{
+			Logger:   log.New(GinkgoWriter, "", 0),
+			S3Client: fakeS3Client,
+			Regex:    `^2.5/.+/(?P<release_name>[a-z-_]+)-(?P<release_version>[0-9\.]+)-(?P<stemcell_os>[a-z-_]+)-(?P<stemcell_version>[\d\.]+)\.tgz$`,
+			Bucket:   "some-bucket",
+		}
+
+		versionConstraint, err := semver.NewConstraint("~1.1")
+		Expect(err).NotTo(HaveOccurred())
+
+		constraint = ReleaseVersionConstraint{
+			Name:            "bpm",
+			Constraint:      versionConstraint,
+			StemcellOS:      "ubuntu-xenial",
+			StemcellVersion: "190.0.0",
+		}
+	})
+
+	It("returns the newest release matching the constraint and stemcell", func() {
+		release, found, err := releaseSource.FindReleaseVersion(constraint)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(release).To(Equal(CompiledRelease{
+			ID:              ReleaseID{Name: "bpm", Version: "1.1.9"},
+			StemcellOS:      "ubuntu-xenial",
+			StemcellVersion: "190.0.0",
+			Path:            "2.5/bpm/bpm-1.1.9-ubuntu-xenial-190.0.0.tgz",
+		}))
+	})
+
+	When("there is no version constraint", func() {
+		BeforeEach(func() {
+			constraint.Constraint = nil
+		})
+
+		It("returns the newest release for the stemcell", func() {
+			release, found, err := releaseSource.FindReleaseVersion(constraint)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(release.ReleaseID()).To(Equal(ReleaseID{Name: "bpm", Version: "1.2.0"}))
+		})
+	})
+
+	When("no release matches", func() {
+		BeforeEach(func() {
+			constraint.StemcellVersion = "192.0.0"
+		})
+
+		It("does not find a release", func() {
+			_, found, err := releaseSource.FindReleaseVersion(constraint)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	When("listing the bucket fails", func() {
+		BeforeEach(func() {
+			fakeS3Client.ListObjectsPagesStub = nil
+			fakeS3Client.ListObjectsPagesReturns(errors.New("boom"))
+		})
+
+		It("returns the error", func() {
+			_, _, err := releaseSource.FindReleaseVersion(constraint)
+			Expect(err).To(MatchError("boom"))
+		})
+	})
+})
@@ -0,0 +1,217 @@
+package fetcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pivotal-cf/kiln/internal/cargo"
+)
+
+const (
+	StemcellSourceBOSHIO = "bosh.io"
+	StemcellSourcePivnet = "pivnet"
+
+	DefaultStemcellInfrastructure = "vsphere-esxi"
+)
+
+var stemcellSlugs = map[string]string{
+	"windows":       "stemcells-windows-server",
+	"ubuntu-xenial": "stemcells-ubuntu-xenial",
+	"ubuntu-trusty": "stemcells",
+}
+
+// StemcellSlug returns the network.pivotal.io product slug of the stemcell OS.
+func StemcellSlug(os string) (string, bool) {
+	slug, ok := stemcellSlugs[os]
+	return slug, ok
+}
+
+// StemcellTarballFilename is the name of the tarball of a locked stemcell in
+// the stemcells directory.
+func StemcellTarballFilename(stemcell cargo.Stemcell) string {
+	return fmt.Sprintf("bosh-stemcell-%s-%s-%s-go_agent.tgz", stemcell.Version, stemcellInfrastructure(stemcell), stemcell.OS)
+}
+
+// StemcellTarballs finds the stemcell tarballs pinned in Kilnfile.lock on
+// bosh.io or network.pivotal.io and downloads them.
+type StemcellTarballs struct {
+	logger    *log.Logger
+	pivnet    *Pivnet
+	serverURI string
+}
+
+func NewStemcellTarballs(logger *log.Logger, pivnet *Pivnet, customBOSHIOServerURI string) StemcellTarballs {
+	if customBOSHIOServerURI == "" {
+		customBOSHIOServerURI = "https://bosh.io"
+	}
+
+	return StemcellTarballs{
+		logger:    logger,
+		pivnet:    pivnet,
+		serverURI: customBOSHIOServerURI,
+	}
+}
+
+func (st StemcellTarballs) SetToken(token string) {
+	st.pivnet.SetToken(token)
+}
+
+// Lock returns the stemcell with the URL and checksum of its tarball. Stemcells
+// without a source are returned unchanged.
+func (st StemcellTarballs) Lock(stemcell cargo.Stemcell) (cargo.Stemcell, error) {
+	switch stemcell.Source {
+	case "":
+		return stemcell, nil
+	case StemcellSourceBOSHIO:
+		return st.lockBOSHIO(stemcell)
+	case StemcellSourcePivnet:
+		return st.lockPivnet(stemcell)
+	default:
+		return cargo.Stemcell{}, fmt.Errorf("unknown stemcell source %q (expected %s or %s)", stemcell.Source, StemcellSourceBOSHIO, StemcellSourcePivnet)
+	}
+}
+
+func (st StemcellTarballs) lockBOSHIO(stemcell cargo.Stemcell) (cargo.Stemcell, error) {
+	name := fmt.Sprintf("bosh-%s-%s-go_agent", stemcellInfrastructure(stemcell), stemcell.OS)
+
+	resp, err := http.Get(fmt.Sprintf("%s/api/v1/stemcells/%s", st.serverURI, name))
+	if err != nil {
+		return cargo.Stemcell{}, fmt.Errorf("bosh.io API is down with error: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return cargo.Stemcell{}, (*ResponseStatusCodeError)(resp)
+	}
+
+	type tarball struct {
+		URL  string `json:"url"`
+		SHA1 string `json:"sha1"`
+	}
+	var versions []struct {
+		Version string   `json:"version"`
+		Regular *tarball `json:"regular"`
+		Light   *tarball `json:"light"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&versions); err != nil {
+		return cargo.Stemcell{}, err
+	}
+
+	for _, version := range versions {
+		if version.Version != stemcell.Version {
+			continue
+		}
+
+		found := version.Regular
+		if found == nil {
+			found = version.Light
+		}
+		if found == nil {
+			break
+		}
+
+		stemcell.URL, stemcell.SHA1 = found.URL, found.SHA1
+		return stemcell, nil
+	}
+
+	return cargo.Stemcell{}, fmt.Errorf("bosh.io has no %s stemcell with version %s", name, stemcell.Version)
+}
+
+// lockPivnet pins the SHA256 network.pivotal.io publishes for the tarball, as
+// it has no SHA1 checksums, so that the tarball is not downloaded to be
+// locked.
+func (st StemcellTarballs) lockPivnet(stemcell cargo.Stemcell) (cargo.Stemcell, error) {
+	slug, ok := StemcellSlug(stemcell.OS)
+	if !ok {
+		return cargo.Stemcell{}, fmt.Errorf("network.pivotal.io has no stemcells for os %s", stemcell.OS)
+	}
+
+	files, err := st.pivnet.ProductFiles(slug, stemcell.Version)
+	if err != nil {
+		return cargo.Stemcell{}, err
+	}
+
+	infrastructure := "-" + stemcellInfrastructure(stemcell) + "-"
+	for _, file := range files {
+		if !strings.Contains(path.Base(file.AWSObjectKey), infrastructure) {
+			continue
+		}
+		if file.SHA256 == "" {
+			return cargo.Stemcell{}, fmt.Errorf("%s %s has no SHA256 for %s", slug, stemcell.Version, path.Base(file.AWSObjectKey))
+		}
+
+		stemcell.URL, stemcell.SHA256 = file.Links.Download.Href, file.SHA256
+		return stemcell, nil
+	}
+
+	return cargo.Stemcell{}, fmt.Errorf("%s %s has no stemcell for infrastructure %s", slug, stemcell.Version, stemcellInfrastructure(stemcell))
+}
+
+// DownloadStemcells puts the tarball of each stemcell into stemcellsDir and
+// verifies its checksum. Tarballs already in stemcellsDir with the locked
+// checksum are kept.
+func (st StemcellTarballs) DownloadStemcells(stemcellsDir string, stemcells []cargo.Stemcell) error {
+	for _, stemcell := range stemcells {
+		algorithm, expectedSum := stemcellChecksum(stemcell)
+		if stemcell.URL == "" || expectedSum == "" {
+			return fmt.Errorf("stemcell %s %s is not pinned to a tarball in Kilnfile.lock (set a stemcell source in the Kilnfile and run kiln update)", stemcell.OS, stemcell.Version)
+		}
+
+		tarballPath := filepath.Join(stemcellsDir, StemcellTarballFilename(stemcell))
+		if _, err := os.Stat(tarballPath); err == nil {
+			if _, err := verifyFileWith(algorithm, tarballPath, stemcell.Source, expectedSum); err == nil {
+				st.logger.Printf("stemcell %s %s is already downloaded\n", stemcell.OS, stemcell.Version)
+				continue
+			}
+			os.Remove(tarballPath)
+		}
+
+		if err := st.download(tarballPath, stemcell); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (st StemcellTarballs) download(tarballPath string, stemcell cargo.Stemcell) error {
+	algorithm, expectedSum := stemcellChecksum(stemcell)
+	_, err := downloadFileWith(algorithm, tarballPath, stemcell.Source, expectedSum, func(file downloadTarget, offset int64) error {
+		if stemcell.Source == StemcellSourcePivnet {
+			// network.pivotal.io redirects a POST to the download link to the file
+			req, err := http.NewRequest(http.MethodPost, stemcell.URL, nil)
+			if err != nil {
+				return err
+			}
+			return downloadHTTPWith(st.logger, st.pivnet.Do, req, file, offset)
+		}
+
+		req, err := http.NewRequest(http.MethodGet, stemcell.URL, nil)
+		if err != nil {
+			return err
+		}
+		return downloadHTTP(st.logger, req, file, offset)
+	})
+	return err
+}
+
+// stemcellChecksum returns the checksum the tarball of the stemcell is pinned
+// by.
+func stemcellChecksum(stemcell cargo.Stemcell) (checksumAlgorithm, string) {
+	if stemcell.SHA256 != "" {
+		return sha256Algorithm, stemcell.SHA256
+	}
+	return sha1Algorithm, stemcell.SHA1
+}
+
+func stemcellInfrastructure(stemcell cargo.Stemcell) string {
+	if stemcell.Infrastructure == "" {
+		return DefaultStemcellInfrastructure
+	}
+	return stemcell.Infrastructure
+}
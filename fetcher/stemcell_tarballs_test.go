@@ -0,0 +1,224 @@
+package fetcher_test
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	. "github.com/pivotal-cf/kiln/fetcher"
+	"github.com/pivotal-cf/kiln/internal/cargo"
+)
+
+// testServerTransport sends the requests meant for network.pivotal.io to a
+// test server.
+type testServerTransport struct {
+	server *ghttp.Server
+}
+
+func (t testServerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	serverURL, err := url.Parse(t.server.URL())
+	if err != nil {
+		return nil, err
+	}
+	req.URL.Scheme, req.URL.Host = serverURL.Scheme, serverURL.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+var _ = Describe("StemcellTarballs", func() {
+	const stemcellContents = "stemcell-contents"
+
+	var (
+		testServer       *ghttp.Server
+		pivnet           *Pivnet
+		stemcellTarballs StemcellTarballs
+		stemcellSHA1     string
+		stemcellSHA256   string
+	)
+
+	BeforeEach(func() {
+		testServer = ghttp.NewServer()
+		pivnet = &Pivnet{Client: &http.Client{Transport: testServerTransport{server: testServer}}}
+		stemcellTarballs = NewStemcellTarballs(log.New(GinkgoWriter, "", 0), pivnet, testServer.URL())
+
+		sum := sha1.Sum([]byte(stemcellContents))
+		stemcellSHA1 = hex.EncodeToString(sum[:])
+		sum256 := sha256.Sum256([]byte(stemcellContents))
+		stemcellSHA256 = hex.EncodeToString(sum256[:])
+	})
+
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	Describe("Lock", func() {
+		It("returns stemcells without a source unchanged", func() {
+			stemcell := cargo.Stemcell{OS: "ubuntu-xenial", Version: "621.5"}
+			Expect(stemcellTarballs.Lock(stemcell)).To(Equal(stemcell))
+		})
+
+		Context("when the source is bosh.io", func() {
+			BeforeEach(func() {
+				testServer.RouteToHandler("GET", "/api/v1/stemcells/bosh-aws-xen-hvm-ubuntu-xenial-go_agent", ghttp.RespondWith(http.StatusOK, `[
+  {"version": "621.6", "regular": {"url": "https://example.com/621.6.tgz", "sha1": "newer-sha1"}},
+  {"version": "621.5", "light": {"url": "https://example.com/light-621.5.tgz", "sha1": "light-sha1"}}
+]`))
+			})
+
+			It("pins the tarball bosh.io has for the version", func() {
+				stemcell, err := stemcellTarballs.Lock(cargo.Stemcell{OS: "ubuntu-xenial", Version: "621.5", Source: "bosh.io", Infrastructure: "aws-xen-hvm"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stemcell.URL).To(Equal("https://example.com/light-621.5.tgz"))
+				Expect(stemcell.SHA1).To(Equal("light-sha1"))
+			})
+
+			It("returns an error when bosh.io does not have the version", func() {
+				_, err := stemcellTarballs.Lock(cargo.Stemcell{OS: "ubuntu-xenial", Version: "456.1", Source: "bosh.io", Infrastructure: "aws-xen-hvm"})
+				Expect(err).To(MatchError("bosh.io has no bosh-aws-xen-hvm-ubuntu-xenial-go_agent stemcell with version 456.1"))
+			})
+		})
+
+		Context("when the source is pivnet", func() {
+			BeforeEach(func() {
+				pivnet.SetToken("some-token")
+				testServer.RouteToHandler("GET", "/api/v2/products/stemcells-ubuntu-xenial/releases", ghttp.RespondWith(http.StatusOK, `{"releases": [{"id": 7, "version": "621.5"}]}`))
+				testServer.RouteToHandler("GET", "/api/v2/products/stemcells-ubuntu-xenial/releases/7/product_files", ghttp.RespondWith(http.StatusOK, `{"product_files": [
+  {"id": 1, "aws_object_key": "product-files/stemcells/bosh-stemcell-621.5-aws-xen-hvm-ubuntu-xenial-go_agent.tgz", "sha256": "aws-sha256", "_links": {"download": {"href": "https://network.pivotal.io/api/v2/products/stemcells-ubuntu-xenial/releases/7/product_files/1/download"}}},
+  {"id": 2, "aws_object_key": "product-files/stemcells/bosh-stemcell-621.5-vsphere-esxi-ubuntu-xenial-go_agent.tgz", "sha256": "vsphere-sha256", "_links": {"download": {"href": "https://network.pivotal.io/api/v2/products/stemcells-ubuntu-xenial/releases/7/product_files/2/download"}}},
+  {"id": 3, "aws_object_key": "product-files/stemcells/bosh-stemcell-621.5-azure-hyperv-ubuntu-xenial-go_agent.tgz", "_links": {"download": {"href": "https://network.pivotal.io/api/v2/products/stemcells-ubuntu-xenial/releases/7/product_files/3/download"}}}
+]}`))
+			})
+
+			It("pins the download link of the file for the infrastructure and its SHA256 without downloading it", func() {
+				stemcell, err := stemcellTarballs.Lock(cargo.Stemcell{OS: "ubuntu-xenial", Version: "621.5", Source: "pivnet"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stemcell.URL).To(Equal("https://network.pivotal.io/api/v2/products/stemcells-ubuntu-xenial/releases/7/product_files/2/download"))
+				Expect(stemcell.SHA256).To(Equal("vsphere-sha256"))
+				Expect(stemcell.SHA1).To(BeEmpty())
+
+				for _, req := range testServer.ReceivedRequests() {
+					Expect(req.Method).To(Equal("GET"))
+				}
+			})
+
+			It("returns an error when network.pivotal.io has no SHA256 for the file", func() {
+				_, err := stemcellTarballs.Lock(cargo.Stemcell{OS: "ubuntu-xenial", Version: "621.5", Source: "pivnet", Infrastructure: "azure-hyperv"})
+				Expect(err).To(MatchError("stemcells-ubuntu-xenial 621.5 has no SHA256 for bosh-stemcell-621.5-azure-hyperv-ubuntu-xenial-go_agent.tgz"))
+			})
+
+			It("returns an error when there is no file for the infrastructure", func() {
+				_, err := stemcellTarballs.Lock(cargo.Stemcell{OS: "ubuntu-xenial", Version: "621.5", Source: "pivnet", Infrastructure: "google-kvm"})
+				Expect(err).To(MatchError("stemcells-ubuntu-xenial 621.5 has no stemcell for infrastructure google-kvm"))
+			})
+		})
+
+		It("returns an error for an unknown source", func() {
+			_, err := stemcellTarballs.Lock(cargo.Stemcell{OS: "ubuntu-xenial", Version: "621.5", Source: "ftp"})
+			Expect(err).To(MatchError(`unknown stemcell source "ftp" (expected bosh.io or pivnet)`))
+		})
+	})
+
+	Describe("DownloadStemcells", func() {
+		var (
+			stemcellsDir string
+			stemcell     cargo.Stemcell
+		)
+
+		BeforeEach(func() {
+			var err error
+			stemcellsDir, err = ioutil.TempDir("", "kiln-stemcells")
+			Expect(err).NotTo(HaveOccurred())
+
+			testServer.RouteToHandler("GET", "/stemcell.tgz", ghttp.RespondWith(http.StatusOK, stemcellContents))
+			stemcell = cargo.Stemcell{OS: "ubuntu-xenial", Version: "621.5", Source: "bosh.io", URL: testServer.URL() + "/stemcell.tgz", SHA1: stemcellSHA1}
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(stemcellsDir)).To(Succeed())
+		})
+
+		It("downloads the pinned tarball into the stemcells directory", func() {
+			Expect(stemcellTarballs.DownloadStemcells(stemcellsDir, []cargo.Stemcell{stemcell})).To(Succeed())
+
+			path := filepath.Join(stemcellsDir, "bosh-stemcell-621.5-vsphere-esxi-ubuntu-xenial-go_agent.tgz")
+			Expect(ioutil.ReadFile(path)).To(BeEquivalentTo(stemcellContents))
+		})
+
+		It("does not download a tarball that is already in the stemcells directory", func() {
+			path := filepath.Join(stemcellsDir, StemcellTarballFilename(stemcell))
+			Expect(ioutil.WriteFile(path, []byte(stemcellContents), 0644)).To(Succeed())
+
+			Expect(stemcellTarballs.DownloadStemcells(stemcellsDir, []cargo.Stemcell{stemcell})).To(Succeed())
+			Expect(testServer.ReceivedRequests()).To(BeEmpty())
+		})
+
+		Context("when the tarball does not have the pinned SHA1", func() {
+			BeforeEach(func() {
+				stemcell.SHA1 = "some-other-sha1"
+			})
+
+			It("returns an error and removes the tarball", func() {
+				err := stemcellTarballs.DownloadStemcells(stemcellsDir, []cargo.Stemcell{stemcell})
+				Expect(err).To(BeAssignableToTypeOf(ChecksumMismatchError{}))
+
+				Expect(ioutil.ReadDir(stemcellsDir)).To(BeEmpty())
+			})
+		})
+
+		Context("when the stemcell is pinned by SHA256", func() {
+			BeforeEach(func() {
+				pivnet.SetToken("some-token")
+				testServer.RouteToHandler("POST", "/api/v2/products/stemcells-ubuntu-xenial/releases/7/product_files/2/download", ghttp.CombineHandlers(
+					ghttp.VerifyHeaderKV("Authorization", "Bearer some-token"),
+					ghttp.RespondWith(http.StatusOK, stemcellContents),
+				))
+				stemcell = cargo.Stemcell{OS: "ubuntu-xenial", Version: "621.5", Source: "pivnet", URL: "https://network.pivotal.io/api/v2/products/stemcells-ubuntu-xenial/releases/7/product_files/2/download", SHA256: stemcellSHA256}
+			})
+
+			It("downloads the tarball from network.pivotal.io and verifies its SHA256", func() {
+				Expect(stemcellTarballs.DownloadStemcells(stemcellsDir, []cargo.Stemcell{stemcell})).To(Succeed())
+
+				path := filepath.Join(stemcellsDir, StemcellTarballFilename(stemcell))
+				Expect(ioutil.ReadFile(path)).To(BeEquivalentTo(stemcellContents))
+			})
+
+			It("does not download a tarball that is already in the stemcells directory", func() {
+				path := filepath.Join(stemcellsDir, StemcellTarballFilename(stemcell))
+				Expect(ioutil.WriteFile(path, []byte(stemcellContents), 0644)).To(Succeed())
+
+				Expect(stemcellTarballs.DownloadStemcells(stemcellsDir, []cargo.Stemcell{stemcell})).To(Succeed())
+				Expect(testServer.ReceivedRequests()).To(BeEmpty())
+			})
+
+			It("returns an error and removes the tarball when it does not have the pinned SHA256", func() {
+				stemcell.SHA256 = "some-other-sha256"
+
+				err := stemcellTarballs.DownloadStemcells(stemcellsDir, []cargo.Stemcell{stemcell})
+				Expect(err).To(MatchError(ChecksumMismatchError{
+					Source:    "pivnet",
+					Release:   StemcellTarballFilename(stemcell),
+					Algorithm: "SHA256",
+					Expected:  "some-other-sha256",
+					Actual:    stemcellSHA256,
+				}))
+
+				Expect(ioutil.ReadDir(stemcellsDir)).To(BeEmpty())
+			})
+		})
+
+		Context("when the stemcell is not pinned to a tarball", func() {
+			It("returns an error", func() {
+				err := stemcellTarballs.DownloadStemcells(stemcellsDir, []cargo.Stemcell{{OS: "ubuntu-xenial", Version: "621.5"}})
+				Expect(err).To(MatchError(ContainSubstring("stemcell ubuntu-xenial 621.5 is not pinned to a tarball in Kilnfile.lock")))
+			})
+		})
+	})
+})
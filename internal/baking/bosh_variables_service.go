@@ -1,5 +1,11 @@
 package baking
 
+import (
+	"fmt"
+
+	"github.com/pivotal-cf/kiln/builder"
+)
+
 type BOSHVariablesService struct {
 	logger logger
 	reader directoryReader
@@ -21,11 +27,11 @@ func (s BOSHVariablesService) FromDirectories(directories []string) (map[string]
 	for _, directory := range directories {
 		directoryVariables, err := s.reader.Read(directory)
 		if err != nil {
-			return nil, err
+			return nil, builder.WithSource(fmt.Sprintf("BOSH variables directory %s", directory), err)
 		}
 
 		for _, boshVariable := range directoryVariables {
-			boshVariables[boshVariable.Name] = boshVariable.Metadata
+			boshVariables[boshVariable.Name] = boshVariable
 		}
 	}
 
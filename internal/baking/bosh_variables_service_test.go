@@ -27,6 +27,7 @@ var _ = Describe("BOSHVariablesService", func() {
 
 			reader.ReadReturns([]builder.Part{
 				{
+					File: "some-key-file",
 					Name: "some-key",
 					Metadata: builder.Metadata{
 						"type": "user",
@@ -44,10 +45,14 @@ var _ = Describe("BOSHVariablesService", func() {
 			variables, err := service.FromDirectories([]string{"some-bosh-variables"})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(variables).To(Equal(map[string]interface{}{
-				"some-key": builder.Metadata{
-					"type": "user",
-					"options": map[string]interface{}{
-						"username": "some-username",
+				"some-key": builder.Part{
+					File: "some-key-file",
+					Name: "some-key",
+					Metadata: builder.Metadata{
+						"type": "user",
+						"options": map[string]interface{}{
+							"username": "some-username",
+						},
 					},
 				},
 			}))
@@ -75,7 +80,7 @@ var _ = Describe("BOSHVariablesService", func() {
 						reader.ReadReturns(nil, errors.New("failed to read"))
 
 						_, err := service.FromDirectories([]string{"some-bosh-variables"})
-						Expect(err).To(MatchError("failed to read"))
+						Expect(err).To(MatchError("BOSH variables directory some-bosh-variables: failed to read"))
 					})
 				})
 			})
@@ -1,5 +1,11 @@
 package baking
 
+import (
+	"fmt"
+
+	"github.com/pivotal-cf/kiln/builder"
+)
+
 type FormsService struct {
 	logger logger
 	reader directoryReader
@@ -23,11 +29,11 @@ func (fs FormsService) FromDirectories(directories []string) (map[string]interfa
 	for _, directory := range directories {
 		directoryForms, err := fs.reader.Read(directory)
 		if err != nil {
-			return nil, err
+			return nil, builder.WithSource(fmt.Sprintf("forms directory %s", directory), err)
 		}
 
 		for _, directoryForm := range directoryForms {
-			forms[directoryForm.Name] = directoryForm.Metadata
+			forms[directoryForm.Name] = directoryForm
 		}
 	}
 
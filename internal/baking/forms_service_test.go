@@ -40,8 +40,12 @@ var _ = Describe("FormsService", func() {
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(forms).To(Equal(map[string]interface{}{
-				"some-form-name": map[string]interface{}{
-					"some-key": "some-value",
+				"some-form-name": builder.Part{
+					File: "some-form-file",
+					Name: "some-form-name",
+					Metadata: map[string]interface{}{
+						"some-key": "some-value",
+					},
 				},
 			}))
 
@@ -74,7 +78,7 @@ var _ = Describe("FormsService", func() {
 					reader.ReadReturns(nil, errors.New("failed to read"))
 
 					_, err := service.FromDirectories([]string{"some-forms"})
-					Expect(err).To(MatchError("failed to read"))
+					Expect(err).To(MatchError("forms directory some-forms: failed to read"))
 				})
 			})
 		})
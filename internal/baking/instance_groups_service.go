@@ -1,5 +1,11 @@
 package baking
 
+import (
+	"fmt"
+
+	"github.com/pivotal-cf/kiln/builder"
+)
+
 type InstanceGroupsService struct {
 	logger logger
 	reader directoryReader
@@ -23,11 +29,11 @@ func (igs InstanceGroupsService) FromDirectories(directories []string) (map[stri
 	for _, directory := range directories {
 		directoryInstanceGroups, err := igs.reader.Read(directory)
 		if err != nil {
-			return nil, err
+			return nil, builder.WithSource(fmt.Sprintf("instance groups directory %s", directory), err)
 		}
 
 		for _, instanceGroup := range directoryInstanceGroups {
-			instanceGroups[instanceGroup.Name] = instanceGroup.Metadata
+			instanceGroups[instanceGroup.Name] = instanceGroup
 		}
 	}
 
@@ -24,6 +24,7 @@ var _ = Describe("InstanceGroupsService", func() {
 			reader = &fakes.DirectoryReader{}
 			reader.ReadReturns([]builder.Part{
 				{
+					File: "some-instance-group-file",
 					Name: "some-instance-group",
 					Metadata: builder.Metadata{
 						"key": "value",
@@ -38,8 +39,12 @@ var _ = Describe("InstanceGroupsService", func() {
 			instanceGroups, err := service.FromDirectories([]string{"some-instance-groups", "other-instance-groups"})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(instanceGroups).To(Equal(map[string]interface{}{
-				"some-instance-group": builder.Metadata{
-					"key": "value",
+				"some-instance-group": builder.Part{
+					File: "some-instance-group-file",
+					Name: "some-instance-group",
+					Metadata: builder.Metadata{
+						"key": "value",
+					},
 				},
 			}))
 
@@ -72,7 +77,7 @@ var _ = Describe("InstanceGroupsService", func() {
 					reader.ReadReturns(nil, errors.New("failed to read"))
 
 					_, err := service.FromDirectories([]string{"some-instance-groups"})
-					Expect(err).To(MatchError("failed to read"))
+					Expect(err).To(MatchError("instance groups directory some-instance-groups: failed to read"))
 				})
 			})
 		})
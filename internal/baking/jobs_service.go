@@ -1,5 +1,11 @@
 package baking
 
+import (
+	"fmt"
+
+	"github.com/pivotal-cf/kiln/builder"
+)
+
 type JobsService struct {
 	logger logger
 	reader directoryReader
@@ -23,11 +29,11 @@ func (js JobsService) FromDirectories(directories []string) (map[string]interfac
 	for _, directory := range directories {
 		directoryJobs, err := js.reader.Read(directory)
 		if err != nil {
-			return nil, err
+			return nil, builder.WithSource(fmt.Sprintf("jobs directory %s", directory), err)
 		}
 
 		for _, job := range directoryJobs {
-			jobs[job.Name] = job.Metadata
+			jobs[job.Name] = job
 		}
 	}
 
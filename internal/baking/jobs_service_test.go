@@ -24,6 +24,7 @@ var _ = Describe("JobsService", func() {
 			reader = &fakes.DirectoryReader{}
 			reader.ReadReturns([]builder.Part{
 				{
+					File: "some-job-file",
 					Name: "some-job",
 					Metadata: builder.Metadata{
 						"key": "value",
@@ -38,8 +39,12 @@ var _ = Describe("JobsService", func() {
 			jobs, err := service.FromDirectories([]string{"some-jobs", "other-jobs"})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(jobs).To(Equal(map[string]interface{}{
-				"some-job": builder.Metadata{
-					"key": "value",
+				"some-job": builder.Part{
+					File: "some-job-file",
+					Name: "some-job",
+					Metadata: builder.Metadata{
+						"key": "value",
+					},
 				},
 			}))
 
@@ -69,7 +74,7 @@ var _ = Describe("JobsService", func() {
 					reader.ReadReturns(nil, errors.New("failed to read"))
 
 					_, err := service.FromDirectories([]string{"some-jobs"})
-					Expect(err).To(MatchError("failed to read"))
+					Expect(err).To(MatchError("jobs directory some-jobs: failed to read"))
 				})
 			})
 		})
@@ -1,5 +1,11 @@
 package baking
 
+import (
+	"fmt"
+
+	"github.com/pivotal-cf/kiln/builder"
+)
+
 type PropertiesService struct {
 	logger logger
 	reader directoryReader
@@ -23,11 +29,11 @@ func (ps PropertiesService) FromDirectories(directories []string) (map[string]in
 	for _, directory := range directories {
 		directoryProperties, err := ps.reader.Read(directory)
 		if err != nil {
-			return nil, err
+			return nil, builder.WithSource(fmt.Sprintf("properties directory %s", directory), err)
 		}
 
 		for _, property := range directoryProperties {
-			properties[property.Name] = property.Metadata
+			properties[property.Name] = property
 		}
 	}
 
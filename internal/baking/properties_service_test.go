@@ -24,6 +24,7 @@ var _ = Describe("PropertiesService", func() {
 			reader = &fakes.DirectoryReader{}
 			reader.ReadReturns([]builder.Part{
 				{
+					File: "some-property-file",
 					Name: "some-property",
 					Metadata: builder.Metadata{
 						"key": "value",
@@ -38,8 +39,12 @@ var _ = Describe("PropertiesService", func() {
 			properties, err := service.FromDirectories([]string{"some-properties", "other-properties"})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(properties).To(Equal(map[string]interface{}{
-				"some-property": builder.Metadata{
-					"key": "value",
+				"some-property": builder.Part{
+					File: "some-property-file",
+					Name: "some-property",
+					Metadata: builder.Metadata{
+						"key": "value",
+					},
 				},
 			}))
 
@@ -69,7 +74,7 @@ var _ = Describe("PropertiesService", func() {
 					reader.ReadReturns(nil, errors.New("failed to read"))
 
 					_, err := service.FromDirectories([]string{"some-properties"})
-					Expect(err).To(MatchError("failed to read"))
+					Expect(err).To(MatchError("properties directory some-properties: failed to read"))
 				})
 			})
 		})
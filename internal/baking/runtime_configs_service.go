@@ -1,5 +1,11 @@
 package baking
 
+import (
+	"fmt"
+
+	"github.com/pivotal-cf/kiln/builder"
+)
+
 type RuntimeConfigsService struct {
 	logger logger
 	reader directoryReader
@@ -23,11 +29,11 @@ func (rcs RuntimeConfigsService) FromDirectories(directories []string) (map[stri
 	for _, directory := range directories {
 		directoryRuntimeConfigs, err := rcs.reader.Read(directory)
 		if err != nil {
-			return nil, err
+			return nil, builder.WithSource(fmt.Sprintf("runtime configs directory %s", directory), err)
 		}
 
 		for _, runtimeConfig := range directoryRuntimeConfigs {
-			runtimeConfigs[runtimeConfig.Name] = runtimeConfig.Metadata
+			runtimeConfigs[runtimeConfig.Name] = runtimeConfig
 		}
 	}
 
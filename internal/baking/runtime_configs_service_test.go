@@ -24,6 +24,7 @@ var _ = Describe("RuntimeConfigsService", func() {
 			reader = &fakes.DirectoryReader{}
 			reader.ReadReturns([]builder.Part{
 				{
+					File: "some-runtime-config-file",
 					Name: "some-runtime-config",
 					Metadata: builder.Metadata{
 						"key": "value",
@@ -38,8 +39,12 @@ var _ = Describe("RuntimeConfigsService", func() {
 			runtimeConfigs, err := service.FromDirectories([]string{"some-runtime-configs", "other-runtime-configs"})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(runtimeConfigs).To(Equal(map[string]interface{}{
-				"some-runtime-config": builder.Metadata{
-					"key": "value",
+				"some-runtime-config": builder.Part{
+					File: "some-runtime-config-file",
+					Name: "some-runtime-config",
+					Metadata: builder.Metadata{
+						"key": "value",
+					},
 				},
 			}))
 
@@ -72,7 +77,7 @@ var _ = Describe("RuntimeConfigsService", func() {
 					reader.ReadReturns(nil, errors.New("failed to read"))
 
 					_, err := service.FromDirectories([]string{"some-runtime-configs"})
-					Expect(err).To(MatchError("failed to read"))
+					Expect(err).To(MatchError("runtime configs directory some-runtime-configs: failed to read"))
 				})
 			})
 		})
@@ -1,8 +1,13 @@
 package baking
 
 import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash"
+	"io"
 	"io/ioutil"
 	"os"
 	"path"
@@ -10,6 +15,7 @@ import (
 	"regexp"
 
 	"github.com/pivotal-cf/kiln/builder"
+	"github.com/pivotal-cf/kiln/internal/cargo"
 	"gopkg.in/yaml.v2"
 )
 
@@ -28,23 +34,9 @@ func NewStemcellService(logger logger, tarballReader partReader) StemcellService
 func (ss StemcellService) FromDirectories(directories []string) (stemcell map[string]interface{}, err error) {
 	ss.logger.Println("Reading stemcells from directories...")
 
-	var tarballs []string
-	for _, directory := range directories {
-		err := filepath.Walk(directory, filepath.WalkFunc(func(path string, _ os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-
-			if match, _ := regexp.MatchString("tgz$|tar.gz$", path); match {
-				tarballs = append(tarballs, path)
-			}
-
-			return nil
-		}))
-
-		if err != nil {
-			return nil, err
-		}
+	tarballs, err := findStemcellTarballs(directories)
+	if err != nil {
+		return nil, err
 	}
 
 	manifests := map[string]interface{}{}
@@ -66,6 +58,70 @@ func (ss StemcellService) FromDirectories(directories []string) (stemcell map[st
 	return manifests, nil
 }
 
+// FromDirectoriesWithKilnfile reads the stemcells in directories and checks
+// them against the stemcells locked in the Kilnfile.lock. Each locked stemcell
+// must have a tarball with the locked version and, when the lock pins it, the
+// locked SHA1. Only the locked stemcells are returned.
+func (ss StemcellService) FromDirectoriesWithKilnfile(directories []string, kilnfilePath string) (map[string]interface{}, error) {
+	lock, err := readKilnfileLock(kilnfilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	ss.logger.Println("Reading stemcells from directories...")
+
+	tarballs, err := findStemcellTarballs(directories)
+	if err != nil {
+		return nil, err
+	}
+
+	type stemcellTarball struct {
+		path     string
+		metadata interface{}
+	}
+	found := map[string]stemcellTarball{}
+	for _, tarball := range tarballs {
+		part, err := ss.tarballReader.Read(tarball)
+		if err != nil {
+			return nil, err
+		}
+		manifest := part.Metadata.(builder.StemcellManifest)
+		found[manifest.OperatingSystem+"/"+manifest.Version] = stemcellTarball{path: tarball, metadata: part.Metadata}
+	}
+
+	manifests := map[string]interface{}{}
+	for _, locked := range lock.StemcellCriteria() {
+		tarball, ok := found[locked.OS+"/"+locked.Version]
+		if !ok {
+			return nil, fmt.Errorf("stemcell %s %s from %s.lock was not found in the stemcells directories", locked.OS, locked.Version, path.Base(kilnfilePath))
+		}
+
+		for _, checksum := range []struct {
+			name     string
+			hash     hash.Hash
+			expected string
+		}{
+			{name: "SHA1", hash: sha1.New(), expected: locked.SHA1},
+			{name: "SHA256", hash: sha256.New(), expected: locked.SHA256},
+		} {
+			if checksum.expected == "" {
+				continue
+			}
+			sum, err := fileSum(tarball.path, checksum.hash)
+			if err != nil {
+				return nil, err
+			}
+			if sum != checksum.expected {
+				return nil, fmt.Errorf("stemcell %s has %s %s, but %s.lock expects %s", tarball.path, checksum.name, sum, path.Base(kilnfilePath), checksum.expected)
+			}
+		}
+
+		manifests[locked.OS] = tarball.metadata
+	}
+
+	return manifests, nil
+}
+
 func (ss StemcellService) FromTarball(path string) (interface{}, error) {
 	if path == "" {
 		return nil, nil
@@ -85,35 +141,73 @@ func (ss StemcellService) FromKilnfile(kilnfilePath string) (map[string]interfac
 	kilnfileLockPath := fmt.Sprintf("%s.lock", kilnfilePath)
 	kilnfileLockBasename := path.Base(kilnfileLockPath)
 	ss.logger.Println(fmt.Sprintf("Reading stemcell criteria from %s", kilnfileLockBasename))
-	kilnfileLock, err := os.Open(kilnfileLockPath)
-	if err != nil {
-		return nil, err
-	}
 
 	type stemcellMetadata struct {
 		Version         string `yaml:"version"`
 		OperatingSystem string `yaml:"os"`
 	}
 
-	stemcellCriteria := struct {
-		Metadata stemcellMetadata `yaml:"stemcell_criteria"`
-	}{}
-
-	lockFileContent, err := ioutil.ReadAll(kilnfileLock)
+	lock, err := readKilnfileLock(kilnfilePath)
 	if err != nil {
 		return nil, err
 	}
 
-	err = yaml.Unmarshal(lockFileContent, &stemcellCriteria)
+	stemcellManifests := map[string]interface{}{}
+	for _, stemcell := range lock.StemcellCriteria() {
+		stemcellManifests[stemcell.OS] = stemcellMetadata{Version: stemcell.Version, OperatingSystem: stemcell.OS}
+	}
+
+	return stemcellManifests, nil
+}
+
+func readKilnfileLock(kilnfilePath string) (cargo.KilnfileLock, error) {
+	lockFileContent, err := ioutil.ReadFile(fmt.Sprintf("%s.lock", kilnfilePath))
 	if err != nil {
-		return nil, err
+		return cargo.KilnfileLock{}, err
 	}
 
-	stemcell := stemcellCriteria.Metadata
+	var lock cargo.KilnfileLock
+	err = yaml.Unmarshal(lockFileContent, &lock)
+	if err != nil {
+		return cargo.KilnfileLock{}, err
+	}
+
+	return lock, nil
+}
+
+func findStemcellTarballs(directories []string) ([]string, error) {
+	var tarballs []string
+	for _, directory := range directories {
+		err := filepath.Walk(directory, filepath.WalkFunc(func(path string, _ os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if match, _ := regexp.MatchString("tgz$|tar.gz$", path); match {
+				tarballs = append(tarballs, path)
+			}
+
+			return nil
+		}))
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tarballs, nil
+}
+
+func fileSum(path string, hash hash.Hash) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
 
-	stemcellManifest := map[string]interface{}{
-		stemcell.OperatingSystem: stemcellCriteria.Metadata,
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
 	}
 
-	return stemcellManifest, err
+	return hex.EncodeToString(hash.Sum(nil)), nil
 }
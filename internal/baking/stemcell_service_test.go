@@ -1,6 +1,8 @@
 package baking_test
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"github.com/pivotal-cf/kiln/builder"
 	. "github.com/pivotal-cf/kiln/internal/baking"
@@ -11,6 +13,7 @@ import (
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
 )
 
 var _ = Describe("StemcellService", func() {
@@ -99,6 +102,181 @@ var _ = Describe("StemcellService", func() {
 		})
 	})
 
+	Describe("FromKilnfile", func() {
+		var (
+			tempDir string
+			service StemcellService
+		)
+
+		BeforeEach(func() {
+			var err error
+			tempDir, err = ioutil.TempDir("", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			service = NewStemcellService(&fakes.Logger{}, &fakes.PartReader{})
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(tempDir)).To(Succeed())
+		})
+
+		stemcellMatcher := func(os, version string) OmegaMatcher {
+			return MatchAllFields(Fields{"OperatingSystem": Equal(os), "Version": Equal(version)})
+		}
+
+		It("reads the stemcell_criteria from the Kilnfile.lock", func() {
+			Expect(ioutil.WriteFile(filepath.Join(tempDir, "Kilnfile.lock"), []byte(`---
+stemcell_criteria:
+  os: ubuntu-xenial
+  version: "621.5"
+`), 0644)).To(Succeed())
+
+			stemcells, err := service.FromKilnfile(filepath.Join(tempDir, "Kilnfile"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stemcells).To(MatchAllKeys(Keys{
+				"ubuntu-xenial": stemcellMatcher("ubuntu-xenial", "621.5"),
+			}))
+		})
+
+		It("reads every stemcell locked in the Kilnfile.lock", func() {
+			Expect(ioutil.WriteFile(filepath.Join(tempDir, "Kilnfile.lock"), []byte(`---
+stemcells:
+- os: ubuntu-xenial
+  version: "621.5"
+- os: windows
+  version: "2019.12"
+`), 0644)).To(Succeed())
+
+			stemcells, err := service.FromKilnfile(filepath.Join(tempDir, "Kilnfile"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stemcells).To(MatchAllKeys(Keys{
+				"ubuntu-xenial": stemcellMatcher("ubuntu-xenial", "621.5"),
+				"windows":       stemcellMatcher("windows", "2019.12"),
+			}))
+		})
+	})
+
+	Describe("FromDirectoriesWithKilnfile", func() {
+		var (
+			tempDir string
+			reader  *fakes.PartReader
+			service StemcellService
+		)
+
+		// sha1 of "xenial-contents"
+		const xenialSHA1 = "eee390c2e16f14002973c6e56d78914baf91c0f4"
+
+		var xenialSHA256 string
+
+		BeforeEach(func() {
+			var err error
+			tempDir, err = ioutil.TempDir("", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			sum := sha256.Sum256([]byte("xenial-contents"))
+			xenialSHA256 = hex.EncodeToString(sum[:])
+
+			Expect(os.Mkdir(filepath.Join(tempDir, "stemcells"), 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(tempDir, "stemcells", "xenial.tgz"), []byte("xenial-contents"), 0644)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(tempDir, "stemcells", "windows.tgz"), []byte("windows-contents"), 0644)).To(Succeed())
+
+			reader = &fakes.PartReader{}
+			reader.ReadStub = func(path string) (builder.Part, error) {
+				if filepath.Base(path) == "xenial.tgz" {
+					return builder.Part{Metadata: builder.StemcellManifest{OperatingSystem: "ubuntu-xenial", Version: "621.5"}}, nil
+				}
+				return builder.Part{Metadata: builder.StemcellManifest{OperatingSystem: "windows2019", Version: "2019.12"}}, nil
+			}
+			service = NewStemcellService(&fakes.Logger{}, reader)
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(tempDir)).To(Succeed())
+		})
+
+		writeKilnfileLock := func(contents string) {
+			Expect(ioutil.WriteFile(filepath.Join(tempDir, "Kilnfile.lock"), []byte(contents), 0644)).To(Succeed())
+		}
+
+		fromDirectories := func() (map[string]interface{}, error) {
+			return service.FromDirectoriesWithKilnfile([]string{filepath.Join(tempDir, "stemcells")}, filepath.Join(tempDir, "Kilnfile"))
+		}
+
+		It("returns the locked stemcells found in the directories", func() {
+			writeKilnfileLock(`---
+stemcell_criteria:
+  os: ubuntu-xenial
+  version: "621.5"
+  sha1: ` + xenialSHA1 + `
+`)
+
+			stemcells, err := fromDirectories()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stemcells).To(Equal(map[string]interface{}{
+				"ubuntu-xenial": builder.StemcellManifest{OperatingSystem: "ubuntu-xenial", Version: "621.5"},
+			}))
+		})
+
+		Context("when a locked stemcell is not in the directories", func() {
+			It("returns an error", func() {
+				writeKilnfileLock(`---
+stemcell_criteria:
+  os: ubuntu-xenial
+  version: "621.6"
+`)
+
+				_, err := fromDirectories()
+				Expect(err).To(MatchError("stemcell ubuntu-xenial 621.6 from Kilnfile.lock was not found in the stemcells directories"))
+			})
+		})
+
+		Context("when a stemcell does not have the locked SHA1", func() {
+			It("returns an error", func() {
+				writeKilnfileLock(`---
+stemcells:
+- os: ubuntu-xenial
+  version: "621.5"
+  sha1: some-other-sha1
+`)
+
+				_, err := fromDirectories()
+				Expect(err).To(MatchError(And(
+					ContainSubstring("xenial.tgz has SHA1 "+xenialSHA1),
+					ContainSubstring("but Kilnfile.lock expects some-other-sha1"),
+				)))
+			})
+		})
+
+		Context("when a stemcell is locked by SHA256", func() {
+			It("checks the SHA256 of the tarball", func() {
+				writeKilnfileLock(`---
+stemcells:
+- os: ubuntu-xenial
+  version: "621.5"
+  sha256: ` + xenialSHA256 + `
+`)
+
+				_, err := fromDirectories()
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("returns an error when the tarball does not have the locked SHA256", func() {
+				writeKilnfileLock(`---
+stemcells:
+- os: ubuntu-xenial
+  version: "621.5"
+  sha256: some-other-sha256
+`)
+
+				_, err := fromDirectories()
+				Expect(err).To(MatchError(And(
+					ContainSubstring("xenial.tgz has SHA256 "+xenialSHA256),
+					ContainSubstring("but Kilnfile.lock expects some-other-sha256"),
+				)))
+			})
+		})
+	})
+
 	Describe("FromTarball", func() {
 		var (
 			logger  *fakes.Logger
@@ -0,0 +1,306 @@
+package cargo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pivotal-cf/kiln/proofing"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// placeholderPattern matches Ops Manager (( )) accessors, allowing the
+// parentheses of parsed_manifest(name) within them.
+var placeholderPattern = regexp.MustCompile(`\(\(\s*((?:[^()]|\([^()]*\))*?)\s*\)\)`)
+
+var parsedManifestPattern = regexp.MustCompile(`^selected_option\.parsed_manifest\((\w+)\)$`)
+
+// builtIns are the Ops Manager accessors that are not backed by a property
+// blueprint. Those without a value here must be set in property_values.
+var builtIns = []string{
+	"$director.hostname",
+	"$director.ca_public_key",
+	"$ops_manager.ca_certificate",
+	"$ops_manager.trusted_certificates",
+	"$self.deployment_name",
+	"$self.service_network",
+	"$self.uaa_client_name",
+	"$self.uaa_client_secret",
+	"$self.stemcell_version",
+}
+
+type evaluator struct {
+	config     OpsManagerConfig
+	blueprints map[string]proofing.PropertyBlueprint
+	jobTypes   map[string]proofing.JobType
+	stemcell   Stemcell
+}
+
+func newEvaluator(template proofing.ProductTemplate, config OpsManagerConfig, stemcell Stemcell) evaluator {
+	e := evaluator{
+		config:     config,
+		blueprints: map[string]proofing.PropertyBlueprint{},
+		jobTypes:   map[string]proofing.JobType{},
+		stemcell:   stemcell,
+	}
+
+	e.addBlueprints(".properties", template.PropertyBlueprints)
+	for _, jobType := range template.JobTypes {
+		e.jobTypes[jobType.Name] = jobType
+		e.addBlueprints("."+jobType.Name, jobType.PropertyBlueprints)
+	}
+
+	return e
+}
+
+func (e evaluator) addBlueprints(prefix string, propertyBlueprints proofing.PropertyBlueprints) {
+	for _, propertyBlueprint := range propertyBlueprints {
+		switch pb := propertyBlueprint.(type) {
+		case proofing.SimplePropertyBlueprint:
+			e.blueprints[prefix+"."+pb.Name] = pb
+		case proofing.CollectionPropertyBlueprint:
+			e.blueprints[prefix+"."+pb.Name] = pb
+		case proofing.SelectorPropertyBlueprint:
+			property := prefix + "." + pb.Name
+			e.blueprints[property] = pb
+			for _, optionTemplate := range pb.OptionTemplates {
+				for _, child := range optionTemplate.PropertyBlueprints {
+					e.blueprints[fmt.Sprintf("%s.%s.%s", property, optionTemplate.Name, child.Name)] = child
+				}
+			}
+		}
+	}
+}
+
+// evaluateManifestSnippet parses a manifest snippet from the metadata and
+// replaces its (( )) accessors. Every accessor that cannot be resolved is
+// reported in problems, prefixed with the path of the snippet.
+func (e evaluator) evaluateManifestSnippet(path, snippet string, problems *[]string) interface{} {
+	var result interface{}
+
+	if snippet == "" {
+		snippet = "{}"
+	}
+
+	err := yaml.Unmarshal([]byte(snippet), &result)
+	if err != nil {
+		*problems = append(*problems, fmt.Sprintf("%s: %s", path, err))
+		return nil
+	}
+
+	return e.evaluateNode(path, result, problems)
+}
+
+func (e evaluator) evaluateNode(path string, node interface{}, problems *[]string) interface{} {
+	switch n := node.(type) {
+	case map[interface{}]interface{}:
+		for key, value := range n {
+			n[key] = e.evaluateNode(path, value, problems)
+		}
+	case []interface{}:
+		for i, value := range n {
+			n[i] = e.evaluateNode(path, value, problems)
+		}
+	case string:
+		return e.evaluateString(path, n, problems)
+	}
+
+	return node
+}
+
+func (e evaluator) evaluateString(path, s string, problems *[]string) interface{} {
+	matches := placeholderPattern.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s
+	}
+
+	// NOTE: a string that is only an accessor takes the type of its value
+	if len(matches) == 1 && matches[0][0] == 0 && matches[0][1] == len(s) {
+		value, err := e.resolve(path, s[matches[0][2]:matches[0][3]], problems)
+		if err != nil {
+			*problems = append(*problems, fmt.Sprintf("%s: cannot resolve %s: %s", path, s, err))
+			return s
+		}
+		return value
+	}
+
+	var result strings.Builder
+	last := 0
+	for _, match := range matches {
+		result.WriteString(s[last:match[0]])
+		last = match[1]
+
+		placeholder := s[match[0]:match[1]]
+		value, err := e.resolve(path, s[match[2]:match[3]], problems)
+		if err != nil {
+			*problems = append(*problems, fmt.Sprintf("%s: cannot resolve %s: %s", path, placeholder, err))
+			result.WriteString(placeholder)
+			continue
+		}
+
+		switch value.(type) {
+		case map[interface{}]interface{}, []interface{}:
+			*problems = append(*problems, fmt.Sprintf("%s: cannot resolve %s: value must be a scalar to be embedded in a string", path, placeholder))
+			result.WriteString(placeholder)
+		case nil:
+		default:
+			fmt.Fprint(&result, value)
+		}
+	}
+	result.WriteString(s[last:])
+
+	return result.String()
+}
+
+func (e evaluator) resolve(path, accessor string, problems *[]string) (interface{}, error) {
+	if value, ok := e.config.PropertyValues[accessor]; ok {
+		return value, nil
+	}
+
+	switch {
+	case strings.HasPrefix(accessor, "$"):
+		return e.resolveBuiltIn(accessor)
+	case strings.HasPrefix(accessor, ".."):
+		return nil, fmt.Errorf("it belongs to another product, set %s in property_values", accessor)
+	case strings.HasPrefix(accessor, "."):
+		return e.resolveProperty(path, accessor, problems)
+	default:
+		return nil, fmt.Errorf("%q is not a property reference", accessor)
+	}
+}
+
+func (e evaluator) resolveBuiltIn(accessor string) (interface{}, error) {
+	switch accessor {
+	case "$self.deployment_name":
+		return e.config.DeploymentName, nil
+	case "$self.stemcell_version":
+		return e.stemcell.Version, nil
+	}
+
+	for _, builtIn := range builtIns {
+		if accessor == builtIn {
+			return nil, fmt.Errorf("set %s in property_values", accessor)
+		}
+	}
+
+	return nil, fmt.Errorf("%s is not a known Ops Manager accessor", accessor)
+}
+
+func (e evaluator) resolveProperty(path, accessor string, problems *[]string) (interface{}, error) {
+	var property string
+	for reference := range e.blueprints {
+		if (accessor == reference || strings.HasPrefix(accessor, reference+".")) && len(reference) > len(property) {
+			property = reference
+		}
+	}
+
+	if property == "" {
+		return e.resolveJobAccessor(accessor)
+	}
+
+	remainder := strings.TrimPrefix(strings.TrimPrefix(accessor, property), ".")
+	if remainder == "" {
+		return nil, fmt.Errorf("%s needs an accessor such as .value", property)
+	}
+
+	blueprint := e.blueprints[property]
+	value, ok := e.config.PropertyValues[property]
+	if !ok {
+		value = simpleBlueprint(blueprint).Default
+	}
+
+	if selector, ok := blueprint.(proofing.SelectorPropertyBlueprint); ok {
+		return e.resolveSelector(path, property, selector, value, remainder, problems)
+	}
+
+	if value == nil && !simpleBlueprint(blueprint).Optional {
+		return nil, fmt.Errorf("%s has no default, set it in property_values", property)
+	}
+
+	if remainder == "value" {
+		return value, nil
+	}
+
+	fields, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s has no %s, set it as a map in property_values", property, remainder)
+	}
+
+	field, ok := fields[remainder]
+	if !ok {
+		return nil, fmt.Errorf("%s has no %s", property, remainder)
+	}
+
+	return field, nil
+}
+
+func (e evaluator) resolveSelector(path, property string, selector proofing.SelectorPropertyBlueprint, value interface{}, remainder string, problems *[]string) (interface{}, error) {
+	selected, ok := value.(string)
+	if !ok || selected == "" {
+		return nil, fmt.Errorf("%s has no selected option, set it in property_values", property)
+	}
+
+	var option *proofing.SelectorPropertyOptionTemplate
+	for i, optionTemplate := range selector.OptionTemplates {
+		if optionTemplate.Name == selected || optionTemplate.SelectValue == selected {
+			option = &selector.OptionTemplates[i]
+			break
+		}
+	}
+
+	if option == nil {
+		return nil, fmt.Errorf("%s has no option %q", property, selected)
+	}
+
+	switch remainder {
+	case "value", "selected_value":
+		return option.SelectValue, nil
+	case "selected_option":
+		return option.Name, nil
+	}
+
+	matches := parsedManifestPattern.FindStringSubmatch(remainder)
+	if matches == nil {
+		return nil, fmt.Errorf("%s is not a selector accessor", remainder)
+	}
+
+	for _, namedManifest := range option.NamedManifests {
+		if namedManifest.Name == matches[1] {
+			return e.evaluateManifestSnippet(fmt.Sprintf("%s(%s.%s)", path, property, option.Name), namedManifest.Manifest, problems), nil
+		}
+	}
+
+	return nil, fmt.Errorf("option %s of %s has no named manifest %s", option.Name, property, matches[1])
+}
+
+func (e evaluator) resolveJobAccessor(accessor string) (interface{}, error) {
+	segments := strings.SplitN(strings.TrimPrefix(accessor, "."), ".", 2)
+	jobType, ok := e.jobTypes[segments[0]]
+	if !ok || len(segments) != 2 {
+		return nil, fmt.Errorf("%s does not match a property blueprint", accessor)
+	}
+
+	switch segments[1] {
+	case "instances":
+		return instanceCount(jobType, e.config.ResourceConfigs), nil
+	case "availability_zones":
+		return availabilityZones(jobType, e.config), nil
+	case "ips", "first_ip", "dns_names":
+		return nil, fmt.Errorf("set %s in property_values", accessor)
+	}
+
+	return nil, fmt.Errorf("%s does not match a property blueprint", accessor)
+}
+
+func simpleBlueprint(propertyBlueprint proofing.PropertyBlueprint) proofing.SimplePropertyBlueprint {
+	switch pb := propertyBlueprint.(type) {
+	case proofing.SimplePropertyBlueprint:
+		return pb
+	case proofing.SelectorPropertyBlueprint:
+		return pb.SimplePropertyBlueprint
+	case proofing.CollectionPropertyBlueprint:
+		return pb.SimplePropertyBlueprint
+	default:
+		return proofing.SimplePropertyBlueprint{}
+	}
+}
@@ -1,16 +1,28 @@
 package cargo
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/pivotal-cf/kiln/internal/cargo/opsman"
 	"github.com/pivotal-cf/kiln/proofing"
-	yaml "gopkg.in/yaml.v2"
 )
 
 type OpsManagerConfig struct {
-	DeploymentName    string
-	AvailabilityZones []string
-	Stemcells         []opsman.Stemcell
-	ResourceConfigs   []opsman.ResourceConfig
+	DeploymentName    string   `yaml:"deployment_name"`
+	AvailabilityZones []string `yaml:"availability_zones"`
+	// SingletonAvailabilityZone places job types that are single_az_only and
+	// defaults to the first of the AvailabilityZones.
+	SingletonAvailabilityZone string                  `yaml:"singleton_availability_zone"`
+	Stemcells                 []opsman.Stemcell       `yaml:"stemcells"`
+	ResourceConfigs           []opsman.ResourceConfig `yaml:"resource_configs"`
+
+	// PropertyValues are keyed by property reference, like .properties.foo,
+	// and take precedence over property blueprint defaults. Accessors that
+	// are not backed by a property blueprint, like $director.hostname, are
+	// keyed by the whole accessor.
+	PropertyValues map[string]interface{} `yaml:"property_values"`
 }
 
 type Generator struct{}
@@ -19,13 +31,22 @@ func NewGenerator() Generator {
 	return Generator{}
 }
 
-func (g Generator) Execute(template proofing.ProductTemplate, config OpsManagerConfig) Manifest {
+func (g Generator) Execute(template proofing.ProductTemplate, config OpsManagerConfig) (Manifest, error) {
 	releases := generateReleases(template.Releases)
 	stemcell := findStemcell(template.StemcellCriteria, config.Stemcells)
 	update := generateUpdate(template.Serial)
-	instanceGroups := generateInstanceGroups(template.JobTypes, config.ResourceConfigs, config.AvailabilityZones, stemcell.Alias)
 	variables := generateVariables(template.Variables)
 
+	var problems []string
+	evaluator := newEvaluator(template, config, stemcell)
+	instanceGroups := generateInstanceGroups(evaluator, template, config, stemcell.Alias, update, &problems)
+	colocateErrands(template, instanceGroups, &problems)
+	runtimeConfigs := generateRuntimeConfigs(evaluator, template.RuntimeConfigs, &problems)
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return Manifest{}, fmt.Errorf("failed to generate manifest:\n- %s", strings.Join(problems, "\n- "))
+	}
+
 	return Manifest{
 		Name:           config.DeploymentName,
 		Releases:       releases,
@@ -33,7 +54,8 @@ func (g Generator) Execute(template proofing.ProductTemplate, config OpsManagerC
 		Update:         update,
 		Variables:      variables,
 		InstanceGroups: instanceGroups,
-	}
+		RuntimeConfigs: runtimeConfigs,
+	}, nil
 }
 
 func generateReleases(templateReleases []proofing.Release) []Release {
@@ -79,48 +101,121 @@ func generateUpdate(serial bool) Update {
 	}
 }
 
-func generateInstanceGroups(jobTypes []proofing.JobType, resourceConfigs []opsman.ResourceConfig, availabilityZones []string, stemcellAlias string) []InstanceGroup {
+func generateInstanceGroups(evaluator evaluator, template proofing.ProductTemplate, config OpsManagerConfig, stemcellAlias string, update Update, problems *[]string) []InstanceGroup {
 	var instanceGroups []InstanceGroup
 
-	for _, jobType := range jobTypes {
+	for i, jobType := range template.JobTypes {
 		lifecycle := "service"
 		if jobType.Errand {
 			lifecycle = "errand"
 		}
 
-		instances := jobType.InstanceDefinition.Default
-		for _, resourceConfig := range resourceConfigs {
-			if resourceConfig.Name == jobType.Name {
-				if !resourceConfig.Instances.IsAutomatic() {
-					instances = resourceConfig.Instances.Value
-				}
+		instances := instanceCount(jobType, config.ResourceConfigs)
+		resourceConfig := findResourceConfig(jobType.Name, config.ResourceConfigs)
+
+		path := fmt.Sprintf("job_types[%d]", i)
+		jobs := generateInstanceGroupJobs(evaluator, path, jobType.Templates, problems)
+		properties := evaluator.evaluateManifestSnippet(path+".manifest", jobType.Manifest, problems)
+
+		instanceGroup := InstanceGroup{
+			Name:               jobType.Name,
+			AZs:                availabilityZones(jobType, config),
+			Lifecycle:          lifecycle,
+			Stemcell:           stemcellAlias,
+			Instances:          instances,
+			VMType:             resourceConfig.VMType,
+			PersistentDiskType: resourceConfig.PersistentDiskType,
+			Update:             generateInstanceGroupUpdate(jobType, update),
+			Jobs:               jobs,
+			Properties:         properties,
+		}
+
+		resources := map[string]int{}
+		for _, resourceDefinition := range jobType.ResourceDefinitions {
+			resources[resourceDefinition.Name] = resourceDefinition.Default
+		}
+
+		if instanceGroup.VMType == "" && (resources["cpu"] > 0 || resources["ram"] > 0 || resources["ephemeral_disk"] > 0) {
+			instanceGroup.VMResources = &VMResources{
+				CPU:               resources["cpu"],
+				RAM:               resources["ram"],
+				EphemeralDiskSize: resources["ephemeral_disk"],
 			}
 		}
 
-		jobs := generateInstanceGroupJobs(jobType.Templates)
-		properties := evaluateManifestSnippet(jobType.Manifest)
+		if instanceGroup.PersistentDiskType == "" {
+			instanceGroup.PersistentDisk = resources["persistent_disk"]
+		}
 
-		instanceGroups = append(instanceGroups, InstanceGroup{
-			Name:       jobType.Name,
-			AZs:        availabilityZones,
-			Lifecycle:  lifecycle,
-			Stemcell:   stemcellAlias,
-			Instances:  instances,
-			Jobs:       jobs,
-			Properties: properties,
-		})
+		instanceGroups = append(instanceGroups, instanceGroup)
 	}
 
 	return instanceGroups
 }
 
-func generateInstanceGroupJobs(templates []proofing.Template) []InstanceGroupJob {
+// generateInstanceGroupUpdate applies the canaries, max_in_flight and serial
+// settings of the job type to the update block of the deployment. As in Ops
+// Manager, a setting of the job type wins whenever it is set, even to 0 or
+// false.
+func generateInstanceGroupUpdate(jobType proofing.JobType, update Update) *Update {
+	if jobType.Canaries != nil {
+		update.Canaries = *jobType.Canaries
+	}
+
+	if jobType.MaxInFlight != nil {
+		update.MaxInFlight = jobType.MaxInFlight
+	}
+
+	if jobType.Serial != nil {
+		update.Serial = *jobType.Serial
+	}
+
+	return &update
+}
+
+func availabilityZones(jobType proofing.JobType, config OpsManagerConfig) []string {
+	if !jobType.SingleAZOnly {
+		return config.AvailabilityZones
+	}
+
+	if config.SingletonAvailabilityZone != "" {
+		return []string{config.SingletonAvailabilityZone}
+	}
+
+	if len(config.AvailabilityZones) > 0 {
+		return config.AvailabilityZones[:1]
+	}
+
+	return nil
+}
+
+func findResourceConfig(name string, resourceConfigs []opsman.ResourceConfig) opsman.ResourceConfig {
+	for _, resourceConfig := range resourceConfigs {
+		if resourceConfig.Name == name {
+			return resourceConfig
+		}
+	}
+
+	return opsman.ResourceConfig{}
+}
+
+func instanceCount(jobType proofing.JobType, resourceConfigs []opsman.ResourceConfig) int {
+	instances := jobType.InstanceDefinition.Default
+	if resourceConfig := findResourceConfig(jobType.Name, resourceConfigs); resourceConfig.Name != "" && !resourceConfig.Instances.IsAutomatic() {
+		instances = resourceConfig.Instances.Value
+	}
+
+	return instances
+}
+
+func generateInstanceGroupJobs(evaluator evaluator, jobTypePath string, templates []proofing.Template, problems *[]string) []InstanceGroupJob {
 	var jobs []InstanceGroupJob
 
-	for _, template := range templates {
-		provides := evaluateManifestSnippet(template.Provides)
-		consumes := evaluateManifestSnippet(template.Consumes)
-		properties := evaluateManifestSnippet(template.Manifest)
+	for i, template := range templates {
+		path := fmt.Sprintf("%s.templates[%d]", jobTypePath, i)
+		provides := evaluator.evaluateManifestSnippet(path+".provides", template.Provides, problems)
+		consumes := evaluator.evaluateManifestSnippet(path+".consumes", template.Consumes, problems)
+		properties := evaluator.evaluateManifestSnippet(path+".manifest", template.Manifest, problems)
 
 		jobs = append(jobs, InstanceGroupJob{
 			Name:       template.Name,
@@ -134,19 +229,67 @@ func generateInstanceGroupJobs(templates []proofing.Template) []InstanceGroupJob
 	return jobs
 }
 
-func evaluateManifestSnippet(snippet string) interface{} {
-	var result interface{}
+// colocateErrands adds the job of each colocated errand to the instance groups
+// it runs on. The job is copied from the job type template with the same
+// name, which says what release it comes from.
+func colocateErrands(template proofing.ProductTemplate, instanceGroups []InstanceGroup, problems *[]string) {
+	for _, errands := range []struct {
+		path      string
+		templates []proofing.ErrandTemplate
+	}{
+		{path: "post_deploy_errands", templates: template.PostDeployErrands},
+		{path: "pre_delete_errands", templates: template.PreDeleteErrands},
+	} {
+		for i, errand := range errands.templates {
+			if !errand.Colocated {
+				continue
+			}
+
+			job, ok := findInstanceGroupJob(instanceGroups, errand.Name)
+			if !ok {
+				*problems = append(*problems, fmt.Sprintf("%s[%d]: colocated errand %s must be a template of a job type", errands.path, i, errand.Name))
+				continue
+			}
+
+			for _, instance := range errand.Instances {
+				name := strings.SplitN(instance, "/", 2)[0]
+				for j := range instanceGroups {
+					if instanceGroups[j].Name != name {
+						continue
+					}
+
+					if _, ok := findInstanceGroupJob(instanceGroups[j:j+1], errand.Name); !ok {
+						instanceGroups[j].Jobs = append(instanceGroups[j].Jobs, job)
+					}
+				}
+			}
+		}
+	}
+}
 
-	if snippet == "" {
-		snippet = "{}"
+func findInstanceGroupJob(instanceGroups []InstanceGroup, name string) (InstanceGroupJob, bool) {
+	for _, instanceGroup := range instanceGroups {
+		for _, job := range instanceGroup.Jobs {
+			if job.Name == name {
+				return job, true
+			}
+		}
 	}
 
-	err := yaml.Unmarshal([]byte(snippet), &result)
-	if err != nil {
-		panic(err)
+	return InstanceGroupJob{}, false
+}
+
+func generateRuntimeConfigs(evaluator evaluator, templates []proofing.RuntimeConfigTemplate, problems *[]string) []RuntimeConfig {
+	var runtimeConfigs []RuntimeConfig
+
+	for i, template := range templates {
+		runtimeConfigs = append(runtimeConfigs, RuntimeConfig{
+			Name:   template.Name,
+			Config: evaluator.evaluateManifestSnippet(fmt.Sprintf("runtime_configs[%d].runtime_config", i), template.RuntimeConfig, problems),
+		})
 	}
 
-	return result
+	return runtimeConfigs
 }
 
 func generateVariables(templateVariables []proofing.Variable) []Variable {
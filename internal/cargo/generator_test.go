@@ -3,6 +3,7 @@ package cargo_test
 import (
 	"io/ioutil"
 	"os"
+	"strings"
 
 	. "github.com/pivotal-cf/kiln/internal/cargo"
 	"github.com/pivotal-cf/kiln/internal/cargo/opsman"
@@ -30,7 +31,7 @@ var _ = Describe("Generator", func() {
 			template, err := proofing.Parse(f)
 			Expect(err).NotTo(HaveOccurred())
 
-			manifest := generator.Execute(template, OpsManagerConfig{
+			manifest, err := generator.Execute(template, OpsManagerConfig{
 				DeploymentName: "some-product-name",
 				AvailabilityZones: []string{
 					"some-az-1",
@@ -59,6 +60,7 @@ var _ = Describe("Generator", func() {
 					},
 				},
 			})
+			Expect(err).NotTo(HaveOccurred())
 
 			actualManifest, err := yaml.Marshal(manifest)
 			Expect(err).NotTo(HaveOccurred())
@@ -68,5 +70,348 @@ var _ = Describe("Generator", func() {
 
 			Expect(actualManifest).To(HelpfullyMatchYAML(string(expectedManifest)))
 		})
+
+		Context("when job types have update, placement and resource settings", func() {
+			var template proofing.ProductTemplate
+
+			BeforeEach(func() {
+				var err error
+				template, err = proofing.Parse(strings.NewReader(`---
+job_types:
+- name: web
+  canaries: 2
+  max_in_flight: 50%
+  serial: true
+  resource_definitions:
+  - name: cpu
+    default: 2
+  - name: ram
+    default: 4096
+  - name: ephemeral_disk
+    default: 8192
+  - name: persistent_disk
+    default: 10240
+- name: database
+  max_in_flight: 3
+  single_az_only: true
+  resource_definitions:
+  - name: cpu
+    default: 1
+  - name: persistent_disk
+    default: 20480
+`))
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("generates an update block, AZs and resources for each instance group", func() {
+				manifest, err := generator.Execute(template, OpsManagerConfig{
+					AvailabilityZones: []string{"z1", "z2", "z3"},
+					ResourceConfigs: []opsman.ResourceConfig{
+						{Name: "database", VMType: "large", PersistentDiskType: "ssd"},
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				web, database := manifest.InstanceGroups[0], manifest.InstanceGroups[1]
+
+				Expect(manifest.Update.Serial).To(BeFalse())
+				Expect(web.Update.Canaries).To(Equal(2))
+				Expect(web.Update.MaxInFlight).To(Equal("50%"))
+				Expect(web.Update.Serial).To(BeTrue())
+				Expect(database.Update.Canaries).To(Equal(1))
+				Expect(database.Update.MaxInFlight).To(Equal(3))
+				Expect(database.Update.Serial).To(BeFalse())
+
+				Expect(web.AZs).To(Equal([]string{"z1", "z2", "z3"}))
+				Expect(database.AZs).To(Equal([]string{"z1"}))
+
+				Expect(web.VMType).To(BeEmpty())
+				Expect(web.VMResources).To(Equal(&VMResources{CPU: 2, RAM: 4096, EphemeralDiskSize: 8192}))
+				Expect(web.PersistentDisk).To(Equal(10240))
+
+				Expect(database.VMType).To(Equal("large"))
+				Expect(database.VMResources).To(BeNil())
+				Expect(database.PersistentDisk).To(BeZero())
+				Expect(database.PersistentDiskType).To(Equal("ssd"))
+			})
+
+			It("places single AZ job types in the singleton availability zone", func() {
+				manifest, err := generator.Execute(template, OpsManagerConfig{
+					AvailabilityZones:         []string{"z1", "z2", "z3"},
+					SingletonAvailabilityZone: "z2",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(manifest.InstanceGroups[1].AZs).To(Equal([]string{"z2"}))
+			})
+		})
+
+		Context("when a job type explicitly turns off serial and canaries", func() {
+			It("overrides the update settings of the product", func() {
+				template, err := proofing.Parse(strings.NewReader(`---
+serial: true
+job_types:
+- name: web
+  canaries: 0
+  max_in_flight: 1
+  serial: false
+- name: worker
+  max_in_flight: 1
+`))
+				Expect(err).NotTo(HaveOccurred())
+
+				manifest, err := generator.Execute(template, OpsManagerConfig{})
+				Expect(err).NotTo(HaveOccurred())
+
+				web, worker := manifest.InstanceGroups[0], manifest.InstanceGroups[1]
+
+				Expect(manifest.Update.Serial).To(BeTrue())
+				Expect(web.Update.Canaries).To(Equal(0))
+				Expect(web.Update.Serial).To(BeFalse())
+				Expect(worker.Update.Canaries).To(Equal(1))
+				Expect(worker.Update.Serial).To(BeTrue())
+			})
+		})
+
+		Context("when manifests have Ops Manager accessors", func() {
+			var template proofing.ProductTemplate
+
+			BeforeEach(func() {
+				var err error
+				template, err = proofing.Parse(strings.NewReader(`---
+property_blueprints:
+- name: domain
+  type: string
+- name: port
+  type: port
+  default: 8080
+- name: optional
+  type: string
+  optional: true
+- name: cert
+  type: rsa_cert_credentials
+- name: users
+  type: collection
+  property_blueprints:
+  - name: name
+    type: string
+  default:
+  - name: admin
+- name: backend
+  type: selector
+  default: internal
+  option_templates:
+  - name: internal
+    select_value: Internal
+    named_manifests:
+    - name: config
+      manifest: |
+        port: (( .properties.port.value ))
+  - name: external
+    select_value: External
+    property_blueprints:
+    - name: address
+      type: string
+    named_manifests:
+    - name: config
+      manifest: |
+        address: (( .properties.backend.external.address.value ))
+job_types:
+- name: some-job-type
+  instance_definition:
+    default: 2
+  property_blueprints:
+  - name: timeout
+    type: integer
+    default: 30
+  templates:
+  - name: some-template-name
+    release: some-release-name
+    manifest: |
+      domain: (( .properties.domain.value ))
+      url: https://(( .properties.domain.value )):(( .properties.port.value ))
+      optional: (( .properties.optional.value ))
+      cert: (( .properties.cert.cert_pem ))
+      users: (( .properties.users.value ))
+      backend: (( .properties.backend.value ))
+      backend_config: (( .properties.backend.selected_option.parsed_manifest(config) ))
+      timeout: (( .some-job-type.timeout.value ))
+      instances: (( .some-job-type.instances ))
+      deployment: (( $self.deployment_name ))
+      director: (( $director.hostname ))
+      router: (( ..cf.router.ips ))
+`))
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("resolves them against property values and blueprint defaults", func() {
+				manifest, err := generator.Execute(template, OpsManagerConfig{
+					DeploymentName: "some-deployment",
+					PropertyValues: map[string]interface{}{
+						".properties.domain": "example.com",
+						".properties.cert": map[interface{}]interface{}{
+							"cert_pem":        "some-cert",
+							"private_key_pem": "some-key",
+						},
+						"$director.hostname": "10.0.0.6",
+						"..cf.router.ips":    []interface{}{"10.0.0.10"},
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(manifest.InstanceGroups[0].Jobs[0].Properties).To(Equal(map[interface{}]interface{}{
+					"domain":         "example.com",
+					"url":            "https://example.com:8080",
+					"optional":       nil,
+					"cert":           "some-cert",
+					"users":          []interface{}{map[interface{}]interface{}{"name": "admin"}},
+					"backend":        "Internal",
+					"backend_config": map[interface{}]interface{}{"port": 8080},
+					"timeout":        30,
+					"instances":      2,
+					"deployment":     "some-deployment",
+					"director":       "10.0.0.6",
+					"router":         []interface{}{"10.0.0.10"},
+				}))
+			})
+
+			It("evaluates the named manifests of the selected option", func() {
+				manifest, err := generator.Execute(template, OpsManagerConfig{
+					PropertyValues: map[string]interface{}{
+						".properties.domain":                   "example.com",
+						".properties.cert":                     map[interface{}]interface{}{"cert_pem": "some-cert"},
+						".properties.backend":                  "external",
+						".properties.backend.external.address": "backend.example.com",
+						"$director.hostname":                   "10.0.0.6",
+						"..cf.router.ips":                      []interface{}{"10.0.0.10"},
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				properties := manifest.InstanceGroups[0].Jobs[0].Properties.(map[interface{}]interface{})
+				Expect(properties["backend"]).To(Equal("External"))
+				Expect(properties["backend_config"]).To(Equal(map[interface{}]interface{}{"address": "backend.example.com"}))
+			})
+
+			It("reports every accessor it cannot resolve", func() {
+				_, err := generator.Execute(template, OpsManagerConfig{})
+				Expect(err).To(MatchError(`failed to generate manifest:
+- job_types[0].templates[0].manifest: cannot resolve (( $director.hostname )): set $director.hostname in property_values
+- job_types[0].templates[0].manifest: cannot resolve (( ..cf.router.ips )): it belongs to another product, set ..cf.router.ips in property_values
+- job_types[0].templates[0].manifest: cannot resolve (( .properties.cert.cert_pem )): .properties.cert has no default, set it in property_values
+- job_types[0].templates[0].manifest: cannot resolve (( .properties.domain.value )): .properties.domain has no default, set it in property_values
+- job_types[0].templates[0].manifest: cannot resolve (( .properties.domain.value )): .properties.domain has no default, set it in property_values`))
+			})
+		})
+
+		Context("when the product has errands and runtime configs", func() {
+			var template proofing.ProductTemplate
+
+			BeforeEach(func() {
+				var err error
+				template, err = proofing.Parse(strings.NewReader(`---
+job_types:
+- name: web
+  templates:
+  - name: web-server
+    release: some-release
+- name: database
+  templates:
+  - name: database-server
+    release: some-release
+  - name: backup-database
+    release: backup-release
+    manifest: |
+      bucket: backups
+- name: smoke-tests
+  errand: true
+  templates:
+  - name: smoke-tests
+    release: some-release
+post_deploy_errands:
+- name: smoke-tests
+- name: backup-database
+  colocated: true
+  label: Backup database
+  instances:
+  - web/first
+  - database/first
+pre_delete_errands:
+- name: drain
+  colocated: true
+  label: Drain
+  instances:
+  - web/first
+runtime_configs:
+- name: some-addon
+  runtime_config: |
+    releases:
+    - name: addon-release
+      version: 1.2.3
+    addons:
+    - name: some-addon
+      include:
+        deployments: [(( $self.deployment_name ))]
+`))
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("adds colocated errand jobs and renders the runtime configs", func() {
+				template.PreDeleteErrands = nil
+
+				manifest, err := generator.Execute(template, OpsManagerConfig{DeploymentName: "some-deployment"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(manifest.InstanceGroups[0].Jobs).To(HaveLen(2))
+				Expect(manifest.InstanceGroups[0].Jobs[1]).To(Equal(InstanceGroupJob{
+					Name:       "backup-database",
+					Release:    "backup-release",
+					Provides:   map[interface{}]interface{}{},
+					Consumes:   map[interface{}]interface{}{},
+					Properties: map[interface{}]interface{}{"bucket": "backups"},
+				}))
+				Expect(manifest.InstanceGroups[1].Jobs).To(HaveLen(2))
+				Expect(manifest.InstanceGroups[2].Lifecycle).To(Equal("errand"))
+
+				Expect(manifest.RuntimeConfigs).To(HaveLen(1))
+				Expect(manifest.RuntimeConfigs[0].Name).To(Equal("some-addon"))
+
+				runtimeConfig, err := yaml.Marshal(manifest.RuntimeConfigs[0].Config)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(runtimeConfig).To(HelpfullyMatchYAML(`
+releases:
+- name: addon-release
+  version: 1.2.3
+addons:
+- name: some-addon
+  include:
+    deployments: [some-deployment]
+`))
+
+				deploymentManifest, err := yaml.Marshal(manifest)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(deploymentManifest)).NotTo(ContainSubstring("addon-release"))
+			})
+
+			It("returns an error when a colocated errand is not a template of a job type", func() {
+				_, err := generator.Execute(template, OpsManagerConfig{})
+				Expect(err).To(MatchError(`failed to generate manifest:
+- pre_delete_errands[0]: colocated errand drain must be a template of a job type`))
+			})
+		})
+
+		Context("when a manifest is not valid YAML", func() {
+			It("returns an error instead of panicking", func() {
+				template, err := proofing.Parse(strings.NewReader(`---
+job_types:
+- name: some-job-type
+  manifest: "{"
+`))
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = generator.Execute(template, OpsManagerConfig{})
+				Expect(err).To(MatchError(ContainSubstring("job_types[0].manifest: yaml:")))
+			})
+		})
 	})
 })
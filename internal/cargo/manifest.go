@@ -7,6 +7,15 @@ type Manifest struct {
 	Update         Update          `yaml:"update"`
 	Variables      []Variable      `yaml:"variables"`
 	InstanceGroups []InstanceGroup `yaml:"instance_groups"`
+
+	// RuntimeConfigs are separate BOSH runtime config documents, so they are
+	// not part of the deployment manifest.
+	RuntimeConfigs []RuntimeConfig `yaml:"-"`
+}
+
+type RuntimeConfig struct {
+	Name   string
+	Config interface{}
 }
 
 type ReleaseManifest struct {
@@ -21,47 +30,85 @@ type CompiledPackage struct {
 }
 
 type Release struct {
-	Name    string `yaml:"name"`
-	SHA1    string `yaml:"sha1"`
-	Version string `yaml:"version"`
+	Name            string `yaml:"name"`
+	SHA1            string `yaml:"sha1"`
+	Version         string `yaml:"version"`
+	StemcellOS      string `yaml:"stemcell_os,omitempty"`
+	StemcellVersion string `yaml:"stemcell_version,omitempty"`
 }
 
 type KilnfileLock struct {
-	Releases []Release `yaml:"releases"`
-	Stemcell Stemcell  `yaml:"stemcell_criteria"`
+	Releases  []Release  `yaml:"releases"`
+	Stemcell  Stemcell   `yaml:"stemcell_criteria,omitempty"`
+	Stemcells []Stemcell `yaml:"stemcells,omitempty"`
 }
 
 type Kilnfile struct {
-	Stemcell        Stemcell              `yaml:"stemcell_criteria"`
+	Stemcell        Stemcell              `yaml:"stemcell_criteria,omitempty"`
+	Stemcells       []Stemcell            `yaml:"stemcells,omitempty"`
+	Releases        []ReleaseConstraint   `yaml:"releases"`
 	ReleaseSources  []ReleaseSourceConfig `yaml:"release_sources"`
 	Slug            string                `yaml:"slug"`
 	PreGaUserGroups []string              `yaml:"pre_ga_user_groups"`
+	Bake            *BakeConfig           `yaml:"bake,omitempty"`
+}
+
+// BakeConfig declares the inputs of kiln bake. Paths are relative to the
+// Kilnfile.
+type BakeConfig struct {
+	Metadata                 string            `yaml:"metadata,omitempty"`
+	Icon                     string            `yaml:"icon,omitempty"`
+	BOSHVariableDirectories  []string          `yaml:"bosh_variables_directories,omitempty"`
+	EmbedPaths               []string          `yaml:"embed,omitempty"`
+	FormDirectories          []string          `yaml:"forms_directories,omitempty"`
+	InstanceGroupDirectories []string          `yaml:"instance_groups_directories,omitempty"`
+	JobDirectories           []string          `yaml:"jobs_directories,omitempty"`
+	MigrationDirectories     []string          `yaml:"migrations_directories,omitempty"`
+	PropertyDirectories      []string          `yaml:"properties_directories,omitempty"`
+	ReleaseDirectories       []string          `yaml:"releases_directories,omitempty"`
+	RuntimeConfigDirectories []string          `yaml:"runtime_configs_directories,omitempty"`
+	StemcellsDirectories     []string          `yaml:"stemcells_directories,omitempty"`
+	VariableFiles            []string          `yaml:"variables_files,omitempty"`
+	Variables                map[string]string `yaml:"variables,omitempty"`
 }
 
+type ReleaseConstraint struct {
+	Name       string `yaml:"name"`
+	Version    string `yaml:"version,omitempty"`
+	StemcellOS string `yaml:"stemcell_os,omitempty"`
+}
+
+// ReleaseSourceConfig is an entry in the release_sources section of the
+// Kilnfile. The keys other than type and publishable are specific to the type
+// and are kept in Options until the release source is built.
 type ReleaseSourceConfig struct {
-	Type            string `yaml:"type"`
-	Compiled        bool   `yaml:"compiled"`
-	Bucket          string `yaml:"bucket"`
-	Region          string `yaml:"region"`
-	AccessKeyId     string `yaml:"access_key_id"`
-	SecretAccessKey string `yaml:"secret_access_key"`
-	Regex           string `yaml:"regex"`
-	Publishable     bool   `yaml:"publishable"`
+	Type        string                 `yaml:"type"`
+	Publishable bool                   `yaml:"publishable"`
+	Options     map[string]interface{} `yaml:",inline"`
 }
 
 type Stemcell struct {
 	Alias   string `yaml:"alias,omitempty"`
 	OS      string `yaml:"os"`
 	Version string `yaml:"version"`
+
+	// Source and Infrastructure say where kiln update finds the stemcell
+	// tarball, which is pinned in Kilnfile.lock by its URL and SHA1, or by the
+	// SHA256 network.pivotal.io publishes for it.
+	Source         string `yaml:"source,omitempty"`
+	Infrastructure string `yaml:"infrastructure,omitempty"`
+	URL            string `yaml:"url,omitempty"`
+	SHA1           string `yaml:"sha1,omitempty"`
+	SHA256         string `yaml:"sha256,omitempty"`
 }
 
 type Update struct {
-	Canaries        int    `yaml:"canaries"`
-	CanaryWatchTime string `yaml:"canary_watch_time"`
-	UpdateWatchTime string `yaml:"update_watch_time"`
-	MaxInFlight     int    `yaml:"max_in_flight"`
-	MaxErrors       int    `yaml:"max_errors"`
-	Serial          bool   `yaml:"serial"`
+	Canaries        int         `yaml:"canaries"`
+	CanaryWatchTime string      `yaml:"canary_watch_time"`
+	UpdateWatchTime string      `yaml:"update_watch_time"`
+	MaxInFlight     interface{} `yaml:"max_in_flight"`
+	MaxErrors       int         `yaml:"max_errors"`
+	Serial          bool        `yaml:"serial"`
 }
 
 type Variable struct {
@@ -71,13 +118,24 @@ type Variable struct {
 }
 
 type InstanceGroup struct {
-	Name       string             `yaml:"name"`
-	AZs        []string           `yaml:"azs"`
-	Lifecycle  string             `yaml:"lifecycle"`
-	Stemcell   string             `yaml:"stemcell"`
-	Instances  int                `yaml:"instances"`
-	Jobs       []InstanceGroupJob `yaml:"jobs"`
-	Properties interface{}        `yaml:"properties"`
+	Name               string             `yaml:"name"`
+	AZs                []string           `yaml:"azs"`
+	Lifecycle          string             `yaml:"lifecycle"`
+	Stemcell           string             `yaml:"stemcell"`
+	Instances          int                `yaml:"instances"`
+	VMType             string             `yaml:"vm_type,omitempty"`
+	VMResources        *VMResources       `yaml:"vm_resources,omitempty"`
+	PersistentDisk     int                `yaml:"persistent_disk,omitempty"`
+	PersistentDiskType string             `yaml:"persistent_disk_type,omitempty"`
+	Update             *Update            `yaml:"update,omitempty"`
+	Jobs               []InstanceGroupJob `yaml:"jobs"`
+	Properties         interface{}        `yaml:"properties"`
+}
+
+type VMResources struct {
+	CPU               int `yaml:"cpu"`
+	RAM               int `yaml:"ram"`
+	EphemeralDiskSize int `yaml:"ephemeral_disk_size"`
 }
 
 type InstanceGroupJob struct {
@@ -1,8 +1,12 @@
 package opsman
 
+import "fmt"
+
 type ResourceConfig struct {
-	Name      string
-	Instances ResourceConfigInstances
+	Name               string                  `yaml:"name"`
+	Instances          ResourceConfigInstances `yaml:"instances"`
+	VMType             string                  `yaml:"vm_type"`
+	PersistentDiskType string                  `yaml:"persistent_disk_type"`
 }
 
 type ResourceConfigInstances struct {
@@ -12,3 +16,20 @@ type ResourceConfigInstances struct {
 func (rci ResourceConfigInstances) IsAutomatic() bool {
 	return rci.Value < 0
 }
+
+// UnmarshalYAML accepts either a number of instances or "automatic".
+func (rci *ResourceConfigInstances) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value int
+	if err := unmarshal(&value); err == nil {
+		rci.Value = value
+		return nil
+	}
+
+	var automatic string
+	if err := unmarshal(&automatic); err != nil || automatic != "automatic" {
+		return fmt.Errorf("resource config instances must be a number or \"automatic\"")
+	}
+
+	rci.Value = -1
+	return nil
+}
@@ -1,10 +1,10 @@
 package opsman
 
 type Stemcell struct {
-	File           string
-	Hypervisor     string
-	Infrastructure string
-	Name           string
-	OS             string
-	Version        string
+	File           string `yaml:"file"`
+	Hypervisor     string `yaml:"hypervisor"`
+	Infrastructure string `yaml:"infrastructure"`
+	Name           string `yaml:"name"`
+	OS             string `yaml:"os"`
+	Version        string `yaml:"version"`
 }
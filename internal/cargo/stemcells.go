@@ -0,0 +1,65 @@
+package cargo
+
+// StemcellCriteria returns the stemcells the tile supports. A Kilnfile lists
+// them under stemcells, or names a single stemcell under stemcell_criteria.
+func (kilnfile Kilnfile) StemcellCriteria() []Stemcell {
+	return stemcellCriteria(kilnfile.Stemcell, kilnfile.Stemcells)
+}
+
+// ReleaseStemcellOS returns the OS of the stemcell the release is compiled
+// against. Releases that do not name one use the first stemcell.
+func (kilnfile Kilnfile) ReleaseStemcellOS(release ReleaseConstraint) string {
+	if release.StemcellOS != "" {
+		return release.StemcellOS
+	}
+	if criteria := kilnfile.StemcellCriteria(); len(criteria) > 0 {
+		return criteria[0].OS
+	}
+	return ""
+}
+
+// StemcellCriteria returns the locked stemcells, one for each OS.
+func (lock KilnfileLock) StemcellCriteria() []Stemcell {
+	return stemcellCriteria(lock.Stemcell, lock.Stemcells)
+}
+
+// ReleaseStemcell returns the stemcell the release is compiled against.
+// Releases locked before they named a stemcell use the first locked stemcell.
+func (lock KilnfileLock) ReleaseStemcell(release Release) Stemcell {
+	criteria := lock.StemcellCriteria()
+
+	if release.StemcellOS == "" {
+		if len(criteria) == 0 {
+			return Stemcell{}
+		}
+		return Stemcell{OS: criteria[0].OS, Version: criteria[0].Version}
+	}
+
+	stemcell := Stemcell{OS: release.StemcellOS, Version: release.StemcellVersion}
+	if stemcell.Version == "" {
+		if locked, ok := FindStemcell(criteria, release.StemcellOS); ok {
+			stemcell.Version = locked.Version
+		}
+	}
+	return stemcell
+}
+
+// FindStemcell returns the stemcell with the given OS.
+func FindStemcell(stemcells []Stemcell, os string) (Stemcell, bool) {
+	for _, stemcell := range stemcells {
+		if stemcell.OS == os {
+			return stemcell, true
+		}
+	}
+	return Stemcell{}, false
+}
+
+func stemcellCriteria(stemcell Stemcell, stemcells []Stemcell) []Stemcell {
+	if len(stemcells) > 0 {
+		return stemcells
+	}
+	if stemcell == (Stemcell{}) {
+		return nil
+	}
+	return []Stemcell{stemcell}
+}
@@ -0,0 +1,89 @@
+package cargo_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/pivotal-cf/kiln/internal/cargo"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var _ = Describe("stemcell criteria", func() {
+	Describe("Kilnfile", func() {
+		It("returns the single stemcell_criteria", func() {
+			var kilnfile Kilnfile
+			Expect(yaml.Unmarshal([]byte(`
+stemcell_criteria:
+  os: ubuntu-xenial
+  version: ~621
+`), &kilnfile)).To(Succeed())
+
+			Expect(kilnfile.StemcellCriteria()).To(Equal([]Stemcell{{OS: "ubuntu-xenial", Version: "~621"}}))
+			Expect(kilnfile.ReleaseStemcellOS(ReleaseConstraint{Name: "uaa"})).To(Equal("ubuntu-xenial"))
+		})
+
+		It("returns the list of stemcells", func() {
+			var kilnfile Kilnfile
+			Expect(yaml.Unmarshal([]byte(`
+stemcells:
+- os: ubuntu-xenial
+  version: ~621
+- os: windows
+  version: ~2019
+`), &kilnfile)).To(Succeed())
+
+			Expect(kilnfile.StemcellCriteria()).To(Equal([]Stemcell{
+				{OS: "ubuntu-xenial", Version: "~621"},
+				{OS: "windows", Version: "~2019"},
+			}))
+			Expect(kilnfile.ReleaseStemcellOS(ReleaseConstraint{Name: "uaa"})).To(Equal("ubuntu-xenial"))
+			Expect(kilnfile.ReleaseStemcellOS(ReleaseConstraint{Name: "hwc", StemcellOS: "windows"})).To(Equal("windows"))
+		})
+
+		It("returns no stemcells when there are none", func() {
+			Expect(Kilnfile{}.StemcellCriteria()).To(BeEmpty())
+		})
+	})
+
+	Describe("KilnfileLock", func() {
+		var lock KilnfileLock
+
+		BeforeEach(func() {
+			lock = KilnfileLock{Stemcells: []Stemcell{
+				{OS: "ubuntu-xenial", Version: "621.5"},
+				{OS: "windows", Version: "2019.12"},
+			}}
+		})
+
+		It("returns the stemcell a release is locked against", func() {
+			release := Release{Name: "hwc", Version: "1.0.0", StemcellOS: "windows", StemcellVersion: "2019.10"}
+			Expect(lock.ReleaseStemcell(release)).To(Equal(Stemcell{OS: "windows", Version: "2019.10"}))
+		})
+
+		It("uses the locked version of the release's stemcell OS when the release has no version", func() {
+			release := Release{Name: "hwc", Version: "1.0.0", StemcellOS: "windows"}
+			Expect(lock.ReleaseStemcell(release)).To(Equal(Stemcell{OS: "windows", Version: "2019.12"}))
+		})
+
+		It("uses the first stemcell for releases that do not name one", func() {
+			Expect(lock.ReleaseStemcell(Release{Name: "uaa", Version: "1.2.3"})).To(Equal(Stemcell{OS: "ubuntu-xenial", Version: "621.5"}))
+		})
+
+		It("writes a single stemcell as stemcell_criteria", func() {
+			contents, err := yaml.Marshal(KilnfileLock{
+				Releases: []Release{{Name: "uaa", Version: "1.2.3", SHA1: "abc", StemcellOS: "ubuntu-xenial", StemcellVersion: "621.5"}},
+				Stemcell: Stemcell{OS: "ubuntu-xenial", Version: "621.5"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal(`releases:
+- name: uaa
+  sha1: abc
+  version: 1.2.3
+  stemcell_os: ubuntu-xenial
+  stemcell_version: "621.5"
+stemcell_criteria:
+  os: ubuntu-xenial
+  version: "621.5"
+`))
+		})
+	})
+})
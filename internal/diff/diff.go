@@ -0,0 +1,349 @@
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pivotal-cf/kiln/internal/jsonvalue"
+	"github.com/pivotal-cf/kiln/proofing"
+	yaml "gopkg.in/yaml.v2"
+)
+
+type ChangeType string
+
+const (
+	Added   ChangeType = "added"
+	Removed ChangeType = "removed"
+	Changed ChangeType = "changed"
+)
+
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old,omitempty"`
+	New   interface{} `json:"new,omitempty"`
+}
+
+type Change struct {
+	Name   string        `json:"name"`
+	Type   ChangeType    `json:"type"`
+	Fields []FieldChange `json:"fields,omitempty"`
+}
+
+type Report struct {
+	PropertyBlueprints []Change `json:"property_blueprints"`
+	JobTypes           []Change `json:"job_types"`
+	Releases           []Change `json:"releases"`
+	StemcellCriteria   []Change `json:"stemcell_criteria"`
+	Migrations         []Change `json:"migrations"`
+	EmbeddedFiles      []Change `json:"embedded_files"`
+}
+
+func (r Report) Empty() bool {
+	return len(r.PropertyBlueprints) == 0 &&
+		len(r.JobTypes) == 0 &&
+		len(r.Releases) == 0 &&
+		len(r.StemcellCriteria) == 0 &&
+		len(r.Migrations) == 0 &&
+		len(r.EmbeddedFiles) == 0
+}
+
+func Compare(oldTile, newTile Tile) Report {
+	return Report{
+		PropertyBlueprints: comparePropertyBlueprints(oldTile.Metadata.AllPropertyBlueprints(), newTile.Metadata.AllPropertyBlueprints()),
+		JobTypes:           compareJobTypes(oldTile.Metadata.JobTypes, newTile.Metadata.JobTypes),
+		Releases:           compareReleases(oldTile.Metadata.Releases, newTile.Metadata.Releases),
+		StemcellCriteria:   compareStemcellCriteria(oldTile.Metadata.StemcellCriteria, newTile.Metadata.StemcellCriteria),
+		Migrations:         compareFiles(oldTile.Migrations, newTile.Migrations),
+		EmbeddedFiles:      compareFiles(oldTile.EmbeddedFiles, newTile.EmbeddedFiles),
+	}
+}
+
+func comparePropertyBlueprints(oldBlueprints, newBlueprints []proofing.NormalizedPropertyBlueprint) []Change {
+	oldByName := map[string]interface{}{}
+	for _, pb := range oldBlueprints {
+		oldByName[pb.Property] = pb
+	}
+
+	newByName := map[string]interface{}{}
+	for _, pb := range newBlueprints {
+		newByName[pb.Property] = pb
+	}
+
+	return compareNamed(oldByName, newByName, func(o, n interface{}) []FieldChange {
+		oldBlueprint, newBlueprint := o.(proofing.NormalizedPropertyBlueprint), n.(proofing.NormalizedPropertyBlueprint)
+
+		var fields []FieldChange
+		fields = compareField(fields, "type", oldBlueprint.Type, newBlueprint.Type)
+		fields = compareField(fields, "default", oldBlueprint.Default, newBlueprint.Default)
+		fields = compareField(fields, "configurable", oldBlueprint.Configurable, newBlueprint.Configurable)
+		fields = compareField(fields, "required", oldBlueprint.Required, newBlueprint.Required)
+		return fields
+	})
+}
+
+func compareJobTypes(oldJobTypes, newJobTypes []proofing.JobType) []Change {
+	oldByName := map[string]interface{}{}
+	for _, jt := range oldJobTypes {
+		oldByName[jt.Name] = jt
+	}
+
+	newByName := map[string]interface{}{}
+	for _, jt := range newJobTypes {
+		newByName[jt.Name] = jt
+	}
+
+	return compareNamed(oldByName, newByName, func(o, n interface{}) []FieldChange {
+		oldJobType, newJobType := o.(proofing.JobType), n.(proofing.JobType)
+
+		var fields []FieldChange
+		fields = compareField(fields, "resource_label", oldJobType.ResourceLabel, newJobType.ResourceLabel)
+		fields = compareField(fields, "max_in_flight", oldJobType.MaxInFlight, newJobType.MaxInFlight)
+		fields = compareField(fields, "canaries", oldJobType.Canaries, newJobType.Canaries)
+		fields = compareField(fields, "serial", oldJobType.Serial, newJobType.Serial)
+		fields = compareField(fields, "single_az_only", oldJobType.SingleAZOnly, newJobType.SingleAZOnly)
+		fields = compareField(fields, "errand", oldJobType.Errand, newJobType.Errand)
+		fields = compareField(fields, "instance_definition.default", oldJobType.InstanceDefinition.Default, newJobType.InstanceDefinition.Default)
+		fields = compareField(fields, "manifest", oldJobType.Manifest, newJobType.Manifest)
+
+		oldTemplates := map[string]proofing.Template{}
+		for _, template := range oldJobType.Templates {
+			oldTemplates[template.Name] = template
+		}
+
+		newTemplates := map[string]proofing.Template{}
+		for _, template := range newJobType.Templates {
+			newTemplates[template.Name] = template
+		}
+
+		for _, name := range unionKeys(oldTemplates, newTemplates) {
+			oldTemplate, inOld := oldTemplates[name]
+			newTemplate, inNew := newTemplates[name]
+
+			field := fmt.Sprintf("templates.%s", name)
+			switch {
+			case !inOld:
+				fields = append(fields, FieldChange{Field: field, New: fmt.Sprintf("release %s", newTemplate.Release)})
+			case !inNew:
+				fields = append(fields, FieldChange{Field: field, Old: fmt.Sprintf("release %s", oldTemplate.Release)})
+			default:
+				fields = compareField(fields, field+".release", oldTemplate.Release, newTemplate.Release)
+				fields = compareField(fields, field+".manifest", oldTemplate.Manifest, newTemplate.Manifest)
+				fields = compareField(fields, field+".consumes", oldTemplate.Consumes, newTemplate.Consumes)
+				fields = compareField(fields, field+".provides", oldTemplate.Provides, newTemplate.Provides)
+			}
+		}
+
+		oldResources := map[string]proofing.ResourceDefinition{}
+		for _, rd := range oldJobType.ResourceDefinitions {
+			oldResources[rd.Name] = rd
+		}
+
+		newResources := map[string]proofing.ResourceDefinition{}
+		for _, rd := range newJobType.ResourceDefinitions {
+			newResources[rd.Name] = rd
+		}
+
+		for _, name := range unionKeys(oldResources, newResources) {
+			var oldDefault, newDefault interface{}
+			if rd, ok := oldResources[name]; ok {
+				oldDefault = rd.Default
+			}
+			if rd, ok := newResources[name]; ok {
+				newDefault = rd.Default
+			}
+
+			fields = compareField(fields, fmt.Sprintf("resource_definitions.%s.default", name), oldDefault, newDefault)
+		}
+
+		return fields
+	})
+}
+
+func compareReleases(oldReleases, newReleases []proofing.Release) []Change {
+	oldByName := map[string]interface{}{}
+	for _, r := range oldReleases {
+		oldByName[r.Name] = r
+	}
+
+	newByName := map[string]interface{}{}
+	for _, r := range newReleases {
+		newByName[r.Name] = r
+	}
+
+	return compareNamed(oldByName, newByName, func(o, n interface{}) []FieldChange {
+		oldRelease, newRelease := o.(proofing.Release), n.(proofing.Release)
+
+		var fields []FieldChange
+		fields = compareField(fields, "version", oldRelease.Version, newRelease.Version)
+		fields = compareField(fields, "file", oldRelease.File, newRelease.File)
+		fields = compareField(fields, "sha1", oldRelease.SHA1, newRelease.SHA1)
+		return fields
+	})
+}
+
+func compareStemcellCriteria(oldCriteria, newCriteria proofing.StemcellCriteria) []Change {
+	var fields []FieldChange
+	fields = compareField(fields, "os", oldCriteria.OS, newCriteria.OS)
+	fields = compareField(fields, "version", oldCriteria.Version, newCriteria.Version)
+	fields = compareField(fields, "enable_patch_security_updates", oldCriteria.EnablePatchSecurityUpdates, newCriteria.EnablePatchSecurityUpdates)
+
+	var changes []Change
+	for _, field := range fields {
+		changes = append(changes, Change{
+			Name:   field.Field,
+			Type:   Changed,
+			Fields: []FieldChange{field},
+		})
+	}
+
+	return changes
+}
+
+func compareFiles(oldFiles, newFiles map[string]uint32) []Change {
+	oldByName := map[string]interface{}{}
+	for name, checksum := range oldFiles {
+		oldByName[name] = checksum
+	}
+
+	newByName := map[string]interface{}{}
+	for name, checksum := range newFiles {
+		newByName[name] = checksum
+	}
+
+	return compareNamed(oldByName, newByName, func(o, n interface{}) []FieldChange {
+		if o != n {
+			return []FieldChange{{Field: "contents"}}
+		}
+
+		return nil
+	})
+}
+
+func compareNamed(oldByName, newByName map[string]interface{}, compare func(o, n interface{}) []FieldChange) []Change {
+	var changes []Change
+	for _, name := range unionKeys(oldByName, newByName) {
+		o, inOld := oldByName[name]
+		n, inNew := newByName[name]
+
+		switch {
+		case !inOld:
+			changes = append(changes, Change{Name: name, Type: Added})
+		case !inNew:
+			changes = append(changes, Change{Name: name, Type: Removed})
+		default:
+			if fields := compare(o, n); len(fields) > 0 {
+				changes = append(changes, Change{Name: name, Type: Changed, Fields: fields})
+			}
+		}
+	}
+
+	return changes
+}
+
+// compareField records the change of a field. Strings holding YAML or JSON
+// documents, such as manifests, are compared key by key.
+func compareField(fields []FieldChange, field string, oldValue, newValue interface{}) []FieldChange {
+	if reflect.DeepEqual(oldValue, newValue) {
+		return fields
+	}
+
+	if oldDocument, newDocument, ok := parseDocuments(oldValue, newValue); ok {
+		return compareDocuments(fields, field, oldDocument, newDocument)
+	}
+
+	return append(fields, FieldChange{Field: field, Old: jsonvalue.FromYAML(oldValue), New: jsonvalue.FromYAML(newValue)})
+}
+
+// parseDocuments parses two strings as YAML, which JSON is a subset of. It
+// fails unless both are maps or lists, or one of them is empty.
+func parseDocuments(oldValue, newValue interface{}) (interface{}, interface{}, bool) {
+	oldDocument, oldOK := parseDocument(oldValue)
+	newDocument, newOK := parseDocument(newValue)
+	if !oldOK || !newOK || oldDocument == nil && newDocument == nil {
+		return nil, nil, false
+	}
+
+	return oldDocument, newDocument, true
+}
+
+func parseDocument(value interface{}) (interface{}, bool) {
+	if value == nil {
+		return nil, true
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return nil, false
+	}
+	if strings.TrimSpace(s) == "" {
+		return nil, true
+	}
+
+	var document interface{}
+	if yaml.Unmarshal([]byte(s), &document) != nil {
+		return nil, false
+	}
+
+	document = jsonvalue.FromYAML(document)
+	switch document.(type) {
+	case map[string]interface{}, []interface{}:
+		return document, true
+	}
+
+	return nil, false
+}
+
+// compareDocuments records the changes between two parsed documents, naming
+// each by its path below field.
+func compareDocuments(fields []FieldChange, field string, oldValue, newValue interface{}) []FieldChange {
+	if reflect.DeepEqual(oldValue, newValue) {
+		return fields
+	}
+
+	oldMap, oldIsMap := oldValue.(map[string]interface{})
+	newMap, newIsMap := newValue.(map[string]interface{})
+	if (oldIsMap || oldValue == nil) && (newIsMap || newValue == nil) {
+		for _, key := range unionKeys(oldMap, newMap) {
+			fields = compareDocuments(fields, field+"."+key, oldMap[key], newMap[key])
+		}
+		return fields
+	}
+
+	oldList, oldIsList := oldValue.([]interface{})
+	newList, newIsList := newValue.([]interface{})
+	if (oldIsList || oldValue == nil) && (newIsList || newValue == nil) {
+		for i := 0; i < len(oldList) || i < len(newList); i++ {
+			var o, n interface{}
+			if i < len(oldList) {
+				o = oldList[i]
+			}
+			if i < len(newList) {
+				n = newList[i]
+			}
+			fields = compareDocuments(fields, fmt.Sprintf("%s[%d]", field, i), o, n)
+		}
+		return fields
+	}
+
+	return append(fields, FieldChange{Field: field, Old: oldValue, New: newValue})
+}
+
+// unionKeys returns the sorted keys of two maps with the same key type.
+func unionKeys(maps ...interface{}) []string {
+	seen := map[string]bool{}
+	for _, m := range maps {
+		for _, key := range reflect.ValueOf(m).MapKeys() {
+			seen[key.String()] = true
+		}
+	}
+
+	var keys []string
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
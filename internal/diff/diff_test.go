@@ -0,0 +1,235 @@
+package diff_test
+
+import (
+	"encoding/json"
+
+	"github.com/pivotal-cf/kiln/proofing"
+
+	. "github.com/pivotal-cf/kiln/internal/diff"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Compare", func() {
+	var oldTile, newTile Tile
+
+	BeforeEach(func() {
+		oldTile = Tile{
+			Metadata: proofing.ProductTemplate{
+				Releases: []proofing.Release{
+					{Name: "some-release", Version: "1.0.0", File: "some-release-1.0.0.tgz"},
+					{Name: "some-removed-release", Version: "2.0.0", File: "some-removed-release-2.0.0.tgz"},
+				},
+				StemcellCriteria: proofing.StemcellCriteria{OS: "ubuntu-xenial", Version: "250.17"},
+				PropertyBlueprints: proofing.PropertyBlueprints{
+					proofing.SimplePropertyBlueprint{Name: "some-property", Type: "string", Default: "some-default"},
+					proofing.SimplePropertyBlueprint{Name: "some-removed-property", Type: "boolean"},
+				},
+				JobTypes: []proofing.JobType{
+					{
+						Name:        "some-instance-group",
+						MaxInFlight: 1,
+						Manifest:    `{"some-key":"some-value"}`,
+						Templates: []proofing.Template{
+							{Name: "some-job", Release: "some-release"},
+							{Name: "some-removed-job", Release: "some-removed-release"},
+						},
+						ResourceDefinitions: []proofing.ResourceDefinition{{Name: "ram", Default: 1024}},
+					},
+				},
+			},
+			Migrations:    map[string]uint32{"migrations/v1/some-migration.js": 1},
+			EmbeddedFiles: map[string]uint32{"embed/some-file": 1, "embed/some-removed-file": 2},
+		}
+
+		newTile = Tile{
+			Metadata: proofing.ProductTemplate{
+				Releases: []proofing.Release{
+					{Name: "some-release", Version: "1.1.0", File: "some-release-1.1.0.tgz"},
+					{Name: "some-added-release", Version: "3.0.0", File: "some-added-release-3.0.0.tgz"},
+				},
+				StemcellCriteria: proofing.StemcellCriteria{OS: "ubuntu-xenial", Version: "250.25"},
+				PropertyBlueprints: proofing.PropertyBlueprints{
+					proofing.SimplePropertyBlueprint{Name: "some-property", Type: "string", Default: "some-other-default"},
+					proofing.SimplePropertyBlueprint{Name: "some-added-property", Type: "integer"},
+				},
+				JobTypes: []proofing.JobType{
+					{
+						Name:        "some-instance-group",
+						MaxInFlight: "50%",
+						Manifest:    `{"some-key":"some-other-value","with":"enough content to be longer than a line"}`,
+						Templates: []proofing.Template{
+							{Name: "some-job", Release: "some-release"},
+							{Name: "some-added-job", Release: "some-added-release"},
+						},
+						ResourceDefinitions: []proofing.ResourceDefinition{{Name: "ram", Default: 2048}},
+					},
+					{Name: "some-added-instance-group"},
+				},
+			},
+			Migrations:    map[string]uint32{"migrations/v1/some-migration.js": 1, "migrations/v1/some-added-migration.js": 3},
+			EmbeddedFiles: map[string]uint32{"embed/some-file": 4},
+		}
+	})
+
+	It("reports the added, removed and changed parts of the tile", func() {
+		report := Compare(oldTile, newTile)
+
+		Expect(report.PropertyBlueprints).To(Equal([]Change{
+			{Name: ".properties.some-added-property", Type: Added},
+			{Name: ".properties.some-property", Type: Changed, Fields: []FieldChange{
+				{Field: "default", Old: "some-default", New: "some-other-default"},
+			}},
+			{Name: ".properties.some-removed-property", Type: Removed},
+		}))
+
+		Expect(report.JobTypes).To(Equal([]Change{
+			{Name: "some-added-instance-group", Type: Added},
+			{Name: "some-instance-group", Type: Changed, Fields: []FieldChange{
+				{Field: "max_in_flight", Old: 1, New: "50%"},
+				{Field: "manifest.some-key", Old: "some-value", New: "some-other-value"},
+				{Field: "manifest.with", New: "enough content to be longer than a line"},
+				{Field: "templates.some-added-job", New: "release some-added-release"},
+				{Field: "templates.some-removed-job", Old: "release some-removed-release"},
+				{Field: "resource_definitions.ram.default", Old: 1024, New: 2048},
+			}},
+		}))
+
+		Expect(report.Releases).To(Equal([]Change{
+			{Name: "some-added-release", Type: Added},
+			{Name: "some-release", Type: Changed, Fields: []FieldChange{
+				{Field: "version", Old: "1.0.0", New: "1.1.0"},
+				{Field: "file", Old: "some-release-1.0.0.tgz", New: "some-release-1.1.0.tgz"},
+			}},
+			{Name: "some-removed-release", Type: Removed},
+		}))
+
+		Expect(report.StemcellCriteria).To(Equal([]Change{
+			{Name: "version", Type: Changed, Fields: []FieldChange{
+				{Field: "version", Old: "250.17", New: "250.25"},
+			}},
+		}))
+
+		Expect(report.Migrations).To(Equal([]Change{
+			{Name: "migrations/v1/some-added-migration.js", Type: Added},
+		}))
+
+		Expect(report.EmbeddedFiles).To(Equal([]Change{
+			{Name: "embed/some-file", Type: Changed, Fields: []FieldChange{{Field: "contents"}}},
+			{Name: "embed/some-removed-file", Type: Removed},
+		}))
+	})
+
+	It("renders the report for humans", func() {
+		Expect(Compare(oldTile, newTile).String()).To(Equal(`Property blueprints:
+  + .properties.some-added-property
+  ~ .properties.some-property
+      default: some-default -> some-other-default
+  - .properties.some-removed-property
+Job types:
+  + some-added-instance-group
+  ~ some-instance-group
+      max_in_flight: 1 -> 50%
+      manifest.some-key: some-value -> some-other-value
+      manifest.with: added (enough content to be longer than a line)
+      templates.some-added-job: added (release some-added-release)
+      templates.some-removed-job: removed (release some-removed-release)
+      resource_definitions.ram.default: 1024 -> 2048
+Releases:
+  + some-added-release
+  ~ some-release
+      version: 1.0.0 -> 1.1.0
+      file: some-release-1.0.0.tgz -> some-release-1.1.0.tgz
+  - some-removed-release
+Stemcell criteria:
+  ~ version
+      version: 250.17 -> 250.25
+Migrations:
+  + migrations/v1/some-added-migration.js
+Embedded files:
+  ~ embed/some-file
+      contents: changed
+  - embed/some-removed-file
+`))
+	})
+
+	It("can be encoded as JSON", func() {
+		oldTile.Metadata.PropertyBlueprints = proofing.PropertyBlueprints{
+			proofing.SimplePropertyBlueprint{Name: "some-property", Type: "collection", Default: []interface{}{
+				map[interface{}]interface{}{"name": "some-name"},
+			}},
+		}
+		newTile.Metadata.PropertyBlueprints = proofing.PropertyBlueprints{
+			proofing.SimplePropertyBlueprint{Name: "some-property", Type: "collection"},
+		}
+
+		contents, err := json.Marshal(Compare(oldTile, newTile).PropertyBlueprints)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(contents).To(MatchJSON(`[
+			{
+				"name": ".properties.some-property",
+				"type": "changed",
+				"fields": [{"field": "default", "old": [{"name": "some-name"}]}]
+			}
+		]`))
+	})
+
+	Context("when a manifest is a multi-line YAML document", func() {
+		BeforeEach(func() {
+			oldTile.Metadata.JobTypes[0].Templates[0].Manifest = `
+some-job:
+  tls:
+    enabled: false
+  ports: [8080, 8443]
+  description: |
+    a description that does not change
+    and spans lines
+`
+			newTile.Metadata.JobTypes[0].Templates[0].Manifest = `
+some-job:
+  tls:
+    enabled: true
+    ciphers: some-ciphers
+  ports: [8080]
+  description: |
+    a description that does not change
+    and spans lines
+`
+		})
+
+		It("reports the changes key by key", func() {
+			report := Compare(oldTile, newTile)
+
+			Expect(report.JobTypes[1].Fields).To(ContainElement(FieldChange{Field: "templates.some-job.manifest.some-job.ports[1]", Old: 8443}))
+			Expect(report.String()).To(ContainSubstring(`
+      templates.some-job.manifest.some-job.ports[1]: removed (8443)
+      templates.some-job.manifest.some-job.tls.ciphers: added (some-ciphers)
+      templates.some-job.manifest.some-job.tls.enabled: false -> true
+`))
+		})
+	})
+
+	Context("when a long value is not a YAML or JSON document", func() {
+		BeforeEach(func() {
+			oldTile.Metadata.JobTypes[0].Templates[0].Manifest = "some text that is too long to be read on a single line of the report"
+			newTile.Metadata.JobTypes[0].Templates[0].Manifest = "some other text that is too long to be read on a single line of the report"
+		})
+
+		It("reports it as changed", func() {
+			Expect(Compare(oldTile, newTile).String()).To(ContainSubstring(`
+      templates.some-job.manifest: changed
+`))
+		})
+	})
+
+	Context("when the tiles are the same", func() {
+		It("reports no differences", func() {
+			report := Compare(oldTile, oldTile)
+
+			Expect(report.Empty()).To(BeTrue())
+			Expect(report.String()).To(Equal("No differences\n"))
+		})
+	})
+})
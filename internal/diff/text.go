@@ -0,0 +1,94 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const maxValueLength = 60
+
+var changeSymbols = map[ChangeType]string{
+	Added:   "+",
+	Removed: "-",
+	Changed: "~",
+}
+
+// String renders the report for humans. Values that are too long to read on a
+// single line, and that are not YAML or JSON documents compared key by key,
+// are only reported as changed.
+func (r Report) String() string {
+	if r.Empty() {
+		return "No differences\n"
+	}
+
+	var b strings.Builder
+	for _, section := range []struct {
+		title   string
+		changes []Change
+	}{
+		{"Property blueprints", r.PropertyBlueprints},
+		{"Job types", r.JobTypes},
+		{"Releases", r.Releases},
+		{"Stemcell criteria", r.StemcellCriteria},
+		{"Migrations", r.Migrations},
+		{"Embedded files", r.EmbeddedFiles},
+	} {
+		if len(section.changes) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "%s:\n", section.title)
+		for _, change := range section.changes {
+			fmt.Fprintf(&b, "  %s %s\n", changeSymbols[change.Type], change.Name)
+			for _, field := range change.Fields {
+				fmt.Fprintf(&b, "      %s\n", field)
+			}
+		}
+	}
+
+	return b.String()
+}
+
+func (fc FieldChange) String() string {
+	oldValue, oldOK := formatValue(fc.Old)
+	newValue, newOK := formatValue(fc.New)
+
+	switch {
+	case fc.Old == nil && fc.New == nil, !oldOK || !newOK:
+		return fmt.Sprintf("%s: changed", fc.Field)
+	case fc.Old == nil:
+		return fmt.Sprintf("%s: added (%s)", fc.Field, newValue)
+	case fc.New == nil:
+		return fmt.Sprintf("%s: removed (%s)", fc.Field, oldValue)
+	default:
+		return fmt.Sprintf("%s: %s -> %s", fc.Field, oldValue, newValue)
+	}
+}
+
+func formatValue(value interface{}) (string, bool) {
+	var formatted string
+	switch v := value.(type) {
+	case nil:
+		return "", true
+	case string:
+		if v == "" {
+			return `""`, true
+		}
+		formatted = v
+	case map[string]interface{}, []interface{}:
+		contents, err := json.Marshal(v)
+		if err != nil {
+			return "", false // NOTE: this cannot happen, the values came from YAML
+		}
+		formatted = string(contents)
+	default:
+		formatted = fmt.Sprintf("%v", v)
+	}
+
+	if len(formatted) > maxValueLength || strings.Contains(formatted, "\n") {
+		return "", false
+	}
+
+	return formatted, true
+}
@@ -0,0 +1,88 @@
+package diff
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pivotal-cf/kiln/proofing"
+)
+
+type Tile struct {
+	Metadata      proofing.ProductTemplate
+	Migrations    map[string]uint32
+	EmbeddedFiles map[string]uint32
+}
+
+// ReadTile reads a .pivotal file, or a metadata file when the path does not
+// point at a zip archive. Migrations and embedded files are keyed by their
+// path within the tile and hold the CRC-32 checksum of their contents.
+func ReadTile(tilePath string) (Tile, error) {
+	zr, err := zip.OpenReader(tilePath)
+	if err != nil {
+		if err != zip.ErrFormat {
+			return Tile{}, err
+		}
+
+		return readMetadataFile(tilePath)
+	}
+	defer zr.Close()
+
+	tile := Tile{
+		Migrations:    map[string]uint32{},
+		EmbeddedFiles: map[string]uint32{},
+	}
+
+	var foundMetadata bool
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		switch {
+		case path.Dir(f.Name) == "metadata" && path.Ext(f.Name) == ".yml":
+			tile.Metadata, err = parseMetadata(f)
+			if err != nil {
+				return Tile{}, fmt.Errorf("failed to parse %s in %s: %s", f.Name, tilePath, err)
+			}
+			foundMetadata = true
+		case strings.HasPrefix(f.Name, "migrations/"):
+			tile.Migrations[f.Name] = f.CRC32
+		case strings.HasPrefix(f.Name, "embed/"):
+			tile.EmbeddedFiles[f.Name] = f.CRC32
+		}
+	}
+
+	if !foundMetadata {
+		return Tile{}, fmt.Errorf("failed to find metadata in %s", tilePath)
+	}
+
+	return tile, nil
+}
+
+func readMetadataFile(metadataPath string) (Tile, error) {
+	f, err := os.Open(metadataPath)
+	if err != nil {
+		return Tile{}, err
+	}
+	defer f.Close()
+
+	metadata, err := proofing.Parse(f)
+	if err != nil {
+		return Tile{}, fmt.Errorf("failed to parse %s: %s", metadataPath, err)
+	}
+
+	return Tile{Metadata: metadata}, nil
+}
+
+func parseMetadata(f *zip.File) (proofing.ProductTemplate, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return proofing.ProductTemplate{}, err
+	}
+	defer rc.Close()
+
+	return proofing.Parse(rc)
+}
@@ -0,0 +1,112 @@
+package diff_test
+
+import (
+	"archive/zip"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/pivotal-cf/kiln/internal/diff"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ReadTile", func() {
+	var tmpDir string
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "diff-test")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	Context("when given a tile", func() {
+		It("reads the metadata, migrations and embedded files", func() {
+			tilePath := filepath.Join(tmpDir, "some-product.pivotal")
+			writeZip(tilePath, map[string]string{
+				"metadata/metadata.yml":             "name: some-product\nproduct_version: 1.2.3\n",
+				"migrations/v1/201901010000_foo.js": "some-migration",
+				"embed/scripts/some-script.sh":      "some-script",
+				"releases/some-release-1.0.0.tgz":   "some-release",
+			})
+
+			tile, err := ReadTile(tilePath)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(tile.Metadata.Name).To(Equal("some-product"))
+			Expect(tile.Metadata.ProductVersion).To(Equal("1.2.3"))
+			Expect(tile.Migrations).To(Equal(map[string]uint32{
+				"migrations/v1/201901010000_foo.js": crc32.ChecksumIEEE([]byte("some-migration")),
+			}))
+			Expect(tile.EmbeddedFiles).To(Equal(map[string]uint32{
+				"embed/scripts/some-script.sh": crc32.ChecksumIEEE([]byte("some-script")),
+			}))
+		})
+
+		Context("when the tile does not contain metadata", func() {
+			It("returns an error", func() {
+				tilePath := filepath.Join(tmpDir, "some-product.pivotal")
+				writeZip(tilePath, map[string]string{
+					"releases/some-release-1.0.0.tgz": "some-release",
+				})
+
+				_, err := ReadTile(tilePath)
+				Expect(err).To(MatchError("failed to find metadata in " + tilePath))
+			})
+		})
+	})
+
+	Context("when given a metadata file", func() {
+		It("reads the metadata", func() {
+			metadataPath := filepath.Join(tmpDir, "metadata.yml")
+			Expect(ioutil.WriteFile(metadataPath, []byte("name: some-product\n"), 0644)).To(Succeed())
+
+			tile, err := ReadTile(metadataPath)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(tile.Metadata.Name).To(Equal("some-product"))
+			Expect(tile.Migrations).To(BeEmpty())
+			Expect(tile.EmbeddedFiles).To(BeEmpty())
+		})
+
+		Context("when the metadata is not valid YAML", func() {
+			It("returns an error", func() {
+				metadataPath := filepath.Join(tmpDir, "metadata.yml")
+				Expect(ioutil.WriteFile(metadataPath, []byte("%%%"), 0644)).To(Succeed())
+
+				_, err := ReadTile(metadataPath)
+				Expect(err).To(MatchError(ContainSubstring("failed to parse " + metadataPath)))
+			})
+		})
+	})
+
+	Context("when the file does not exist", func() {
+		It("returns an error", func() {
+			_, err := ReadTile(filepath.Join(tmpDir, "missing.pivotal"))
+			Expect(err).To(MatchError(ContainSubstring("no such file or directory")))
+		})
+	})
+})
+
+func writeZip(path string, files map[string]string) {
+	f, err := os.Create(path)
+	Expect(err).NotTo(HaveOccurred())
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for name, contents := range files {
+		w, err := zw.Create(name)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = w.Write([]byte(contents))
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	Expect(zw.Close()).To(Succeed())
+}
@@ -0,0 +1,35 @@
+package jsonvalue
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// FromYAML converts the maps decoded from YAML, which have interface{} keys,
+// into maps that can be encoded as JSON. Lists are converted element by
+// element, and pointers, such as the optional fields of proofing types, are
+// replaced by the values they point to.
+func FromYAML(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			converted[fmt.Sprintf("%v", key)] = FromYAML(value)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, value := range v {
+			converted[i] = FromYAML(value)
+		}
+		return converted
+	default:
+		if v := reflect.ValueOf(value); v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return nil
+			}
+			return FromYAML(v.Elem().Interface())
+		}
+		return value
+	}
+}
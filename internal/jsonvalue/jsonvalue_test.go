@@ -0,0 +1,50 @@
+package jsonvalue_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/pivotal-cf/kiln/internal/jsonvalue"
+)
+
+var _ = Describe("FromYAML", func() {
+	It("converts nested maps decoded from YAML so that they can be encoded as JSON", func() {
+		var document interface{}
+		Expect(yaml.Unmarshal([]byte(`
+name: some-name
+1: one
+items:
+- key: value
+- [a, b]
+`), &document)).To(Succeed())
+
+		converted := jsonvalue.FromYAML(document)
+
+		Expect(converted).To(Equal(map[string]interface{}{
+			"name": "some-name",
+			"1":    "one",
+			"items": []interface{}{
+				map[string]interface{}{"key": "value"},
+				[]interface{}{"a", "b"},
+			},
+		}))
+
+		_, err := json.Marshal(converted)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("replaces pointers with the values they point to", func() {
+		three := 3
+
+		Expect(jsonvalue.FromYAML(&three)).To(Equal(3))
+		Expect(jsonvalue.FromYAML((*bool)(nil))).To(BeNil())
+	})
+
+	It("returns other values as they are", func() {
+		Expect(jsonvalue.FromYAML("some-string")).To(Equal("some-string"))
+		Expect(jsonvalue.FromYAML(nil)).To(BeNil())
+	})
+})
@@ -0,0 +1,90 @@
+package progress
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Meter counts the bytes written to it and calls its report function at
+// every interval until it is stopped, so that long downloads and writes can
+// log how far along they are.
+type Meter struct {
+	written int64
+	start   time.Time
+	done    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+}
+
+// Start starts a Meter that calls report at every interval.
+func Start(interval time.Duration, report func(*Meter)) *Meter {
+	m := &Meter{
+		start:   time.Now(),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	go func() {
+		defer close(m.stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				report(m)
+			case <-m.done:
+				return
+			}
+		}
+	}()
+
+	return m
+}
+
+func (m *Meter) Write(b []byte) (int, error) {
+	atomic.AddInt64(&m.written, int64(len(b)))
+	return len(b), nil
+}
+
+// Written returns the number of bytes written so far.
+func (m *Meter) Written() int64 {
+	return atomic.LoadInt64(&m.written)
+}
+
+// Elapsed returns the time since the Meter started.
+func (m *Meter) Elapsed() time.Duration {
+	return time.Since(m.start)
+}
+
+// Throughput returns the bytes written per second since the Meter started.
+func (m *Meter) Throughput() int64 {
+	written := m.Written()
+	seconds := m.Elapsed().Seconds()
+	if seconds <= 0 {
+		return written
+	}
+	return int64(float64(written) / seconds)
+}
+
+// Stop stops the reports and waits for one in progress to be logged. It may
+// be called more than once.
+func (m *Meter) Stop() {
+	m.once.Do(func() { close(m.done) })
+	<-m.stopped
+}
+
+// FormatBytes formats n in binary units, such as 1.5 MiB.
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,43 @@
+package progress_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-cf/kiln/internal/progress"
+)
+
+var _ = Describe("Meter", func() {
+	It("reports the bytes written at every interval until it is stopped", func() {
+		reports := make(chan int64, 100)
+		meter := progress.Start(5*time.Millisecond, func(m *progress.Meter) {
+			reports <- m.Written()
+		})
+
+		_, err := meter.Write([]byte("some-bytes"))
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(reports).Should(Receive(Equal(int64(10))))
+
+		meter.Stop()
+		meter.Stop()
+
+		Expect(meter.Written()).To(Equal(int64(10)))
+		Expect(meter.Throughput()).To(BeNumerically(">", 0))
+
+		count := len(reports)
+		time.Sleep(20 * time.Millisecond)
+		Expect(reports).To(HaveLen(count))
+	})
+})
+
+var _ = Describe("FormatBytes", func() {
+	It("formats sizes in binary units", func() {
+		Expect(progress.FormatBytes(512)).To(Equal("512 B"))
+		Expect(progress.FormatBytes(1536)).To(Equal("1.5 KiB"))
+		Expect(progress.FormatBytes(3 * 1024 * 1024)).To(Equal("3.0 MiB"))
+		Expect(progress.FormatBytes(5 * 1024 * 1024 * 1024)).To(Equal("5.0 GiB"))
+	})
+})
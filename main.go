@@ -12,6 +12,7 @@ import (
 	"github.com/pivotal-cf/kiln/fetcher"
 	"github.com/pivotal-cf/kiln/helper"
 	"github.com/pivotal-cf/kiln/internal/baking"
+	"github.com/pivotal-cf/kiln/internal/cargo"
 )
 
 var version = "unknown"
@@ -54,7 +55,7 @@ func main() {
 
 	filesystem := helper.NewFilesystem()
 	zipper := builder.NewZipper()
-	interpolator := builder.NewInterpolator()
+	interpolator := builder.NewInterpolatorWithLogger(errLogger)
 	tileWriter := builder.NewTileWriter(filesystem, &zipper, errLogger)
 
 	releaseManifestReader := builder.NewReleaseManifestReader()
@@ -96,12 +97,19 @@ func main() {
 
 	releaseSourcesFactory := fetcher.NewReleaseSourcesFactory(outLogger)
 
-	commandSet["fetch"] = commands.NewFetch(outLogger, releaseSourcesFactory, localReleaseDirectory)
+	releaseCache := fetcher.NewReleaseCache(outLogger)
+
+	pivnet := new(fetcher.Pivnet)
+	stemcellTarballs := fetcher.NewStemcellTarballs(outLogger, pivnet, "")
+
+	commandSet["fetch"] = commands.NewFetch(outLogger, releaseSourcesFactory, localReleaseDirectory, releaseCache, stemcellTarballs)
+	commandSet["cache"] = commands.NewCache(outLogger, releaseCache)
 	commandSet["publish"] = commands.NewPublish(outLogger, errLogger, osfs.New(""))
 	commandSet["bake"] = commands.NewBake(
 		interpolator,
 		tileWriter,
 		outLogger,
+		errLogger,
 		templateVariablesService,
 		boshVariablesService,
 		releasesService,
@@ -116,8 +124,15 @@ func main() {
 		checksummer,
 	)
 
+	commandSet["validate"] = commands.NewValidate(outLogger)
+	commandSet["diff"] = commands.NewDiff(outLogger)
+	commandSet["manifest"] = commands.NewManifest(outLogger, cargo.NewGenerator())
+	commandSet["init"] = commands.NewInit(outLogger)
+
 	commandSet["update"] = commands.Update{
-		StemcellsVersionsService: new(fetcher.Pivnet),
+		StemcellsVersionsService: pivnet,
+		StemcellTarballs:         stemcellTarballs,
+		ReleaseSourcesFactory:    releaseSourcesFactory,
 	}
 
 	err = commandSet.Execute(command, args)
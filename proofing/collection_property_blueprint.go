@@ -1,8 +1,21 @@
 package proofing
 
+import "fmt"
+
 type CollectionPropertyBlueprint struct {
 	SimplePropertyBlueprint `yaml:",inline"`
 
 	PropertyBlueprints []SimplePropertyBlueprint `yaml:"property_blueprints"`
 	NamedManifests     []NamedManifest           `yaml:"named_manifests"`
 }
+
+func (cp CollectionPropertyBlueprint) Validate() error {
+	err := cp.SimplePropertyBlueprint.Validate()
+	err = ValidatePresence(err, cp, "PropertyBlueprints")
+
+	for i, propertyBlueprint := range cp.PropertyBlueprints {
+		err = AddNestedValidationErrors(err, fmt.Sprintf("property_blueprints[%d]", i), propertyBlueprint.Validate())
+	}
+
+	return err
+}
@@ -62,4 +62,31 @@ var _ = Describe("CollectionPropertyBlueprint", func() {
 		})
 	})
 
+	Context("validations", func() {
+		BeforeEach(func() {
+			collectionPropertyBlueprint = CollectionPropertyBlueprint{
+				SimplePropertyBlueprint: SimplePropertyBlueprint{
+					Name: "some-name",
+					Type: "collection",
+				},
+				PropertyBlueprints: []SimplePropertyBlueprint{
+					{Name: "some-nested-name", Type: "string"},
+				},
+			}
+		})
+
+		It("is valid", func() {
+			Expect(collectionPropertyBlueprint.Validate()).To(Succeed())
+		})
+
+		It("validates the presence of the PropertyBlueprints field", func() {
+			collectionPropertyBlueprint.PropertyBlueprints = nil
+			Expect(collectionPropertyBlueprint.Validate()).To(MatchError("collection property blueprint property_blueprints must be present"))
+		})
+
+		It("validates the nested property blueprints", func() {
+			collectionPropertyBlueprint.PropertyBlueprints[0].Name = ""
+			Expect(collectionPropertyBlueprint.Validate()).To(MatchError("property_blueprints[0]: simple property blueprint name must be present"))
+		})
+	})
 })
@@ -1,7 +1,19 @@
 package proofing
 
+import "fmt"
+
 type CollectionPropertyInput struct {
 	SimplePropertyInput `yaml:",inline"`
 
 	PropertyInputs []CollectionSubfieldPropertyInput `yaml:"property_inputs"`
 }
+
+func (cpi CollectionPropertyInput) Validate() error {
+	err := cpi.SimplePropertyInput.Validate()
+
+	for i, propertyInput := range cpi.PropertyInputs {
+		err = AddNestedValidationErrors(err, fmt.Sprintf("property_inputs[%d]", i), propertyInput.Validate())
+	}
+
+	return err
+}
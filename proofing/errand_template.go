@@ -1,12 +1,47 @@
 package proofing
 
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var errandInstancePattern = regexp.MustCompile(`^[^/]+/[^/]+$`)
+
 type ErrandTemplate struct {
 	Name        string   `yaml:"name"`
 	Colocated   bool     `yaml:"colocated"`
 	RunDefault  bool     `yaml:"run_default"`
-	Instances   []string `yaml:"instances"` // TODO: how to validate?
+	Instances   []string `yaml:"instances"`
 	Label       string   `yaml:"label"`
 	Description string   `yaml:"description"`
+}
+
+func (et ErrandTemplate) Validate() error {
+	var err error
+	err = ValidatePresence(err, et, "Name")
+
+	if et.Colocated {
+		err = ValidatePresence(err, et, "Label")
+		err = ValidatePresence(err, et, "Instances")
+	}
+
+	for i, instance := range et.Instances {
+		if !errandInstancePattern.MatchString(instance) {
+			err = AddNestedValidationErrors(err, fmt.Sprintf("instances[%d]", i), NewValidationError(et, fmt.Sprintf("instance %q must be of the form instance_group/index", instance)))
+		}
+	}
+
+	return err
+}
+
+func (et ErrandTemplate) instanceGroups() []string {
+	var instanceGroups []string
+	for _, instance := range et.Instances {
+		if errandInstancePattern.MatchString(instance) {
+			instanceGroups = append(instanceGroups, instance[:strings.Index(instance, "/")])
+		}
+	}
 
-	// TODO: validations: https://github.com/pivotal-cf/installation/blob/039a2ef3f751ef5915c425da8150a29af4b764dd/web/app/models/persistence/metadata/errand_template.rb#L11-L22
+	return instanceGroups
 }
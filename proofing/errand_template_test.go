@@ -31,4 +31,45 @@ var _ = Describe("ErrandTemplate", func() {
 		Expect(errandTemplate.Name).To(Equal("some-name"))
 		Expect(errandTemplate.RunDefault).To(BeTrue())
 	})
+
+	Context("validations", func() {
+		BeforeEach(func() {
+			errandTemplate = ErrandTemplate{
+				Name:      "some-name",
+				Colocated: true,
+				Label:     "some-label",
+				Instances: []string{"control/first"},
+			}
+		})
+
+		It("is valid", func() {
+			Expect(errandTemplate.Validate()).To(Succeed())
+		})
+
+		It("validates the presence of the Name field", func() {
+			errandTemplate.Name = ""
+			Expect(errandTemplate.Validate()).To(MatchError("errand template name must be present"))
+		})
+
+		Context("when the errand is colocated", func() {
+			It("validates the presence of the Label and Instances fields", func() {
+				errandTemplate.Label = ""
+				errandTemplate.Instances = nil
+				Expect(errandTemplate.Validate()).To(MatchError(`- errand template label must be present
+- errand template instances must be present`))
+			})
+		})
+
+		Context("when the errand is not colocated", func() {
+			It("does not require a label or instances", func() {
+				errandTemplate = ErrandTemplate{Name: "some-name"}
+				Expect(errandTemplate.Validate()).To(Succeed())
+			})
+		})
+
+		It("validates the format of the instances", func() {
+			errandTemplate.Instances = []string{"control"}
+			Expect(errandTemplate.Validate()).To(MatchError(`instances[0]: errand template instance "control" must be of the form instance_group/index`))
+		})
+	})
 })
@@ -1,5 +1,7 @@
 package proofing
 
+import "fmt"
+
 type FormType struct {
 	Verifiers      []VerifierBlueprint `yaml:"verifiers,omitempty"`
 	PropertyInputs PropertyInputs      `yaml:"property_inputs"`
@@ -8,6 +10,33 @@ type FormType struct {
 	Label       string `yaml:"label"`
 	Description string `yaml:"description"`
 	Markdown    string `yaml:"markdown,omitempty"`
+}
+
+func (ft FormType) Validate() error {
+	var err error
+	err = ValidatePresence(err, ft, "Name")
+	err = ValidatePresence(err, ft, "Label")
+
+	for i, propertyInput := range ft.PropertyInputs {
+		err = AddNestedValidationErrors(err, fmt.Sprintf("property_inputs[%d]", i), validatePropertyInput(propertyInput))
+	}
+
+	for i, verifier := range ft.Verifiers {
+		err = AddNestedValidationErrors(err, fmt.Sprintf("verifiers[%d]", i), verifier.Validate())
+	}
+
+	return err
+}
 
-	// TODO: validations: https://github.com/pivotal-cf/installation/blob/039a2ef3f751ef5915c425da8150a29af4b764dd/web/app/models/persistence/metadata/form_type.rb#L13-L24
+func validatePropertyInput(propertyInput PropertyInput) error {
+	switch pi := propertyInput.(type) {
+	case SimplePropertyInput:
+		return pi.Validate()
+	case CollectionPropertyInput:
+		return pi.Validate()
+	case SelectorPropertyInput:
+		return pi.Validate()
+	default:
+		return nil
+	}
 }
@@ -32,4 +32,49 @@ var _ = Describe("FormType", func() {
 		Expect(formType.PropertyInputs).To(HaveLen(3))
 		Expect(formType.Verifiers).To(HaveLen(1))
 	})
+
+	Context("validations", func() {
+		BeforeEach(func() {
+			formType = FormType{
+				Name:  "some-name",
+				Label: "some-label",
+				PropertyInputs: PropertyInputs{
+					SimplePropertyInput{Reference: ".properties.some-property", Label: "some-label"},
+					SelectorPropertyInput{
+						SimplePropertyInput: SimplePropertyInput{Reference: ".properties.some-selector", Label: "some-label"},
+						SelectorPropertyInputs: []SelectorOptionPropertyInput{
+							{
+								Reference: ".properties.some-selector.some-option",
+								Label:     "some-label",
+								PropertyInputs: []SimplePropertyInput{
+									{Reference: ".properties.some-selector.some-option.some-property", Label: "some-label"},
+								},
+							},
+						},
+					},
+				},
+			}
+		})
+
+		It("is valid", func() {
+			Expect(formType.Validate()).To(Succeed())
+		})
+
+		It("validates the presence of the Name and Label fields", func() {
+			formType.Name = ""
+			formType.Label = ""
+			Expect(formType.Validate()).To(MatchError(`- form type name must be present
+- form type label must be present`))
+		})
+
+		It("validates the property inputs", func() {
+			formType.PropertyInputs[0] = SimplePropertyInput{Label: "some-label"}
+
+			selectorPropertyInput := formType.PropertyInputs[1].(SelectorPropertyInput)
+			selectorPropertyInput.SelectorPropertyInputs[0].PropertyInputs[0].Label = ""
+
+			Expect(formType.Validate()).To(MatchError(`- property_inputs[0]: simple property input reference must be present
+- property_inputs[1].selector_property_inputs[0].property_inputs[0]: simple property input label must be present`))
+		})
+	})
 })
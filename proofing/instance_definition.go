@@ -5,6 +5,13 @@ type InstanceDefinition struct {
 	Configurable bool          `yaml:"configurable"`
 	Constraints  interface{}   `yaml:"constraints,omitempty"` // TODO: schema?
 	ZeroIf       ZeroIfBinding `yaml:"zero_if,omitempty"`     // TODO: schema?
+}
+
+func (id InstanceDefinition) Validate() error {
+	var err error
+	if id.Default < 0 {
+		err = AddValidationError(err, NewValidationError(id, "default must be greater than or equal to 0"))
+	}
 
-	// TODO: validations: https://github.com/pivotal-cf/installation/blob/039a2ef3f751ef5915c425da8150a29af4b764dd/web/app/models/persistence/metadata/instance_definition.rb#L9-L12
+	return err
 }
@@ -29,4 +29,11 @@ var _ = Describe("InstanceDefinition", func() {
 		Expect(instanceDefinition.ZeroIf.PropertyReference).To(Equal("some-property-reference"))
 		Expect(instanceDefinition.Constraints).To(Equal("some-constraints"))
 	})
+
+	Context("validations", func() {
+		It("validates the Default field is not negative", func() {
+			instanceDefinition.Default = -1
+			Expect(instanceDefinition.Validate()).To(MatchError("instance definition default must be greater than or equal to 0"))
+		})
+	})
 })
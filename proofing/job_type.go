@@ -1,5 +1,12 @@
 package proofing
 
+import (
+	"fmt"
+	"regexp"
+)
+
+var maxInFlightPercentagePattern = regexp.MustCompile(`^(\d+)%$`)
+
 type JobType struct {
 	Name          string `yaml:"name"`
 	ResourceLabel string `yaml:"resource_label"`
@@ -8,9 +15,11 @@ type JobType struct {
 	Manifest    string      `yaml:"manifest"`
 	MaxInFlight interface{} `yaml:"max_in_flight"`
 
-	Canaries     int  `yaml:"canaries"`
-	Serial       bool `yaml:"serial,omitempty"`
-	SingleAZOnly bool `yaml:"single_az_only"`
+	// Canaries and Serial are nil unless the job type sets them, so that an
+	// explicit 0 or false can override the update settings of the product.
+	Canaries     *int  `yaml:"canaries,omitempty"`
+	Serial       *bool `yaml:"serial,omitempty"`
+	SingleAZOnly bool  `yaml:"single_az_only"`
 
 	Errand                     bool `yaml:"errand"`
 	RunPreDeleteErrandDefault  bool `yaml:"run_pre_delete_errand_default"`
@@ -22,8 +31,60 @@ type JobType struct {
 	PropertyBlueprints      PropertyBlueprints   `yaml:"property_blueprints,omitempty"`
 	RequiresProductVersions []ProductVersion     `yaml:"requires_product_versions"`
 
-	// TODO: validations: https://github.com/pivotal-cf/installation/blob/039a2ef3f751ef5915c425da8150a29af4b764dd/web/app/models/persistence/metadata/job_type.rb#L11-L15
-	// TODO: more validations: https://github.com/pivotal-cf/installation/blob/039a2ef3f751ef5915c425da8150a29af4b764dd/web/app/models/persistence/metadata/job_type.rb#L33-L55
 	// TODO: find_object: https://github.com/pivotal-cf/installation/blob/039a2ef3f751ef5915c425da8150a29af4b764dd/web/app/models/persistence/metadata/job_type.rb#L57-L58
-	// TODO: max_in_flight can be int or percentage
+}
+
+func (jt JobType) Validate() error {
+	var err error
+	err = ValidatePresence(err, jt, "Name")
+	err = ValidatePresence(err, jt, "ResourceLabel")
+	err = ValidatePresence(err, jt, "Templates")
+
+	if jt.Canaries != nil && *jt.Canaries < 0 {
+		err = AddValidationError(err, NewValidationError(jt, "canaries must be greater than or equal to 0"))
+	}
+
+	err = AddValidationError(err, jt.validateMaxInFlight())
+	err = AddNestedValidationErrors(err, "instance_definition", jt.InstanceDefinition.Validate())
+
+	templateNames := map[string]bool{}
+	for i, template := range jt.Templates {
+		path := fmt.Sprintf("templates[%d]", i)
+		err = AddNestedValidationErrors(err, path, template.Validate())
+		err = AddNestedValidationErrors(err, path, validateUniqueness(templateNames, template, template.Name))
+	}
+
+	for i, resourceDefinition := range jt.ResourceDefinitions {
+		err = AddNestedValidationErrors(err, fmt.Sprintf("resource_definitions[%d]", i), resourceDefinition.Validate())
+	}
+
+	err = AddNestedValidationErrors(err, "property_blueprints", jt.PropertyBlueprints.Validate())
+
+	return err
+}
+
+func (jt JobType) validateMaxInFlight() error {
+	switch maxInFlight := jt.MaxInFlight.(type) {
+	case nil:
+		return NewValidationError(jt, "max_in_flight must be present")
+	case int:
+		if maxInFlight < 1 {
+			return NewValidationError(jt, "max_in_flight must be greater than 0")
+		}
+	case string:
+		matches := maxInFlightPercentagePattern.FindStringSubmatch(maxInFlight)
+		if matches == nil {
+			return NewValidationError(jt, fmt.Sprintf("max_in_flight %q must be an integer or a percentage", maxInFlight))
+		}
+
+		var percentage int
+		fmt.Sscan(matches[1], &percentage)
+		if percentage < 1 || percentage > 100 {
+			return NewValidationError(jt, fmt.Sprintf("max_in_flight %q must be a percentage between 1%% and 100%%", maxInFlight))
+		}
+	default:
+		return NewValidationError(jt, fmt.Sprintf("max_in_flight %v must be an integer or a percentage", maxInFlight))
+	}
+
+	return nil
 }
@@ -2,11 +2,14 @@ package proofing_test
 
 import (
 	"os"
+	"strings"
 
 	. "github.com/pivotal-cf/kiln/proofing"
 
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
 )
 
 var _ = Describe("JobType", func() {
@@ -24,7 +27,7 @@ var _ = Describe("JobType", func() {
 	})
 
 	It("parses their structure", func() {
-		Expect(jobType.Canaries).To(Equal(1))
+		Expect(jobType.Canaries).To(PointTo(Equal(1)))
 		Expect(jobType.Description).To(Equal("some-description"))
 		Expect(jobType.Errand).To(BeTrue())
 		Expect(jobType.Manifest).To(Equal("some-manifest"))
@@ -33,7 +36,7 @@ var _ = Describe("JobType", func() {
 		Expect(jobType.ResourceLabel).To(Equal("some-resource-label"))
 		Expect(jobType.RunPreDeleteErrandDefault).To(BeTrue())
 		Expect(jobType.RunPostDeployErrandDefault).To(BeTrue())
-		Expect(jobType.Serial).To(BeTrue())
+		Expect(jobType.Serial).To(PointTo(BeTrue()))
 		Expect(jobType.SingleAZOnly).To(BeTrue())
 
 		Expect(jobType.InstanceDefinition).To(BeAssignableToTypeOf(InstanceDefinition{}))
@@ -43,6 +46,23 @@ var _ = Describe("JobType", func() {
 		Expect(jobType.RequiresProductVersions).To(HaveLen(1))
 	})
 
+	It("tells the canaries and serial the job type sets apart from the ones it does not", func() {
+		productTemplate, err := Parse(strings.NewReader(`---
+job_types:
+- name: unset
+- name: explicit
+  canaries: 0
+  serial: false
+`))
+		Expect(err).NotTo(HaveOccurred())
+
+		unset, explicit := productTemplate.JobTypes[0], productTemplate.JobTypes[1]
+		Expect(unset.Canaries).To(BeNil())
+		Expect(unset.Serial).To(BeNil())
+		Expect(explicit.Canaries).To(PointTo(Equal(0)))
+		Expect(explicit.Serial).To(PointTo(BeFalse()))
+	})
+
 	Context("property_blueprints", func() {
 		It("parses their structure", func() {
 			propertyBlueprint, ok := jobType.PropertyBlueprints[0].(SimplePropertyBlueprint)
@@ -56,4 +76,66 @@ var _ = Describe("JobType", func() {
 			Expect(propertyBlueprint.Type).To(Equal("some-type"))
 		})
 	})
+
+	Context("validations", func() {
+		BeforeEach(func() {
+			jobType = JobType{
+				Name:          "some-name",
+				ResourceLabel: "some-resource-label",
+				MaxInFlight:   1,
+				Templates: []Template{
+					{Name: "some-template", Release: "some-release"},
+				},
+			}
+		})
+
+		It("is valid", func() {
+			Expect(jobType.Validate()).To(Succeed())
+		})
+
+		It("validates the presence of the required fields", func() {
+			jobType = JobType{MaxInFlight: 1}
+			Expect(jobType.Validate()).To(MatchError(`- job type name must be present
+- job type resource_label must be present
+- job type templates must be present`))
+		})
+
+		It("validates the Canaries field is not negative", func() {
+			canaries := -1
+			jobType.Canaries = &canaries
+			Expect(jobType.Validate()).To(MatchError("job type canaries must be greater than or equal to 0"))
+		})
+
+		DescribeTable("max_in_flight",
+			func(maxInFlight interface{}, expectedError string) {
+				jobType.MaxInFlight = maxInFlight
+				if expectedError == "" {
+					Expect(jobType.Validate()).To(Succeed())
+				} else {
+					Expect(jobType.Validate()).To(MatchError(expectedError))
+				}
+			},
+			Entry("an integer", 3, ""),
+			Entry("a percentage", "20%", ""),
+			Entry("missing", nil, "job type max_in_flight must be present"),
+			Entry("zero", 0, "job type max_in_flight must be greater than 0"),
+			Entry("not a percentage", "some-max-in-flight", `job type max_in_flight "some-max-in-flight" must be an integer or a percentage`),
+			Entry("an out of range percentage", "120%", `job type max_in_flight "120%" must be a percentage between 1% and 100%`),
+		)
+
+		It("validates the templates", func() {
+			jobType.Templates = append(jobType.Templates, Template{Name: "some-template"})
+			Expect(jobType.Validate()).To(MatchError(`- templates[1]: template release must be present
+- templates[1]: template name "some-template" must be unique`))
+		})
+
+		It("validates the nested definitions and property blueprints", func() {
+			jobType.InstanceDefinition.Default = -1
+			jobType.ResourceDefinitions = []ResourceDefinition{{Name: "some-resource"}}
+			jobType.PropertyBlueprints = PropertyBlueprints{SimplePropertyBlueprint{Name: "some-property"}}
+			Expect(jobType.Validate()).To(MatchError(`- instance_definition: instance definition default must be greater than or equal to 0
+- resource_definitions[0]: resource definition name "some-resource" must be one of ram, ephemeral_disk, persistent_disk, cpu
+- property_blueprints[0]: simple property blueprint type must be present`))
+		})
+	})
 })
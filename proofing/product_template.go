@@ -1,6 +1,10 @@
 package proofing
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver"
+)
 
 type ProductTemplate struct {
 	Name                     string `yaml:"name"`
@@ -33,14 +37,7 @@ type ProductTemplate struct {
 	PreDeleteErrands        []ErrandTemplate        `yaml:"pre_delete_errands"`
 	RuntimeConfigs          []RuntimeConfigTemplate `yaml:"runtime_configs"`
 
-	// TODO: validates_presence_of: https://github.com/pivotal-cf/installation/blob/b7be08d7b50d305c08d520ee0afe81ae3a98bd9d/web/app/models/persistence/metadata/product_template.rb#L20-L25
-	// TODO: version_attribute: https://github.com/pivotal-cf/installation/blob/b7be08d7b50d305c08d520ee0afe81ae3a98bd9d/web/app/models/persistence/metadata/product_template.rb#L30-L32
-	// TODO: validates_string: https://github.com/pivotal-cf/installation/blob/039a2ef3f751ef5915c425da8150a29af4b764dd/web/app/models/persistence/metadata/product_template.rb#L56
-	// TODO: validates_integer: https://github.com/pivotal-cf/installation/blob/039a2ef3f751ef5915c425da8150a29af4b764dd/web/app/models/persistence/metadata/product_template.rb#L60
 	// TODO: validates_manifest: https://github.com/pivotal-cf/installation/blob/039a2ef3f751ef5915c425da8150a29af4b764dd/web/app/models/persistence/metadata/product_template.rb#L61
-	// TODO: validations: https://github.com/pivotal-cf/installation/blob/039a2ef3f751ef5915c425da8150a29af4b764dd/web/app/models/persistence/metadata/product_template.rb#L64-L70
-	// TODO: validates: https://github.com/pivotal-cf/installation/blob/039a2ef3f751ef5915c425da8150a29af4b764dd/web/app/models/persistence/metadata/product_template.rb#L72
-	// TODO: validates_object(s): https://github.com/pivotal-cf/installation/blob/039a2ef3f751ef5915c425da8150a29af4b764dd/web/app/models/persistence/metadata/product_template.rb#L74-L82
 	// TODO: find_object: https://github.com/pivotal-cf/installation/blob/039a2ef3f751ef5915c425da8150a29af4b764dd/web/app/models/persistence/metadata/product_template.rb#L84-L86
 }
 
@@ -62,3 +59,104 @@ func (pt ProductTemplate) AllPropertyBlueprints() []NormalizedPropertyBlueprint
 
 	return propertyBlueprints
 }
+
+func (pt ProductTemplate) Validate() error {
+	var err error
+	err = ValidatePresence(err, pt, "Name")
+	err = ValidatePresence(err, pt, "ProductVersion")
+	err = ValidatePresence(err, pt, "MetadataVersion")
+	err = ValidatePresence(err, pt, "Label")
+
+	if _, versionErr := semver.NewVersion(pt.ProductVersion); pt.ProductVersion != "" && versionErr != nil {
+		err = AddValidationError(err, NewValidationError(pt, fmt.Sprintf("product_version %q must be a valid version", pt.ProductVersion)))
+	}
+
+	if _, versionErr := semver.NewVersion(pt.MinimumVersionForUpgrade); pt.MinimumVersionForUpgrade != "" && versionErr != nil {
+		err = AddValidationError(err, NewValidationError(pt, fmt.Sprintf("minimum_version_for_upgrade %q must be a valid version", pt.MinimumVersionForUpgrade)))
+	}
+
+	if pt.Rank < 0 {
+		err = AddValidationError(err, NewValidationError(pt, "rank must be greater than or equal to 0"))
+	}
+
+	releases := map[string]bool{}
+	for i, release := range pt.Releases {
+		path := fmt.Sprintf("releases[%d]", i)
+		err = AddNestedValidationErrors(err, path, release.Validate())
+		err = AddNestedValidationErrors(err, path, validateUniqueness(releases, release, release.Name))
+	}
+
+	err = AddNestedValidationErrors(err, "stemcell_criteria", pt.StemcellCriteria.Validate())
+	err = AddNestedValidationErrors(err, "property_blueprints", pt.PropertyBlueprints.Validate())
+
+	formTypes := map[string]bool{}
+	for i, formType := range pt.FormTypes {
+		path := fmt.Sprintf("form_types[%d]", i)
+		err = AddNestedValidationErrors(err, path, formType.Validate())
+		err = AddNestedValidationErrors(err, path, validateUniqueness(formTypes, formType, formType.Name))
+	}
+
+	jobTypes := map[string]JobType{}
+	for i, jobType := range pt.JobTypes {
+		path := fmt.Sprintf("job_types[%d]", i)
+		err = AddNestedValidationErrors(err, path, jobType.Validate())
+
+		if _, ok := jobTypes[jobType.Name]; ok && jobType.Name != "" {
+			err = AddNestedValidationErrors(err, path, NewValidationError(jobType, fmt.Sprintf("name %q must be unique", jobType.Name)))
+		}
+		jobTypes[jobType.Name] = jobType
+
+		for j, template := range jobType.Templates {
+			if template.Release != "" && !releases[template.Release] {
+				err = AddNestedValidationErrors(err, fmt.Sprintf("%s.templates[%d]", path, j), NewValidationError(template, fmt.Sprintf("release %q must be listed in releases", template.Release)))
+			}
+		}
+	}
+
+	for _, errands := range []struct {
+		path      string
+		templates []ErrandTemplate
+	}{
+		{path: "post_deploy_errands", templates: pt.PostDeployErrands},
+		{path: "pre_delete_errands", templates: pt.PreDeleteErrands},
+	} {
+		for i, errand := range errands.templates {
+			path := fmt.Sprintf("%s[%d]", errands.path, i)
+			err = AddNestedValidationErrors(err, path, errand.Validate())
+
+			if !errand.Colocated && errand.Name != "" {
+				if jobType, ok := jobTypes[errand.Name]; !ok || !jobType.Errand {
+					err = AddNestedValidationErrors(err, path, NewValidationError(errand, fmt.Sprintf("name %q must match an errand job type", errand.Name)))
+				}
+			}
+
+			for _, instanceGroup := range errand.instanceGroups() {
+				if _, ok := jobTypes[instanceGroup]; !ok {
+					err = AddNestedValidationErrors(err, path, NewValidationError(errand, fmt.Sprintf("instance group %q must match a job type", instanceGroup)))
+				}
+			}
+		}
+	}
+
+	runtimeConfigs := map[string]bool{}
+	for i, runtimeConfig := range pt.RuntimeConfigs {
+		path := fmt.Sprintf("runtime_configs[%d]", i)
+		err = AddNestedValidationErrors(err, path, runtimeConfig.Validate())
+		err = AddNestedValidationErrors(err, path, validateUniqueness(runtimeConfigs, runtimeConfig, runtimeConfig.Name))
+	}
+
+	return err
+}
+
+func validateUniqueness(seen map[string]bool, v interface{}, name string) error {
+	if name == "" {
+		return nil
+	}
+
+	if seen[name] {
+		return NewValidationError(v, fmt.Sprintf("name %q must be unique", name))
+	}
+	seen[name] = true
+
+	return nil
+}
@@ -106,4 +106,64 @@ var _ = Describe("ProductTemplate", func() {
 			Expect(instanceGroupSelectorOptionBlueprint.Configurable).To(BeTrue())
 		})
 	})
+
+	Describe("Validate", func() {
+		BeforeEach(func() {
+			f, err := os.Open("fixtures/valid_metadata.yml")
+			defer f.Close()
+			Expect(err).NotTo(HaveOccurred())
+
+			productTemplate, err = Parse(f)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("is valid", func() {
+			Expect(productTemplate.Validate()).To(Succeed())
+		})
+
+		It("validates the presence of the required fields", func() {
+			productTemplate.Name = ""
+			productTemplate.Label = ""
+			Expect(productTemplate.Validate()).To(MatchError(`- product template name must be present
+- product template label must be present`))
+		})
+
+		It("validates the versions", func() {
+			productTemplate.ProductVersion = "some-version"
+			productTemplate.MinimumVersionForUpgrade = "some-other-version"
+			Expect(productTemplate.Validate()).To(MatchError(`- product template product_version "some-version" must be a valid version
+- product template minimum_version_for_upgrade "some-other-version" must be a valid version`))
+		})
+
+		It("reports every nested violation with its path", func() {
+			productTemplate.Releases[0].File = ""
+			productTemplate.StemcellCriteria.OS = ""
+			productTemplate.FormTypes[0].Label = ""
+			productTemplate.JobTypes[0].Templates[0].Name = ""
+			productTemplate.RuntimeConfigs[0].Name = ""
+
+			Expect(productTemplate.Validate()).To(MatchError(`- releases[0]: release file must be present
+- stemcell_criteria: stemcell criteria os must be present
+- form_types[0]: form type label must be present
+- job_types[0].templates[0]: template name must be present
+- runtime_configs[0]: runtime config template name must be present`))
+		})
+
+		It("validates the property blueprints", func() {
+			productTemplate.PropertyBlueprints = append(productTemplate.PropertyBlueprints, SimplePropertyBlueprint{Name: "some-string", Type: "string"})
+			Expect(productTemplate.Validate()).To(MatchError(`property_blueprints[3]: simple property blueprint name "some-string" must be unique`))
+		})
+
+		It("validates that templates reference listed releases", func() {
+			productTemplate.JobTypes[0].Templates[0].Release = "some-other-release"
+			Expect(productTemplate.Validate()).To(MatchError(`job_types[0].templates[0]: template release "some-other-release" must be listed in releases`))
+		})
+
+		It("validates that errands reference job types", func() {
+			productTemplate.PostDeployErrands[0].Name = "some-instance-group"
+			productTemplate.PostDeployErrands[1].Instances = []string{"some-other-instance-group/first"}
+			Expect(productTemplate.Validate()).To(MatchError(`- post_deploy_errands[0]: errand template name "some-instance-group" must match an errand job type
+- post_deploy_errands[1]: errand template instance group "some-other-instance-group" must match a job type`))
+		})
+	})
 })
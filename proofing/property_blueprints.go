@@ -1,13 +1,44 @@
 package proofing
 
-import yaml "gopkg.in/yaml.v2"
+import (
+	"fmt"
+
+	yaml "gopkg.in/yaml.v2"
+)
 
 type PropertyBlueprint interface {
 	Normalize(prefix string) []NormalizedPropertyBlueprint
+	Validate() error
 }
 
 type PropertyBlueprints []PropertyBlueprint
 
+func (pb PropertyBlueprints) Validate() error {
+	var err error
+
+	names := map[string]bool{}
+	for i, propertyBlueprint := range pb {
+		path := fmt.Sprintf("[%d]", i)
+		err = AddNestedValidationErrors(err, path, propertyBlueprint.Validate())
+		err = AddNestedValidationErrors(err, path, validateUniqueness(names, propertyBlueprint, propertyBlueprintName(propertyBlueprint)))
+	}
+
+	return err
+}
+
+func propertyBlueprintName(propertyBlueprint PropertyBlueprint) string {
+	switch pb := propertyBlueprint.(type) {
+	case SimplePropertyBlueprint:
+		return pb.Name
+	case SelectorPropertyBlueprint:
+		return pb.Name
+	case CollectionPropertyBlueprint:
+		return pb.Name
+	default:
+		return ""
+	}
+}
+
 type NormalizedPropertyBlueprint struct {
 	Property     string
 	Configurable bool
@@ -0,0 +1,237 @@
+package proofing
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var propertyReferencePattern = regexp.MustCompile(`\(\(\s*(\.[\w\-.]+)`)
+
+// jobAccessors are the values Ops Manager provides for every instance group
+// that are not backed by a property blueprint.
+var jobAccessors = []string{"ips", "first_ip", "dns_names", "instances", "availability_zones"}
+
+var selectorAccessors = []string{"value", "selected_option", "selected_value"}
+
+type PropertyReference struct {
+	Path      string
+	Reference string
+}
+
+func (pt ProductTemplate) PropertyReferences() []PropertyReference {
+	var references []PropertyReference
+
+	for i, jobType := range pt.JobTypes {
+		path := fmt.Sprintf("job_types[%d]", i)
+		references = append(references, manifestReferences(path+".manifest", jobType.Manifest)...)
+
+		for j, template := range jobType.Templates {
+			references = append(references, manifestReferences(fmt.Sprintf("%s.templates[%d].manifest", path, j), template.Manifest)...)
+		}
+
+		if zeroIf := jobType.InstanceDefinition.ZeroIf.PropertyReference; zeroIf != "" {
+			references = append(references, PropertyReference{
+				Path:      path + ".instance_definition.zero_if.property_reference",
+				Reference: zeroIf,
+			})
+		}
+
+		references = append(references, namedManifestReferences(path+".property_blueprints", jobType.PropertyBlueprints)...)
+	}
+
+	references = append(references, namedManifestReferences("property_blueprints", pt.PropertyBlueprints)...)
+
+	for i, formType := range pt.FormTypes {
+		for j, propertyInput := range formType.PropertyInputs {
+			path := fmt.Sprintf("form_types[%d].property_inputs[%d]", i, j)
+			references = append(references, propertyInputReferences(path, propertyInput)...)
+		}
+	}
+
+	for i, runtimeConfig := range pt.RuntimeConfigs {
+		references = append(references, manifestReferences(fmt.Sprintf("runtime_configs[%d].runtime_config", i), runtimeConfig.RuntimeConfig)...)
+	}
+
+	return references
+}
+
+func (pt ProductTemplate) ValidatePropertyReferences() error {
+	properties := pt.referenceableProperties()
+
+	jobTypes := map[string]bool{}
+	for _, jobType := range pt.JobTypes {
+		jobTypes[jobType.Name] = true
+	}
+
+	var err error
+	for _, reference := range pt.PropertyReferences() {
+		if reference.Reference == "" || properties.resolve(reference.Reference) {
+			continue
+		}
+
+		segments := strings.Split(strings.TrimPrefix(reference.Reference, "."), ".")
+		if len(segments) > 1 && jobTypes[segments[0]] && contains(jobAccessors, segments[1]) {
+			continue
+		}
+
+		err = AddNestedValidationErrors(err, reference.Path, NewValidationError(reference, fmt.Sprintf("%q does not match a property blueprint", reference.Reference)))
+	}
+
+	return err
+}
+
+func (pt ProductTemplate) UnusedPropertyBlueprints() []NormalizedPropertyBlueprint {
+	var references []string
+	for _, reference := range pt.PropertyReferences() {
+		references = append(references, reference.Reference)
+	}
+
+	var unused []NormalizedPropertyBlueprint
+	for _, propertyBlueprint := range pt.AllPropertyBlueprints() {
+		used := false
+		for _, reference := range references {
+			if matches(reference, propertyBlueprint.Property) {
+				used = true
+				break
+			}
+		}
+
+		if !used {
+			unused = append(unused, propertyBlueprint)
+		}
+	}
+
+	return unused
+}
+
+type referenceableProperties struct {
+	blueprints      []string
+	selectors       []string
+	selectorOptions []string
+}
+
+// resolve reports whether the reference points at a property blueprint. A
+// reference may continue past a blueprint with an accessor such as ".value",
+// but not past a selector or one of its options unless it names a blueprint
+// nested within that option.
+func (rp referenceableProperties) resolve(reference string) bool {
+	var longest string
+	for _, property := range append(append([]string{}, rp.blueprints...), rp.selectorOptions...) {
+		if matches(reference, property) && len(property) > len(longest) {
+			longest = property
+		}
+	}
+
+	if longest == "" {
+		return false
+	}
+
+	remainder := strings.TrimPrefix(strings.TrimPrefix(reference, longest), ".")
+	if remainder == "" {
+		return true
+	}
+
+	if contains(rp.selectorOptions, longest) {
+		return false
+	}
+
+	if contains(rp.selectors, longest) {
+		return contains(selectorAccessors, strings.Split(remainder, ".")[0])
+	}
+
+	return true
+}
+
+func (pt ProductTemplate) referenceableProperties() referenceableProperties {
+	var properties referenceableProperties
+	for _, propertyBlueprint := range pt.AllPropertyBlueprints() {
+		properties.blueprints = append(properties.blueprints, propertyBlueprint.Property)
+	}
+
+	properties.addSelectors(".properties", pt.PropertyBlueprints)
+	for _, jobType := range pt.JobTypes {
+		properties.addSelectors("."+jobType.Name, jobType.PropertyBlueprints)
+	}
+
+	return properties
+}
+
+func (rp *referenceableProperties) addSelectors(prefix string, propertyBlueprints PropertyBlueprints) {
+	for _, propertyBlueprint := range propertyBlueprints {
+		if selector, ok := propertyBlueprint.(SelectorPropertyBlueprint); ok {
+			property := fmt.Sprintf("%s.%s", prefix, selector.Name)
+			rp.selectors = append(rp.selectors, property)
+
+			for _, optionTemplate := range selector.OptionTemplates {
+				rp.selectorOptions = append(rp.selectorOptions, fmt.Sprintf("%s.%s", property, optionTemplate.Name))
+			}
+		}
+	}
+}
+
+func namedManifestReferences(path string, propertyBlueprints PropertyBlueprints) []PropertyReference {
+	var references []PropertyReference
+	for i, propertyBlueprint := range propertyBlueprints {
+		switch pb := propertyBlueprint.(type) {
+		case SelectorPropertyBlueprint:
+			for j, optionTemplate := range pb.OptionTemplates {
+				for k, namedManifest := range optionTemplate.NamedManifests {
+					references = append(references, manifestReferences(fmt.Sprintf("%s[%d].option_templates[%d].named_manifests[%d].manifest", path, i, j, k), namedManifest.Manifest)...)
+				}
+			}
+		case CollectionPropertyBlueprint:
+			for j, namedManifest := range pb.NamedManifests {
+				references = append(references, manifestReferences(fmt.Sprintf("%s[%d].named_manifests[%d].manifest", path, i, j), namedManifest.Manifest)...)
+			}
+		}
+	}
+
+	return references
+}
+
+func propertyInputReferences(path string, propertyInput PropertyInput) []PropertyReference {
+	switch pi := propertyInput.(type) {
+	case SimplePropertyInput:
+		return []PropertyReference{{Path: path + ".reference", Reference: pi.Reference}}
+	case CollectionPropertyInput:
+		// NOTE: the property inputs of a collection reference its fields by name
+		return []PropertyReference{{Path: path + ".reference", Reference: pi.Reference}}
+	case SelectorPropertyInput:
+		references := []PropertyReference{{Path: path + ".reference", Reference: pi.Reference}}
+		for i, selectorInput := range pi.SelectorPropertyInputs {
+			selectorPath := fmt.Sprintf("%s.selector_property_inputs[%d]", path, i)
+			references = append(references, PropertyReference{Path: selectorPath + ".reference", Reference: selectorInput.Reference})
+
+			for j, nestedInput := range selectorInput.PropertyInputs {
+				references = append(references, PropertyReference{
+					Path:      fmt.Sprintf("%s.property_inputs[%d].reference", selectorPath, j),
+					Reference: nestedInput.Reference,
+				})
+			}
+		}
+		return references
+	default:
+		return nil
+	}
+}
+
+func manifestReferences(path, manifest string) []PropertyReference {
+	var references []PropertyReference
+	for _, match := range propertyReferencePattern.FindAllStringSubmatch(manifest, -1) {
+		reference := strings.TrimSuffix(match[1], ".")
+
+		// NOTE: references starting with ".." point at properties of other products
+		if strings.HasPrefix(reference, "..") {
+			continue
+		}
+
+		references = append(references, PropertyReference{Path: path, Reference: reference})
+	}
+
+	return references
+}
+
+func matches(reference, property string) bool {
+	return reference == property || strings.HasPrefix(reference, property+".")
+}
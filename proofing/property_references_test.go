@@ -0,0 +1,75 @@
+package proofing_test
+
+import (
+	"os"
+
+	. "github.com/pivotal-cf/kiln/proofing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PropertyReferences", func() {
+	var productTemplate ProductTemplate
+
+	BeforeEach(func() {
+		f, err := os.Open("fixtures/property_references.yml")
+		defer f.Close()
+		Expect(err).NotTo(HaveOccurred())
+
+		productTemplate, err = Parse(f)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("PropertyReferences", func() {
+		It("finds the references in manifests, bindings and form inputs", func() {
+			Expect(productTemplate.PropertyReferences()).To(ConsistOf([]PropertyReference{
+				{Path: "job_types[0].manifest", Reference: ".some-instance-group.ips"},
+				{Path: "job_types[0].templates[0].manifest", Reference: ".properties.some-string.value"},
+				{Path: "job_types[0].templates[0].manifest", Reference: ".properties.some-secret.value"},
+				{Path: "job_types[0].templates[0].manifest", Reference: ".some-instance-group.some-job-property.value"},
+				{Path: "job_types[0].templates[0].manifest", Reference: ".properties.some-mising-string.value"},
+				{Path: "job_types[0].templates[0].manifest", Reference: ".properties.some-selector.selected_option.parsed_manifest"},
+				{Path: "job_types[0].instance_definition.zero_if.property_reference", Reference: ".properties.some-missing-toggle"},
+				{Path: "property_blueprints[2].option_templates[0].named_manifests[0].manifest", Reference: ".properties.some-selector.enabled.some-port.value"},
+				{Path: "form_types[0].property_inputs[0].reference", Reference: ".properties.some-string"},
+				{Path: "form_types[0].property_inputs[1].reference", Reference: ".properties.some-selector"},
+				{Path: "form_types[0].property_inputs[1].selector_property_inputs[0].reference", Reference: ".properties.some-selector.disabled"},
+				{Path: "form_types[0].property_inputs[1].selector_property_inputs[1].reference", Reference: ".properties.some-selector.enabled"},
+				{Path: "form_types[0].property_inputs[1].selector_property_inputs[1].property_inputs[0].reference", Reference: ".properties.some-selector.enabled.some-missing-port"},
+				{Path: "runtime_configs[0].runtime_config", Reference: ".some-missing-instance-group.ips"},
+			}))
+		})
+	})
+
+	Describe("ValidatePropertyReferences", func() {
+		It("reports references that do not match a property blueprint", func() {
+			Expect(productTemplate.ValidatePropertyReferences()).To(MatchError(`- job_types[0].templates[0].manifest: property reference ".properties.some-mising-string.value" does not match a property blueprint
+- job_types[0].instance_definition.zero_if.property_reference: property reference ".properties.some-missing-toggle" does not match a property blueprint
+- form_types[0].property_inputs[1].selector_property_inputs[1].property_inputs[0].reference: property reference ".properties.some-selector.enabled.some-missing-port" does not match a property blueprint
+- runtime_configs[0].runtime_config: property reference ".some-missing-instance-group.ips" does not match a property blueprint`))
+		})
+
+		Context("when every reference matches a property blueprint", func() {
+			It("succeeds", func() {
+				productTemplate.JobTypes[0].Templates[0].Manifest = "string: (( .properties.some-string.value ))"
+				productTemplate.JobTypes[0].InstanceDefinition.ZeroIf = ZeroIfBinding{}
+				productTemplate.FormTypes = nil
+				productTemplate.RuntimeConfigs = nil
+
+				Expect(productTemplate.ValidatePropertyReferences()).To(Succeed())
+			})
+		})
+	})
+
+	Describe("UnusedPropertyBlueprints", func() {
+		It("returns the property blueprints that are never referenced", func() {
+			var unused []string
+			for _, propertyBlueprint := range productTemplate.UnusedPropertyBlueprints() {
+				unused = append(unused, propertyBlueprint.Property)
+			}
+
+			Expect(unused).To(Equal([]string{".properties.some-unused-string"}))
+		})
+	})
+})
@@ -1,10 +1,30 @@
 package proofing
 
+import (
+	"fmt"
+	"strings"
+)
+
 type ResourceDefinition struct {
 	Name         string      `yaml:"name"`
 	Default      int         `yaml:"default"`
 	Configurable bool        `yaml:"configurable"`
 	Constraints  interface{} `yaml:"constraints,omitempty"` // TODO: schema?
+}
+
+var resourceDefinitionNames = []string{"ram", "ephemeral_disk", "persistent_disk", "cpu"}
+
+func (rd ResourceDefinition) Validate() error {
+	var err error
+	err = ValidatePresence(err, rd, "Name")
+
+	if rd.Name != "" && !contains(resourceDefinitionNames, rd.Name) {
+		err = AddValidationError(err, NewValidationError(rd, fmt.Sprintf("name %q must be one of %s", rd.Name, strings.Join(resourceDefinitionNames, ", "))))
+	}
+
+	if rd.Default < 0 {
+		err = AddValidationError(err, NewValidationError(rd, "default must be greater than or equal to 0"))
+	}
 
-	// TODO: validations: https://github.com/pivotal-cf/installation/blob/039a2ef3f751ef5915c425da8150a29af4b764dd/web/app/models/persistence/metadata/resource_definition.rb#L9
+	return err
 }
@@ -29,4 +29,27 @@ var _ = Describe("ResourceDefinitions", func() {
 		Expect(resourceDefinition.Default).To(Equal(1))
 		Expect(resourceDefinition.Name).To(Equal("some-name"))
 	})
+
+	Context("validations", func() {
+		BeforeEach(func() {
+			resourceDefinition = ResourceDefinition{
+				Name:    "ram",
+				Default: 1024,
+			}
+		})
+
+		It("is valid", func() {
+			Expect(resourceDefinition.Validate()).To(Succeed())
+		})
+
+		It("validates the Name field is a known resource", func() {
+			resourceDefinition.Name = "some-name"
+			Expect(resourceDefinition.Validate()).To(MatchError(`resource definition name "some-name" must be one of ram, ephemeral_disk, persistent_disk, cpu`))
+		})
+
+		It("validates the Default field is not negative", func() {
+			resourceDefinition.Default = -1
+			Expect(resourceDefinition.Validate()).To(MatchError("resource definition default must be greater than or equal to 0"))
+		})
+	})
 })
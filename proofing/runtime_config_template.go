@@ -1,8 +1,21 @@
 package proofing
 
+import yaml "gopkg.in/yaml.v2"
+
 type RuntimeConfigTemplate struct {
 	Name          string `yaml:"name"`
 	RuntimeConfig string `yaml:"runtime_config"`
+}
+
+func (rct RuntimeConfigTemplate) Validate() error {
+	var err error
+	err = ValidatePresence(err, rct, "Name")
+	err = ValidatePresence(err, rct, "RuntimeConfig")
+
+	var runtimeConfig map[string]interface{}
+	if yamlErr := yaml.Unmarshal([]byte(rct.RuntimeConfig), &runtimeConfig); yamlErr != nil {
+		err = AddValidationError(err, NewValidationError(rct, "runtime_config must be a valid YAML hash"))
+	}
 
-	// TODO: validations: https://github.com/pivotal-cf/installation/blob/039a2ef3f751ef5915c425da8150a29af4b764dd/web/app/models/persistence/metadata/runtime_config_template.rb#L7-L12
+	return err
 }
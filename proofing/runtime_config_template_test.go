@@ -27,4 +27,27 @@ var _ = Describe("RuntimeConfigTemplate", func() {
 		Expect(runtimeConfigTemplate.Name).To(Equal("some-name"))
 		Expect(runtimeConfigTemplate.RuntimeConfig).To(Equal("some-runtime-config"))
 	})
+
+	Context("validations", func() {
+		BeforeEach(func() {
+			runtimeConfigTemplate = RuntimeConfigTemplate{
+				Name:          "some-name",
+				RuntimeConfig: "releases: []",
+			}
+		})
+
+		It("is valid", func() {
+			Expect(runtimeConfigTemplate.Validate()).To(Succeed())
+		})
+
+		It("validates the presence of the Name field", func() {
+			runtimeConfigTemplate.Name = ""
+			Expect(runtimeConfigTemplate.Validate()).To(MatchError("runtime config template name must be present"))
+		})
+
+		It("validates that the RuntimeConfig field is a YAML hash", func() {
+			runtimeConfigTemplate.RuntimeConfig = "some-runtime-config"
+			Expect(runtimeConfigTemplate.Validate()).To(MatchError("runtime config template runtime_config must be a valid YAML hash"))
+		})
+	})
 })
@@ -1,10 +1,22 @@
 package proofing
 
+import "fmt"
+
 type SelectorOptionPropertyInput struct {
 	Reference string `yaml:"reference"`
 	Label     string `yaml:"label"`
 
 	PropertyInputs []SimplePropertyInput `yaml:"property_inputs,omitempty"`
+}
+
+func (sopi SelectorOptionPropertyInput) Validate() error {
+	var err error
+	err = ValidatePresence(err, sopi, "Reference")
+	err = ValidatePresence(err, sopi, "Label")
+
+	for i, propertyInput := range sopi.PropertyInputs {
+		err = AddNestedValidationErrors(err, fmt.Sprintf("property_inputs[%d]", i), propertyInput.Validate())
+	}
 
-	// TODO: validations: https://github.com/pivotal-cf/installation/blob/039a2ef3f751ef5915c425da8150a29af4b764dd/web/app/models/persistence/metadata/selector_option_property_input.rb#L8-L12
+	return err
 }
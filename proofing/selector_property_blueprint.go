@@ -7,7 +7,6 @@ type SelectorPropertyBlueprint struct {
 
 	OptionTemplates []SelectorPropertyOptionTemplate `yaml:"option_templates"`
 
-	// TODO: validations: https://github.com/pivotal-cf/installation/blob/039a2ef3f751ef5915c425da8150a29af4b764dd/web/app/models/persistence/metadata/selector_property_blueprint.rb#L10
 	// TODO: find_object: https://github.com/pivotal-cf/installation/blob/039a2ef3f751ef5915c425da8150a29af4b764dd/web/app/models/persistence/metadata/selector_property_blueprint.rb#L8
 }
 
@@ -23,3 +22,21 @@ func (sp SelectorPropertyBlueprint) Normalize(prefix string) []NormalizedPropert
 
 	return propertyBlueprints
 }
+
+func (sp SelectorPropertyBlueprint) Validate() error {
+	err := sp.SimplePropertyBlueprint.Validate()
+	err = ValidatePresence(err, sp, "OptionTemplates")
+
+	options := map[string]bool{}
+	for i, optionTemplate := range sp.OptionTemplates {
+		err = AddNestedValidationErrors(err, fmt.Sprintf("option_templates[%d]", i), optionTemplate.Validate())
+		options[optionTemplate.Name] = true
+		options[optionTemplate.SelectValue] = true
+	}
+
+	if defaultValue, ok := sp.Default.(string); ok && defaultValue != "" && !options[defaultValue] {
+		err = AddValidationError(err, NewValidationError(sp, fmt.Sprintf("default %q must match an option template", defaultValue)))
+	}
+
+	return err
+}
@@ -69,4 +69,47 @@ var _ = Describe("SelectorPropertyBlueprint", func() {
 			})
 		})
 	})
+
+	Context("validations", func() {
+		BeforeEach(func() {
+			selectorPropertyBlueprint = SelectorPropertyBlueprint{
+				SimplePropertyBlueprint: SimplePropertyBlueprint{
+					Name:    "some-name",
+					Type:    "selector",
+					Default: "some-option",
+				},
+				OptionTemplates: []SelectorPropertyOptionTemplate{
+					{
+						Name:        "some-option",
+						SelectValue: "some-select-value",
+						PropertyBlueprints: []SimplePropertyBlueprint{
+							{Name: "some-nested-name", Type: "string"},
+						},
+					},
+				},
+			}
+		})
+
+		It("is valid", func() {
+			Expect(selectorPropertyBlueprint.Validate()).To(Succeed())
+		})
+
+		It("validates the presence of the OptionTemplates field", func() {
+			selectorPropertyBlueprint.Default = nil
+			selectorPropertyBlueprint.OptionTemplates = nil
+			Expect(selectorPropertyBlueprint.Validate()).To(MatchError("selector property blueprint option_templates must be present"))
+		})
+
+		It("validates the option templates and their property blueprints", func() {
+			selectorPropertyBlueprint.OptionTemplates[0].SelectValue = ""
+			selectorPropertyBlueprint.OptionTemplates[0].PropertyBlueprints[0].Type = ""
+			Expect(selectorPropertyBlueprint.Validate()).To(MatchError(`- option_templates[0]: selector property option template select_value must be present
+- option_templates[0].property_blueprints[0]: simple property blueprint type must be present`))
+		})
+
+		It("validates the Default field matches an option template", func() {
+			selectorPropertyBlueprint.Default = "some-other-option"
+			Expect(selectorPropertyBlueprint.Validate()).To(MatchError(`selector property blueprint default "some-other-option" must match an option template`))
+		})
+	})
 })
@@ -1,7 +1,19 @@
 package proofing
 
+import "fmt"
+
 type SelectorPropertyInput struct {
 	SimplePropertyInput `yaml:",inline"`
 
 	SelectorPropertyInputs []SelectorOptionPropertyInput `yaml:"selector_property_inputs,omitempty"`
 }
+
+func (spi SelectorPropertyInput) Validate() error {
+	err := spi.SimplePropertyInput.Validate()
+
+	for i, propertyInput := range spi.SelectorPropertyInputs {
+		err = AddNestedValidationErrors(err, fmt.Sprintf("selector_property_inputs[%d]", i), propertyInput.Validate())
+	}
+
+	return err
+}
@@ -1,5 +1,7 @@
 package proofing
 
+import "fmt"
+
 type SelectorPropertyOptionTemplate struct {
 	Name               string                    `yaml:"name"`
 	SelectValue        string                    `yaml:"select_value"`
@@ -8,3 +10,15 @@ type SelectorPropertyOptionTemplate struct {
 
 	// TODO: find_object: https://github.com/pivotal-cf/installation/blob/039a2ef3f751ef5915c425da8150a29af4b764dd/web/app/models/persistence/metadata/selector_property_option_template.rb#L11
 }
+
+func (spot SelectorPropertyOptionTemplate) Validate() error {
+	var err error
+	err = ValidatePresence(err, spot, "Name")
+	err = ValidatePresence(err, spot, "SelectValue")
+
+	for i, propertyBlueprint := range spot.PropertyBlueprints {
+		err = AddNestedValidationErrors(err, fmt.Sprintf("property_blueprints[%d]", i), propertyBlueprint.Validate())
+	}
+
+	return err
+}
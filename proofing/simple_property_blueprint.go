@@ -1,6 +1,43 @@
 package proofing
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
+
+var propertyBlueprintTypes = []string{
+	"boolean",
+	"ca_certificate",
+	"collection",
+	"disk_type_dropdown",
+	"domain",
+	"dropdown_select",
+	"email",
+	"http_url",
+	"integer",
+	"ip_address",
+	"ip_ranges",
+	"ldap_url",
+	"multi_select_options",
+	"network_address",
+	"network_address_list",
+	"port",
+	"rsa_cert_credentials",
+	"rsa_pkey_credentials",
+	"salted_credentials",
+	"secret",
+	"selector",
+	"service_network_az_multi_select",
+	"service_network_az_single_select",
+	"simple_credentials",
+	"stemcell_selector",
+	"string",
+	"string_list",
+	"text",
+	"uuid",
+	"vm_type_dropdown",
+	"wildcard_domain",
+}
 
 type SimplePropertyBlueprint struct {
 	Name           string                    `yaml:"name"`
@@ -15,8 +52,34 @@ type SimplePropertyBlueprint struct {
 	Unique bool `yaml:"unique"`
 
 	ResourceDefinitions []ResourceDefinition `yaml:"resource_definitions"`
+}
+
+func (sp SimplePropertyBlueprint) Validate() error {
+	var err error
+	err = ValidatePresence(err, sp, "Name")
+	err = ValidatePresence(err, sp, "Type")
+
+	if strings.ContainsAny(sp.Name, ". ") {
+		err = AddValidationError(err, NewValidationError(sp, fmt.Sprintf("name %q must not contain periods or spaces", sp.Name)))
+	}
+
+	if sp.Type != "" && !contains(propertyBlueprintTypes, sp.Type) {
+		err = AddValidationError(err, NewValidationError(sp, fmt.Sprintf("type %q is not a known property blueprint type", sp.Type)))
+	}
 
-	// TODO: validations: https://github.com/pivotal-cf/installation/blob/039a2ef3f751ef5915c425da8150a29af4b764dd/web/app/models/persistence/metadata/property_blueprint.rb#L27-L39
+	if sp.Type == "dropdown_select" || sp.Type == "multi_select_options" {
+		err = ValidatePresence(err, sp, "Options")
+	}
+
+	for i, option := range sp.Options {
+		err = AddNestedValidationErrors(err, fmt.Sprintf("options[%d]", i), option.Validate())
+	}
+
+	for i, resourceDefinition := range sp.ResourceDefinitions {
+		err = AddNestedValidationErrors(err, fmt.Sprintf("resource_definitions[%d]", i), resourceDefinition.Validate())
+	}
+
+	return err
 }
 
 func (sp SimplePropertyBlueprint) Normalize(prefix string) []NormalizedPropertyBlueprint {
@@ -35,3 +98,10 @@ type PropertyBlueprintOption struct {
 	Label string `yaml:"label"`
 	Name  string `yaml:"name"`
 }
+
+func (pbo PropertyBlueprintOption) Validate() error {
+	var err error
+	err = ValidatePresence(err, pbo, "Name")
+	err = ValidatePresence(err, pbo, "Label")
+	return err
+}
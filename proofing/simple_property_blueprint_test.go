@@ -71,4 +71,51 @@ var _ = Describe("SimplePropertyBlueprint", func() {
 			Expect(option.Name).To(Equal("some-name"))
 		})
 	})
+
+	Context("validations", func() {
+		BeforeEach(func() {
+			simplePropertyBlueprint = SimplePropertyBlueprint{
+				Name: "some-name",
+				Type: "string",
+			}
+		})
+
+		It("is valid", func() {
+			Expect(simplePropertyBlueprint.Validate()).To(Succeed())
+		})
+
+		It("validates the presence of the Name and Type fields", func() {
+			simplePropertyBlueprint = SimplePropertyBlueprint{}
+			Expect(simplePropertyBlueprint.Validate()).To(MatchError(`- simple property blueprint name must be present
+- simple property blueprint type must be present`))
+		})
+
+		It("validates the Name field does not contain periods", func() {
+			simplePropertyBlueprint.Name = "some.name"
+			Expect(simplePropertyBlueprint.Validate()).To(MatchError(`simple property blueprint name "some.name" must not contain periods or spaces`))
+		})
+
+		It("validates the Type field is a known type", func() {
+			simplePropertyBlueprint.Type = "some-type"
+			Expect(simplePropertyBlueprint.Validate()).To(MatchError(`simple property blueprint type "some-type" is not a known property blueprint type`))
+		})
+
+		Context("when the type requires options", func() {
+			It("validates the presence of the Options field", func() {
+				simplePropertyBlueprint.Type = "dropdown_select"
+				Expect(simplePropertyBlueprint.Validate()).To(MatchError("simple property blueprint options must be present"))
+			})
+
+			It("validates each option", func() {
+				simplePropertyBlueprint.Type = "dropdown_select"
+				simplePropertyBlueprint.Options = []PropertyBlueprintOption{{Name: "some-name"}}
+				Expect(simplePropertyBlueprint.Validate()).To(MatchError("options[0]: property blueprint option label must be present"))
+			})
+		})
+
+		It("validates the resource definitions", func() {
+			simplePropertyBlueprint.ResourceDefinitions = []ResourceDefinition{{Name: "cpu", Default: -1}}
+			Expect(simplePropertyBlueprint.Validate()).To(MatchError("resource_definitions[0]: resource definition default must be greater than or equal to 0"))
+		})
+	})
 })
@@ -28,4 +28,28 @@ var _ = Describe("StemcellCriteria", func() {
 		Expect(stemcellCriteria.Version).To(Equal("some-version"))
 		Expect(stemcellCriteria.EnablePatchSecurityUpdates).To(BeTrue())
 	})
+
+	Context("validations", func() {
+		BeforeEach(func() {
+			stemcellCriteria = StemcellCriteria{
+				OS:      "ubuntu-xenial",
+				Version: "250.17",
+			}
+		})
+
+		It("is valid", func() {
+			Expect(stemcellCriteria.Validate()).To(Succeed())
+		})
+
+		It("validates the presence of the OS and Version fields", func() {
+			stemcellCriteria = StemcellCriteria{}
+			Expect(stemcellCriteria.Validate()).To(MatchError(`- stemcell criteria os must be present
+- stemcell criteria version must be present`))
+		})
+
+		It("validates the format of the Version field", func() {
+			stemcellCriteria.Version = "250.17.1"
+			Expect(stemcellCriteria.Validate()).To(MatchError("stemcell criteria version must be a major or major.minor version number"))
+		})
+	})
 })
@@ -1,11 +1,24 @@
 package proofing
 
+import yaml "gopkg.in/yaml.v2"
+
 type Template struct {
 	Name     string `yaml:"name"`
 	Release  string `yaml:"release"`
 	Manifest string `yaml:"manifest,omitempty"`
 	Consumes string `yaml:"consumes,omitempty"`
 	Provides string `yaml:"provides,omitempty"`
+}
+
+func (t Template) Validate() error {
+	var err error
+	err = ValidatePresence(err, t, "Name")
+	err = ValidatePresence(err, t, "Release")
+
+	var manifest map[string]interface{}
+	if yamlErr := yaml.Unmarshal([]byte(t.Manifest), &manifest); yamlErr != nil {
+		err = AddValidationError(err, NewValidationError(t, "manifest must be a valid YAML hash"))
+	}
 
-	// TODO: validations: https://github.com/pivotal-cf/installation/blob/039a2ef3f751ef5915c425da8150a29af4b764dd/web/app/models/persistence/metadata/template.rb#L9
+	return err
 }
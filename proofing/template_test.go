@@ -30,4 +30,29 @@ var _ = Describe("Template", func() {
 		Expect(template.Provides).To(Equal("some-provides"))
 		Expect(template.Release).To(Equal("some-release"))
 	})
+
+	Context("validations", func() {
+		BeforeEach(func() {
+			template = Template{
+				Name:     "some-name",
+				Release:  "some-release",
+				Manifest: "some-property: some-value",
+			}
+		})
+
+		It("is valid", func() {
+			Expect(template.Validate()).To(Succeed())
+		})
+
+		It("validates the presence of the Name and Release fields", func() {
+			template = Template{}
+			Expect(template.Validate()).To(MatchError(`- template name must be present
+- template release must be present`))
+		})
+
+		It("validates that the Manifest field is a YAML hash", func() {
+			template.Manifest = "some-manifest"
+			Expect(template.Validate()).To(MatchError("template manifest must be a valid YAML hash"))
+		})
+	})
 })
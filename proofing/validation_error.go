@@ -3,6 +3,7 @@ package proofing
 import (
 	"fmt"
 	"reflect"
+	"regexp"
 	"strings"
 )
 
@@ -18,24 +19,94 @@ func NewValidationError(kind interface{}, message string) ValidationError {
 	}
 }
 
+var wordBoundary = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+
 func (ve ValidationError) Error() string {
 	t := reflect.TypeOf(ve.Kind)
-	return fmt.Sprintf("%s %s", strings.ToLower(t.Name()), ve.Message)
+	kind := strings.ToLower(wordBoundary.ReplaceAllString(t.Name(), "$1 $2"))
+	return fmt.Sprintf("%s %s", kind, ve.Message)
+}
+
+type PathError struct {
+	Path string
+	Err  error
+}
+
+func (pe PathError) Error() string {
+	return fmt.Sprintf("%s: %s", pe.Path, pe.Err)
 }
 
 func ValidatePresence(err error, v interface{}, field string) error {
 	value := reflect.ValueOf(v).FieldByName(field)
 	if value.Len() == 0 {
-		validationError := NewValidationError(v, fmt.Sprintf("%s must be present", strings.ToLower(field)))
-		switch e := err.(type) {
-		case *CompoundError:
-			e.Add(validationError)
-		case ValidationError:
-			err = &CompoundError{err, validationError}
-		default:
-			err = validationError
-		}
+		return AddValidationError(err, NewValidationError(v, fmt.Sprintf("%s must be present", fieldName(v, field))))
 	}
 
 	return err
 }
+
+// AddValidationError appends validationError to err, combining them into a
+// CompoundError when there is more than one.
+func AddValidationError(err error, validationError error) error {
+	if validationError == nil {
+		return err
+	}
+
+	switch e := err.(type) {
+	case nil:
+		return validationError
+	case *CompoundError:
+		e.Add(validationError)
+		return e
+	default:
+		return &CompoundError{err, validationError}
+	}
+}
+
+// AddNestedValidationErrors appends each error found in nested to err,
+// prefixing them with the path of the nested object.
+func AddNestedValidationErrors(err error, path string, nested error) error {
+	switch e := nested.(type) {
+	case nil:
+		return err
+	case *CompoundError:
+		for _, nestedError := range *e {
+			err = AddNestedValidationErrors(err, path, nestedError)
+		}
+		return err
+	case PathError:
+		return AddValidationError(err, PathError{Path: joinPath(path, e.Path), Err: e.Err})
+	default:
+		return AddValidationError(err, PathError{Path: path, Err: nested})
+	}
+}
+
+func joinPath(parent, child string) string {
+	if strings.HasPrefix(child, "[") {
+		return parent + child
+	}
+
+	return parent + "." + child
+}
+
+func fieldName(v interface{}, field string) string {
+	structField, ok := reflect.TypeOf(v).FieldByName(field)
+	if ok {
+		name := strings.Split(structField.Tag.Get("yaml"), ",")[0]
+		if name != "" {
+			return name
+		}
+	}
+
+	return strings.ToLower(field)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
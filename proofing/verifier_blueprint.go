@@ -4,3 +4,7 @@ type VerifierBlueprint struct {
 	Name       string      `yaml:"name"`
 	Properties interface{} `yaml:"properties"` // TODO: schema?
 }
+
+func (vb VerifierBlueprint) Validate() error {
+	return ValidatePresence(nil, vb, "Name")
+}